@@ -129,6 +129,18 @@ class SimCtlShell(cmd.Cmd):
         "Send POST /stop to stop the simulation"
         send_request("POST", "/stop")
 
+    def do_pause(self, arg):
+        "Send POST /pause to freeze the simulation"
+        send_request("POST", "/pause")
+
+    def do_resume(self, arg):
+        "Send POST /resume to resume a paused simulation"
+        send_request("POST", "/resume")
+
+    def do_reset(self, arg):
+        "Send POST /reset to tear down the simulation instance"
+        send_request("POST", "/reset")
+
     def do_status(self, arg):
         "Send GET /status to check current simulation status"
         send_request("GET", "/status")
@@ -0,0 +1,251 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package arrival
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+)
+
+/* UE arrival processes */
+
+type Model string
+
+const (
+	Poisson       Model = "POISSON"
+	Deterministic Model = "DETERMINISTIC"
+	Mmpp          Model = "MMPP"
+	Diurnal       Model = "DIURNAL"
+	FlashCrowd    Model = "FLASH_CROWD"
+	Trace         Model = "TRACE"
+)
+
+// Config selects the arrival model and its parameters.
+// Rates are expressed in UEs per second, times in seconds.
+type Config struct {
+	Model Model `yaml:"model" json:"model"`
+	// mean rate of POISSON, DETERMINISTIC and DIURNAL, base rate of FLASH_CROWD
+	Rate float64 `yaml:"rate,omitempty" json:"rate,omitempty"`
+	// MMPP states, visited in a round robin fashion
+	States []MmppState `yaml:"states,omitempty" json:"states,omitempty"`
+	// DIURNAL: relative amplitude in [0, 1], period and time of the peak within the period
+	Amplitude float64 `yaml:"amplitude,omitempty" json:"amplitude,omitempty"`
+	PeriodSec float64 `yaml:"periodSec,omitempty" json:"periodSec,omitempty"`
+	PeakAtSec float64 `yaml:"peakAtSec,omitempty" json:"peakAtSec,omitempty"`
+	// FLASH_CROWD: additional rate applied for durationSec starting at atSec
+	AtSec       float64 `yaml:"atSec,omitempty" json:"atSec,omitempty"`
+	DurationSec float64 `yaml:"durationSec,omitempty" json:"durationSec,omitempty"`
+	PeakRate    float64 `yaml:"peakRate,omitempty" json:"peakRate,omitempty"`
+	// TRACE: file listing the arrival times
+	TraceFile string `yaml:"traceFile,omitempty" json:"traceFile,omitempty"`
+}
+
+type MmppState struct {
+	Rate            float64 `yaml:"rate" json:"rate"`
+	MeanDurationSec float64 `yaml:"meanDurationSec" json:"meanDurationSec"`
+}
+
+// A Process generates the arrival times of the UEs
+type Process interface {
+	// Next returns the delay to the next arrival, given the simulated time elapsed
+	// since the start of the process. It returns false when no arrival is left.
+	Next(elapsed time.Duration) (time.Duration, bool)
+}
+
+// NewProcess validates the configuration and builds the arrival process drawing from random
+func NewProcess(cfg *Config, random *utils.Random) (Process, error) {
+	switch cfg.Model {
+	case Poisson, "":
+		if cfg.Rate <= 0 {
+			return nil, fmt.Errorf("poisson arrivals require a positive rate")
+		}
+		return &poissonProcess{rate: cfg.Rate, random: random}, nil
+
+	case Deterministic:
+		if cfg.Rate <= 0 {
+			return nil, fmt.Errorf("deterministic arrivals require a positive rate")
+		}
+		return &deterministicProcess{interval: seconds(1 / cfg.Rate)}, nil
+
+	case Mmpp:
+		if len(cfg.States) == 0 {
+			return nil, fmt.Errorf("mmpp arrivals require at least one state")
+		}
+		active := false
+		for i, state := range cfg.States {
+			if state.Rate < 0 || state.MeanDurationSec <= 0 {
+				return nil, fmt.Errorf("mmpp state %d requires a non negative rate and a positive mean duration", i)
+			}
+			active = active || state.Rate > 0
+		}
+		// without any arrival the process would cycle through the states forever
+		if !active {
+			return nil, fmt.Errorf("mmpp arrivals require at least one state with a positive rate")
+		}
+		return &mmppProcess{states: cfg.States, random: random}, nil
+
+	case Diurnal:
+		if cfg.Rate <= 0 || cfg.PeriodSec <= 0 || cfg.Amplitude < 0 || cfg.Amplitude > 1 {
+			return nil, fmt.Errorf("diurnal arrivals require a positive rate and period, and an amplitude in [0, 1]")
+		}
+		return &thinningProcess{
+			random:  random,
+			maxRate: cfg.Rate * (1 + cfg.Amplitude),
+			rate: func(t float64) float64 {
+				return cfg.Rate * (1 + cfg.Amplitude*math.Cos(2*math.Pi*(t-cfg.PeakAtSec)/cfg.PeriodSec))
+			},
+		}, nil
+
+	case FlashCrowd:
+		if cfg.Rate < 0 || cfg.PeakRate <= 0 || cfg.DurationSec <= 0 || cfg.AtSec < 0 {
+			return nil, fmt.Errorf("flash crowd arrivals require a positive peak rate and duration")
+		}
+		return &thinningProcess{
+			random:  random,
+			maxRate: cfg.Rate + cfg.PeakRate,
+			rate: func(t float64) float64 {
+				if t >= cfg.AtSec && t < cfg.AtSec+cfg.DurationSec {
+					return cfg.Rate + cfg.PeakRate
+				}
+				return cfg.Rate
+			},
+			// without a base rate no arrival is left after the crowd
+			horizon: horizon(cfg.Rate, cfg.AtSec+cfg.DurationSec),
+		}, nil
+
+	case Trace:
+		times, err := loadTrace(cfg.TraceFile)
+		if err != nil {
+			return nil, err
+		}
+		return &traceProcess{times: times}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown arrival model %q", cfg.Model)
+	}
+}
+
+type poissonProcess struct {
+	rate   float64
+	random *utils.Random
+}
+
+func (p *poissonProcess) Next(elapsed time.Duration) (time.Duration, bool) {
+	return seconds(expRand(p.random, p.rate)), true
+}
+
+type deterministicProcess struct {
+	interval time.Duration
+}
+
+func (p *deterministicProcess) Next(elapsed time.Duration) (time.Duration, bool) {
+	return p.interval, true
+}
+
+// mmppProcess is a Markov modulated Poisson process: the arrival rate
+// changes with the state, held for an exponentially distributed time
+type mmppProcess struct {
+	states   []MmppState
+	random   *utils.Random
+	current  int
+	stateEnd float64
+	started  bool
+}
+
+func (p *mmppProcess) Next(elapsed time.Duration) (time.Duration, bool) {
+	now := elapsed.Seconds()
+	if !p.started {
+		p.started = true
+		p.stateEnd = now + expRand(p.random, 1/p.states[0].MeanDurationSec)
+	}
+
+	t := now
+	for {
+		state := p.states[p.current]
+		if state.Rate > 0 {
+			// the exponential distribution is memoryless, a sample crossing
+			// the end of the state is discarded and drawn again in the next state
+			if next := t + expRand(p.random, state.Rate); next < p.stateEnd {
+				return seconds(next - now), true
+			}
+		}
+		t = p.stateEnd
+		p.current = (p.current + 1) % len(p.states)
+		p.stateEnd = t + expRand(p.random, 1/p.states[p.current].MeanDurationSec)
+	}
+}
+
+// thinningProcess samples a non homogeneous Poisson process by thinning
+// a homogeneous process with rate maxRate
+type thinningProcess struct {
+	random  *utils.Random
+	maxRate float64
+	rate    func(t float64) float64
+	horizon float64
+}
+
+func (p *thinningProcess) Next(elapsed time.Duration) (time.Duration, bool) {
+	now := elapsed.Seconds()
+	t := now
+	for {
+		if p.horizon > 0 && t >= p.horizon {
+			return 0, false
+		}
+		t += expRand(p.random, p.maxRate)
+		if p.random.Float64()*p.maxRate < p.rate(t) {
+			return seconds(t - now), true
+		}
+	}
+}
+
+type traceProcess struct {
+	times []float64
+	next  int
+}
+
+func (p *traceProcess) Next(elapsed time.Duration) (time.Duration, bool) {
+	if p.next >= len(p.times) {
+		return 0, false
+	}
+	at := p.times[p.next]
+	p.next++
+	return max(seconds(at)-elapsed, 0), true
+}
+
+// horizon returns the end of a process whose rate drops to zero at end
+func horizon(rate float64, end float64) float64 {
+	if rate > 0 {
+		return 0
+	}
+	return end
+}
+
+// exponential random variable with mean 1/λ, in seconds
+func expRand(random *utils.Random, lambda float64) float64 {
+	u := random.Float64()
+	return -math.Log(1-u) / lambda
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
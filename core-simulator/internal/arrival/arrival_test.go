@@ -0,0 +1,213 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package arrival
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+)
+
+// arrivals returns the times of the first count arrivals of a process, in seconds
+func arrivals(t *testing.T, p Process, count int) []float64 {
+	t.Helper()
+	times := []float64{}
+	elapsed := time.Duration(0)
+	for len(times) < count {
+		delay, ok := p.Next(elapsed)
+		if !ok {
+			break
+		}
+		if delay < 0 {
+			t.Fatalf("got negative delay %s", delay)
+		}
+		elapsed += delay
+		times = append(times, elapsed.Seconds())
+	}
+	return times
+}
+
+func newProcess(t *testing.T, cfg Config) Process {
+	t.Helper()
+	p, err := NewProcess(&cfg, utils.NewRandom(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// checkRate checks the mean rate of the arrivals within a relative tolerance
+func checkRate(t *testing.T, times []float64, want float64, tolerance float64) {
+	t.Helper()
+	rate := float64(len(times)) / times[len(times)-1]
+	if math.Abs(rate-want) > want*tolerance {
+		t.Fatalf("got a rate of %.2f UEs/s, want %.2f", rate, want)
+	}
+}
+
+func TestNewProcessRejectsInvalidConfigs(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"poisson without rate", Config{Model: Poisson}},
+		{"deterministic with negative rate", Config{Model: Deterministic, Rate: -1}},
+		{"mmpp without states", Config{Model: Mmpp}},
+		{"mmpp with negative rate", Config{Model: Mmpp, States: []MmppState{{Rate: -1, MeanDurationSec: 1}}}},
+		{"mmpp without duration", Config{Model: Mmpp, States: []MmppState{{Rate: 1}}}},
+		{"mmpp without positive rate", Config{Model: Mmpp, States: []MmppState{{Rate: 0, MeanDurationSec: 1}, {Rate: 0, MeanDurationSec: 2}}}},
+		{"diurnal with amplitude above 1", Config{Model: Diurnal, Rate: 1, PeriodSec: 10, Amplitude: 1.5}},
+		{"diurnal without period", Config{Model: Diurnal, Rate: 1}},
+		{"flash crowd without peak", Config{Model: FlashCrowd, Rate: 1, DurationSec: 10}},
+		{"flash crowd without duration", Config{Model: FlashCrowd, PeakRate: 10}},
+		{"trace without file", Config{Model: Trace}},
+		{"trace with missing file", Config{Model: Trace, TraceFile: filepath.Join(t.TempDir(), "missing.csv")}},
+		{"unknown model", Config{Model: "PARETO", Rate: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewProcess(&tt.cfg, nil); err == nil {
+				t.Fatalf("got no error for %+v", tt.cfg)
+			}
+		})
+	}
+}
+
+func TestPoissonArrivals(t *testing.T) {
+	times := arrivals(t, newProcess(t, Config{Model: Poisson, Rate: 10}), 20000)
+	checkRate(t, times, 10, 0.05)
+
+	// the model defaults to poisson
+	times = arrivals(t, newProcess(t, Config{Rate: 2}), 20000)
+	checkRate(t, times, 2, 0.05)
+}
+
+func TestDeterministicArrivals(t *testing.T) {
+	times := arrivals(t, newProcess(t, Config{Model: Deterministic, Rate: 4}), 10)
+	for i, at := range times {
+		if want := float64(i+1) / 4; math.Abs(at-want) > 1e-9 {
+			t.Fatalf("got arrival %d at %.3fs, want %.3fs", i, at, want)
+		}
+	}
+}
+
+func TestMmppArrivals(t *testing.T) {
+	// an on-off source: no arrival for 1s on average, then 20 UEs/s for 1s on average
+	p := newProcess(t, Config{Model: Mmpp, States: []MmppState{
+		{Rate: 0, MeanDurationSec: 1},
+		{Rate: 20, MeanDurationSec: 1},
+	}})
+	times := arrivals(t, p, 20000)
+	checkRate(t, times, 10, 0.1)
+
+	// the arrivals come in bursts, the gaps of the off state are longer than the
+	// gaps of a poisson process with the same mean rate
+	long := 0
+	for i := 1; i < len(times); i++ {
+		if times[i]-times[i-1] > 0.5 {
+			long++
+		}
+	}
+	if poisson := float64(len(times)) * math.Exp(-10*0.5); float64(long) < 3*poisson {
+		t.Fatalf("got %d gaps longer than 0.5s, want bursts", long)
+	}
+}
+
+func TestDiurnalArrivals(t *testing.T) {
+	p := newProcess(t, Config{Model: Diurnal, Rate: 10, Amplitude: 0.8, PeriodSec: 100, PeakAtSec: 25})
+	times := arrivals(t, p, 50000)
+	checkRate(t, times, 10, 0.05)
+
+	// the rate is 18 UEs/s at the peak and 2 UEs/s at the trough
+	peak, trough := 0, 0
+	for _, at := range times {
+		switch phase := math.Mod(at, 100); {
+		case phase >= 20 && phase < 30:
+			peak++
+		case phase >= 70 && phase < 80:
+			trough++
+		}
+	}
+	if ratio := float64(peak) / float64(trough); ratio < 5 || ratio > 12 {
+		t.Fatalf("got %d arrivals around the peak and %d around the trough, want a ratio of about 8", peak, trough)
+	}
+}
+
+func TestFlashCrowdArrivals(t *testing.T) {
+	p := newProcess(t, Config{Model: FlashCrowd, Rate: 1, PeakRate: 99, AtSec: 100, DurationSec: 10})
+	times := arrivals(t, p, 2000)
+	during := 0
+	for _, at := range times {
+		if at >= 100 && at < 110 {
+			during++
+		}
+	}
+	if during < 900 || during > 1100 {
+		t.Fatalf("got %d arrivals during the flash crowd, want about 1000", during)
+	}
+
+	// without a base rate the arrivals stop with the flash crowd
+	p = newProcess(t, Config{Model: FlashCrowd, PeakRate: 50, AtSec: 10, DurationSec: 5})
+	times = arrivals(t, p, 10000)
+	if len(times) < 200 || len(times) > 300 {
+		t.Fatalf("got %d arrivals, want about 250", len(times))
+	}
+	if times[0] < 10 || times[len(times)-1] >= 15 {
+		t.Fatalf("got arrivals from %.2fs to %.2fs, want them within the flash crowd", times[0], times[len(times)-1])
+	}
+}
+
+func TestTraceArrivals(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "arrivals.csv")
+	if err := os.WriteFile(path, []byte("# time,count\n2.5\n\n1,2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p := newProcess(t, Config{Model: Trace, TraceFile: path})
+
+	want := []time.Duration{time.Second, 0, 1500 * time.Millisecond}
+	elapsed := time.Duration(0)
+	for i, w := range want {
+		delay, ok := p.Next(elapsed)
+		if !ok || delay != w {
+			t.Fatalf("got arrival %d after %s (%v), want %s", i, delay, ok, w)
+		}
+		elapsed += delay
+	}
+	if _, ok := p.Next(elapsed); ok {
+		t.Fatal("got an arrival past the end of the trace")
+	}
+
+	// a late process arrives right away
+	p = newProcess(t, Config{Model: Trace, TraceFile: path})
+	if delay, ok := p.Next(5 * time.Second); !ok || delay != 0 {
+		t.Fatalf("got delay %s, want an immediate arrival", delay)
+	}
+
+	if err := os.WriteFile(path, []byte("1\nsoon\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewProcess(&Config{Model: Trace, TraceFile: path}, nil); err == nil {
+		t.Fatal("got no error for an invalid arrival time")
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package arrival
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+)
+
+// DepartureConfig powers the UEs off after an exponentially distributed on time.
+// Departed UEs are powered on again by the following arrivals, once the whole
+// population has been spawned.
+type DepartureConfig struct {
+	MeanOnTimeSec float64 `yaml:"meanOnTimeSec" json:"meanOnTimeSec"`
+}
+
+func (d *DepartureConfig) Validate() error {
+	if d.MeanOnTimeSec <= 0 {
+		return fmt.Errorf("departures require a positive mean on time")
+	}
+	return nil
+}
+
+// OnTime draws the time a UE stays powered on
+func (d *DepartureConfig) OnTime(random *utils.Random) time.Duration {
+	return seconds(expRand(random, 1/d.MeanOnTimeSec))
+}
@@ -0,0 +1,76 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package arrival
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// loadTrace reads the arrival times from a trace file, one time in seconds
+// from the start of the simulation per line. Lines starting with # are ignored,
+// an optional second column replicates the arrival (e.g. "12.5,3").
+func loadTrace(path string) ([]float64, error) {
+	if path == "" {
+		return nil, fmt.Errorf("trace arrivals require a trace file")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open arrival trace: %w", err)
+	}
+	defer file.Close()
+
+	times := []float64{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.FieldsFunc(text, func(r rune) bool {
+			return r == ',' || r == ';' || r == ' ' || r == '\t'
+		})
+		at, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil || at < 0 {
+			return nil, fmt.Errorf("invalid arrival time at line %d of %s", line, path)
+		}
+		count := 1
+		if len(fields) > 1 {
+			count, err = strconv.Atoi(fields[1])
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("invalid arrival count at line %d of %s", line, path)
+			}
+		}
+		for i := 0; i < count; i++ {
+			times = append(times, at)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read arrival trace: %w", err)
+	}
+
+	sort.Float64s(times)
+	return times, nil
+}
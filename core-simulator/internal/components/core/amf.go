@@ -20,37 +20,74 @@
 package core
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/giuliocarot0/gitc"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
 	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
 )
 
+// AmfEventObserver is invoked for every event report generated by the AMF,
+// regardless of the presence of subscribers.
+type AmfEventObserver func(report models.AmfEventReport)
+
+// amfIdentifier is the AMF of the GUAMI of the simulated AMF: region 1, set 1, pointer 0
+const amfIdentifier = "010040"
+
 type Amf struct {
-	PlmnId        models.PlmnId
-	AmfId         string
-	Subscriptions map[models.AmfEventTypeAnyOf][]string
+	PlmnId models.PlmnId
+	AmfId  string
+	// NF instance id of the AMF
+	InstanceId    string
+	Subscriptions map[string]*models.AmfEventSubscription // subscriptionId -> subscription
 	SubMutex      sync.RWMutex
+	reports       map[string]*reportState[models.AmfEventReport] // subscriptionId -> notification method
+	observers     []AmfEventObserver
+	notifier      *Notifier
+	// idle UEs are implicitly deregistered after the mobile reachable timer, a non positive value disables it
+	MobileReachableTimer time.Duration
+	// clock of the simulation, the wall clock when nil
+	Clock *utils.Clock
+	// dispatcher of the tasks of the simulation, shared with the UEs
+	Dispatcher *gitc.Dispatcher
+
+	ueContexts map[string]*amfUeContext // supi -> context of the registered UEs
+	barred     map[string]bool
+	ctxMutex   sync.Mutex
+	pause      *utils.PauseGate
+	paused     time.Duration // total time spent paused
+	stop       chan struct{}
 }
 
-func NewAmf(plmnId models.PlmnId) *Amf {
+func NewAmf(plmnId models.PlmnId, pause *utils.PauseGate, notifier *Notifier) *Amf {
 	return &Amf{
-		PlmnId:        plmnId,
-		AmfId:         fmt.Sprintf("AMF-%s%s", plmnId.Mcc, plmnId.Mnc),
-		Subscriptions: make(map[models.AmfEventTypeAnyOf][]string),
-		SubMutex:      sync.RWMutex{},
+		PlmnId:               plmnId,
+		AmfId:                fmt.Sprintf("AMF-%s%s", plmnId.Mcc, plmnId.Mnc),
+		InstanceId:           uuid.NewString(),
+		Subscriptions:        make(map[string]*models.AmfEventSubscription),
+		reports:              make(map[string]*reportState[models.AmfEventReport]),
+		SubMutex:             sync.RWMutex{},
+		notifier:             notifier,
+		MobileReachableTimer: DefaultMobileReachableTimer,
+		ueContexts:           make(map[string]*amfUeContext),
+		barred:               make(map[string]bool),
+		pause:                pause,
+		Dispatcher:           gitc.GlobalDispatcher,
 	}
 }
 
 func (amf *Amf) InitAmf() {
 	log.Printf("[%s] started", amf.AmfId)
-	err := gitc.StartTask("AMF", func(msg gitc.Message) {
+	err := amf.Dispatcher.StartTask("AMF", func(msg gitc.Message) {
 		switch msg.Type {
 		case models.UeToAmfType:
 			//			log.Printf("[%s] Received message UeToAmfMsg from %s", amf.AmfId, msg.From)
@@ -60,21 +97,39 @@ func (amf *Amf) InitAmf() {
 	if err != nil {
 		log.Fatalf("[%s] could not start AMF task: %s", amf.AmfId, err.Error())
 	}
+	amf.stop = make(chan struct{})
+	go amf.runReports(amf.stop)
+	if amf.MobileReachableTimer > 0 {
+		go amf.runReachability(amf.stop)
+	}
+}
+
+// StopAmf terminates the AMF task
+func (amf *Amf) StopAmf() {
+	if amf.stop != nil {
+		close(amf.stop)
+		amf.stop = nil
+	}
+	if err := amf.Dispatcher.StopTask("AMF"); err != nil {
+		log.Printf("[%s] could not stop AMF task: %s", amf.AmfId, err.Error())
+		return
+	}
+	log.Printf("[%s] stopped", amf.AmfId)
 }
 
 func (amf *Amf) handleUeToAmfEvent(msg *models.UeToAmfMsg) {
 	//log.Printf("[%s] UeToAmfMsg: %+v", amf.AmfId, msg)
 
-	// Process the message and notify subscribers
-	amf.SubMutex.RLock()
-	defer amf.SubMutex.RUnlock()
+	// the events of barred subscribers are not reported
+	if !amf.trackUeContext(msg) {
+		return
+	}
 
 	//prepare the basic report
 	amfReport := models.AmfEventReport{
 		Type:      msg.EventType,
 		TimeStamp: msg.TimeStamp,
 		Supi:      models.PtrString(msg.Supi),
-		Gpsi:      models.PtrString(msg.Gpsi), // MSISDN in E.164 format
 		State: models.AmfEventState{
 			Active: true},
 		AccessTypeList: []models.AccessType{msg.AccessType},
@@ -94,6 +149,16 @@ func (amf *Amf) handleUeToAmfEvent(msg *models.UeToAmfMsg) {
 		},
 	}
 
+	if msg.Gpsi != "" {
+		amfReport.Gpsi = models.PtrString(msg.Gpsi) // MSISDN in E.164 format
+	}
+	if msg.Pei != "" {
+		amfReport.Pei = models.PtrString("imei-" + msg.Pei)
+	}
+	if msg.Suci != "" {
+		amfReport.Suci = models.PtrString(msg.Suci)
+	}
+
 	switch msg.EventType {
 	case models.AMFEVENTTYPEANYOF_CONNECTIVITY_STATE_REPORT:
 		amfReport.CmInfoList = []models.CmInfo{{
@@ -109,13 +174,45 @@ func (amf *Amf) handleUeToAmfEvent(msg *models.UeToAmfMsg) {
 		// no need to add anything specific for location report
 	case models.AMFEVENTTYPEANYOF_LOSS_OF_CONNECTIVITY:
 		amfReport.LossOfConnectReason = models.LOSSOFCONNECTIVITYREASONANYOF_DEREGISTERED
+		if msg.LossReason != "" {
+			amfReport.LossOfConnectReason = msg.LossReason
+		}
 
 	case models.AMFEVENTTYPEANYOF_UES_IN_AREA_REPORT:
 	}
 
-	// buildup the notification structure
+	// the observers run outside of SubMutex, they call the other network functions
+	amf.SubMutex.RLock()
+	observers := amf.observers
+	amf.SubMutex.RUnlock()
+	for _, observer := range observers {
+		observer(amfReport)
+	}
+
+	// Process the message and notify subscribers
+	amf.SubMutex.RLock()
+	defer amf.SubMutex.RUnlock()
+
+	// one time subscriptions and the ones reaching their maximum number of reports
+	// are removed by the next check of the reports
+
+	for subId, sub := range amf.Subscriptions {
+		if !subscribedToAmfEvent(sub, msg.EventType) || !targetsUe(sub.Supi, msg.Supi) {
+			continue
+		}
+		report := amfReport
+		report.SubscriptionId = models.PtrString(subId)
+		if reports := amf.reports[subId].add(report, amf.Clock.Now()); len(reports) > 0 {
+			amf.notify(subId, sub, reports)
+		}
+	}
+}
+
+// notify sends the reports of a subscription to its subscriber
+func (amf *Amf) notify(subId string, sub *models.AmfEventSubscription, reports []models.AmfEventReport) {
 	amfNotification := &models.AmfEventNotification{
-		ReportList: []models.AmfEventReport{amfReport},
+		NotifyCorrelationId: models.PtrString(sub.NotifyCorrelationId),
+		ReportList:          reports,
 	}
 
 	//	log.Printf("[%s] generating notification : %+v", amf.AmfId, amfNotification)
@@ -126,19 +223,80 @@ func (amf *Amf) handleUeToAmfEvent(msg *models.UeToAmfMsg) {
 		return
 	}
 
-	for _, callbackUrl := range amf.Subscriptions[msg.EventType] {
-		go func(url string, data []byte) {
-			resp, err := http.Post(url, "application/json", bytes.NewBuffer(data)) // Replace nil with actual payload if needed
-			if err != nil {
-				log.Printf("Error notifying subscriber %s: %v", url, err)
-				return
+	amf.notifier.Notify(&Notification{
+		Source:         amf.AmfId,
+		SubscriptionId: subId,
+		Uri:            sub.EventNotifyUri,
+		Body:           callbackBody,
+		OnFailure: func() {
+			amf.removeSubscription(subId, "the subscriber is not reachable")
+		},
+	})
+}
+
+// runReports sends the periodic reports and removes the subscriptions that are over
+func (amf *Amf) runReports(stop <-chan struct{}) {
+	ticker := time.NewTicker(reportCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		advanced := amf.Clock.Advanced()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		case <-advanced:
+		}
+
+		now := amf.Clock.Now()
+		over := []string{}
+		amf.SubMutex.RLock()
+		for subId, sub := range amf.Subscriptions {
+			state := amf.reports[subId]
+			if reports := state.flush(now); len(reports) > 0 {
+				amf.notify(subId, sub, reports)
 			}
-			defer func() {
-				_ = resp.Body.Close()
-			}()
-			//log.Printf("Notified subscriber %s with response status: %s", url, resp.Status)
-		}(callbackUrl, callbackBody)
+			if state.over(now) {
+				over = append(over, subId)
+			}
+		}
+		amf.SubMutex.RUnlock()
+
+		for _, subId := range over {
+			amf.removeSubscription(subId, "the subscription is over")
+		}
+	}
+}
+
+// Page sends a paging request to an idle UE
+func (amf *Amf) Page(supi string) error {
+	msg := &models.AmfToUeMsg{
+		Command:   models.AmfPaging,
+		TimeStamp: amf.Clock.Now(),
+		Supi:      supi,
 	}
+	return amf.Dispatcher.Send("AMF", ran.RanTask, models.AmfToUeType, msg)
+}
+
+// targetsUe reports whether a subscription for the given SUPI, any UE when nil, covers the UE
+func targetsUe(supi *string, ueSupi string) bool {
+	return supi == nil || strings.TrimPrefix(*supi, "imsi-") == strings.TrimPrefix(ueSupi, "imsi-")
+}
+
+func subscribedToAmfEvent(sub *models.AmfEventSubscription, eventType models.AmfEventTypeAnyOf) bool {
+	for _, event := range sub.EventList {
+		if event.Type == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterObserver adds an internal observer of the AMF events
+func (amf *Amf) RegisterObserver(observer AmfEventObserver) {
+	amf.SubMutex.Lock()
+	defer amf.SubMutex.Unlock()
+	amf.observers = append(amf.observers, observer)
 }
 
 // NORTHBOUND Definitions
@@ -147,45 +305,176 @@ func (amf *Amf) HandleNewSubscription(w http.ResponseWriter, r *http.Request) {
 
 	subData := &models.AmfCreateEventSubscription{}
 
-	if err := json.NewDecoder(r.Body).Decode(subData); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if !decodeRequest(w, r, subData, WriteProblem) {
 		return
 	}
 
-	sub, ok := subData.GetSubscriptionOk()
-	if !ok {
-		http.Error(w, "could not find subscription information", http.StatusBadRequest)
+	v := newValidator(amf.PlmnId)
+	v.validateAmfCreateEventSubscription(subData)
+	if v.reply(w, WriteProblem) {
 		return
 	}
+	sub := &subData.Subscription
 
-	callbackUrl, ok := sub.GetEventNotifyUriOk()
-	if !ok {
-		http.Error(w, "could not find callbackUri information", http.StatusBadRequest)
+	state, err := newAmfReportState(sub.Options, amf.Clock.Now())
+	if err != nil {
+		reportModeProblem(w, err, map[string]string{
+			"method":     "/subscription/options/trigger",
+			"repPeriod":  "/subscription/options/repPeriod",
+			"maxReports": "/subscription/options/maxReports",
+		})
 		return
 	}
 
+	subId := uuid.New().String()
+
+	amf.SubMutex.Lock()
+	amf.Subscriptions[subId] = sub
+	amf.reports[subId] = state
+	amf.SubMutex.Unlock()
+
+	w.Header().Set("Location", "/namf-evts/v1/subscriptions/"+subId)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+
+	if err := json.NewEncoder(w).Encode(models.NewAmfCreatedEventSubscription(*sub, subId)); err != nil {
+		log.Printf("[%s] could not encode response: %s", amf.AmfId, err.Error())
+	}
+
+	log.Printf("[%s] created new subscription %s for: %s", amf.AmfId, subId, sub.EventNotifyUri)
+}
+
+// newAmfReportState applies the event reporting mode of a subscription, continuous by default
+func newAmfReportState(mode *models.AmfEventMode, now time.Time) (*reportState[models.AmfEventReport], error) {
+	if mode == nil {
+		return newReportState[models.AmfEventReport](reportOnEvent, nil, nil, nil, now)
+	}
+	method := reportOnEvent
+	if trigger := mode.Trigger.AmfEventTriggerAnyOf; trigger != nil {
+		switch *trigger {
+		case models.AMFEVENTTRIGGERANYOF_ONE_TIME:
+			method = reportOneTime
+		case models.AMFEVENTTRIGGERANYOF_PERIODIC:
+			method = reportPeriodic
+		}
+	}
+	return newReportState[models.AmfEventReport](method, mode.RepPeriod, mode.MaxReports, mode.Expiry, now)
+}
+
+// removeSubscription deletes a subscription
+func (amf *Amf) removeSubscription(subId string, reason string) {
 	amf.SubMutex.Lock()
 	defer amf.SubMutex.Unlock()
+	if _, ok := amf.Subscriptions[subId]; ok {
+		delete(amf.Subscriptions, subId)
+		delete(amf.reports, subId)
+		log.Printf("[%s] removed subscription %s, %s", amf.AmfId, subId, reason)
+	}
+}
 
-	for _, event := range sub.GetEventList() {
-		if listSub, exist := amf.Subscriptions[event.Type]; exist {
-			amf.Subscriptions[event.Type] = append(listSub, *callbackUrl)
-		} else {
-			amf.Subscriptions[event.Type] = []string{*callbackUrl}
+// TransferSubscription moves a subscription to a new subscription id, as done when it is
+// transferred to another AMF, and notifies the new id to the subscriber
+func (amf *Amf) TransferSubscription(subId string) (string, error) {
+	amf.SubMutex.Lock()
+	defer amf.SubMutex.Unlock()
+
+	sub, ok := amf.Subscriptions[subId]
+	if !ok {
+		return "", ErrSubscriptionNotFound
+	}
+	return amf.transferLocked(subId, sub, models.AMFEVENTTYPEANYOF_SUBSCRIPTION_ID_CHANGE), nil
+}
+
+// RelocateSubscriptions emulates a change of serving AMF: the subscriptions get a new id on the
+// target AMF, notified as SUBSCRIPTION_ID_CHANGE for the UE specific subscriptions and as
+// SUBSCRIPTION_ID_ADDITION for the any UE and group subscriptions. It returns the new ids
+// indexed by the old ones.
+func (amf *Amf) RelocateSubscriptions() map[string]string {
+	amf.SubMutex.Lock()
+	defer amf.SubMutex.Unlock()
+
+	subs := make(map[string]*models.AmfEventSubscription, len(amf.Subscriptions))
+	for subId, sub := range amf.Subscriptions {
+		subs[subId] = sub
+	}
+	transferred := make(map[string]string, len(subs))
+	for subId, sub := range subs {
+		change := models.AMFEVENTTYPEANYOF_SUBSCRIPTION_ID_CHANGE
+		if sub.Supi == nil && sub.Gpsi == nil {
+			change = models.AMFEVENTTYPEANYOF_SUBSCRIPTION_ID_ADDITION
 		}
+		transferred[subId] = amf.transferLocked(subId, sub, change)
 	}
+	log.Printf("[%s] relocated %d subscriptions", amf.AmfId, len(transferred))
+	return transferred
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+func (amf *Amf) transferLocked(subId string, sub *models.AmfEventSubscription, change models.AmfEventTypeAnyOf) string {
+	newId := uuid.New().String()
+	amf.Subscriptions[newId] = sub
+	amf.reports[newId] = amf.reports[subId]
+	delete(amf.Subscriptions, subId)
+	delete(amf.reports, subId)
+	log.Printf("[%s] subscription %s transferred to %s", amf.AmfId, subId, newId)
+
+	if sub.SubsChangeNotifyUri == nil {
+		return newId
+	}
+	amfNotification := &models.AmfEventNotification{
+		SubsChangeNotifyCorrelationId: sub.SubsChangeNotifyCorrelationId,
+		ReportList: []models.AmfEventReport{{
+			Type:           change,
+			TimeStamp:      amf.Clock.Now(),
+			State:          models.AmfEventState{Active: true},
+			SubscriptionId: models.PtrString(newId),
+			Supi:           sub.Supi,
+			Gpsi:           sub.Gpsi,
+		}},
+	}
+	callbackBody, err := json.Marshal(amfNotification)
+	if err != nil {
+		log.Printf("[%s] error while marshalling notification: %s", amf.AmfId, err.Error())
+		return newId
+	}
+	// the subscription change has its own queue: a failure does not remove the subscription
+	amf.notifier.Notify(&Notification{
+		Source:         amf.AmfId,
+		SubscriptionId: newId + "/subs-change",
+		Uri:            *sub.SubsChangeNotifyUri,
+		Body:           callbackBody,
+	})
+	return newId
+}
+
+// GetSubscriptions returns a copy of the active event subscriptions indexed by subscription id
+func (amf *Amf) GetSubscriptions() map[string]models.AmfEventSubscription {
+	amf.SubMutex.RLock()
+	defer amf.SubMutex.RUnlock()
 
-	if err := json.NewEncoder(w).Encode(subData); err != nil {
-		http.Error(w, "could not encode response", http.StatusInternalServerError)
+	subs := make(map[string]models.AmfEventSubscription, len(amf.Subscriptions))
+	for subId, sub := range amf.Subscriptions {
+		subs[subId] = *sub
 	}
+	return subs
+}
+
+// HandleDeleteSubscription removes a subscription, TS 29.518 DeleteSubscription
+func (amf *Amf) HandleDeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	subId := mux.Vars(r)["subscriptionId"]
 
-	log.Printf("[%s] created new subscription for: %s", amf.AmfId, *callbackUrl)
+	amf.SubMutex.RLock()
+	_, ok := amf.Subscriptions[subId]
+	amf.SubMutex.RUnlock()
+	if !ok {
+		WriteProblem(w, http.StatusNotFound, CauseSubscriptionNotFound, "subscription "+subId+" is not found")
+		return
+	}
+	amf.removeSubscription(subId, "deleted by the subscriber")
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (amf *Amf) RegisterNorthboundAPIs(r *mux.Router) {
-	r.HandleFunc("/namf-evts/v1/subscriptions", amf.HandleNewSubscription)
+	r.HandleFunc("/namf-evts/v1/subscriptions", amf.HandleNewSubscription).Methods(http.MethodPost)
+	r.HandleFunc("/namf-evts/v1/subscriptions/{subscriptionId}", amf.HandleDeleteSubscription).Methods(http.MethodDelete)
 	log.Printf("[%s] namf-evts has been registered", amf.AmfId)
 }
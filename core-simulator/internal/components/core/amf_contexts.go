@@ -0,0 +1,232 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* UE contexts kept by the AMF: mobile reachability and subscriber barring */
+
+// DefaultMobileReachableTimer is the default periodic registration timer (T3512, 54 minutes)
+// plus the 4 minutes of margin of TS 24.501
+const DefaultMobileReachableTimer = 58 * time.Minute
+
+// interval between two checks of the mobile reachable timers
+const reachabilityCheckInterval = time.Second
+
+var ErrUeContextNotFound = errors.New("ue context not found")
+
+// amfUeContext is the view of the AMF on a registered UE
+type amfUeContext struct {
+	cmState models.CmState
+	// start of the CM-IDLE period, on the AMF clock
+	idleSince time.Time
+}
+
+// AmfUeContext is a point in time view of a UE context of the AMF
+type AmfUeContext struct {
+	Supi    string         `json:"supi"`
+	CmState models.CmState `json:"cmState"`
+	Barred  bool           `json:"barred"`
+}
+
+// clock returns the current time of the AMF, which stops while the simulation is paused
+func (amf *Amf) clock() time.Time {
+	return amf.Clock.Now().Add(-amf.paused)
+}
+
+// trackUeContext updates the UE context with a report of the UE. It returns false
+// if the report comes from a barred subscriber, whose registration is rejected.
+// The deregistration reports of a barred subscriber are still accepted.
+func (amf *Amf) trackUeContext(msg *models.UeToAmfMsg) bool {
+	amf.ctxMutex.Lock()
+	defer amf.ctxMutex.Unlock()
+
+	ueCtx, registered := amf.ueContexts[msg.Supi]
+	if amf.barred[msg.Supi] && !registered && msg.RmState == models.RmStateRegistered {
+		if msg.EventType == models.AMFEVENTTYPEANYOF_REGISTRATION_STATE_REPORT {
+			log.Printf("[%s] rejecting registration of barred ue %s", amf.AmfId, msg.Supi)
+			amf.sendDeregistration(msg.Supi, models.AmfRegistrationReject, models.LOSSOFCONNECTIVITYREASONANYOF_PURGED)
+		}
+		return false
+	}
+
+	switch msg.EventType {
+	case models.AMFEVENTTYPEANYOF_REGISTRATION_STATE_REPORT:
+		if msg.RmState != models.RmStateRegistered {
+			delete(amf.ueContexts, msg.Supi)
+			return true
+		}
+		ueCtx = &amfUeContext{}
+		amf.ueContexts[msg.Supi] = ueCtx
+	case models.AMFEVENTTYPEANYOF_LOSS_OF_CONNECTIVITY:
+		delete(amf.ueContexts, msg.Supi)
+		return true
+	}
+	if ueCtx == nil {
+		return true
+	}
+
+	if msg.CmState == models.CmStateIdle && ueCtx.cmState != models.CmStateIdle {
+		ueCtx.idleSince = amf.clock()
+	}
+	ueCtx.cmState = msg.CmState
+	return true
+}
+
+// checkReachability implicitly deregisters the UEs idle for longer than the mobile reachable timer
+func (amf *Amf) checkReachability() {
+	amf.ctxMutex.Lock()
+	defer amf.ctxMutex.Unlock()
+
+	expiry := amf.clock().Add(-amf.MobileReachableTimer)
+	for supi, ueCtx := range amf.ueContexts {
+		if ueCtx.cmState == models.CmStateIdle && ueCtx.idleSince.Before(expiry) {
+			log.Printf("[%s] mobile reachable timer of ue %s expired, implicit deregistration", amf.AmfId, supi)
+			delete(amf.ueContexts, supi)
+			amf.sendDeregistration(supi, models.AmfImplicitDeregistration, models.LOSSOFCONNECTIVITYREASONANYOF_MAX_DETECTION_TIME_EXPIRED)
+		}
+	}
+}
+
+// runReachability drives the mobile reachable timers until the AMF is stopped
+func (amf *Amf) runReachability(stop <-chan struct{}) {
+	ticker := time.NewTicker(reachabilityCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		advanced := amf.Clock.Advanced()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		case <-advanced:
+		}
+
+		if amf.pause != nil && amf.pause.IsPaused() {
+			pausedAt := amf.Clock.Now().Add(-amf.pause.PausedFor())
+			for amf.pause.IsPaused() {
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+				}
+			}
+			amf.ctxMutex.Lock()
+			amf.paused += amf.Clock.Since(pausedAt)
+			amf.ctxMutex.Unlock()
+		}
+		amf.checkReachability()
+	}
+}
+
+// Deregister performs a network initiated deregistration of a registered UE
+func (amf *Amf) Deregister(supi string) error {
+	amf.ctxMutex.Lock()
+	defer amf.ctxMutex.Unlock()
+
+	if _, ok := amf.ueContexts[supi]; !ok {
+		return ErrUeContextNotFound
+	}
+	delete(amf.ueContexts, supi)
+	return amf.sendDeregistration(supi, models.AmfDeregistration, models.LOSSOFCONNECTIVITYREASONANYOF_DEREGISTERED)
+}
+
+// Bar bars a subscriber: a registered UE is deregistered as purged and
+// the following registrations are rejected until the barring is lifted.
+func (amf *Amf) Bar(supi string) error {
+	amf.ctxMutex.Lock()
+	defer amf.ctxMutex.Unlock()
+
+	amf.barred[supi] = true
+	log.Printf("[%s] barred ue %s", amf.AmfId, supi)
+	if _, ok := amf.ueContexts[supi]; !ok {
+		return nil
+	}
+	delete(amf.ueContexts, supi)
+	return amf.sendDeregistration(supi, models.AmfDeregistration, models.LOSSOFCONNECTIVITYREASONANYOF_PURGED)
+}
+
+// Unbar lifts the barring of a subscriber, it returns false if the subscriber was not barred
+func (amf *Amf) Unbar(supi string) bool {
+	amf.ctxMutex.Lock()
+	defer amf.ctxMutex.Unlock()
+
+	if !amf.barred[supi] {
+		return false
+	}
+	delete(amf.barred, supi)
+	log.Printf("[%s] lifted barring of ue %s", amf.AmfId, supi)
+	return true
+}
+
+// IsBarred reports whether the subscriber is barred
+func (amf *Amf) IsBarred(supi string) bool {
+	amf.ctxMutex.Lock()
+	defer amf.ctxMutex.Unlock()
+	return amf.barred[supi]
+}
+
+// BarredUes returns the barred subscribers sorted by SUPI
+func (amf *Amf) BarredUes() []string {
+	amf.ctxMutex.Lock()
+	defer amf.ctxMutex.Unlock()
+
+	barred := make([]string, 0, len(amf.barred))
+	for supi := range amf.barred {
+		barred = append(barred, supi)
+	}
+	sort.Strings(barred)
+	return barred
+}
+
+// GetUeContext returns the context of a registered UE
+func (amf *Amf) GetUeContext(supi string) (AmfUeContext, bool) {
+	amf.ctxMutex.Lock()
+	defer amf.ctxMutex.Unlock()
+
+	ueCtx, ok := amf.ueContexts[supi]
+	if !ok {
+		return AmfUeContext{}, false
+	}
+	return AmfUeContext{Supi: supi, CmState: ueCtx.cmState, Barred: amf.barred[supi]}, true
+}
+
+// sendDeregistration tells the UE it is no longer registered
+func (amf *Amf) sendDeregistration(supi string, command models.AmfToUeCommand, reason models.LossOfConnectivityReasonAnyOf) error {
+	msg := &models.AmfToUeMsg{
+		Command:   command,
+		TimeStamp: amf.Clock.Now(),
+		Supi:      supi,
+		Reason:    reason,
+	}
+	err := amf.Dispatcher.Send("AMF", ran.RanTask, models.AmfToUeType, msg)
+	if err != nil {
+		log.Printf("[%s] could not deregister ue %s: %s", amf.AmfId, supi, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,304 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* Binding Support Function, PCF discovery by UE address of TS 29.521 */
+
+// bsfBinding is a registered binding, the sequence orders the bindings matching a query
+type bsfBinding struct {
+	seq     uint64
+	binding models.PcfBinding
+}
+
+// A Bsf holds the bindings of the PDU sessions to the PCF serving them. The SMF registers
+// the bindings of the sessions it establishes, the consumers of Npcf_PolicyAuthorization
+// discover the PCF of a UE address by querying the BSF.
+type Bsf struct {
+	PlmnId models.PlmnId
+	BsfId  string
+
+	bindings map[string]*bsfBinding // bindingId -> binding
+	seq      uint64
+	mutex    sync.RWMutex
+}
+
+func NewBsf(plmnId models.PlmnId) *Bsf {
+	return &Bsf{
+		PlmnId:   plmnId,
+		BsfId:    fmt.Sprintf("BSF-%s%s", plmnId.Mcc, plmnId.Mnc),
+		bindings: make(map[string]*bsfBinding),
+	}
+}
+
+// sameAddress tells whether two bindings are for the same UE address in the same IP domain
+func sameAddress(a *models.PcfBinding, b *models.PcfBinding) bool {
+	if a.GetIpDomain() != b.GetIpDomain() {
+		return false
+	}
+	return (a.Ipv4Addr != nil && b.Ipv4Addr != nil && *a.Ipv4Addr == *b.Ipv4Addr) ||
+		(a.Ipv6Prefix != nil && b.Ipv6Prefix != nil && *a.Ipv6Prefix == *b.Ipv6Prefix)
+}
+
+// Register adds a binding, it fails if a binding exists for the same UE address
+func (bsf *Bsf) Register(binding models.PcfBinding) (string, error) {
+	bsf.mutex.Lock()
+	defer bsf.mutex.Unlock()
+
+	for id, existing := range bsf.bindings {
+		if sameAddress(&existing.binding, &binding) {
+			return id, fmt.Errorf("binding %s exists for the UE address", id)
+		}
+	}
+	id := uuid.NewString()
+	bsf.seq++
+	bsf.bindings[id] = &bsfBinding{seq: bsf.seq, binding: binding}
+	return id, nil
+}
+
+// Deregister removes a binding, it returns false if the binding does not exist
+func (bsf *Bsf) Deregister(bindingId string) bool {
+	bsf.mutex.Lock()
+	defer bsf.mutex.Unlock()
+
+	_, ok := bsf.bindings[bindingId]
+	delete(bsf.bindings, bindingId)
+	return ok
+}
+
+// GetBindings returns a copy of the bindings indexed by binding id
+func (bsf *Bsf) GetBindings() map[string]models.PcfBinding {
+	bsf.mutex.RLock()
+	defer bsf.mutex.RUnlock()
+
+	bindings := make(map[string]models.PcfBinding, len(bsf.bindings))
+	for id, registered := range bsf.bindings {
+		bindings[id] = registered.binding
+	}
+	return bindings
+}
+
+// bindingQuery holds the query parameters of a binding discovery, TS 29.521 clause 6.1.3.2.3.1
+type bindingQuery struct {
+	ipv4Addr   *netip.Addr
+	ipv6Prefix *netip.Prefix
+	ipDomain   string
+	supi       string
+	gpsi       string
+	dnn        string
+	snssai     *models.Snssai
+}
+
+// matches tells whether a binding matches all the parameters of the query. An IPv6 address or
+// prefix of the query matches the bindings whose prefix contains it.
+func (q *bindingQuery) matches(binding *models.PcfBinding) bool {
+	if q.ipv4Addr != nil && (binding.Ipv4Addr == nil || *binding.Ipv4Addr != q.ipv4Addr.String()) {
+		return false
+	}
+	if q.ipv6Prefix != nil {
+		if binding.Ipv6Prefix == nil {
+			return false
+		}
+		prefix, err := netip.ParsePrefix(*binding.Ipv6Prefix)
+		if err != nil || prefix.Bits() > q.ipv6Prefix.Bits() || !prefix.Contains(q.ipv6Prefix.Addr()) {
+			return false
+		}
+	}
+	if q.ipDomain != "" && binding.GetIpDomain() != q.ipDomain {
+		return false
+	}
+	if q.supi != "" && strings.TrimPrefix(binding.GetSupi(), "imsi-") != strings.TrimPrefix(q.supi, "imsi-") {
+		return false
+	}
+	if q.gpsi != "" && binding.GetGpsi() != q.gpsi {
+		return false
+	}
+	if q.dnn != "" && binding.Dnn != q.dnn {
+		return false
+	}
+	return q.snssai == nil || sameSnssai(binding.Snssai, *q.snssai)
+}
+
+// parseBindingQuery decodes the query parameters of a binding discovery, a UE address or
+// identity is mandatory
+func (bsf *Bsf) parseBindingQuery(w http.ResponseWriter, r *http.Request) (*bindingQuery, bool) {
+	values := r.URL.Query()
+	q := &bindingQuery{
+		ipDomain: values.Get("ipDomain"),
+		supi:     values.Get("supi"),
+		gpsi:     values.Get("gpsi"),
+		dnn:      values.Get("dnn"),
+	}
+	invalid := func(param string, reason string) (*bindingQuery, bool) {
+		WriteProblem(w, http.StatusBadRequest, CauseInvalidQueryParam, param+" is invalid", InvalidParam(param, reason))
+		return nil, false
+	}
+	if raw := values.Get("ipv4Addr"); raw != "" {
+		addr, err := netip.ParseAddr(raw)
+		if err != nil || !addr.Is4() {
+			return invalid("ipv4Addr", "not an IPv4 address")
+		}
+		q.ipv4Addr = &addr
+	}
+	if raw := values.Get("ipv6Prefix"); raw != "" {
+		prefix, err := netip.ParsePrefix(raw)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(raw)
+			if addrErr != nil || !addr.Is6() {
+				return invalid("ipv6Prefix", "not an IPv6 prefix")
+			}
+			prefix = netip.PrefixFrom(addr, 128)
+		}
+		if !prefix.Addr().Is6() {
+			return invalid("ipv6Prefix", "not an IPv6 prefix")
+		}
+		q.ipv6Prefix = &prefix
+	}
+	if raw := values.Get("snssai"); raw != "" {
+		q.snssai = &models.Snssai{}
+		if err := json.Unmarshal([]byte(raw), q.snssai); err != nil {
+			return invalid("snssai", "not a S-NSSAI")
+		}
+	}
+	if q.ipv4Addr == nil && q.ipv6Prefix == nil && q.supi == "" && q.gpsi == "" {
+		WriteProblem(w, http.StatusBadRequest, CauseMandatoryQueryParamMissing, "the UE address or identity is missing",
+			InvalidParam("ipv4Addr", "missing"))
+		return nil, false
+	}
+	return q, true
+}
+
+// NORTHBOUND Definitions
+
+// HandleGetBinding answers with the binding of a UE address or identity, the first registered
+// one when several bindings match, TS 29.521 clause 5.2.2.2
+func (bsf *Bsf) HandleGetBinding(w http.ResponseWriter, r *http.Request) {
+	q, ok := bsf.parseBindingQuery(w, r)
+	if !ok {
+		return
+	}
+
+	bsf.mutex.RLock()
+	var found *bsfBinding
+	for _, registered := range bsf.bindings {
+		if q.matches(&registered.binding) && (found == nil || registered.seq < found.seq) {
+			found = registered
+		}
+	}
+	var binding models.PcfBinding
+	if found != nil {
+		binding = found.binding
+	}
+	bsf.mutex.RUnlock()
+
+	if found == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(&binding); err != nil {
+		log.Printf("[%s] could not serialize response body: %s", bsf.BsfId, err.Error())
+	}
+}
+
+// HandleNewBinding registers the binding of a consumer, TS 29.521 clause 5.2.2.3
+func (bsf *Bsf) HandleNewBinding(w http.ResponseWriter, r *http.Request) {
+	binding := &models.PcfBinding{}
+	if !decodeRequest(w, r, binding, WriteProblem) {
+		return
+	}
+
+	v := newValidator(bsf.PlmnId)
+	v.mandatory("/dnn", binding.Dnn != "")
+	v.snssai(true, "/snssai", binding.Snssai.Sst, binding.Snssai.Sd)
+	if v.mandatory("/ipv4Addr", binding.Ipv4Addr != nil || binding.Ipv6Prefix != nil) {
+		if binding.Ipv4Addr != nil {
+			if addr, err := netip.ParseAddr(*binding.Ipv4Addr); err != nil || !addr.Is4() {
+				v.incorrect(true, "/ipv4Addr", "not an IPv4 address")
+			}
+		}
+		if binding.Ipv6Prefix != nil {
+			if prefix, err := netip.ParsePrefix(*binding.Ipv6Prefix); err != nil || !prefix.Addr().Is6() {
+				v.incorrect(true, "/ipv6Prefix", "not an IPv6 prefix")
+			}
+		}
+	}
+	if v.mandatory("/pcfId", binding.PcfId != nil || binding.PcfFqdn != nil) && binding.PcfId != nil {
+		v.nfInstanceId("/pcfId", *binding.PcfId)
+	}
+	if binding.Supi != nil {
+		v.supi("/supi", *binding.Supi)
+	}
+	if binding.Gpsi != nil {
+		v.gpsi("/gpsi", *binding.Gpsi)
+	}
+	if binding.SuppFeat != nil {
+		v.features(false, "/suppFeat", *binding.SuppFeat)
+	}
+	if v.reply(w, WriteProblem) {
+		return
+	}
+
+	id, err := bsf.Register(*binding)
+	if err != nil {
+		w.Header().Set("Location", "/nbsf-management/v1/pcfBindings/"+id)
+		WriteProblem(w, http.StatusForbidden, CauseExistingBindingInfoFound, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/nbsf-management/v1/pcfBindings/"+id)
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(binding); err != nil {
+		log.Printf("[%s] could not serialize response body: %s", bsf.BsfId, err.Error())
+	}
+	log.Printf("[%s] registered binding %s", bsf.BsfId, id)
+}
+
+// HandleDeleteBinding deregisters a binding, TS 29.521 clause 5.2.2.4
+func (bsf *Bsf) HandleDeleteBinding(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["bindingId"]
+	if !bsf.Deregister(id) {
+		WriteProblem(w, http.StatusNotFound, CauseContextNotFound, "binding "+id+" is not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+	log.Printf("[%s] deregistered binding %s", bsf.BsfId, id)
+}
+
+func (bsf *Bsf) RegisterNorthboundAPIs(r *mux.Router) {
+	r.HandleFunc("/nbsf-management/v1/pcfBindings", bsf.HandleGetBinding).Methods(http.MethodGet)
+	r.HandleFunc("/nbsf-management/v1/pcfBindings", bsf.HandleNewBinding).Methods(http.MethodPost)
+	r.HandleFunc("/nbsf-management/v1/pcfBindings/{bindingId}", bsf.HandleDeleteBinding).Methods(http.MethodDelete)
+	log.Printf("[%s] nbsf-management has been registered", bsf.BsfId)
+}
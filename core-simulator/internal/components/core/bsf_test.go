@@ -0,0 +1,87 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* BSF bindings registered by the SMF and discovered by UE address */
+
+func TestBsfSessionBinding(t *testing.T) {
+	n := newConformanceNetwork(t)
+	session := models.UeToSmfMsg{
+		EventType:   models.SMFEVENTANYOF_PDU_SES_EST,
+		TimeStamp:   time.Now(),
+		Supi:        testSupi,
+		Gpsi:        "+" + testMsisdn,
+		PlmnId:      testPlmn,
+		AccessType:  models.ACCESSTYPE__3_GPP_ACCESS,
+		Dnn:         "internet",
+		Snssai:      models.Snssai{Sst: 1},
+		UeAddress:   "10.0.0.1",
+		PduSessType: models.PDUSESSIONTYPEANYOF_IPV4,
+		PduSessId:   1,
+	}
+	n.smf.handleUeToSmfEvent(&session)
+
+	n.call(t, http.MethodGet, "/nbsf-management/v1/pcfBindings?ipv4Addr=10.0.0.1", ``, http.StatusOK, models.PcfBinding{})
+	binding := models.PcfBinding{}
+	n.get(t, "/nbsf-management/v1/pcfBindings?supi=imsi-"+testSupi+"&dnn=internet&snssai="+url.QueryEscape(`{"sst":1}`), &binding)
+	if binding.GetPcfId() != n.pcf.InstanceId || binding.GetGpsi() != "msisdn-"+testMsisdn || binding.Ipv4Addr == nil || *binding.Ipv4Addr != "10.0.0.1" {
+		t.Errorf("got binding %+v of the PDU session", binding)
+	}
+	// the bindings of the other DNNs, slices and UE addresses are not discovered
+	n.call(t, http.MethodGet, "/nbsf-management/v1/pcfBindings?ipv4Addr=10.0.0.1&dnn=ims", ``, http.StatusNoContent, nil)
+	n.call(t, http.MethodGet, "/nbsf-management/v1/pcfBindings?ipv4Addr=10.0.0.1&snssai="+url.QueryEscape(`{"sst":2}`), ``, http.StatusNoContent, nil)
+	n.call(t, http.MethodGet, "/nbsf-management/v1/pcfBindings?ipv4Addr=10.0.0.2", ``, http.StatusNoContent, nil)
+
+	session.EventType = models.SMFEVENTANYOF_PDU_SES_REL
+	n.smf.handleUeToSmfEvent(&session)
+	n.call(t, http.MethodGet, "/nbsf-management/v1/pcfBindings?ipv4Addr=10.0.0.1", ``, http.StatusNoContent, nil)
+	if len(n.bsf.GetBindings()) != 0 {
+		t.Errorf("the binding of the released PDU session is left")
+	}
+
+	n.call(t, http.MethodGet, "/nbsf-management/v1/pcfBindings?dnn=internet", ``, http.StatusBadRequest, models.ProblemDetails{})
+	n.call(t, http.MethodGet, "/nbsf-management/v1/pcfBindings?ipv4Addr=10.0.0", ``, http.StatusBadRequest, models.ProblemDetails{})
+}
+
+func TestBsfConsumerBinding(t *testing.T) {
+	n := newConformanceNetwork(t)
+	body := `{"ipv6Prefix":"2001:db8:1::/64","dnn":"internet","snssai":{"sst":1},"pcfId":"` + testNfId + `"}`
+	resp := n.call(t, http.MethodPost, "/nbsf-management/v1/pcfBindings", body, http.StatusCreated, models.PcfBinding{})
+	n.call(t, http.MethodPost, "/nbsf-management/v1/pcfBindings", body, http.StatusForbidden, models.ProblemDetails{})
+
+	// an address of the bound prefix discovers the binding
+	n.call(t, http.MethodGet, "/nbsf-management/v1/pcfBindings?ipv6Prefix="+url.QueryEscape("2001:db8:1::1"), ``, http.StatusOK, models.PcfBinding{})
+	n.call(t, http.MethodGet, "/nbsf-management/v1/pcfBindings?ipv6Prefix="+url.QueryEscape("2001:db8:2::1"), ``, http.StatusNoContent, nil)
+
+	n.call(t, http.MethodDelete, resp.Header.Get("Location"), ``, http.StatusNoContent, nil)
+	n.call(t, http.MethodDelete, resp.Header.Get("Location"), ``, http.StatusNotFound, models.ProblemDetails{})
+
+	n.call(t, http.MethodPost, "/nbsf-management/v1/pcfBindings", `{"dnn":"internet","snssai":{"sst":1}}`,
+		http.StatusBadRequest, models.ProblemDetails{})
+}
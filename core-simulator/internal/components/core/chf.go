@@ -0,0 +1,559 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* Charging Function, converged charging of the PDU sessions with volume quotas of TS 32.291 */
+
+const (
+	// rating group of the traffic of the PDU sessions
+	defaultRatingGroup    int32 = 1
+	defaultInitialBalance int64 = 1 << 30
+	defaultQuotaVolume    int64 = 10 << 20
+)
+
+var defaultRestrictedQos = models.SessionQos{Var5qi: 9, MaxBitrateUl: "64 Kbps", MaxBitrateDl: "64 Kbps"}
+
+// ChfConfig describes the accounts and the quotas of the CHF, the volumes are in bytes
+type ChfConfig struct {
+	// optional initial balance of the subscriber accounts, 1 GiB by default
+	InitialBalance *int64 `yaml:"initialBalance,omitempty" json:"initialBalance,omitempty"`
+	// optional volume granted by each quota, 10 MiB by default
+	QuotaVolume int64 `yaml:"quotaVolume,omitempty" json:"quotaVolume,omitempty"`
+	// optional action on the PDU sessions out of credit, TERMINATE by default or RESTRICT_ACCESS
+	FinalUnitAction string `yaml:"finalUnitAction,omitempty" json:"finalUnitAction,omitempty"`
+	// optional QoS of the restricted PDU sessions, 64 Kbps by default
+	RestrictedQos *models.SessionQos `yaml:"restrictedQos,omitempty" json:"restrictedQos,omitempty"`
+	// optional initial balance of the sponsors of the sponsored data connectivity
+	Sponsors map[string]int64 `yaml:"sponsors,omitempty" json:"sponsors,omitempty"`
+}
+
+func (cfg *ChfConfig) Validate() error {
+	if cfg.InitialBalance != nil && *cfg.InitialBalance < 0 {
+		return fmt.Errorf("negative initial balance %d", *cfg.InitialBalance)
+	}
+	if cfg.QuotaVolume < 0 {
+		return fmt.Errorf("negative quota volume %d", cfg.QuotaVolume)
+	}
+	switch cfg.FinalUnitAction {
+	case "", models.FinalUnitActionTerminate, models.FinalUnitActionRestrictAccess:
+	default:
+		return fmt.Errorf("unsupported final unit action %s", cfg.FinalUnitAction)
+	}
+	if qos := cfg.RestrictedQos; qos != nil {
+		for _, bitRate := range []string{qos.MaxBitrateUl, qos.MaxBitrateDl} {
+			if bitRate != "" && !bitRatePattern.MatchString(bitRate) {
+				return fmt.Errorf("invalid restricted bit rate %s", bitRate)
+			}
+		}
+	}
+	for sponsor, balance := range cfg.Sponsors {
+		if strings.HasPrefix(sponsor, "imsi-") || balance < 0 {
+			return fmt.Errorf("invalid sponsor %s with balance %d", sponsor, balance)
+		}
+	}
+	return nil
+}
+
+// ChargingAccount is the credit of a subscriber, identified by its imsi- SUPI, or of a sponsor
+type ChargingAccount struct {
+	Id       string `json:"id"`
+	Sponsor  bool   `json:"sponsor"`
+	Balance  int64  `json:"balance"`
+	Consumed int64  `json:"consumed"`
+}
+
+// ChargingSession is the usage of a PDU session counted by its charging data session
+type ChargingSession struct {
+	Supi           string `json:"supi"`
+	PduSessionId   int32  `json:"pduSessionId"`
+	Payer          string `json:"payer"`
+	UplinkVolume   int64  `json:"uplinkVolume"`
+	DownlinkVolume int64  `json:"downlinkVolume"`
+	Time           int64  `json:"time"`
+	GrantedVolume  int64  `json:"grantedVolume"`
+	OutOfCredit    bool   `json:"outOfCredit"`
+}
+
+type chargingSession struct {
+	ChargingSession
+	// notifies the consumer, to re-authorize the session once its payer is topped up
+	notify func(models.ChargingNotifyRequest)
+}
+
+// sponsorship is the sponsored data connectivity of a PDU session requested by an application session
+type sponsorship struct {
+	appSessionId  string
+	sponsorId     string
+	onOutOfCredit func(models.OutOfCreditInformation)
+}
+
+// A Chf charges the usage of the PDU sessions reported by the SMF to the account of the subscriber,
+// or of the sponsor of the session. It grants volume quotas out of the balance of the account and
+// answers with the final unit action once the balance is exhausted.
+type Chf struct {
+	PlmnId models.PlmnId
+	ChfId  string
+	// QoS of the PDU sessions restricted once out of credit
+	RestrictedQos models.SessionQos
+
+	initialBalance  int64
+	quotaVolume     int64
+	finalUnitAction string
+	notifier        *Notifier
+
+	accounts     map[string]*ChargingAccount // account id -> account
+	sessions     map[string]*chargingSession // charging data ref -> session
+	sponsorships map[string]*sponsorship     // supi/pduSessId -> sponsorship
+	mutex        sync.Mutex
+	// clock of the simulation, the wall clock when nil
+	Clock *utils.Clock
+}
+
+// NewChf creates a CHF, a nil configuration takes the defaults
+func NewChf(plmnId models.PlmnId, config *ChfConfig, notifier *Notifier) *Chf {
+	if config == nil {
+		config = &ChfConfig{}
+	}
+	chf := &Chf{
+		PlmnId:          plmnId,
+		ChfId:           fmt.Sprintf("CHF-%s%s", plmnId.Mcc, plmnId.Mnc),
+		RestrictedQos:   defaultRestrictedQos,
+		initialBalance:  defaultInitialBalance,
+		quotaVolume:     defaultQuotaVolume,
+		finalUnitAction: models.FinalUnitActionTerminate,
+		notifier:        notifier,
+		accounts:        make(map[string]*ChargingAccount),
+		sessions:        make(map[string]*chargingSession),
+		sponsorships:    make(map[string]*sponsorship),
+	}
+	if config.InitialBalance != nil {
+		chf.initialBalance = *config.InitialBalance
+	}
+	if config.QuotaVolume > 0 {
+		chf.quotaVolume = config.QuotaVolume
+	}
+	if config.FinalUnitAction != "" {
+		chf.finalUnitAction = config.FinalUnitAction
+	}
+	if config.RestrictedQos != nil {
+		chf.RestrictedQos = *config.RestrictedQos
+	}
+	for sponsor, balance := range config.Sponsors {
+		chf.accounts[sponsor] = &ChargingAccount{Id: sponsor, Sponsor: true, Balance: balance}
+	}
+	return chf
+}
+
+// pduSessionKey identifies a PDU session of a UE
+func pduSessionKey(supi string, pduSessId int32) string {
+	return fmt.Sprintf("%s/%d", strings.TrimPrefix(supi, "imsi-"), pduSessId)
+}
+
+// payer returns the account charged for a session: the sponsor of the session, if any, or the subscriber
+func (chf *Chf) payer(s *chargingSession) (*ChargingAccount, *sponsorship) {
+	if sponsor := chf.sponsorships[pduSessionKey(s.Supi, s.PduSessionId)]; sponsor != nil {
+		if account, ok := chf.accounts[sponsor.sponsorId]; ok {
+			return account, sponsor
+		}
+	}
+	return chf.subscriberAccount(s.Supi), nil
+}
+
+func (chf *Chf) subscriberAccount(supi string) *ChargingAccount {
+	id := "imsi-" + strings.TrimPrefix(supi, "imsi-")
+	account, ok := chf.accounts[id]
+	if !ok {
+		account = &ChargingAccount{Id: id, Balance: chf.initialBalance}
+		chf.accounts[id] = account
+	}
+	return account
+}
+
+// charge debits the used units of a request and grants the next quota of every rating group. The
+// quotas are carved out of the balance in turn, a rating group left without units reaches the quota
+// limit. Once the payer is out of credit, it returns the notification of the sponsor to send out of the lock.
+func (chf *Chf) charge(s *chargingSession, req *models.ChargingDataRequest) (*models.ChargingDataResponse, func()) {
+	payer, sponsor := chf.payer(s)
+	s.Payer = payer.Id
+
+	var consumed int64
+	ratingGroups := []int32{}
+	requested := map[int32]int64{} // rating group -> requested volume
+	for _, usage := range req.MultipleUnitUsage {
+		if _, ok := requested[usage.RatingGroup]; !ok {
+			ratingGroups = append(ratingGroups, usage.RatingGroup)
+			requested[usage.RatingGroup] = 0
+		}
+		if usage.RequestedUnit != nil && usage.RequestedUnit.TotalVolume != nil {
+			requested[usage.RatingGroup] = max(requested[usage.RatingGroup], *usage.RequestedUnit.TotalVolume)
+		}
+		for _, used := range usage.UsedUnitContainer {
+			s.UplinkVolume += used.UplinkVolume
+			s.DownlinkVolume += used.DownlinkVolume
+			s.Time += used.Time
+			consumed += used.TotalVolume
+		}
+	}
+	if len(ratingGroups) == 0 {
+		ratingGroups = append(ratingGroups, defaultRatingGroup)
+	}
+	payer.Consumed += consumed
+	payer.Balance = max(payer.Balance-consumed, 0)
+
+	response := &models.ChargingDataResponse{
+		InvocationTimeStamp:      chf.Clock.Now(),
+		InvocationSequenceNumber: req.InvocationSequenceNumber,
+	}
+	var outOfCredit func()
+	if payer.Balance > 0 {
+		available := payer.Balance
+		s.GrantedVolume, s.OutOfCredit = 0, false
+		for _, ratingGroup := range ratingGroups {
+			quota := chf.quotaVolume
+			if requested[ratingGroup] > 0 {
+				quota = requested[ratingGroup]
+			}
+			granted := min(quota, available)
+			available -= granted
+			s.GrantedVolume += granted
+			info := models.MultipleUnitInformation{
+				ResultCode:  models.ChargingResultSuccess,
+				RatingGroup: ratingGroup,
+				GrantedUnit: &models.GrantedUnit{TotalVolume: models.PtrInt64(granted)},
+			}
+			if granted == 0 {
+				info.ResultCode, info.GrantedUnit = models.ChargingResultQuotaLimitReached, nil
+			}
+			// the last units of the balance are the final ones
+			if available == 0 {
+				info.FinalUnitIndication = &models.FinalUnitIndication{FinalUnitAction: chf.finalUnitAction}
+			}
+			response.MultipleUnitInformation = append(response.MultipleUnitInformation, info)
+		}
+		return response, nil
+	}
+
+	if !s.OutOfCredit && sponsor != nil && sponsor.onOutOfCredit != nil {
+		action := chf.finalUnitAction
+		onOutOfCredit := sponsor.onOutOfCredit
+		outOfCredit = func() {
+			onOutOfCredit(models.OutOfCreditInformation{FinUnitAct: models.FinalUnitAction{String: &action}})
+		}
+	}
+	if !s.OutOfCredit {
+		log.Printf("[%s] PDU session %d of UE %s is out of credit, account %s", chf.ChfId, s.PduSessionId, s.Supi, payer.Id)
+	}
+	s.GrantedVolume, s.OutOfCredit = 0, true
+	for _, ratingGroup := range ratingGroups {
+		response.MultipleUnitInformation = append(response.MultipleUnitInformation, models.MultipleUnitInformation{
+			ResultCode:          models.ChargingResultQuotaLimitReached,
+			RatingGroup:         ratingGroup,
+			FinalUnitIndication: &models.FinalUnitIndication{FinalUnitAction: chf.finalUnitAction},
+		})
+	}
+	return response, outOfCredit
+}
+
+// Open creates the charging data session of a PDU session and grants its first quota, the
+// consumer is notified with notify
+func (chf *Chf) Open(req *models.ChargingDataRequest, notify func(models.ChargingNotifyRequest)) (string, *models.ChargingDataResponse) {
+	ref := uuid.NewString()
+	s := &chargingSession{notify: notify}
+	s.Supi = strings.TrimPrefix(req.GetSubscriberIdentifier(), "imsi-")
+	if info := req.PduSessionChargingInformation; info != nil {
+		s.PduSessionId = info.PduSessionInformation.PduSessionId
+	}
+
+	chf.mutex.Lock()
+	chf.sessions[ref] = s
+	response, outOfCredit := chf.charge(s, req)
+	chf.mutex.Unlock()
+
+	if outOfCredit != nil {
+		outOfCredit()
+	}
+	log.Printf("[%s] opened charging data session %s for PDU session %d of UE %s", chf.ChfId, ref, s.PduSessionId, s.Supi)
+	return ref, response
+}
+
+// Update charges the usage of a charging data session and grants its next quota,
+// it returns false if the session does not exist
+func (chf *Chf) Update(ref string, req *models.ChargingDataRequest) (*models.ChargingDataResponse, bool) {
+	chf.mutex.Lock()
+	s, ok := chf.sessions[ref]
+	if !ok {
+		chf.mutex.Unlock()
+		return nil, false
+	}
+	response, outOfCredit := chf.charge(s, req)
+	chf.mutex.Unlock()
+
+	if outOfCredit != nil {
+		outOfCredit()
+	}
+	return response, true
+}
+
+// Release charges the final usage of a charging data session and removes it,
+// it returns false if the session does not exist
+func (chf *Chf) Release(ref string, req *models.ChargingDataRequest) bool {
+	chf.mutex.Lock()
+	defer chf.mutex.Unlock()
+
+	s, ok := chf.sessions[ref]
+	if !ok {
+		return false
+	}
+	// no quota is granted to a released session
+	payer, _ := chf.payer(s)
+	for _, usage := range req.MultipleUnitUsage {
+		for _, used := range usage.UsedUnitContainer {
+			s.UplinkVolume += used.UplinkVolume
+			s.DownlinkVolume += used.DownlinkVolume
+			s.Time += used.Time
+			payer.Consumed += used.TotalVolume
+			payer.Balance = max(payer.Balance-used.TotalVolume, 0)
+		}
+	}
+	delete(chf.sessions, ref)
+	log.Printf("[%s] released charging data session %s, %d bytes in %d s", chf.ChfId, ref, s.UplinkVolume+s.DownlinkVolume, s.Time)
+	return true
+}
+
+// Sponsor charges a PDU session to a sponsor until the application session requesting the
+// sponsored data connectivity is removed, it fails if the sponsor has no account
+func (chf *Chf) Sponsor(supi string, pduSessId int32, appSessionId string, sponsorId string, onOutOfCredit func(models.OutOfCreditInformation)) error {
+	chf.mutex.Lock()
+	account, ok := chf.accounts[sponsorId]
+	if !ok || !account.Sponsor {
+		chf.mutex.Unlock()
+		return fmt.Errorf("sponsor %s has no account", sponsorId)
+	}
+	key := pduSessionKey(supi, pduSessId)
+	chf.sponsorships[key] = &sponsorship{appSessionId: appSessionId, sponsorId: sponsorId, onOutOfCredit: onOutOfCredit}
+	// a session out of credit is re-authorized with the credit of the sponsor
+	notifications := []func(models.ChargingNotifyRequest){}
+	if account.Balance > 0 {
+		for _, s := range chf.sessions {
+			if s.OutOfCredit && pduSessionKey(s.Supi, s.PduSessionId) == key {
+				notifications = append(notifications, s.notify)
+			}
+		}
+	}
+	chf.mutex.Unlock()
+
+	chf.reauthorize(notifications)
+	log.Printf("[%s] PDU session %d of UE %s is sponsored by %s", chf.ChfId, pduSessId, supi, sponsorId)
+	return nil
+}
+
+// Unsponsor ends the sponsored data connectivity requested by an application session
+func (chf *Chf) Unsponsor(appSessionId string) {
+	chf.mutex.Lock()
+	defer chf.mutex.Unlock()
+
+	for key, sponsor := range chf.sponsorships {
+		if sponsor.appSessionId == appSessionId {
+			delete(chf.sponsorships, key)
+		}
+	}
+}
+
+// TopUp credits an account, created if needed, and re-authorizes the sessions it pays that are out of credit
+func (chf *Chf) TopUp(accountId string, volume int64) (ChargingAccount, error) {
+	if volume <= 0 {
+		return ChargingAccount{}, fmt.Errorf("the top-up volume must be positive")
+	}
+	chf.mutex.Lock()
+	account, ok := chf.accounts[accountId]
+	if !ok {
+		if strings.HasPrefix(accountId, "imsi-") {
+			account = chf.subscriberAccount(accountId)
+		} else {
+			account = &ChargingAccount{Id: accountId, Sponsor: true}
+			chf.accounts[accountId] = account
+		}
+	}
+	account.Balance += volume
+	notifications := []func(models.ChargingNotifyRequest){}
+	for _, s := range chf.sessions {
+		if payer, _ := chf.payer(s); s.OutOfCredit && payer == account {
+			notifications = append(notifications, s.notify)
+		}
+	}
+	topped := *account
+	chf.mutex.Unlock()
+
+	chf.reauthorize(notifications)
+	log.Printf("[%s] account %s topped up with %d bytes", chf.ChfId, accountId, volume)
+	return topped, nil
+}
+
+// reauthorize asks the consumers to request new quotas
+func (chf *Chf) reauthorize(notifications []func(models.ChargingNotifyRequest)) {
+	for _, notify := range notifications {
+		if notify != nil {
+			notify(models.ChargingNotifyRequest{
+				NotificationType:       models.ChargingNotificationReauthorization,
+				ReauthorizationDetails: []models.ReauthorizationDetails{{RatingGroup: defaultRatingGroup}},
+			})
+		}
+	}
+}
+
+// GetAccounts returns a copy of the accounts indexed by account id
+func (chf *Chf) GetAccounts() map[string]ChargingAccount {
+	chf.mutex.Lock()
+	defer chf.mutex.Unlock()
+
+	accounts := make(map[string]ChargingAccount, len(chf.accounts))
+	for id, account := range chf.accounts {
+		accounts[id] = *account
+	}
+	return accounts
+}
+
+// GetSessions returns a copy of the charging data sessions indexed by charging data ref
+func (chf *Chf) GetSessions() map[string]ChargingSession {
+	chf.mutex.Lock()
+	defer chf.mutex.Unlock()
+
+	sessions := make(map[string]ChargingSession, len(chf.sessions))
+	for ref, s := range chf.sessions {
+		sessions[ref] = s.ChargingSession
+	}
+	return sessions
+}
+
+// NORTHBOUND Definitions
+
+func (chf *Chf) reply(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("[%s] could not serialize response body: %s", chf.ChfId, err.Error())
+	}
+}
+
+// validateChargingDataRequest checks a charging data request, TS 32.291 clause 6.1.6.2.1.1
+func (chf *Chf) validateChargingDataRequest(w http.ResponseWriter, req *models.ChargingDataRequest, initial bool) bool {
+	v := newValidator(chf.PlmnId)
+	v.mandatory("/nfConsumerIdentification/nodeFunctionality", req.NfConsumerIdentification.NodeFunctionality != "")
+	if initial {
+		if v.mandatory("/subscriberIdentifier", req.SubscriberIdentifier != nil) {
+			v.supi("/subscriberIdentifier", *req.SubscriberIdentifier)
+		}
+		v.mandatory("/pDUSessionChargingInformation", req.PduSessionChargingInformation != nil)
+	}
+	if req.NotifyUri != nil {
+		v.uri(false, "/notifyUri", *req.NotifyUri)
+	}
+	for i, usage := range req.MultipleUnitUsage {
+		for j, used := range usage.UsedUnitContainer {
+			if used.TotalVolume < 0 || used.UplinkVolume < 0 || used.DownlinkVolume < 0 || used.Time < 0 {
+				v.incorrect(false, fmt.Sprintf("/multipleUnitUsage/%d/usedUnitContainer/%d", i, j), "negative usage")
+			}
+		}
+	}
+	return !v.reply(w, WriteProblem)
+}
+
+// HandleNewChargingData opens a charging data session, TS 32.291 clause 6.1.3.2
+func (chf *Chf) HandleNewChargingData(w http.ResponseWriter, r *http.Request) {
+	req := &models.ChargingDataRequest{}
+	if !decodeRequest(w, r, req, WriteProblem) || !chf.validateChargingDataRequest(w, req, true) {
+		return
+	}
+	var ref string
+	var notify func(models.ChargingNotifyRequest)
+	if uri := req.GetNotifyUri(); uri != "" {
+		notify = func(notification models.ChargingNotifyRequest) {
+			chf.notifyConsumer(ref, uri, notification)
+		}
+	}
+	ref, response := chf.Open(req, notify)
+	w.Header().Set("Location", "/nchf-convergedcharging/v3/chargingdata/"+ref)
+	chf.reply(w, http.StatusCreated, response)
+}
+
+// notifyConsumer sends a charging notification to the consumer of a session, TS 32.291 clause 6.2.3.2
+func (chf *Chf) notifyConsumer(ref string, uri string, notification models.ChargingNotifyRequest) {
+	body, err := json.Marshal(&notification)
+	if err != nil {
+		log.Printf("[%s] error while marshalling notification: %s", chf.ChfId, err.Error())
+		return
+	}
+	chf.notifier.Notify(&Notification{
+		Source:         chf.ChfId,
+		SubscriptionId: ref,
+		Uri:            uri,
+		Body:           body,
+	})
+}
+
+// HandleUpdateChargingData charges the usage of a session and grants new quotas, TS 32.291 clause 6.1.3.3
+func (chf *Chf) HandleUpdateChargingData(w http.ResponseWriter, r *http.Request) {
+	req := &models.ChargingDataRequest{}
+	if !decodeRequest(w, r, req, WriteProblem) || !chf.validateChargingDataRequest(w, req, false) {
+		return
+	}
+	ref := mux.Vars(r)["chargingDataRef"]
+	response, ok := chf.Update(ref, req)
+	if !ok {
+		WriteProblem(w, http.StatusNotFound, CauseContextNotFound, "charging data session "+ref+" is not found")
+		return
+	}
+	chf.reply(w, http.StatusOK, response)
+}
+
+// HandleReleaseChargingData closes a session with its final usage, TS 32.291 clause 6.1.3.4
+func (chf *Chf) HandleReleaseChargingData(w http.ResponseWriter, r *http.Request) {
+	req := &models.ChargingDataRequest{}
+	if !decodeRequest(w, r, req, WriteProblem) || !chf.validateChargingDataRequest(w, req, false) {
+		return
+	}
+	ref := mux.Vars(r)["chargingDataRef"]
+	if !chf.Release(ref, req) {
+		WriteProblem(w, http.StatusNotFound, CauseContextNotFound, "charging data session "+ref+" is not found")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (chf *Chf) RegisterNorthboundAPIs(r *mux.Router) {
+	r.HandleFunc("/nchf-convergedcharging/v3/chargingdata", chf.HandleNewChargingData).Methods(http.MethodPost)
+	r.HandleFunc("/nchf-convergedcharging/v3/chargingdata/{chargingDataRef}/update", chf.HandleUpdateChargingData).Methods(http.MethodPost)
+	r.HandleFunc("/nchf-convergedcharging/v3/chargingdata/{chargingDataRef}/release", chf.HandleReleaseChargingData).Methods(http.MethodPost)
+	log.Printf("[%s] nchf-convergedcharging has been registered", chf.ChfId)
+}
@@ -0,0 +1,267 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* CHF charging the PDU sessions of the SMF to the subscribers and to the sponsors */
+
+// useChf replaces the CHF of the network with one of the given configuration
+func (n *conformanceNetwork) useChf(config *ChfConfig) {
+	n.chf = NewChf(testPlmn, config, nil)
+	n.smf.Chf, n.pcf.Chf = n.chf, n.chf
+}
+
+// chargedSession establishes a PDU session of the test UE at established
+func (n *conformanceNetwork) chargedSession(t *testing.T, ueAddr string, established time.Time) models.UeToSmfMsg {
+	t.Helper()
+	session := models.UeToSmfMsg{
+		EventType:   models.SMFEVENTANYOF_PDU_SES_EST,
+		TimeStamp:   established,
+		Supi:        testSupi,
+		PlmnId:      testPlmn,
+		AccessType:  models.ACCESSTYPE__3_GPP_ACCESS,
+		Dnn:         "internet",
+		Snssai:      models.Snssai{Sst: 1},
+		UeAddress:   ueAddr,
+		PduSessType: models.PDUSESSIONTYPEANYOF_IPV4,
+		PduSessId:   1,
+	}
+	n.smf.handleUeToSmfEvent(&session)
+	if len(n.chf.GetSessions()) != 1 {
+		t.Fatalf("got charging sessions %+v of the established PDU session", n.chf.GetSessions())
+	}
+	return session
+}
+
+// reportUsage reports the cumulative usage of the PDU session at elapsed seconds
+func (n *conformanceNetwork) reportUsage(session models.UeToSmfMsg, elapsed int, ulBytes int64, dlBytes int64) {
+	session.EventType = models.SMFEVENTANYOF_QOS_MON
+	session.TimeStamp = session.TimeStamp.Add(time.Duration(elapsed) * time.Second)
+	session.UpReport = &models.UpStatsReport{UpStats: models.UpStats{PduSessId: session.PduSessId, TotalUlBytes: ulBytes, TotalDlBytes: dlBytes}}
+	n.smf.handleUeToSmfEvent(&session)
+}
+
+// chargingState returns the charging data session of the test PDU session in the SMF
+func (n *conformanceNetwork) chargingState(t *testing.T) chargingState {
+	t.Helper()
+	n.smf.chargingMutex.Lock()
+	defer n.smf.chargingMutex.Unlock()
+	state, ok := n.smf.charging[pduSessionKey(testSupi, 1)]
+	if !ok {
+		t.Fatalf("no charging data session of the PDU session in the SMF")
+	}
+	return *state
+}
+
+func TestChfSessionCharging(t *testing.T) {
+	n := newConformanceNetwork(t)
+	n.useChf(&ChfConfig{InitialBalance: models.PtrInt64(10000), QuotaVolume: 4000})
+	session := n.chargedSession(t, "10.0.0.1", time.Now())
+
+	n.reportUsage(session, 5, 1000, 2000)
+	n.reportUsage(session, 10, 1500, 4000)
+	account := n.chf.GetAccounts()["imsi-"+testSupi]
+	if account.Consumed != 5500 || account.Balance != 4500 {
+		t.Errorf("got account %+v after 5500 bytes", account)
+	}
+	for _, s := range n.chf.GetSessions() {
+		if s.Payer != account.Id || s.UplinkVolume != 1500 || s.DownlinkVolume != 4000 || s.Time != 10 || s.GrantedVolume != 4000 {
+			t.Errorf("got charging session %+v after 10 s", s)
+		}
+	}
+
+	// the exhausted balance terminates the session, the top-up grants new units
+	n.reportUsage(session, 15, 1500, 9000)
+	if state := n.chargingState(t); !state.terminated {
+		t.Errorf("the PDU session out of credit is not terminated")
+	}
+	if account := n.chf.GetAccounts()["imsi-"+testSupi]; account.Balance != 0 || account.Consumed != 10500 {
+		t.Errorf("got account %+v out of credit", account)
+	}
+	if _, err := n.chf.TopUp("imsi-"+testSupi, 1000); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range n.chf.GetSessions() {
+		if s.OutOfCredit || s.GrantedVolume != 1000 {
+			t.Errorf("got charging session %+v after the top-up", s)
+		}
+	}
+	if _, err := n.chf.TopUp("imsi-"+testSupi, 0); err == nil {
+		t.Errorf("a top-up of no volume is accepted")
+	}
+
+	session.EventType = models.SMFEVENTANYOF_PDU_SES_REL
+	n.smf.handleUeToSmfEvent(&session)
+	if len(n.chf.GetSessions()) != 0 {
+		t.Errorf("the charging session of the released PDU session is left")
+	}
+}
+
+func TestChfRestrictAccess(t *testing.T) {
+	n := newConformanceNetwork(t)
+	n.useChf(&ChfConfig{InitialBalance: models.PtrInt64(1000), FinalUnitAction: models.FinalUnitActionRestrictAccess})
+	session := n.chargedSession(t, "10.0.0.1", time.Now())
+
+	n.reportUsage(session, 5, 0, 2000)
+	if state := n.chargingState(t); !state.restricted || state.terminated {
+		t.Errorf("got charging state %+v of the PDU session out of credit", state)
+	}
+	if _, err := n.chf.TopUp("imsi-"+testSupi, 1000); err != nil {
+		t.Fatal(err)
+	}
+	if state := n.chargingState(t); state.restricted {
+		t.Errorf("the PDU session topped up is still restricted")
+	}
+}
+
+func TestChfSponsoredData(t *testing.T) {
+	n := newConformanceNetwork(t)
+	n.useChf(&ChfConfig{Sponsors: map[string]int64{"sponsor": 3000}})
+	ueAddr, err := n.ipam.AllocateIP(testSupi, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := n.chargedSession(t, ueAddr, time.Now())
+
+	appSession := `{"ascReqData":{"notifUri":"` + n.sinkUrl + `/pcf","suppFeat":"0","ueIpv4":"` + ueAddr + `",
+		"aspId":"asp","sponId":"%s","evSubsc":{"events":[{"event":"OUT_OF_CREDIT"}]},
+		"medComponents":{"1":{"medCompN":1,"medType":"VIDEO","marBwDl":"5 Mbps","marBwUl":"1 Mbps"}}}}`
+	n.call(t, http.MethodPost, "/npcf-policyauthorization/v1/app-sessions", fmt.Sprintf(appSession, "unknown"),
+		http.StatusForbidden, models.ExtendedProblemDetails{})
+	resp := n.call(t, http.MethodPost, "/npcf-policyauthorization/v1/app-sessions", fmt.Sprintf(appSession, "sponsor"),
+		http.StatusCreated, models.AppSessionContext{})
+
+	n.reportUsage(session, 5, 0, 2000)
+	accounts := n.chf.GetAccounts()
+	if accounts["sponsor"].Consumed != 2000 || accounts["imsi-"+testSupi].Consumed != 0 {
+		t.Errorf("got accounts %+v after the sponsored usage", accounts)
+	}
+	// the AF is notified once the credit of the sponsor is exhausted
+	n.reportUsage(session, 10, 0, 4000)
+	n.reportUsage(session, 15, 0, 5000)
+	n.notifications(t, "/pcf", 1, models.EventsNotification{})
+	if len(n.sink.bodies("/pcf")) != 1 {
+		t.Errorf("got %d notifications of the exhausted sponsor", len(n.sink.bodies("/pcf")))
+	}
+
+	// the subscriber is charged again once the application session is removed
+	n.call(t, http.MethodPost, resp.Header.Get("Location")+"/delete", ``, http.StatusNoContent, nil)
+	n.reportUsage(session, 20, 0, 6000)
+	if account := n.chf.GetAccounts()["imsi-"+testSupi]; account.Consumed != 1000 {
+		t.Errorf("got account %+v after the sponsorship", account)
+	}
+}
+
+func TestChfRatingGroups(t *testing.T) {
+	n := newConformanceNetwork(t)
+	n.useChf(&ChfConfig{InitialBalance: models.PtrInt64(10000), QuotaVolume: 4000})
+	request := func(usage ...models.MultipleUnitUsage) *models.ChargingDataRequest {
+		return &models.ChargingDataRequest{
+			SubscriberIdentifier:     models.PtrString("imsi-" + testSupi),
+			NfConsumerIdentification: models.NfIdentification{NodeFunctionality: "SMF"},
+			MultipleUnitUsage:        usage,
+		}
+	}
+	type grant struct {
+		result string
+		volume int64
+		final  bool
+	}
+	grants := func(response *models.ChargingDataResponse) []grant {
+		var got []grant
+		for _, info := range response.MultipleUnitInformation {
+			g := grant{result: info.ResultCode, final: info.FinalUnitIndication != nil}
+			if info.GrantedUnit != nil && info.GrantedUnit.TotalVolume != nil {
+				g.volume = *info.GrantedUnit.TotalVolume
+			}
+			got = append(got, g)
+		}
+		return got
+	}
+
+	// every rating group is granted its own quota out of the balance
+	ref, response := n.chf.Open(request(
+		models.MultipleUnitUsage{RatingGroup: 1, RequestedUnit: &models.RequestedUnit{TotalVolume: models.PtrInt64(3000)}},
+		models.MultipleUnitUsage{RatingGroup: 2},
+		models.MultipleUnitUsage{RatingGroup: 3},
+		models.MultipleUnitUsage{RatingGroup: 4},
+	), nil)
+	want := []grant{
+		{models.ChargingResultSuccess, 3000, false},
+		{models.ChargingResultSuccess, 4000, false},
+		{models.ChargingResultSuccess, 3000, true},
+		{models.ChargingResultQuotaLimitReached, 0, true},
+	}
+	if got := grants(response); !slices.Equal(got, want) {
+		t.Fatalf("got grants %+v, want %+v", got, want)
+	}
+	if s := n.chf.GetSessions()[ref]; s.GrantedVolume != 10000 {
+		t.Fatalf("got granted volume %d, want 10000", s.GrantedVolume)
+	}
+
+	// the usage of all the rating groups is debited from the balance
+	response, _ = n.chf.Update(ref, request(
+		models.MultipleUnitUsage{RatingGroup: 1, UsedUnitContainer: []models.UsedUnitContainer{{TotalVolume: 3000}}},
+		models.MultipleUnitUsage{RatingGroup: 2, UsedUnitContainer: []models.UsedUnitContainer{{TotalVolume: 2000}}},
+	))
+	want = []grant{
+		{models.ChargingResultSuccess, 4000, false},
+		{models.ChargingResultSuccess, 1000, true},
+	}
+	if got := grants(response); !slices.Equal(got, want) {
+		t.Fatalf("got grants %+v, want %+v", got, want)
+	}
+	if account := n.chf.GetAccounts()["imsi-"+testSupi]; account.Balance != 5000 || account.Consumed != 5000 {
+		t.Fatalf("got account %+v after 5000 bytes", account)
+	}
+}
+
+func TestConformanceNchfConvergedCharging(t *testing.T) {
+	n := newConformanceNetwork(t)
+	request := `{"subscriberIdentifier":"imsi-` + testSupi + `","nfConsumerIdentification":{"nodeFunctionality":"SMF"},
+		"invocationTimeStamp":"2025-01-01T00:00:00Z","invocationSequenceNumber":0,"notifyUri":"` + n.sinkUrl + `/chf",
+		"multipleUnitUsage":[{"ratingGroup":1,"requestedUnit":{"totalVolume":1000}}],
+		"pDUSessionChargingInformation":{"chargingId":1,"pduSessionInformation":{"pduSessionID":5,"dnnId":"internet",
+		"networkSlicingInfo":{"sNSSAI":{"sst":1}},"pduAddress":{"pduIPv4Address":"10.0.0.5"}}}}`
+	resp := n.call(t, http.MethodPost, "/nchf-convergedcharging/v3/chargingdata", request, http.StatusCreated, models.ChargingDataResponse{})
+	chargingData := resp.Header.Get("Location")
+
+	usage := `{"nfConsumerIdentification":{"nodeFunctionality":"SMF"},"invocationTimeStamp":"2025-01-01T00:00:05Z",
+		"invocationSequenceNumber":1,"multipleUnitUsage":[{"ratingGroup":1,
+		"usedUnitContainer":[{"time":5,"totalVolume":800,"uplinkVolume":300,"downlinkVolume":500}]}]}`
+	n.call(t, http.MethodPost, chargingData+"/update", usage, http.StatusOK, models.ChargingDataResponse{})
+	n.call(t, http.MethodPost, chargingData+"/release", usage, http.StatusNoContent, nil)
+	n.call(t, http.MethodPost, chargingData+"/release", usage, http.StatusNotFound, models.ProblemDetails{})
+	n.call(t, http.MethodPost, chargingData+"/update", usage, http.StatusNotFound, models.ProblemDetails{})
+
+	n.call(t, http.MethodPost, "/nchf-convergedcharging/v3/chargingdata", `{"nfConsumerIdentification":{"nodeFunctionality":"SMF"}}`,
+		http.StatusBadRequest, models.ProblemDetails{})
+	n.call(t, http.MethodPost, chargingData+"/update", `{"nfConsumerIdentification":{"nodeFunctionality":"SMF"},
+		"multipleUnitUsage":[{"ratingGroup":1,"usedUnitContainer":[{"totalVolume":-1}]}]}`, http.StatusBadRequest, models.ProblemDetails{})
+}
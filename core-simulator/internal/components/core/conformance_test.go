@@ -0,0 +1,427 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* Conformance of the SBI responses and notifications to TS 29.518, TS 29.508 and TS 29.514 */
+
+// The schemas are the models generated from the OpenAPI specifications of the services. A document
+// conforms when it has no unknown attribute, all the required attributes, no null value outside of
+// the nullable attributes, known values of the closed enumerations and matches the patterns of
+// the models and of the common data types of TS 29.571. The documents are also validated against
+// the specifications themselves, see spec_test.go.
+
+var commonDataPatterns = map[string]*regexp.Regexp{
+	"mcc":               regexp.MustCompile(`^\d{3}$`),
+	"mnc":               regexp.MustCompile(`^\d{2,3}$`),
+	"tac":               regexp.MustCompile(`^([A-Fa-f0-9]{4}|[A-Fa-f0-9]{6})$`),
+	"nrCellId":          regexp.MustCompile(`^[A-Fa-f0-9]{9}$`),
+	"sd":                regexp.MustCompile(`^[A-Fa-f0-9]{6}$`),
+	"amfId":             regexp.MustCompile(`^[A-Fa-f0-9]{6}$`),
+	"ipv4Addr":          regexp.MustCompile(`^(([0-9]|[1-9][0-9]|1[0-9][0-9]|2[0-4][0-9]|25[0-5])\.){3}([0-9]|[1-9][0-9]|1[0-9][0-9]|2[0-4][0-9]|25[0-5])$`),
+	"ueIpv4":            regexp.MustCompile(`^(([0-9]|[1-9][0-9]|1[0-9][0-9]|2[0-4][0-9]|25[0-5])\.){3}([0-9]|[1-9][0-9]|1[0-9][0-9]|2[0-4][0-9]|25[0-5])$`),
+	"suppFeat":          regexp.MustCompile(`^[A-Fa-f0-9]*$`),
+	"supportedFeatures": regexp.MustCompile(`^[A-Fa-f0-9]*$`),
+}
+
+var commonDataRanges = map[string][2]float64{
+	"sst":     {0, 255},
+	"pduSeId": {0, 255},
+	"qfi":     {0, 63},
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// conforms returns the violations of a JSON document to the schema of a model
+func conforms(body []byte, schema any) []string {
+	dst := reflect.New(reflect.TypeOf(schema))
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst.Interface()); err != nil {
+		return []string{err.Error()}
+	}
+	var raw any
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return []string{err.Error()}
+	}
+	violations := []string{}
+	walkSchema("", "", reflect.TypeOf(schema), raw, &violations)
+	return violations
+}
+
+func walkSchema(path string, name string, typ reflect.Type, raw any, violations *[]string) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if strings.HasPrefix(typ.Name(), "Nullable") {
+		if value, ok := typ.FieldByName("value"); ok {
+			if raw != nil {
+				walkSchema(path, name, value.Type, raw, violations)
+			}
+			return
+		}
+	}
+	if raw == nil {
+		*violations = append(*violations, path+": null value of a non nullable attribute")
+		return
+	}
+
+	switch value := raw.(type) {
+	case string:
+		if pattern, ok := commonDataPatterns[name]; ok && !pattern.MatchString(value) {
+			*violations = append(*violations, fmt.Sprintf("%s: %q does not match %s", path, value, pattern))
+		}
+		if typ == timeType {
+			if _, err := time.Parse(time.RFC3339, value); err != nil {
+				*violations = append(*violations, fmt.Sprintf("%s: %q is not a date-time", path, value))
+			}
+		}
+	case float64:
+		if typ == timeType {
+			*violations = append(*violations, fmt.Sprintf("%s: %v is not a date-time", path, value))
+		}
+		if bounds, ok := commonDataRanges[name]; ok && (value < bounds[0] || value > bounds[1]) {
+			*violations = append(*violations, fmt.Sprintf("%s: %v not in %v..%v", path, value, bounds[0], bounds[1]))
+		}
+	case []any:
+		if typ.Kind() == reflect.Slice {
+			for i, item := range value {
+				walkSchema(fmt.Sprintf("%s/%d", path, i), name, typ.Elem(), item, violations)
+			}
+		}
+	case map[string]any:
+		switch typ.Kind() {
+		case reflect.Map:
+			for key, item := range value {
+				walkSchema(path+"/"+key, key, typ.Elem(), item, violations)
+			}
+		case reflect.Struct:
+			walkObject(path, typ, value, violations)
+		}
+	}
+}
+
+// walkObject checks the attributes of an object, the models without json tags are
+// (un)marshalled by their own methods that check the required attributes
+func walkObject(path string, typ reflect.Type, obj map[string]any, violations *[]string) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous {
+			walkObject(path, field.Type, obj, violations)
+			continue
+		}
+		tag, hasTag := field.Tag.Lookup("json")
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+
+		var raw any
+		present := false
+		for key, value := range obj {
+			if key == name || (!hasTag && strings.EqualFold(key, field.Name)) {
+				name, raw, present = key, value, true
+				break
+			}
+		}
+		if !present {
+			if hasTag && !strings.Contains(opts, "omitempty") {
+				*violations = append(*violations, path+"/"+name+": missing required attribute")
+			}
+			continue
+		}
+		if pattern, ok := strings.CutPrefix(field.Tag.Get("validate"), "regexp="); ok {
+			if s, ok := raw.(string); ok && !regexp.MustCompile(strings.ReplaceAll(pattern, `\\`, `\`)).MatchString(s) {
+				*violations = append(*violations, fmt.Sprintf("%s/%s: %q does not match %s", path, name, s, pattern))
+			}
+		}
+		walkSchema(path+"/"+name, name, field.Type, raw, violations)
+	}
+}
+
+// conformanceSink records the notifications received by the subscribers
+type conformanceSink struct {
+	mutex    sync.Mutex
+	received map[string][][]byte // path -> bodies
+}
+
+func (sink *conformanceSink) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	sink.received[r.URL.Path] = append(sink.received[r.URL.Path], body)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (sink *conformanceSink) bodies(path string) [][]byte {
+	sink.mutex.Lock()
+	defer sink.mutex.Unlock()
+	return sink.received[path]
+}
+
+type conformanceNetwork struct {
+	amf     *Amf
+	smf     *Smf
+	pcf     *Pcf
+	udm     *Udm
+	bsf     *Bsf
+	chf     *Chf
+	nwdaf   *Nwdaf
+	nef     *Nef
+	ipam    *utils.IPAllocator
+	server  *httptest.Server
+	sink    *conformanceSink
+	sinkUrl string
+}
+
+func newConformanceNetwork(t *testing.T) *conformanceNetwork {
+	t.Helper()
+	notifier := NewNotifier(testNotifierConfig)
+	t.Cleanup(notifier.Stop)
+	ipam := utils.NewIpamService("10.0.0.0", "16")
+	n := &conformanceNetwork{
+		amf:   NewAmf(testPlmn, utils.NewPauseGate(nil), notifier),
+		smf:   NewSmf(testPlmn, ipam, notifier),
+		pcf:   NewPcf(testPlmn, ipam, notifier),
+		udm:   NewUdm(testPlmn, models.Snssai{Sst: 1}, "internet", nil),
+		bsf:   NewBsf(testPlmn),
+		chf:   NewChf(testPlmn, nil, notifier),
+		nwdaf: NewNwdaf(testPlmn, nil, notifier),
+		nef:   NewNef(testPlmn, nil, testResolver, ipam, notifier),
+		ipam:  ipam,
+		sink:  &conformanceSink{received: make(map[string][][]byte)},
+	}
+	n.server = httptest.NewServer(NewSbiRouter(n.amf, notifier, n.smf, n.pcf, n.udm, n.bsf, n.chf, n.nwdaf, n.nef))
+	t.Cleanup(n.server.Close)
+	n.udm.ApiRoot, n.nef.ApiRoot, notifier.ApiRoot = n.server.URL, n.server.URL, n.server.URL
+	n.smf.Bsf, n.smf.PcfInstanceId = n.bsf, n.pcf.InstanceId
+	n.smf.Chf, n.pcf.Chf = n.chf, n.chf
+	n.nwdaf.AmfInstanceId, n.nwdaf.SmfInstanceId = n.amf.InstanceId, n.smf.InstanceId
+	n.udm.AmfInstanceId, n.udm.SmfInstanceId = n.amf.InstanceId, n.smf.InstanceId
+	n.pcf.Smf = n.smf
+	n.amf.RegisterObserver(n.pcf.ObserveAmfEvent)
+	n.amf.RegisterObserver(n.nwdaf.ObserveAmfEvent)
+	n.smf.RegisterObserver(n.nwdaf.ObserveSmfEvent)
+	sink := httptest.NewServer(n.sink)
+	t.Cleanup(sink.Close)
+	n.sinkUrl = sink.URL
+	return n
+}
+
+// call sends a request on the SBI and checks the status and the conformance of the response
+func (n *conformanceNetwork) call(t *testing.T, method string, path string, body string, status int, schema any) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, n.server.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != status {
+		t.Fatalf("%s %s: got status %d, want %d: %s", method, path, resp.StatusCode, status, respBody)
+	}
+	if schema == nil {
+		if len(respBody) != 0 {
+			t.Errorf("%s %s: unexpected body %s", method, path, respBody)
+		}
+		return resp
+	}
+	wantType := "application/json"
+	if status >= 400 {
+		wantType = ProblemContentType
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != wantType {
+		t.Errorf("%s %s: got content type %q, want %q", method, path, ct, wantType)
+	}
+	if violations := conforms(respBody, schema); len(violations) > 0 {
+		t.Errorf("%s %s: response does not conform to %T:\n%s\n%s", method, path, schema, strings.Join(violations, "\n"), respBody)
+	}
+	checkSpec(t, method+" "+path, respBody, schema)
+	// the NEF APIs locate the created resources by their absolute URI
+	location := strings.TrimPrefix(resp.Header.Get("Location"), n.server.URL)
+	if status == http.StatusCreated && !strings.HasPrefix(location, path+"/") {
+		t.Errorf("%s %s: got location %q", method, path, resp.Header.Get("Location"))
+	}
+	return resp
+}
+
+// notifications waits for the notifications of a subscriber and checks their conformance
+func (n *conformanceNetwork) notifications(t *testing.T, path string, count int, schema any) {
+	t.Helper()
+	waitFor(t, func() bool { return len(n.sink.bodies(path)) >= count })
+	for _, body := range n.sink.bodies(path) {
+		if violations := conforms(body, schema); len(violations) > 0 {
+			t.Errorf("notification to %s does not conform to %T:\n%s\n%s", path, schema, strings.Join(violations, "\n"), body)
+		}
+		checkSpec(t, "notification to "+path, body, schema)
+	}
+}
+
+func TestConformanceNamfEventExposure(t *testing.T) {
+	n := newConformanceNetwork(t)
+	n.call(t, http.MethodPost, "/namf-evts/v1/subscriptions", `{"subscription":{
+		"eventList":[{"type":"REGISTRATION_STATE_REPORT"},{"type":"CONNECTIVITY_STATE_REPORT"},{"type":"LOCATION_REPORT"},{"type":"LOSS_OF_CONNECTIVITY"}],
+		"eventNotifyUri":"`+n.sinkUrl+`/amf","notifyCorrelationId":"1","nfId":"`+testNfId+`",
+		"subsChangeNotifyUri":"`+n.sinkUrl+`/amf-subs-change","subsChangeNotifyCorrelationId":"2","anyUE":true}}`,
+		http.StatusCreated, models.AmfCreatedEventSubscription{})
+
+	ue := models.UeToAmfMsg{
+		TimeStamp:     time.Now(),
+		Supi:          "001060000000001",
+		Gpsi:          "msisdn-33612345678",
+		PlmnId:        testPlmn,
+		CurrentCellId: "000000001",
+		AccessType:    models.ACCESSTYPE__3_GPP_ACCESS,
+		Pei:           "490154203237518",
+	}
+	for _, event := range []struct {
+		eventType models.AmfEventTypeAnyOf
+		rm        models.RmState
+		cm        models.CmState
+	}{
+		{models.AMFEVENTTYPEANYOF_REGISTRATION_STATE_REPORT, models.RmStateRegistered, models.CmStateConnected},
+		{models.AMFEVENTTYPEANYOF_CONNECTIVITY_STATE_REPORT, models.RmStateRegistered, models.CmStateIdle},
+		{models.AMFEVENTTYPEANYOF_LOCATION_REPORT, models.RmStateRegistered, models.CmStateConnected},
+		{models.AMFEVENTTYPEANYOF_LOSS_OF_CONNECTIVITY, models.RmStateDeregistered, models.CmStateIdle},
+	} {
+		msg := ue
+		msg.EventType, msg.RmState, msg.CmState = event.eventType, event.rm, event.cm
+		n.amf.handleUeToAmfEvent(&msg)
+	}
+	n.notifications(t, "/amf", 4, models.AmfEventNotification{})
+
+	subs := n.amf.GetSubscriptions()
+	for subId := range subs {
+		if _, err := n.amf.TransferSubscription(subId); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n.notifications(t, "/amf-subs-change", 1, models.AmfEventNotification{})
+
+	n.call(t, http.MethodPost, "/namf-evts/v1/subscriptions", `{"subscription":{"eventList":[]}}`,
+		http.StatusBadRequest, models.ProblemDetails{})
+	n.call(t, http.MethodPost, "/namf-evts/v1/subscriptions", `{"subscription":`,
+		http.StatusBadRequest, models.ProblemDetails{})
+	n.call(t, http.MethodPut, "/namf-evts/v1/subscriptions", `{}`,
+		http.StatusMethodNotAllowed, models.ProblemDetails{})
+}
+
+func TestConformanceNsmfEventExposure(t *testing.T) {
+	n := newConformanceNetwork(t)
+	n.call(t, http.MethodPost, "/nsmf-event-exposure/v1/subscriptions", `{"notifId":"1","notifUri":"`+n.sinkUrl+`/smf",
+		"eventSubs":[{"event":"PDU_SES_EST"},{"event":"PDU_SES_REL"},{"event":"QOS_MON"},{"event":"DDDS"},{"event":"QFI_ALLOC"},{"event":"UP_PATH_CH"}],
+		"anyUeInd":true}`, http.StatusCreated, models.NsmfEventExposure{})
+
+	session := models.UeToSmfMsg{
+		TimeStamp:   time.Now(),
+		Supi:        "001060000000001",
+		Gpsi:        "msisdn-33612345678",
+		PlmnId:      testPlmn,
+		AccessType:  models.ACCESSTYPE__3_GPP_ACCESS,
+		Dnn:         "internet",
+		Snssai:      models.Snssai{Sst: 1},
+		UeAddress:   "10.0.0.1",
+		PduSessType: models.PDUSESSIONTYPEANYOF_IPV4,
+		PduSessId:   1,
+		DddsState:   models.DLDATADELIVERYSTATUSANYOF_BUFFERED,
+		UpReport:    &models.UpStatsReport{UpStats: *models.NewUpStats(1, time.Now())},
+		Qfi:         2,
+		AppId:       "video",
+		SourceDnai:  "central",
+		TargetDnai:  "edge",
+	}
+	events := []models.SmfEventAnyOf{
+		models.SMFEVENTANYOF_PDU_SES_EST,
+		models.SMFEVENTANYOF_QOS_MON,
+		models.SMFEVENTANYOF_DDDS,
+		models.SMFEVENTANYOF_QFI_ALLOC,
+		models.SMFEVENTANYOF_UP_PATH_CH,
+		models.SMFEVENTANYOF_PDU_SES_REL,
+	}
+	for _, event := range events {
+		msg := session
+		msg.EventType = event
+		n.smf.handleUeToSmfEvent(&msg)
+	}
+	n.notifications(t, "/smf", len(events), models.NsmfEventExposureNotification{})
+
+	n.call(t, http.MethodPost, "/nsmf-event-exposure/v1/subscriptions", `{"notifId":"1","notifUri":"`+n.sinkUrl+`/smf","eventSubs":[{"event":"PDU_SES_EST"}],
+		"snssai":{"sst":1,"sd":"0"}}`, http.StatusBadRequest, models.ProblemDetails{})
+}
+
+func TestConformanceNpcfPolicyAuthorization(t *testing.T) {
+	n := newConformanceNetwork(t)
+	ueAddr, err := n.ipam.AllocateIP("001060000000001", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := n.call(t, http.MethodPost, "/npcf-policyauthorization/v1/app-sessions", `{"ascReqData":{"notifUri":"`+n.sinkUrl+`/pcf",
+		"suppFeat":"0","ueIpv4":"`+ueAddr+`","medComponents":{"1":{"medCompN":1,"medType":"VIDEO","marBwDl":"5 Mbps","marBwUl":"1 Mbps"}}}}`,
+		http.StatusCreated, models.AppSessionContext{})
+	appSession := resp.Header.Get("Location")
+
+	n.call(t, http.MethodPatch, appSession, `{}`, http.StatusNotImplemented, models.ExtendedProblemDetails{})
+	n.call(t, http.MethodPost, appSession+"/delete", ``, http.StatusNoContent, nil)
+	n.call(t, http.MethodPost, appSession+"/delete", ``, http.StatusNotFound, models.ExtendedProblemDetails{})
+	n.call(t, http.MethodPost, "/npcf-policyauthorization/v1/app-sessions", `{"ascReqData":{"notifUri":"`+n.sinkUrl+`/pcf",
+		"suppFeat":"0","ueIpv4":"10.0.255.1","afRoutReq":{"routeToLocs":[{"dnai":"edge","routeProfId":"default"}]}}}`,
+		http.StatusInternalServerError, models.ExtendedProblemDetails{})
+}
+
+func TestConformsDetectsViolations(t *testing.T) {
+	violations := conforms([]byte(`{"notifId":"1","notifUri":null,"eventSubs":[{"event":"PDU_SES_EST"}],"snssai":{"sst":1,"sd":"xyz"}}`), models.NsmfEventExposure{})
+	if len(violations) != 2 {
+		t.Fatalf("got violations %v, want the null uri and the pattern of sd", violations)
+	}
+	if violations := conforms([]byte(`{"notifId":"1","eventSubs":[],"unknown":true}`), models.NsmfEventExposure{}); len(violations) != 1 {
+		t.Fatalf("got violations %v, want the unknown attribute", violations)
+	}
+	if violations := conforms([]byte(`{"notifId":"1","eventSubs":[]}`), models.NsmfEventExposure{}); len(violations) != 1 {
+		t.Fatalf("got violations %v, want the missing notifUri", violations)
+	}
+}
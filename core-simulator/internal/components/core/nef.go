@@ -0,0 +1,929 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* Network Exposure Function, northbound APIs of TS 29.522 on top of the core network services */
+
+const (
+	monitoringEventApi  = "/3gpp-monitoring-event/v1"
+	asSessionWithQosApi = "/3gpp-as-session-with-qos/v1"
+	trafficInfluenceApi = "/3gpp-traffic-influence/v1"
+	// notifications of the core network to the NEF
+	nefCallbackApi = "/nnef-callback/v1/subscriptions"
+)
+
+// NefConfig enables the NEF of the simulated network
+type NefConfig struct {
+	// optional QoS references of the AS session with QoS API, added to or overriding the default ones
+	QosReferences map[string]QosReference `yaml:"qosReferences,omitempty" json:"qosReferences,omitempty"`
+}
+
+// QosReference is the media component requested to the PCF for a QoS reference of the AFs
+type QosReference struct {
+	MedType string `yaml:"medType" json:"medType"`
+	MarBwUl string `yaml:"marBwUl,omitempty" json:"marBwUl,omitempty"`
+	MarBwDl string `yaml:"marBwDl,omitempty" json:"marBwDl,omitempty"`
+}
+
+var defaultQosReferences = map[string]QosReference{
+	"qos-voice": {MedType: "AUDIO", MarBwUl: "64 Kbps", MarBwDl: "64 Kbps"},
+	"qos-video": {MedType: "VIDEO", MarBwUl: "2 Mbps", MarBwDl: "20 Mbps"},
+	"qos-data":  {MedType: "DATA", MarBwUl: "10 Mbps", MarBwDl: "50 Mbps"},
+}
+
+func (cfg *NefConfig) Validate() error {
+	for name, ref := range cfg.QosReferences {
+		if !slices.Contains(supportedMedType, ref.MedType) {
+			return fmt.Errorf("qos reference %s: unknown media type %q", name, ref.MedType)
+		}
+		for _, bitRate := range []string{ref.MarBwUl, ref.MarBwDl} {
+			if bitRate != "" && !bitRatePattern.MatchString(bitRate) {
+				return fmt.Errorf("qos reference %s: invalid bit rate %q", name, bitRate)
+			}
+		}
+	}
+	return nil
+}
+
+// UeResolver maps the UE identifiers of the AFs to the UEs of the network
+type UeResolver interface {
+	// ResolveUe returns the SUPI of the UE with the given MSISDN or external identifier
+	ResolveUe(id string) (string, bool)
+}
+
+type nefApi string
+
+const (
+	nefMonitoring       nefApi = "monitoring event"
+	nefAsSessionWithQos nefApi = "as session with qos"
+	nefTrafficInfluence nefApi = "traffic influence"
+)
+
+// nefSubscription is a subscription of an AF and the resources it holds in the core network
+type nefSubscription struct {
+	api  nefApi
+	afId string
+	id   string
+	// resource of the AF, with its self link
+	resource                any
+	notificationDestination string
+	supi                    string
+	pduSessId               int32
+	// resources created on the core network, released with the subscription
+	core    []coreResource
+	reports int32
+	// the usage threshold of an AS session was reported
+	usageReported bool
+}
+
+// coreResource is a subscription or an app session of the NEF in the core network
+type coreResource struct {
+	method string
+	uri    string
+}
+
+// A Nef exposes the events and the policies of the core network to the AFs. It translates the
+// subscriptions of the AFs into AMF and SMF event subscriptions and PCF app sessions, maps the
+// external identifiers and MSISDNs to SUPIs and relays the notifications of the core network.
+type Nef struct {
+	PlmnId models.PlmnId
+	NefId  string
+	// consumer of the core network services, the NEF receives the notifications on the same SBI
+	sbiConsumer
+	// clock of the simulation, the wall clock when nil
+	Clock *utils.Clock
+
+	instanceId    string
+	qosReferences map[string]QosReference
+	resolver      UeResolver
+	ipamInstance  *utils.IPAllocator
+	notifier      *Notifier
+
+	subscriptions map[string]*nefSubscription
+	subMutex      sync.RWMutex
+}
+
+// NewNef creates a NEF, a nil configuration takes the default QoS references
+func NewNef(plmnId models.PlmnId, config *NefConfig, resolver UeResolver, ipamInstance *utils.IPAllocator, notifier *Notifier) *Nef {
+	qosReferences := maps.Clone(defaultQosReferences)
+	if config != nil {
+		maps.Copy(qosReferences, config.QosReferences)
+	}
+	return &Nef{
+		PlmnId:        plmnId,
+		NefId:         fmt.Sprintf("NEF-%s%s", plmnId.Mcc, plmnId.Mnc),
+		instanceId:    uuid.NewString(),
+		qosReferences: qosReferences,
+		resolver:      resolver,
+		ipamInstance:  ipamInstance,
+		notifier:      notifier,
+		sbiConsumer:   newSbiConsumer(),
+		subscriptions: make(map[string]*nefSubscription),
+	}
+}
+
+// NORTHBOUND Definitions
+
+// HandleNewMonitoringSubscription creates a monitoring event subscription, TS 29.122 clause 5.3
+func (nef *Nef) HandleNewMonitoringSubscription(w http.ResponseWriter, r *http.Request) {
+	sub := &models.MonitoringEventSubscription{}
+	if !decodeRequest(w, r, sub, WriteProblem) {
+		return
+	}
+
+	v := newValidator(nef.PlmnId)
+	if v.mandatory("/notificationDestination", sub.NotificationDestination != "") {
+		v.uri(true, "/notificationDestination", sub.NotificationDestination)
+	}
+	if sub.ExternalGroupId != nil {
+		v.incorrect(false, "/externalGroupId", "group monitoring is not supported")
+	}
+	supi := ""
+	switch {
+	case sub.ExternalId != nil && sub.Msisdn != nil:
+		v.incorrect(true, "/msisdn", "only one of externalId or msisdn is allowed")
+	case sub.ExternalId != nil:
+		supi = nef.resolveUe(v, "/externalId", *sub.ExternalId)
+	case sub.Msisdn != nil:
+		supi = nef.resolveUe(v, "/msisdn", *sub.Msisdn)
+	default:
+		v.fail(CauseMandatoryIeMissing, "/externalId", "one of externalId or msisdn is required")
+	}
+	if v.mandatory("/monitoringType", sub.MonitoringType != "") && !supportedMonitoringType(sub.MonitoringType) {
+		v.incorrect(true, "/monitoringType", "not supported")
+	}
+	if sub.MaximumNumberOfReports != nil && *sub.MaximumNumberOfReports < 1 {
+		v.incorrect(false, "/maximumNumberOfReports", "not a positive number")
+	}
+	if sub.MonitorExpireTime != nil && !sub.MonitorExpireTime.After(nef.Clock.Now()) {
+		v.incorrect(false, "/monitorExpireTime", "in the past")
+	}
+	if v.reply(w, WriteProblem) {
+		return
+	}
+
+	sub.Self = nil
+	rec := nef.newSubscription(nefMonitoring, mux.Vars(r)["afId"], sub.NotificationDestination, sub)
+	rec.supi = supi
+	sub.Self = models.PtrString(nef.selfLink(monitoringEventApi, rec))
+
+	var err error
+	switch sub.MonitoringType {
+	case models.MonitoringTypeLossOfConnectivity:
+		err = nef.subscribeAmf(rec, models.AMFEVENTTYPEANYOF_LOSS_OF_CONNECTIVITY, sub.MonitorExpireTime)
+	case models.MonitoringTypeUeReachability:
+		err = nef.subscribeAmf(rec, models.AMFEVENTTYPEANYOF_CONNECTIVITY_STATE_REPORT, sub.MonitorExpireTime)
+	case models.MonitoringTypeLocationReporting:
+		err = nef.subscribeAmf(rec, models.AMFEVENTTYPEANYOF_LOCATION_REPORT, sub.MonitorExpireTime)
+	case models.MonitoringTypePdnConnectivity:
+		err = nef.subscribeSmf(rec, sub.MonitorExpireTime, smfEventSubscription(models.SMFEVENTANYOF_PDU_SES_EST),
+			smfEventSubscription(models.SMFEVENTANYOF_PDU_SES_REL))
+	case models.MonitoringTypeDownlinkDataDelivery:
+		err = nef.subscribeSmf(rec, sub.MonitorExpireTime, smfEventSubscription(models.SMFEVENTANYOF_DDDS))
+	}
+	if err != nil {
+		nef.failSubscription(w, rec, err)
+		return
+	}
+	nef.replyCreated(w, *sub.Self, sub)
+}
+
+// HandleNewAsSessionWithQos creates an AS session with QoS, TS 29.122 clause 5.14
+func (nef *Nef) HandleNewAsSessionWithQos(w http.ResponseWriter, r *http.Request) {
+	sub := &models.AsSessionWithQoSSubscription{}
+	if !decodeRequest(w, r, sub, WriteProblem) {
+		return
+	}
+
+	v := newValidator(nef.PlmnId)
+	if v.mandatory("/notificationDestination", sub.NotificationDestination != "") {
+		v.uri(true, "/notificationDestination", sub.NotificationDestination)
+	}
+	// the simulated PDU sessions only have IPv4 addresses
+	if v.mandatory("/ueIpv4Addr", sub.UeIpv4Addr != nil) {
+		if ip := net.ParseIP(*sub.UeIpv4Addr); ip == nil || ip.To4() == nil {
+			v.incorrect(true, "/ueIpv4Addr", "not an IPv4 address")
+		}
+	}
+	reference, known := QosReference{}, false
+	if v.mandatory("/qosReference", sub.QosReference != nil) {
+		if reference, known = nef.qosReferences[*sub.QosReference]; !known {
+			v.incorrect(true, "/qosReference", "unknown QoS reference")
+		}
+	}
+	for i, flow := range sub.FlowInfo {
+		if flow.FlowId < 0 {
+			v.incorrect(false, fmt.Sprintf("/flowInfo/%d/flowId", i), "not a positive number")
+		}
+	}
+	if sub.Snssai != nil {
+		v.snssai(false, "/snssai", sub.Snssai.Sst, sub.Snssai.Sd)
+	}
+	if v.reply(w, WriteProblem) {
+		return
+	}
+
+	sub.Self = nil
+	rec := nef.newSubscription(nefAsSessionWithQos, mux.Vars(r)["afId"], sub.NotificationDestination, sub)
+	sub.Self = models.PtrString(nef.selfLink(asSessionWithQosApi, rec))
+
+	component := models.MediaComponent{
+		MedCompN: 1,
+		MedType:  models.PtrString(reference.MedType),
+	}
+	if reference.MarBwUl != "" {
+		component.MarBwUl = models.PtrString(reference.MarBwUl)
+	}
+	if reference.MarBwDl != "" {
+		component.MarBwDl = models.PtrString(reference.MarBwDl)
+	}
+	if len(sub.FlowInfo) > 0 {
+		subComponents := make(map[string]models.MediaSubComponent, len(sub.FlowInfo))
+		for _, flow := range sub.FlowInfo {
+			subComponents[fmt.Sprint(flow.FlowId)] = models.MediaSubComponent{FNum: flow.FlowId, FDescs: flow.FlowDescriptions}
+		}
+		component.MedSubComps = &subComponents
+	}
+	req := &models.AppSessionContextReqData{
+		NotifUri:      nef.callbackUri(rec, "app-session"),
+		SuppFeat:      "0",
+		UeIpv4:        sub.UeIpv4Addr,
+		Dnn:           sub.Dnn,
+		SliceInfo:     sub.Snssai,
+		MedComponents: &map[string]models.MediaComponent{"1": component},
+	}
+	if err := nef.createAppSession(rec, req); err != nil {
+		nef.failSubscription(w, rec, err)
+		return
+	}
+
+	// the session termination and the usage are reported by the SMF
+	supi, pduSessId, _ := nef.ipamInstance.GetUserStringOk(*sub.UeIpv4Addr)
+	nef.subMutex.Lock()
+	rec.supi, rec.pduSessId = supi, pduSessId
+	nef.subMutex.Unlock()
+	events := []models.EventSubscription{smfEventSubscription(models.SMFEVENTANYOF_PDU_SES_REL)}
+	if sub.UsageThreshold != nil {
+		events = append(events, smfEventSubscription(models.SMFEVENTANYOF_QOS_MON))
+	}
+	if err := nef.subscribeSmf(rec, nil, events...); err != nil {
+		nef.failSubscription(w, rec, err)
+		return
+	}
+	nef.replyCreated(w, *sub.Self, sub)
+}
+
+// HandleNewTrafficInfluence creates a traffic influence subscription, TS 29.522 clause 5.4
+func (nef *Nef) HandleNewTrafficInfluence(w http.ResponseWriter, r *http.Request) {
+	sub := &models.TrafficInfluSub{}
+	if !decodeRequest(w, r, sub, WriteProblem) {
+		return
+	}
+
+	v := newValidator(nef.PlmnId)
+	if sub.AfAppId == nil && len(sub.TrafficFilters) == 0 {
+		v.fail(CauseMandatoryIeMissing, "/afAppId", "one of afAppId or trafficFilters is required")
+	}
+	if v.mandatory("/trafficRoutes", len(sub.TrafficRoutes) > 0) {
+		for i := range sub.TrafficRoutes {
+			if sub.TrafficRoutes[i].GetDnai() == "" {
+				v.fail(CauseMandatoryIeMissing, fmt.Sprintf("/trafficRoutes/%d/dnai", i), "missing")
+			}
+		}
+	}
+	upPathChange := false
+	for i, event := range sub.SubscribedEvents {
+		if event != models.TrafficInfluEventUpPathChange {
+			v.incorrect(false, fmt.Sprintf("/subscribedEvents/%d", i), "not supported")
+		}
+		upPathChange = true
+	}
+	if upPathChange && v.mandatory("/notificationDestination", sub.NotificationDestination != nil) {
+		v.uri(true, "/notificationDestination", *sub.NotificationDestination)
+	}
+	if sub.DnaiChgType != nil {
+		v.extensibleEnum(false, "/dnaiChgType", sub.DnaiChgType, sub.DnaiChgType.DnaiChangeTypeAnyOf != nil)
+	}
+	if sub.Snssai != nil {
+		v.snssai(false, "/snssai", sub.Snssai.Sst, sub.Snssai.Sd)
+	}
+	ueIpv4, supi, pduSessId := "", "", int32(0)
+	switch {
+	case sub.AnyUeInd != nil && *sub.AnyUeInd:
+		v.incorrect(false, "/anyUeInd", "influencing the traffic of any UE is not supported")
+	case sub.ExternalGroupId != nil:
+		v.incorrect(false, "/externalGroupId", "influencing the traffic of a group is not supported")
+	case sub.Ipv4Addr != nil:
+		ok := false
+		if supi, pduSessId, ok = nef.ipamInstance.GetUserStringOk(*sub.Ipv4Addr); !ok {
+			v.incorrect(true, "/ipv4Addr", "no PDU session has this address")
+		}
+		ueIpv4 = *sub.Ipv4Addr
+	case sub.Gpsi != nil:
+		if supi = nef.resolveUe(v, "/gpsi", *sub.Gpsi); supi != "" {
+			ok := false
+			if ueIpv4, pduSessId, ok = nef.pduSessionOf(supi); !ok {
+				v.incorrect(true, "/gpsi", "the UE has no PDU session")
+			}
+		}
+	default:
+		v.fail(CauseMandatoryIeMissing, "/ipv4Addr", "one of ipv4Addr or gpsi is required")
+	}
+	if v.reply(w, WriteProblem) {
+		return
+	}
+
+	sub.Self = nil
+	notificationDestination := ""
+	if sub.NotificationDestination != nil {
+		notificationDestination = *sub.NotificationDestination
+	}
+	rec := nef.newSubscription(nefTrafficInfluence, mux.Vars(r)["afId"], notificationDestination, sub)
+	rec.supi, rec.pduSessId = supi, pduSessId
+	sub.Self = models.PtrString(nef.selfLink(trafficInfluenceApi, rec))
+
+	req := &models.AppSessionContextReqData{
+		NotifUri:  nef.callbackUri(rec, "app-session"),
+		SuppFeat:  "0",
+		AfAppId:   sub.AfAppId,
+		UeIpv4:    models.PtrString(ueIpv4),
+		Dnn:       sub.Dnn,
+		SliceInfo: sub.Snssai,
+		AfRoutReq: &models.AfRoutingRequirement{
+			AppReloc:    sub.AppReloInd,
+			RouteToLocs: sub.TrafficRoutes,
+			TempVals:    sub.TempValidities,
+		},
+	}
+	if err := nef.createAppSession(rec, req); err != nil {
+		nef.failSubscription(w, rec, err)
+		return
+	}
+	if upPathChange {
+		event := smfEventSubscription(models.SMFEVENTANYOF_UP_PATH_CH)
+		event.DnaiChgType = sub.DnaiChgType
+		if err := nef.subscribeSmf(rec, nil, event); err != nil {
+			nef.failSubscription(w, rec, err)
+			return
+		}
+	}
+	nef.replyCreated(w, *sub.Self, sub)
+}
+
+// resolveUe returns the SUPI of the UE with the given MSISDN or external identifier
+func (nef *Nef) resolveUe(v *validator, param string, id string) string {
+	supi, ok := nef.resolver.ResolveUe(id)
+	if !ok {
+		v.incorrect(true, param, "no UE of the network has this identifier")
+	}
+	return supi
+}
+
+// pduSessionOf returns the address of the first PDU session of a UE
+func (nef *Nef) pduSessionOf(supi string) (string, int32, bool) {
+	for _, allocation := range nef.ipamInstance.Allocations() {
+		if allocation.Supi == strings.TrimPrefix(supi, "imsi-") {
+			return allocation.Ip, allocation.PduSessId, true
+		}
+	}
+	return "", 0, false
+}
+
+func supportedMonitoringType(monitoringType models.MonitoringType) bool {
+	switch monitoringType {
+	case models.MonitoringTypeLossOfConnectivity, models.MonitoringTypeUeReachability, models.MonitoringTypeLocationReporting,
+		models.MonitoringTypePdnConnectivity, models.MonitoringTypeDownlinkDataDelivery:
+		return true
+	}
+	return false
+}
+
+func smfEventSubscription(event models.SmfEventAnyOf) models.EventSubscription {
+	return models.EventSubscription{Event: event}
+}
+
+// newSubscription registers a subscription of an AF before its resources are created in
+// the core network, so that the notifications racing with the creation are not lost
+func (nef *Nef) newSubscription(api nefApi, afId string, notificationDestination string, resource any) *nefSubscription {
+	rec := &nefSubscription{
+		api:                     api,
+		afId:                    afId,
+		id:                      uuid.NewString(),
+		resource:                resource,
+		notificationDestination: notificationDestination,
+	}
+	nef.subMutex.Lock()
+	nef.subscriptions[rec.id] = rec
+	nef.subMutex.Unlock()
+	return rec
+}
+
+func (nef *Nef) selfLink(api string, rec *nefSubscription) string {
+	return fmt.Sprintf("%s%s/%s/subscriptions/%s", nef.ApiRoot, api, rec.afId, rec.id)
+}
+
+func (nef *Nef) callbackUri(rec *nefSubscription, source string) string {
+	return fmt.Sprintf("%s%s/%s/%s", nef.ApiRoot, nefCallbackApi, rec.id, source)
+}
+
+func (nef *Nef) replyCreated(w http.ResponseWriter, self string, resource any) {
+	w.Header().Set("Location", self)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(resource); err != nil {
+		log.Printf("[%s] could not serialize response body: %s", nef.NefId, err.Error())
+	}
+}
+
+// failSubscription releases a subscription that could not be created and relays the error of the core network
+func (nef *Nef) failSubscription(w http.ResponseWriter, rec *nefSubscription, err error) {
+	nef.removeSubscription(rec.id, "could not be created")
+	relayError(w, err)
+}
+
+// addCoreResource records a resource created in the core network, released with the subscription
+func (nef *Nef) addCoreResource(rec *nefSubscription, method string, location string) {
+	nef.subMutex.Lock()
+	defer nef.subMutex.Unlock()
+	rec.core = append(rec.core, coreResource{method: method, uri: strings.TrimPrefix(location, nef.ApiRoot)})
+}
+
+// subscribeAmf subscribes to an AMF event of the UE of the subscription
+func (nef *Nef) subscribeAmf(rec *nefSubscription, eventType models.AmfEventTypeAnyOf, expiry *time.Time) error {
+	trigger := models.AMFEVENTTRIGGERANYOF_CONTINUOUS
+	create := &models.AmfCreateEventSubscription{
+		Subscription: models.AmfEventSubscription{
+			EventList:           []models.AmfEvent{{Type: eventType}},
+			EventNotifyUri:      nef.callbackUri(rec, "amf-events"),
+			NotifyCorrelationId: rec.id,
+			NfId:                nef.instanceId,
+			Supi:                models.PtrString(rec.supi),
+			Options: &models.AmfEventMode{
+				Trigger: models.AmfEventTrigger{AmfEventTriggerAnyOf: &trigger},
+				Expiry:  expiry,
+			},
+		},
+	}
+	location, err := nef.call(http.MethodPost, "/namf-evts/v1/subscriptions", create)
+	if err != nil {
+		return err
+	}
+	nef.addCoreResource(rec, http.MethodDelete, location)
+	return nil
+}
+
+// subscribeSmf subscribes to SMF events of the UE of the subscription
+func (nef *Nef) subscribeSmf(rec *nefSubscription, expiry *time.Time, events ...models.EventSubscription) error {
+	sub := &models.NsmfEventExposure{
+		Supi:      models.PtrString(rec.supi),
+		NotifId:   rec.id,
+		NotifUri:  nef.callbackUri(rec, "smf-events"),
+		EventSubs: events,
+		Expiry:    expiry,
+	}
+	location, err := nef.call(http.MethodPost, "/nsmf-event-exposure/v1/subscriptions", sub)
+	if err != nil {
+		return err
+	}
+	nef.addCoreResource(rec, http.MethodDelete, location)
+	return nil
+}
+
+// createAppSession requests a policy to the PCF, the app session is deleted with a POST to its delete resource
+func (nef *Nef) createAppSession(rec *nefSubscription, req *models.AppSessionContextReqData) error {
+	ctx := &models.AppSessionContext{AscReqData: *models.NewNullableAppSessionContextReqData(req)}
+	location, err := nef.call(http.MethodPost, "/npcf-policyauthorization/v1/app-sessions", ctx)
+	if err != nil {
+		return err
+	}
+	nef.addCoreResource(rec, http.MethodPost, location+"/delete")
+	return nil
+}
+
+// removeSubscription forgets a subscription and releases its resources in the core network
+func (nef *Nef) removeSubscription(id string, reason string) bool {
+	nef.subMutex.Lock()
+	rec, ok := nef.subscriptions[id]
+	delete(nef.subscriptions, id)
+	nef.subMutex.Unlock()
+	if !ok {
+		return false
+	}
+
+	for _, resource := range rec.core {
+		// the core network may have removed the resource already, e.g. an expired subscription
+		if _, err := nef.call(resource.method, resource.uri, nil); err != nil {
+			log.Printf("[%s] could not release %s: %s", nef.NefId, resource.uri, err.Error())
+		}
+	}
+	log.Printf("[%s] removed %s subscription %s: %s", nef.NefId, rec.api, id, reason)
+	return true
+}
+
+// lookup returns a subscription of an AF through an API
+func (nef *Nef) lookup(api nefApi, afId string, id string) (*nefSubscription, bool) {
+	nef.subMutex.RLock()
+	defer nef.subMutex.RUnlock()
+	rec, ok := nef.subscriptions[id]
+	if !ok || rec.api != api || rec.afId != afId {
+		return nil, false
+	}
+	return rec, true
+}
+
+// handleList answers with the subscriptions of an AF through an API
+func (nef *Nef) handleList(api nefApi) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		afId := mux.Vars(r)["afId"]
+
+		nef.subMutex.RLock()
+		recs := []*nefSubscription{}
+		for _, rec := range nef.subscriptions {
+			if rec.api == api && rec.afId == afId {
+				recs = append(recs, rec)
+			}
+		}
+		nef.subMutex.RUnlock()
+
+		// listed in a stable order
+		slices.SortFunc(recs, func(a, b *nefSubscription) int { return strings.Compare(a.id, b.id) })
+		resources := make([]any, 0, len(recs))
+		for _, rec := range recs {
+			resources = append(resources, rec.resource)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resources); err != nil {
+			log.Printf("[%s] could not serialize response body: %s", nef.NefId, err.Error())
+		}
+	}
+}
+
+// handleGet answers with a subscription of an AF
+func (nef *Nef) handleGet(api nefApi) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		rec, ok := nef.lookup(api, vars["afId"], vars["subscriptionId"])
+		if !ok {
+			WriteProblem(w, http.StatusNotFound, CauseSubscriptionNotFound, "subscription "+vars["subscriptionId"]+" is not found")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(rec.resource); err != nil {
+			log.Printf("[%s] could not serialize response body: %s", nef.NefId, err.Error())
+		}
+	}
+}
+
+// handleDelete removes a subscription of an AF and its resources in the core network
+func (nef *Nef) handleDelete(api nefApi) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		if _, ok := nef.lookup(api, vars["afId"], vars["subscriptionId"]); !ok {
+			WriteProblem(w, http.StatusNotFound, CauseSubscriptionNotFound, "subscription "+vars["subscriptionId"]+" is not found")
+			return
+		}
+		nef.removeSubscription(vars["subscriptionId"], "deleted by the AF")
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// CALLBACK Definitions
+
+// HandleAmfNotification relays the AMF events of a monitoring event subscription
+func (nef *Nef) HandleAmfNotification(w http.ResponseWriter, r *http.Request) {
+	notification := &models.AmfEventNotification{}
+	if !decodeRequest(w, r, notification, WriteProblem) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	rec, ok := nef.callbackSubscription(mux.Vars(r)["subscriptionId"])
+	if !ok || rec.api != nefMonitoring {
+		return
+	}
+	sub := rec.resource.(*models.MonitoringEventSubscription)
+	reports := []models.MonitoringEventReport{}
+	for _, amfReport := range notification.ReportList {
+		if report, ok := monitoringReportFromAmf(sub, &amfReport); ok {
+			reports = append(reports, report)
+		}
+	}
+	nef.relayMonitoringReports(rec, sub, reports)
+}
+
+// HandleSmfNotification relays the SMF events of a subscription
+func (nef *Nef) HandleSmfNotification(w http.ResponseWriter, r *http.Request) {
+	notification := &models.NsmfEventExposureNotification{}
+	if !decodeRequest(w, r, notification, WriteProblem) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	rec, ok := nef.callbackSubscription(mux.Vars(r)["subscriptionId"])
+	if !ok {
+		return
+	}
+	switch rec.api {
+	case nefMonitoring:
+		sub := rec.resource.(*models.MonitoringEventSubscription)
+		reports := []models.MonitoringEventReport{}
+		for _, event := range notification.EventNotifs {
+			if report, ok := monitoringReportFromSmf(sub, &event); ok {
+				reports = append(reports, report)
+			}
+		}
+		nef.relayMonitoringReports(rec, sub, reports)
+	case nefAsSessionWithQos:
+		nef.relayUserPlaneEvents(rec, notification.EventNotifs)
+	case nefTrafficInfluence:
+		nef.relayUpPathChanges(rec, notification.EventNotifs)
+	}
+}
+
+// HandleAppSessionNotification acknowledges the notifications of the PCF on the app sessions of the NEF
+func (nef *Nef) HandleAppSessionNotification(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (nef *Nef) callbackSubscription(id string) (*nefSubscription, bool) {
+	nef.subMutex.RLock()
+	defer nef.subMutex.RUnlock()
+	rec, ok := nef.subscriptions[id]
+	return rec, ok
+}
+
+// monitoringReportFromAmf translates an AMF event into a monitoring event report, TS 29.122 clause 5.3.2.1.2
+func monitoringReportFromAmf(sub *models.MonitoringEventSubscription, amfReport *models.AmfEventReport) (models.MonitoringEventReport, bool) {
+	report := newMonitoringReport(sub, amfReport.TimeStamp)
+	switch amfReport.Type {
+	case models.AMFEVENTTYPEANYOF_LOSS_OF_CONNECTIVITY:
+		// Loss-Of-Connectivity-Reason of TS 29.336
+		reason := int32(0)
+		switch amfReport.LossOfConnectReason {
+		case models.LOSSOFCONNECTIVITYREASONANYOF_MAX_DETECTION_TIME_EXPIRED:
+			reason = 2
+		case models.LOSSOFCONNECTIVITYREASONANYOF_PURGED:
+			reason = 4
+		}
+		report.LossOfConnectReason = &reason
+	case models.AMFEVENTTYPEANYOF_CONNECTIVITY_STATE_REPORT:
+		// the UE is reachable for data once connected
+		if len(amfReport.CmInfoList) == 0 || amfReport.CmInfoList[0].CmState != models.CmStateConnected {
+			return report, false
+		}
+		report.ReachabilityType = models.PtrString("DATA")
+	case models.AMFEVENTTYPEANYOF_LOCATION_REPORT:
+		if amfReport.Location == nil || amfReport.Location.NrLocation == nil {
+			return report, false
+		}
+		location := amfReport.Location.NrLocation
+		report.LocationInfo = &models.MonitoringLocationInfo{
+			CellId:         models.PtrString(location.Ncgi.NrCellId),
+			TrackingAreaId: models.PtrString(location.Tai.Tac),
+			PlmnId:         &location.Tai.PlmnId,
+		}
+	default:
+		return report, false
+	}
+	return report, true
+}
+
+// monitoringReportFromSmf translates a SMF event into a monitoring event report, TS 29.122 clause 5.3.2.1.2
+func monitoringReportFromSmf(sub *models.MonitoringEventSubscription, event *models.EventNotification) (models.MonitoringEventReport, bool) {
+	report := newMonitoringReport(sub, event.TimeStamp)
+	switch event.Event {
+	case models.SMFEVENTANYOF_PDU_SES_EST, models.SMFEVENTANYOF_PDU_SES_REL:
+		status := "CREATED"
+		if event.Event == models.SMFEVENTANYOF_PDU_SES_REL {
+			status = "RELEASED"
+		}
+		report.PdnConnInfoList = []models.PdnConnectionInformation{{
+			Status:   status,
+			Apn:      event.Dnn,
+			PdnType:  "IPV4",
+			Ipv4Addr: event.Ipv4Addr,
+			Snssai:   event.Snssai,
+		}}
+	case models.SMFEVENTANYOF_DDDS:
+		if event.DddStatus == nil || event.DddStatus.DlDataDeliveryStatusAnyOf == nil {
+			return report, false
+		}
+		report.DddStatus = models.PtrString(string(*event.DddStatus.DlDataDeliveryStatusAnyOf))
+	default:
+		return report, false
+	}
+	return report, true
+}
+
+func newMonitoringReport(sub *models.MonitoringEventSubscription, eventTime time.Time) models.MonitoringEventReport {
+	return models.MonitoringEventReport{
+		ExternalId:     sub.ExternalId,
+		Msisdn:         sub.Msisdn,
+		MonitoringType: sub.MonitoringType,
+		EventTime:      &eventTime,
+	}
+}
+
+// relayMonitoringReports notifies the reports to the AF, the subscription is removed once
+// its maximum number of reports is reached
+func (nef *Nef) relayMonitoringReports(rec *nefSubscription, sub *models.MonitoringEventSubscription, reports []models.MonitoringEventReport) {
+	if len(reports) == 0 {
+		return
+	}
+
+	nef.subMutex.Lock()
+	over := false
+	if max := sub.MaximumNumberOfReports; max != nil {
+		remaining := *max - rec.reports
+		if remaining <= 0 {
+			nef.subMutex.Unlock()
+			return
+		}
+		if int32(len(reports)) >= remaining {
+			reports = reports[:remaining]
+			over = true
+		}
+	}
+	rec.reports += int32(len(reports))
+	nef.subMutex.Unlock()
+
+	nef.notify(rec, &models.MonitoringNotification{
+		Subscription:      *sub.Self,
+		MonitoringReports: reports,
+	})
+	if over {
+		nef.removeSubscription(rec.id, "the maximum number of reports is reached")
+	}
+}
+
+// relayUserPlaneEvents notifies the termination of the PDU session of an AS session with QoS
+// and its usage once the threshold is reached
+func (nef *Nef) relayUserPlaneEvents(rec *nefSubscription, events []models.EventNotification) {
+	sub := rec.resource.(*models.AsSessionWithQoSSubscription)
+	reports := []models.UserPlaneEventReport{}
+	terminated := false
+
+	nef.subMutex.Lock()
+	for _, event := range events {
+		if event.PduSeId != nil && *event.PduSeId != rec.pduSessId {
+			continue
+		}
+		switch event.Event {
+		case models.SMFEVENTANYOF_PDU_SES_REL:
+			reports = append(reports, models.UserPlaneEventReport{Event: models.UserPlaneEventSessionTermination})
+			terminated = true
+		case models.SMFEVENTANYOF_QOS_MON:
+			if rec.usageReported || event.CustomizedData == nil || sub.UsageThreshold == nil {
+				continue
+			}
+			volume := event.CustomizedData.UsageReport.Volume
+			if !usageThresholdReached(sub.UsageThreshold, volume) {
+				continue
+			}
+			rec.usageReported = true
+			reports = append(reports, models.UserPlaneEventReport{
+				Event: models.UserPlaneEventUsageReport,
+				AccumulatedUsage: &models.AccumulatedUsage{
+					TotalVolume:    models.PtrInt64(volume.Total),
+					DownlinkVolume: models.PtrInt64(volume.Downlink),
+					UplinkVolume:   models.PtrInt64(volume.Uplink),
+				},
+			})
+		}
+	}
+	nef.subMutex.Unlock()
+
+	if len(reports) == 0 {
+		return
+	}
+	nef.notify(rec, &models.UserPlaneNotificationData{
+		Transaction:  *sub.Self,
+		EventReports: reports,
+	})
+	if terminated {
+		nef.removeSubscription(rec.id, "the PDU session is released")
+	}
+}
+
+func usageThresholdReached(threshold *models.UsageThreshold, volume models.Volume) bool {
+	return (threshold.TotalVolume != nil && volume.Total >= *threshold.TotalVolume) ||
+		(threshold.DownlinkVolume != nil && volume.Downlink >= *threshold.DownlinkVolume) ||
+		(threshold.UplinkVolume != nil && volume.Uplink >= *threshold.UplinkVolume)
+}
+
+// relayUpPathChanges notifies the user plane path changes of the PDU session influenced by the AF
+func (nef *Nef) relayUpPathChanges(rec *nefSubscription, events []models.EventNotification) {
+	sub := rec.resource.(*models.TrafficInfluSub)
+	dnaiChgType := string(models.DNAICHANGETYPEANYOF_EARLY)
+	if sub.DnaiChgType != nil && sub.DnaiChgType.DnaiChangeTypeAnyOf != nil {
+		dnaiChgType = string(*sub.DnaiChgType.DnaiChangeTypeAnyOf)
+	}
+
+	for _, event := range events {
+		if event.Event != models.SMFEVENTANYOF_UP_PATH_CH || (event.PduSeId != nil && *event.PduSeId != rec.pduSessId) {
+			continue
+		}
+		changeType := dnaiChgType
+		if event.DnaiChgType != nil && event.DnaiChgType.DnaiChangeTypeAnyOf != nil {
+			changeType = string(*event.DnaiChgType.DnaiChangeTypeAnyOf)
+		}
+		ueIpv4 := event.SourceUeIpv4Addr
+		if ueIpv4 == nil || *ueIpv4 == "" {
+			ueIpv4 = sub.Ipv4Addr
+		}
+		notification := &models.TrafficInfluNotification{
+			AfTransId:       sub.AfTransId,
+			DnaiChgType:     changeType,
+			SourceDnai:      event.SourceDnai,
+			TargetDnai:      event.TargetDnai,
+			Gpsi:            sub.Gpsi,
+			SubscribedEvent: models.TrafficInfluEventUpPathChange,
+		}
+		// the addresses are omitted when the UE is identified otherwise
+		if ueIpv4 != nil && *ueIpv4 != "" {
+			notification.SrcUeIpv4Addr = ueIpv4
+		}
+		if event.TargetUeIpv4Addr != nil && *event.TargetUeIpv4Addr != "" {
+			notification.TgtUeIpv4Addr = event.TargetUeIpv4Addr
+		}
+		nef.notify(rec, notification)
+	}
+}
+
+// notify sends a notification to the AF of a subscription
+func (nef *Nef) notify(rec *nefSubscription, body any) {
+	if rec.notificationDestination == "" {
+		return
+	}
+	callbackBody, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("[%s] error while marshalling notification: %s", nef.NefId, err.Error())
+		return
+	}
+	nef.notifier.Notify(&Notification{
+		Source:         nef.NefId,
+		SubscriptionId: rec.id,
+		Uri:            rec.notificationDestination,
+		Body:           callbackBody,
+		OnFailure: func() {
+			nef.removeSubscription(rec.id, "the AF is not reachable")
+		},
+	})
+}
+
+func (nef *Nef) RegisterNorthboundAPIs(r *mux.Router) {
+	apis := []struct {
+		root   string
+		api    nefApi
+		create http.HandlerFunc
+	}{
+		{monitoringEventApi, nefMonitoring, nef.HandleNewMonitoringSubscription},
+		{asSessionWithQosApi, nefAsSessionWithQos, nef.HandleNewAsSessionWithQos},
+		{trafficInfluenceApi, nefTrafficInfluence, nef.HandleNewTrafficInfluence},
+	}
+	for _, api := range apis {
+		r.HandleFunc(api.root+"/{afId}/subscriptions", api.create).Methods(http.MethodPost)
+		r.HandleFunc(api.root+"/{afId}/subscriptions", nef.handleList(api.api)).Methods(http.MethodGet)
+		r.HandleFunc(api.root+"/{afId}/subscriptions/{subscriptionId}", nef.handleGet(api.api)).Methods(http.MethodGet)
+		r.HandleFunc(api.root+"/{afId}/subscriptions/{subscriptionId}", nef.handleDelete(api.api)).Methods(http.MethodDelete)
+	}
+	r.HandleFunc(nefCallbackApi+"/{subscriptionId}/amf-events", nef.HandleAmfNotification).Methods(http.MethodPost)
+	r.HandleFunc(nefCallbackApi+"/{subscriptionId}/smf-events", nef.HandleSmfNotification).Methods(http.MethodPost)
+	r.HandleFunc(nefCallbackApi+"/{subscriptionId}/app-session", nef.HandleAppSessionNotification).Methods(http.MethodPost)
+	log.Printf("[%s] 3gpp-monitoring-event, 3gpp-as-session-with-qos and 3gpp-traffic-influence have been registered", nef.NefId)
+}
@@ -0,0 +1,262 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* NEF northbound APIs on top of the core network services */
+
+const (
+	testSupi   = "001060000000001"
+	testMsisdn = "33612345678"
+)
+
+// ueResolverFunc adapts a function to the UeResolver interface
+type ueResolverFunc func(id string) (string, bool)
+
+func (f ueResolverFunc) ResolveUe(id string) (string, bool) {
+	return f(id)
+}
+
+var testResolver = ueResolverFunc(func(id string) (string, bool) {
+	switch id {
+	case testMsisdn, "msisdn-" + testMsisdn, testMsisdn + "@coresim.org":
+		return testSupi, true
+	}
+	return "", false
+})
+
+func (n *conformanceNetwork) decodeNotification(t *testing.T, path string, index int, dst any) {
+	t.Helper()
+	if err := json.Unmarshal(n.sink.bodies(path)[index], dst); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNefMonitoringEvent(t *testing.T) {
+	n := newConformanceNetwork(t)
+	resp := n.call(t, http.MethodPost, "/3gpp-monitoring-event/v1/af1/subscriptions", `{"msisdn":"`+testMsisdn+`",
+		"notificationDestination":"`+n.sinkUrl+`/loss","monitoringType":"LOSS_OF_CONNECTIVITY","maximumNumberOfReports":1}`,
+		http.StatusCreated, models.MonitoringEventSubscription{})
+	subscription := resp.Header.Get("Location")
+	if len(n.amf.GetSubscriptions()) != 1 {
+		t.Fatalf("got %d AMF subscriptions, want 1", len(n.amf.GetSubscriptions()))
+	}
+
+	ue := models.UeToAmfMsg{
+		EventType:     models.AMFEVENTTYPEANYOF_LOSS_OF_CONNECTIVITY,
+		TimeStamp:     time.Now(),
+		Supi:          "001060000000002",
+		PlmnId:        testPlmn,
+		CurrentCellId: "000000001",
+		AccessType:    models.ACCESSTYPE__3_GPP_ACCESS,
+		RmState:       models.RmStateDeregistered,
+		CmState:       models.CmStateIdle,
+		LossReason:    models.LOSSOFCONNECTIVITYREASONANYOF_PURGED,
+	}
+	// only the events of the monitored UE are reported
+	n.amf.handleUeToAmfEvent(&ue)
+	ue.Supi = testSupi
+	n.amf.handleUeToAmfEvent(&ue)
+	n.notifications(t, "/loss", 1, models.MonitoringNotification{})
+
+	notification := models.MonitoringNotification{}
+	n.decodeNotification(t, "/loss", 0, &notification)
+	if notification.Subscription != subscription || len(notification.MonitoringReports) != 1 {
+		t.Fatalf("got notification %+v of %s", notification, subscription)
+	}
+	report := notification.MonitoringReports[0]
+	if report.Msisdn == nil || *report.Msisdn != testMsisdn || report.LossOfConnectReason == nil || *report.LossOfConnectReason != 4 {
+		t.Errorf("got report %+v, want the PURGED reason of the monitored MSISDN", report)
+	}
+
+	// the subscription is over after its maximum number of reports
+	waitFor(t, func() bool { return len(n.amf.GetSubscriptions()) == 0 })
+	n.call(t, http.MethodGet, subscription[len(n.server.URL):], ``, http.StatusNotFound, models.ProblemDetails{})
+
+	resp = n.call(t, http.MethodPost, "/3gpp-monitoring-event/v1/af1/subscriptions", `{"externalId":"`+testMsisdn+`@coresim.org",
+		"notificationDestination":"`+n.sinkUrl+`/pdn","monitoringType":"PDN_CONNECTIVITY_STATUS"}`,
+		http.StatusCreated, models.MonitoringEventSubscription{})
+	subscription = resp.Header.Get("Location")[len(n.server.URL):]
+	n.smf.handleUeToSmfEvent(&models.UeToSmfMsg{
+		EventType:   models.SMFEVENTANYOF_PDU_SES_EST,
+		TimeStamp:   time.Now(),
+		Supi:        testSupi,
+		PlmnId:      testPlmn,
+		AccessType:  models.ACCESSTYPE__3_GPP_ACCESS,
+		Dnn:         "internet",
+		Snssai:      models.Snssai{Sst: 1},
+		UeAddress:   "10.0.0.1",
+		PduSessType: models.PDUSESSIONTYPEANYOF_IPV4,
+		PduSessId:   1,
+	})
+	n.notifications(t, "/pdn", 1, models.MonitoringNotification{})
+	n.decodeNotification(t, "/pdn", 0, &notification)
+	if infos := notification.MonitoringReports[0].PdnConnInfoList; len(infos) != 1 || infos[0].Status != "CREATED" || *infos[0].Ipv4Addr != "10.0.0.1" {
+		t.Errorf("got PDN connection information %+v", infos)
+	}
+
+	n.call(t, http.MethodGet, "/3gpp-monitoring-event/v1/af1/subscriptions", ``, http.StatusOK, []models.MonitoringEventSubscription{})
+	n.call(t, http.MethodGet, "/3gpp-monitoring-event/v1/af2/subscriptions/"+subscription[len(subscription)-36:], ``,
+		http.StatusNotFound, models.ProblemDetails{})
+	n.call(t, http.MethodDelete, subscription, ``, http.StatusNoContent, nil)
+	if len(n.smf.GetSubscriptions()) != 0 {
+		t.Errorf("the SMF subscription of the deleted monitoring event subscription is left")
+	}
+
+	n.call(t, http.MethodPost, "/3gpp-monitoring-event/v1/af1/subscriptions", `{"msisdn":"33600000000",
+		"notificationDestination":"`+n.sinkUrl+`/loss","monitoringType":"NUMBER_OF_UES_IN_AN_AREA"}`,
+		http.StatusBadRequest, models.ProblemDetails{})
+}
+
+func TestNefAsSessionWithQos(t *testing.T) {
+	n := newConformanceNetwork(t)
+	ueAddr, err := n.ipam.AllocateIP(testSupi, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := n.call(t, http.MethodPost, "/3gpp-as-session-with-qos/v1/af1/subscriptions", `{"ueIpv4Addr":"`+ueAddr+`",
+		"notificationDestination":"`+n.sinkUrl+`/qos","qosReference":"qos-video","usageThreshold":{"totalVolume":1000},
+		"flowInfo":[{"flowId":1,"flowDescriptions":["permit out ip from 10.10.0.1 to `+ueAddr+`"]}]}`,
+		http.StatusCreated, models.AsSessionWithQoSSubscription{})
+	subscription := resp.Header.Get("Location")
+	sessions := n.pcf.GetAppSessions()
+	if len(sessions) != 1 {
+		t.Fatalf("got %d app sessions, want 1", len(sessions))
+	}
+	for _, session := range sessions {
+		component := (*session.AscReqData.Get().MedComponents)["1"]
+		if component.GetMedType() != "VIDEO" || component.GetMarBwDl() != "20 Mbps" {
+			t.Errorf("got media component %+v, want the qos-video reference", component)
+		}
+	}
+
+	session := models.UeToSmfMsg{
+		TimeStamp:   time.Now(),
+		Supi:        testSupi,
+		PlmnId:      testPlmn,
+		AccessType:  models.ACCESSTYPE__3_GPP_ACCESS,
+		Dnn:         "internet",
+		Snssai:      models.Snssai{Sst: 1},
+		UeAddress:   ueAddr,
+		PduSessType: models.PDUSESSIONTYPEANYOF_IPV4,
+		PduSessId:   1,
+		UpReport:    &models.UpStatsReport{UpStats: *models.NewUpStats(1, time.Now())},
+	}
+	// the usage is reported once, when the threshold is reached
+	for _, total := range []int64{500, 1500, 2500} {
+		msg := session
+		msg.EventType = models.SMFEVENTANYOF_QOS_MON
+		msg.UpReport = &models.UpStatsReport{UpStats: *models.NewUpStats(1, time.Now())}
+		msg.UpReport.TotalBytes = total
+		n.smf.handleUeToSmfEvent(&msg)
+	}
+	session.EventType = models.SMFEVENTANYOF_PDU_SES_REL
+	n.smf.handleUeToSmfEvent(&session)
+	n.notifications(t, "/qos", 2, models.UserPlaneNotificationData{})
+
+	events := []models.UserPlaneEvent{}
+	for i := range n.sink.bodies("/qos") {
+		notification := models.UserPlaneNotificationData{}
+		n.decodeNotification(t, "/qos", i, &notification)
+		if notification.Transaction != subscription {
+			t.Errorf("got transaction %s, want %s", notification.Transaction, subscription)
+		}
+		for _, report := range notification.EventReports {
+			events = append(events, report.Event)
+			if report.Event == models.UserPlaneEventUsageReport && *report.AccumulatedUsage.TotalVolume != 1500 {
+				t.Errorf("got accumulated usage %d, want 1500", *report.AccumulatedUsage.TotalVolume)
+			}
+		}
+	}
+	if len(events) != 2 || events[0] != models.UserPlaneEventUsageReport || events[1] != models.UserPlaneEventSessionTermination {
+		t.Fatalf("got events %v, want a usage report and the session termination", events)
+	}
+
+	// the AS session is released with the PDU session
+	waitFor(t, func() bool { return len(n.pcf.GetAppSessions()) == 0 && len(n.smf.GetSubscriptions()) == 0 })
+
+	n.call(t, http.MethodPost, "/3gpp-as-session-with-qos/v1/af1/subscriptions", `{"ueIpv4Addr":"`+ueAddr+`",
+		"notificationDestination":"`+n.sinkUrl+`/qos","qosReference":"qos-unknown"}`,
+		http.StatusBadRequest, models.ProblemDetails{})
+	n.call(t, http.MethodPost, "/3gpp-as-session-with-qos/v1/af1/subscriptions", `{"ueIpv4Addr":"10.0.255.1",
+		"notificationDestination":"`+n.sinkUrl+`/qos","qosReference":"qos-voice"}`,
+		http.StatusInternalServerError, models.ExtendedProblemDetails{})
+}
+
+func TestNefTrafficInfluence(t *testing.T) {
+	n := newConformanceNetwork(t)
+	ueAddr, err := n.ipam.AllocateIP(testSupi, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := n.call(t, http.MethodPost, "/3gpp-traffic-influence/v1/af1/subscriptions", `{"afAppId":"edge-app","afTransId":"t1",
+		"gpsi":"msisdn-`+testMsisdn+`","trafficRoutes":[{"dnai":"edge","routeProfId":"default"}],"subscribedEvents":["UP_PATH_CHANGE"],
+		"notificationDestination":"`+n.sinkUrl+`/influence","dnaiChgType":"LATE"}`,
+		http.StatusCreated, models.TrafficInfluSub{})
+	subscription := resp.Header.Get("Location")[len(n.server.URL):]
+	sessions := n.pcf.GetAppSessions()
+	if len(sessions) != 1 {
+		t.Fatalf("got %d app sessions, want 1", len(sessions))
+	}
+	for _, session := range sessions {
+		if req := session.AscReqData.Get(); req.GetUeIpv4() != ueAddr || req.AfRoutReq.RouteToLocs[0].GetDnai() != "edge" {
+			t.Errorf("got app session %+v, want the route to edge of %s", req, ueAddr)
+		}
+	}
+
+	n.smf.handleUeToSmfEvent(&models.UeToSmfMsg{
+		EventType:  models.SMFEVENTANYOF_UP_PATH_CH,
+		TimeStamp:  time.Now(),
+		Supi:       testSupi,
+		PlmnId:     testPlmn,
+		AccessType: models.ACCESSTYPE__3_GPP_ACCESS,
+		Dnn:        "internet",
+		Snssai:     models.Snssai{Sst: 1},
+		PduSessId:  1,
+		SourceDnai: "central",
+		TargetDnai: "edge",
+	})
+	n.notifications(t, "/influence", 1, models.TrafficInfluNotification{})
+	notification := models.TrafficInfluNotification{}
+	n.decodeNotification(t, "/influence", 0, &notification)
+	if notification.DnaiChgType != "LATE" || *notification.TargetDnai != "edge" || *notification.AfTransId != "t1" {
+		t.Errorf("got notification %+v", notification)
+	}
+
+	n.call(t, http.MethodGet, subscription, ``, http.StatusOK, models.TrafficInfluSub{})
+	n.call(t, http.MethodDelete, subscription, ``, http.StatusNoContent, nil)
+	if len(n.pcf.GetAppSessions()) != 0 || len(n.smf.GetSubscriptions()) != 0 {
+		t.Errorf("the core network resources of the deleted subscription are left")
+	}
+	n.call(t, http.MethodDelete, subscription, ``, http.StatusNotFound, models.ProblemDetails{})
+
+	n.call(t, http.MethodPost, "/3gpp-traffic-influence/v1/af1/subscriptions", `{"afAppId":"edge-app","anyUeInd":true,
+		"trafficRoutes":[{"dnai":"edge","routeProfId":"default"}]}`, http.StatusBadRequest, models.ProblemDetails{})
+}
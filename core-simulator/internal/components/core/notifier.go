@@ -0,0 +1,343 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/monitoring"
+)
+
+/* Delivery of the event notifications to the subscribers */
+
+var ErrSubscriptionNotFound = errors.New("subscription not found")
+
+const (
+	defaultNotifyTimeout        = 5 * time.Second
+	defaultNotifyMaxRetries     = 3
+	defaultNotifyInitialBackoff = 500 * time.Millisecond
+	defaultNotifyMaxBackoff     = 10 * time.Second
+	defaultNotifyQueueSize      = 1024
+	// a subscriber queue without notifications releases its routine after this delay
+	notifyIdleTimeout = 30 * time.Second
+)
+
+// NotifierConfig tunes the delivery of the notifications, zero values take the defaults
+type NotifierConfig struct {
+	// timeout of a single notification request
+	TimeoutMs int `yaml:"timeoutMs,omitempty" json:"timeoutMs,omitempty"`
+	// retries of a notification after the first attempt, negative to disable the retries
+	MaxRetries int `yaml:"maxRetries,omitempty" json:"maxRetries,omitempty"`
+	// backoff before the first retry, doubled at every retry up to maxBackoffMs
+	InitialBackoffMs int `yaml:"initialBackoffMs,omitempty" json:"initialBackoffMs,omitempty"`
+	MaxBackoffMs     int `yaml:"maxBackoffMs,omitempty" json:"maxBackoffMs,omitempty"`
+	// notifications waiting for each subscriber, the newest are dropped when the queue is full
+	QueueSize int `yaml:"queueSize,omitempty" json:"queueSize,omitempty"`
+}
+
+// Validate checks the notifier configuration
+func (cfg *NotifierConfig) Validate() error {
+	if cfg.TimeoutMs < 0 || cfg.InitialBackoffMs < 0 || cfg.MaxBackoffMs < 0 || cfg.QueueSize < 0 {
+		return fmt.Errorf("notification timeouts, backoffs and queue size must not be negative")
+	}
+	if cfg.MaxBackoffMs > 0 && cfg.MaxBackoffMs < cfg.InitialBackoffMs {
+		return fmt.Errorf("maxBackoffMs %d is lower than initialBackoffMs %d", cfg.MaxBackoffMs, cfg.InitialBackoffMs)
+	}
+	return nil
+}
+
+func msOrDefault(ms int, def time.Duration) time.Duration {
+	if ms == 0 {
+		return def
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// Notification is an event notification addressed to the subscriber of a subscription
+type Notification struct {
+	// origin of the notification, for logs and metrics
+	Source         string
+	SubscriptionId string
+	Uri            string
+	// alternate addresses of the subscriber, tried when Uri is not reachable
+	AltUris []string
+	Body    []byte
+	// invoked once the subscriber is considered gone, the subscription should be removed
+	OnFailure func()
+}
+
+// A Notifier delivers the notifications of the network functions. Each subscription has its own
+// queue served by a single routine, so that the notifications reach the subscriber in order and
+// a slow subscriber does not delay the others. The routine is released once the queue is idle.
+// Failed deliveries are retried with an exponential backoff on the primary and the alternate
+// addresses. A subscriber answering with a client error other than 408 and 429, or not reachable
+// after all the retries, is considered gone: its pending notifications are dropped and the
+// subscription is removed as allowed by TS 29.501.
+type Notifier struct {
+	// root of the SBI serving the notification channels, set once the SBI is served
+	ApiRoot string
+
+	client         *http.Client
+	maxRetries     int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	queueSize      int
+
+	queues   map[string]*notifyQueue   // subscriptionId -> queue
+	channels map[string]*notifyChannel // channelId -> websocket
+	mutex    sync.Mutex
+	// cancelled by Stop, aborting the running deliveries
+	ctx  context.Context
+	stop context.CancelFunc
+	wg   sync.WaitGroup
+}
+
+type notifyQueue struct {
+	pending chan *Notification
+	failed  bool
+}
+
+// NewNotifier creates a notifier, a nil configuration takes the defaults
+func NewNotifier(cfg *NotifierConfig) *Notifier {
+	if cfg == nil {
+		cfg = &NotifierConfig{}
+	}
+	maxRetries := cfg.MaxRetries
+	switch {
+	case maxRetries == 0:
+		maxRetries = defaultNotifyMaxRetries
+	case maxRetries < 0:
+		maxRetries = 0
+	}
+	queueSize := cfg.QueueSize
+	if queueSize == 0 {
+		queueSize = defaultNotifyQueueSize
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	return &Notifier{
+		client: &http.Client{
+			Timeout: msOrDefault(cfg.TimeoutMs, defaultNotifyTimeout),
+		},
+		maxRetries:     maxRetries,
+		initialBackoff: msOrDefault(cfg.InitialBackoffMs, defaultNotifyInitialBackoff),
+		maxBackoff:     msOrDefault(cfg.MaxBackoffMs, defaultNotifyMaxBackoff),
+		queueSize:      queueSize,
+		queues:         make(map[string]*notifyQueue),
+		channels:       make(map[string]*notifyChannel),
+		ctx:            ctx,
+		stop:           stop,
+	}
+}
+
+// Notify queues a notification to its subscriber. It never blocks: the notification
+// is dropped if the queue of the subscriber is full or the notifier is stopped.
+func (n *Notifier) Notify(notif *Notification) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if n.ctx.Err() != nil {
+		return
+	}
+
+	q, ok := n.queues[notif.SubscriptionId]
+	if !ok {
+		q = &notifyQueue{pending: make(chan *Notification, n.queueSize)}
+		n.queues[notif.SubscriptionId] = q
+		n.wg.Add(1)
+		go n.serve(notif.SubscriptionId, q)
+	}
+	if q.failed {
+		monitoring.Notifications.WithLabelValues(notif.Source, "dropped").Inc()
+		return
+	}
+
+	select {
+	case q.pending <- notif:
+		monitoring.NotificationsPending.WithLabelValues(notif.Source).Inc()
+	default:
+		log.Printf("[%s] notification queue of subscription %s is full, dropping notification", notif.Source, notif.SubscriptionId)
+		monitoring.Notifications.WithLabelValues(notif.Source, "dropped").Inc()
+	}
+}
+
+// Stop aborts the running deliveries and drops the pending notifications
+func (n *Notifier) Stop() {
+	n.mutex.Lock()
+	n.stop()
+	n.mutex.Unlock()
+	n.closeChannels()
+	n.wg.Wait()
+}
+
+// serve delivers the notifications of a subscription in order
+func (n *Notifier) serve(subscriptionId string, q *notifyQueue) {
+	defer n.wg.Done()
+	idle := time.NewTimer(notifyIdleTimeout)
+	defer idle.Stop()
+
+	for {
+		select {
+		case <-n.ctx.Done():
+			n.drain(q)
+			return
+		case <-idle.C:
+			n.mutex.Lock()
+			if len(q.pending) == 0 {
+				delete(n.queues, subscriptionId)
+				n.mutex.Unlock()
+				return
+			}
+			n.mutex.Unlock()
+		case notif := <-q.pending:
+			monitoring.NotificationsPending.WithLabelValues(notif.Source).Dec()
+			n.mutex.Lock()
+			failed := q.failed
+			n.mutex.Unlock()
+			if failed {
+				monitoring.Notifications.WithLabelValues(notif.Source, "dropped").Inc()
+			} else if !n.deliver(notif) {
+				n.mutex.Lock()
+				q.failed = true
+				n.mutex.Unlock()
+				if notif.OnFailure != nil {
+					notif.OnFailure()
+				}
+			}
+		}
+		idle.Reset(notifyIdleTimeout)
+	}
+}
+
+// drain drops the notifications left in the queue
+func (n *Notifier) drain(q *notifyQueue) {
+	for {
+		select {
+		case notif := <-q.pending:
+			monitoring.NotificationsPending.WithLabelValues(notif.Source).Dec()
+			monitoring.Notifications.WithLabelValues(notif.Source, "dropped").Inc()
+		default:
+			return
+		}
+	}
+}
+
+// deliver sends a notification until it is accepted, it returns false if the subscriber is gone
+func (n *Notifier) deliver(notif *Notification) bool {
+	start := time.Now()
+	uris := append([]string{notif.Uri}, notif.AltUris...)
+	backoff := n.initialBackoff
+
+	for attempt := 0; ; attempt++ {
+		for _, uri := range uris {
+			status, err := n.post(uri, notif.Body)
+			if n.ctx.Err() != nil {
+				monitoring.Notifications.WithLabelValues(notif.Source, "dropped").Inc()
+				return true
+			}
+			switch {
+			case err == nil && status >= 200 && status < 300:
+				monitoring.Notifications.WithLabelValues(notif.Source, "delivered").Inc()
+				monitoring.NotificationLatency.WithLabelValues(notif.Source).Observe(time.Since(start).Seconds())
+				return true
+			case err == nil && isPermanentFailure(status):
+				log.Printf("[%s] subscriber %s of subscription %s rejected the notification with status %d", notif.Source, uri, notif.SubscriptionId, status)
+				monitoring.Notifications.WithLabelValues(notif.Source, "failed").Inc()
+				return false
+			case err != nil:
+				log.Printf("Error notifying subscriber %s: %v", uri, err)
+			default:
+				log.Printf("Error notifying subscriber %s: status %d", uri, status)
+			}
+		}
+
+		if attempt >= n.maxRetries {
+			log.Printf("[%s] subscription %s not reachable after %d attempts", notif.Source, notif.SubscriptionId, attempt+1)
+			monitoring.Notifications.WithLabelValues(notif.Source, "failed").Inc()
+			return false
+		}
+		monitoring.Notifications.WithLabelValues(notif.Source, "retried").Inc()
+		select {
+		case <-n.ctx.Done():
+			monitoring.Notifications.WithLabelValues(notif.Source, "dropped").Inc()
+			return true
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, n.maxBackoff)
+	}
+}
+
+func (n *Notifier) post(uri string, body []byte) (int, error) {
+	if channelId, ok := n.channelOf(uri); ok {
+		if err := n.sendOnChannel(channelId, body); err != nil {
+			return 0, err
+		}
+		return http.StatusNoContent, nil
+	}
+
+	req, err := http.NewRequestWithContext(n.ctx, http.MethodPost, uri, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+// isPermanentFailure reports whether the status of a notification says that the subscriber
+// will never accept it: client errors other than request timeout and too many requests
+func isPermanentFailure(status int) bool {
+	return status >= 400 && status < 500 &&
+		status != http.StatusRequestTimeout && status != http.StatusTooManyRequests
+}
+
+// AlternateNotifUris returns the notification uri rebased on the alternate addresses of the subscriber
+func AlternateNotifUris(notifUri string, ipv4Addrs []string, fqdns []string) []string {
+	primary, err := url.Parse(notifUri)
+	if err != nil || primary.Host == "" {
+		return nil
+	}
+	alts := []string{}
+	for _, host := range append(append([]string{}, ipv4Addrs...), fqdns...) {
+		alt := *primary
+		alt.Host = host
+		if port := primary.Port(); port != "" {
+			alt.Host = net.JoinHostPort(host, port)
+		}
+		alts = append(alts, alt.String())
+	}
+	return alts
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/net/websocket"
+)
+
+/* Websocket notification channels, for the consumers without a callback address */
+
+// NotificationChannelPath is the SBI path of the notification channels. A consumer opens a
+// websocket on NotificationChannelPath/{channelId} and uses the url of the channel, under the
+// API root of the SBI, as notification uri of its subscriptions: the notifications are then sent
+// as text messages on the websocket. A channel accepts a single connection at a time.
+const NotificationChannelPath = "/notification-channels/v1/"
+
+var errChannelNotConnected = errors.New("notification channel not connected")
+
+type notifyChannel struct {
+	mutex sync.Mutex // serializes the notifications of the subscriptions sharing the channel
+	conn  *websocket.Conn
+}
+
+// channelOf returns the notification channel addressed by a notification uri,
+// only the uris under the API root of the SBI address a channel
+func (n *Notifier) channelOf(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || n.ApiRoot == "" {
+		return "", false
+	}
+	root, err := url.Parse(n.ApiRoot)
+	if err != nil || !strings.EqualFold(u.Scheme, root.Scheme) || !strings.EqualFold(u.Host, root.Host) {
+		return "", false
+	}
+	channelId, ok := strings.CutPrefix(u.Path, NotificationChannelPath)
+	return channelId, ok && channelId != "" && !strings.Contains(channelId, "/")
+}
+
+// sendOnChannel writes a notification on a channel, a channel not connected yet is a transient failure
+func (n *Notifier) sendOnChannel(channelId string, body []byte) error {
+	n.mutex.Lock()
+	ch := n.channels[channelId]
+	n.mutex.Unlock()
+	if ch == nil {
+		return errChannelNotConnected
+	}
+
+	ch.mutex.Lock()
+	defer ch.mutex.Unlock()
+	if err := ch.conn.SetWriteDeadline(time.Now().Add(n.client.Timeout)); err != nil {
+		return err
+	}
+	return websocket.Message.Send(ch.conn, string(body))
+}
+
+// connected tells whether a consumer is connected on a channel
+func (n *Notifier) connected(channelId string) bool {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.channels[channelId] != nil
+}
+
+// handleChannel serves a notification channel until the consumer closes it.
+// A connection to a channel already connected is closed: the channel stays with its first consumer.
+func (n *Notifier) handleChannel(ws *websocket.Conn) {
+	channelId := mux.Vars(ws.Request())["channelId"]
+	ch := &notifyChannel{conn: ws}
+
+	n.mutex.Lock()
+	if n.ctx.Err() != nil || n.channels[channelId] != nil {
+		n.mutex.Unlock()
+		return
+	}
+	n.channels[channelId] = ch
+	n.mutex.Unlock()
+	log.Printf("notification channel %s connected from %s", channelId, ws.Request().RemoteAddr)
+
+	// the consumer is not expected to send anything, reading detects the closure of the channel
+	var discard []byte
+	for websocket.Message.Receive(ws, &discard) == nil {
+	}
+
+	n.mutex.Lock()
+	if n.channels[channelId] == ch {
+		delete(n.channels, channelId)
+	}
+	n.mutex.Unlock()
+	log.Printf("notification channel %s closed", channelId)
+}
+
+// closeChannels disconnects all the consumers
+func (n *Notifier) closeChannels() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	for _, ch := range n.channels {
+		_ = ch.conn.Close()
+	}
+}
+
+// RegisterNorthboundAPIs exposes the notification channels on the SBI
+func (n *Notifier) RegisterNorthboundAPIs(r *mux.Router) {
+	channels := websocket.Server{Handler: n.handleChannel}
+	r.HandleFunc(NotificationChannelPath+"{channelId}", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+			WriteProblem(w, http.StatusBadRequest, CauseInvalidMsgFormat, "notification channels are websocket connections")
+			return
+		}
+		if channelId := mux.Vars(r)["channelId"]; n.connected(channelId) {
+			WriteProblem(w, http.StatusConflict, "", "notification channel "+channelId+" is already connected")
+			return
+		}
+		channels.ServeHTTP(w, r)
+	})
+	log.Printf("notification channels have been registered")
+}
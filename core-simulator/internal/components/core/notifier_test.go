@@ -0,0 +1,260 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/net/websocket"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+var testNotifierConfig = &NotifierConfig{
+	TimeoutMs:        200,
+	MaxRetries:       2,
+	InitialBackoffMs: 10,
+	MaxBackoffMs:     20,
+}
+
+// recorder is a subscriber recording the bodies of the notifications
+type recorder struct {
+	mutex    sync.Mutex
+	received []string
+	// status answered to the first notifications
+	failures []int
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+	if len(rec.failures) > 0 {
+		w.WriteHeader(rec.failures[0])
+		rec.failures = rec.failures[1:]
+		return
+	}
+	rec.received = append(rec.received, string(body))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (rec *recorder) bodies() []string {
+	rec.mutex.Lock()
+	defer rec.mutex.Unlock()
+	return slices.Clone(rec.received)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestNotifierOrderedDelivery(t *testing.T) {
+	rec := &recorder{failures: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+	notifier := NewNotifier(testNotifierConfig)
+	defer notifier.Stop()
+
+	want := []string{}
+	for i := range 50 {
+		want = append(want, strconv.Itoa(i))
+		notifier.Notify(&Notification{Source: "test", SubscriptionId: "sub", Uri: server.URL, Body: []byte(want[i])})
+	}
+	waitFor(t, func() bool { return len(rec.bodies()) == len(want) })
+	if got := rec.bodies(); !slices.Equal(got, want) {
+		t.Fatalf("notifications delivered as %v, want %v", got, want)
+	}
+}
+
+func TestNotifierAlternateAddress(t *testing.T) {
+	rec := &recorder{}
+	server := httptest.NewServer(rec)
+	defer server.Close()
+	notifier := NewNotifier(testNotifierConfig)
+	defer notifier.Stop()
+
+	// the primary address refuses the connections
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	notifier.Notify(&Notification{Source: "test", SubscriptionId: "sub", Uri: closed.URL, AltUris: []string{server.URL}, Body: []byte("alt")})
+	waitFor(t, func() bool { return len(rec.bodies()) == 1 })
+}
+
+func TestNotifierRemovesGoneSubscribers(t *testing.T) {
+	for name, status := range map[string]int{"not found": http.StatusNotFound, "unreachable": http.StatusServiceUnavailable} {
+		t.Run(name, func(t *testing.T) {
+			failures := []int{}
+			for range 10 {
+				failures = append(failures, status)
+			}
+			rec := &recorder{failures: failures}
+			server := httptest.NewServer(rec)
+			defer server.Close()
+			notifier := NewNotifier(testNotifierConfig)
+			defer notifier.Stop()
+
+			removed := make(chan struct{}, 10)
+			for i := range 3 {
+				notifier.Notify(&Notification{
+					Source:         "test",
+					SubscriptionId: "sub",
+					Uri:            server.URL,
+					Body:           []byte(fmt.Sprint(i)),
+					OnFailure:      func() { removed <- struct{}{} },
+				})
+			}
+			select {
+			case <-removed:
+			case <-time.After(5 * time.Second):
+				t.Fatal("subscription not removed")
+			}
+			time.Sleep(100 * time.Millisecond)
+			if len(removed) != 0 || len(rec.bodies()) != 0 {
+				t.Fatalf("notifications of a removed subscription still delivered")
+			}
+		})
+	}
+}
+
+func TestNotifierSlowSubscriber(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+	rec := &recorder{}
+	fast := httptest.NewServer(rec)
+	defer fast.Close()
+	notifier := NewNotifier(&NotifierConfig{TimeoutMs: 5000, QueueSize: 10})
+	defer notifier.Stop()
+
+	// the queue of the slow subscriber is bounded and does not delay the others
+	for range 1000 {
+		notifier.Notify(&Notification{Source: "test", SubscriptionId: "slow", Uri: slow.URL})
+	}
+	notifier.Notify(&Notification{Source: "test", SubscriptionId: "fast", Uri: fast.URL})
+	waitFor(t, func() bool { return len(rec.bodies()) == 1 })
+}
+
+func TestAlternateNotifUris(t *testing.T) {
+	got := AlternateNotifUris("http://af.example.com:8080/notify?id=1", []string{"10.0.0.1"}, []string{"af2.example.com"})
+	want := []string{"http://10.0.0.1:8080/notify?id=1", "http://af2.example.com:8080/notify?id=1"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestNotifierChannel(t *testing.T) {
+	notifier := NewNotifier(testNotifierConfig)
+	defer notifier.Stop()
+	r := mux.NewRouter()
+	notifier.RegisterNorthboundAPIs(r)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	notifier.ApiRoot = server.URL
+
+	channelUri := server.URL + NotificationChannelPath + "harness"
+	ws, err := websocket.Dial(strings.Replace(channelUri, "http", "ws", 1), "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	waitFor(t, func() bool {
+		notifier.mutex.Lock()
+		defer notifier.mutex.Unlock()
+		return notifier.channels["harness"] != nil
+	})
+
+	for i := range 3 {
+		notifier.Notify(&Notification{Source: "test", SubscriptionId: "sub", Uri: channelUri, Body: []byte(strconv.Itoa(i))})
+	}
+	for i := range 3 {
+		var msg string
+		if err := ws.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+			t.Fatal(err)
+		}
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg != strconv.Itoa(i) {
+			t.Fatalf("received %q on the channel, want %d", msg, i)
+		}
+	}
+
+	// the channel stays with its first consumer
+	if _, err := websocket.Dial(strings.Replace(channelUri, "http", "ws", 1), "", server.URL); err == nil {
+		t.Fatalf("second connection to a live channel accepted")
+	}
+	notifier.Notify(&Notification{Source: "test", SubscriptionId: "sub", Uri: channelUri, Body: []byte("3")})
+	var msg string
+	if err := websocket.Message.Receive(ws, &msg); err != nil || msg != "3" {
+		t.Fatalf("received %q, %v on the channel, want 3", msg, err)
+	}
+}
+
+func TestNotifierChannelOf(t *testing.T) {
+	notifier := NewNotifier(testNotifierConfig)
+	defer notifier.Stop()
+	notifier.ApiRoot = "http://127.0.0.1:8080"
+
+	tests := []struct {
+		uri     string
+		channel string
+		ok      bool
+	}{
+		{"http://127.0.0.1:8080" + NotificationChannelPath + "af", "af", true},
+		{"HTTP://127.0.0.1:8080" + NotificationChannelPath + "af", "af", true},
+		{"http://af.example.com:8080" + NotificationChannelPath + "af", "", false},
+		{"http://127.0.0.1:9090" + NotificationChannelPath + "af", "", false},
+		{"https://127.0.0.1:8080" + NotificationChannelPath + "af", "", false},
+		{"http://127.0.0.1:8080" + NotificationChannelPath, "", false},
+		{"http://127.0.0.1:8080" + NotificationChannelPath + "af/notify", "", false},
+		{"http://127.0.0.1:8080/notify", "", false},
+	}
+	for _, tt := range tests {
+		channel, ok := notifier.channelOf(tt.uri)
+		if ok != tt.ok || ok && channel != tt.channel {
+			t.Fatalf("%s: got %q, %v, want %q, %v", tt.uri, channel, ok, tt.channel, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,1350 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* Network Data Analytics Function, statistics and predictions of TS 29.520 learnt from the AMF and SMF events */
+
+const (
+	defaultNwdafHistory          = time.Hour
+	defaultAmfCapacity           = 1000
+	defaultSmfCapacity           = 1000
+	defaultPingPongCount         = 3
+	defaultServiceAccessCount    = 20
+	defaultRadioLinkFailureCount = 3
+	defaultLargeRateMbps         = 100
+	// number of observations behind a prediction of full confidence
+	fullConfidenceObservations = 10
+)
+
+var (
+	supportedNwdafEvents = []string{models.NwdafEventUeMobility, models.NwdafEventUeCommunication, models.NwdafEventNfLoad,
+		models.NwdafEventSliceLoadLevel, models.NwdafEventAbnormalBehaviour}
+	supportedExceptions = []string{models.ExceptionPingPongAcrossCells, models.ExceptionTooFrequentServiceAccess,
+		models.ExceptionUnexpectedRadioLinkFailures, models.ExceptionUnexpectedLargeRateFlow}
+)
+
+// NwdafConfig describes the history, the capacities and the thresholds of the analytics of the NWDAF
+type NwdafConfig struct {
+	// optional history of the statistics and of the predictions in seconds, 1 hour by default
+	HistorySec int `yaml:"historySec,omitempty" json:"historySec,omitempty"`
+	// optional number of registered UEs loading the AMF at 100%, 1000 by default
+	AmfCapacity int `yaml:"amfCapacity,omitempty" json:"amfCapacity,omitempty"`
+	// optional number of PDU sessions loading the SMF at 100%, 1000 by default
+	SmfCapacity int `yaml:"smfCapacity,omitempty" json:"smfCapacity,omitempty"`
+	// optional number of PDU sessions loading a slice at 100%, the SMF capacity by default
+	SliceCapacity int `yaml:"sliceCapacity,omitempty" json:"sliceCapacity,omitempty"`
+	// optional occurrences in the history making an abnormal behaviour
+	PingPongCount         int `yaml:"pingPongCount,omitempty" json:"pingPongCount,omitempty"`
+	ServiceAccessCount    int `yaml:"serviceAccessCount,omitempty" json:"serviceAccessCount,omitempty"`
+	RadioLinkFailureCount int `yaml:"radioLinkFailureCount,omitempty" json:"radioLinkFailureCount,omitempty"`
+	// optional bit rate of a PDU session making an unexpected large rate flow, 100 Mbps by default
+	LargeRateMbps float64 `yaml:"largeRateMbps,omitempty" json:"largeRateMbps,omitempty"`
+}
+
+func (cfg *NwdafConfig) Validate() error {
+	for name, value := range map[string]int{
+		"historySec": cfg.HistorySec, "amfCapacity": cfg.AmfCapacity, "smfCapacity": cfg.SmfCapacity,
+		"sliceCapacity": cfg.SliceCapacity, "pingPongCount": cfg.PingPongCount,
+		"serviceAccessCount": cfg.ServiceAccessCount, "radioLinkFailureCount": cfg.RadioLinkFailureCount,
+	} {
+		if value < 0 {
+			return fmt.Errorf("negative %s %d", name, value)
+		}
+	}
+	if cfg.LargeRateMbps < 0 {
+		return fmt.Errorf("negative large rate %f Mbps", cfg.LargeRateMbps)
+	}
+	return nil
+}
+
+// locationSample is the cell of a UE from the time of an AMF event, gone when the UE left the network
+type locationSample struct {
+	at   time.Time
+	tai  models.Tai
+	ncgi models.Ncgi
+	gone bool
+}
+
+// commSession is a PDU session of a UE, the volumes are cumulative and the end is zero while it is active
+type commSession struct {
+	id       int32
+	dnn      string
+	snssai   models.Snssai
+	start    time.Time
+	end      time.Time
+	ulVol    int64
+	dlVol    int64
+	reported time.Time
+}
+
+// ueHistory is the history of a UE observed by the NWDAF
+type ueHistory struct {
+	locations []locationSample
+	sessions  []*commSession
+	// occurrences of the events of the abnormal behaviours, by exception id
+	exceptions map[string][]time.Time
+}
+
+// loadSample is a load level in percent, from its time until the next sample
+type loadSample struct {
+	at    time.Time
+	level float64
+}
+
+// analyticsWindow is the period of the analytics, the predictions are computed
+// from the history of the same duration up to now
+type analyticsWindow struct {
+	start      time.Time
+	end        time.Time
+	prediction bool
+}
+
+// analyticsQuery selects the analytics of an event
+type analyticsQuery struct {
+	event         string
+	requirement   *models.EventReportingRequirement
+	tgtUe         *models.TargetUeInformation
+	anySlice      bool
+	snssais       []models.Snssai
+	dnns          []string
+	nfInstanceIds []string
+	nfTypes       []string
+	excepIds      []string
+	// resolved when the analytics are computed: the window and the SUPIs of the target UEs, nil for any UE
+	window     analyticsWindow
+	supis      []string
+	maxObjects int
+}
+
+// nwdafSubscription is a subscription to the analytics of the NWDAF, each report is the list of notified analytics
+type nwdafSubscription struct {
+	sub        *models.NnwdafEventsSubscription
+	queries    []*analyticsQuery
+	state      *reportState[[]models.NnwdafEventNotification]
+	nextReport time.Time
+	// event detection: the thresholds crossed and the occurrences of the exceptions already reported
+	above    map[string]bool
+	reported map[string]int32
+}
+
+// A Nwdaf computes the analytics of the simulated network out of the events of the AMF and of the
+// SMF: the cells visited and the PDU sessions of the UEs, the load of the AMF, of the SMF and of the
+// slices, and the abnormal behaviours of the UEs. The statistics cover a period of the history, the
+// predictions extrapolate the history to a future period.
+type Nwdaf struct {
+	PlmnId     models.PlmnId
+	NwdafId    string
+	InstanceId string
+	// NF instances whose load is analysed
+	AmfInstanceId string
+	SmfInstanceId string
+
+	history       time.Duration
+	amfCapacity   int
+	smfCapacity   int
+	sliceCapacity int
+	thresholds    map[string]int // exception id -> occurrences in the window
+	largeRate     float64        // bps
+	notifier      *Notifier
+
+	ues        map[string]*ueHistory // supi -> history
+	gpsis      map[string]string     // msisdn -> supi
+	registered map[string]bool
+	sessions   int
+	slices     map[string]models.Snssai // slice key -> slice
+	sliceUse   map[string]int           // slice key -> active PDU sessions
+	loads      map[string][]loadSample  // nf instance id or slice key -> load levels
+	mutex      sync.Mutex
+
+	subscriptions map[string]*nwdafSubscription
+	subMutex      sync.Mutex
+	stop          chan struct{}
+	// clock of the simulation, the wall clock when nil
+	Clock *utils.Clock
+}
+
+// NewNwdaf creates a NWDAF, a nil configuration takes the defaults
+func NewNwdaf(plmnId models.PlmnId, config *NwdafConfig, notifier *Notifier) *Nwdaf {
+	if config == nil {
+		config = &NwdafConfig{}
+	}
+	orDefault := func(value int, def int) int {
+		if value > 0 {
+			return value
+		}
+		return def
+	}
+	nwdaf := &Nwdaf{
+		PlmnId:      plmnId,
+		NwdafId:     fmt.Sprintf("NWDAF-%s%s", plmnId.Mcc, plmnId.Mnc),
+		InstanceId:  uuid.NewString(),
+		history:     time.Duration(orDefault(config.HistorySec, int(defaultNwdafHistory/time.Second))) * time.Second,
+		amfCapacity: orDefault(config.AmfCapacity, defaultAmfCapacity),
+		smfCapacity: orDefault(config.SmfCapacity, defaultSmfCapacity),
+		thresholds: map[string]int{
+			models.ExceptionPingPongAcrossCells:         orDefault(config.PingPongCount, defaultPingPongCount),
+			models.ExceptionTooFrequentServiceAccess:    orDefault(config.ServiceAccessCount, defaultServiceAccessCount),
+			models.ExceptionUnexpectedRadioLinkFailures: orDefault(config.RadioLinkFailureCount, defaultRadioLinkFailureCount),
+			models.ExceptionUnexpectedLargeRateFlow:     1,
+		},
+		largeRate:     defaultLargeRateMbps * 1e6,
+		notifier:      notifier,
+		ues:           make(map[string]*ueHistory),
+		gpsis:         make(map[string]string),
+		registered:    make(map[string]bool),
+		slices:        make(map[string]models.Snssai),
+		sliceUse:      make(map[string]int),
+		loads:         make(map[string][]loadSample),
+		subscriptions: make(map[string]*nwdafSubscription),
+	}
+	nwdaf.sliceCapacity = orDefault(config.SliceCapacity, nwdaf.smfCapacity)
+	if config.LargeRateMbps > 0 {
+		nwdaf.largeRate = config.LargeRateMbps * 1e6
+	}
+	return nwdaf
+}
+
+// InitNwdaf starts the reports of the subscriptions
+func (nwdaf *Nwdaf) InitNwdaf() {
+	nwdaf.stop = make(chan struct{})
+	go nwdaf.runReports(nwdaf.stop)
+	log.Printf("[%s] started", nwdaf.NwdafId)
+}
+
+// StopNwdaf stops the reports of the subscriptions
+func (nwdaf *Nwdaf) StopNwdaf() {
+	if nwdaf.stop != nil {
+		close(nwdaf.stop)
+		nwdaf.stop = nil
+	}
+	log.Printf("[%s] stopped", nwdaf.NwdafId)
+}
+
+func sliceKey(s models.Snssai) string {
+	return fmt.Sprintf("%d-%s", s.Sst, strings.ToLower(sdOf(s)))
+}
+
+// msisdnOf returns the MSISDN of a GPSI, in E.164 format or prefixed by msisdn-
+func msisdnOf(gpsi string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(gpsi, "msisdn-"), "+")
+}
+
+// ue returns the history of a UE, created if needed
+func (nwdaf *Nwdaf) ue(supi string) *ueHistory {
+	h, ok := nwdaf.ues[supi]
+	if !ok {
+		h = &ueHistory{exceptions: make(map[string][]time.Time)}
+		nwdaf.ues[supi] = h
+	}
+	return h
+}
+
+// recordLoad adds a load level of an NF instance or of a slice, the levels are capped at 100%
+func (nwdaf *Nwdaf) recordLoad(id string, at time.Time, used int, capacity int) {
+	level := min(float64(used)*100/float64(capacity), 100)
+	samples := nwdaf.loads[id]
+	if n := len(samples); n > 0 && samples[n-1].level == level {
+		return
+	}
+	nwdaf.loads[id] = append(samples, loadSample{at: at, level: level})
+}
+
+// recordLocation adds the cell of a UE when it changes, a return to the cell before the
+// previous one is a ping-pong
+func (nwdaf *Nwdaf) recordLocation(h *ueHistory, sample locationSample) {
+	n := len(h.locations)
+	if n > 0 {
+		last := h.locations[n-1]
+		if last.gone == sample.gone && last.ncgi.NrCellId == sample.ncgi.NrCellId {
+			return
+		}
+		if n > 1 && !sample.gone && !last.gone && !h.locations[n-2].gone && h.locations[n-2].ncgi.NrCellId == sample.ncgi.NrCellId {
+			h.exceptions[models.ExceptionPingPongAcrossCells] = append(h.exceptions[models.ExceptionPingPongAcrossCells], sample.at)
+		}
+	}
+	h.locations = append(h.locations, sample)
+}
+
+// ObserveAmfEvent records the cells visited by the UEs, their registrations and their radio link failures
+func (nwdaf *Nwdaf) ObserveAmfEvent(report models.AmfEventReport) {
+	supi := strings.TrimPrefix(report.GetSupi(), "imsi-")
+	if supi == "" {
+		return
+	}
+
+	nwdaf.mutex.Lock()
+	defer nwdaf.mutex.Unlock()
+	h := nwdaf.ue(supi)
+	if gpsi := report.Gpsi; gpsi != nil && *gpsi != "" {
+		nwdaf.gpsis[msisdnOf(*gpsi)] = supi
+	}
+	sample := locationSample{at: report.TimeStamp}
+	if location := report.Location; location != nil && location.NrLocation != nil {
+		sample.tai, sample.ncgi = location.NrLocation.Tai, location.NrLocation.Ncgi
+	}
+
+	left := false
+	switch report.Type {
+	case models.AMFEVENTTYPEANYOF_REGISTRATION_STATE_REPORT:
+		left = len(report.RmInfoList) > 0 && report.RmInfoList[0].RmState != models.RmStateRegistered
+		if !left {
+			nwdaf.registered[supi] = true
+		}
+	case models.AMFEVENTTYPEANYOF_LOSS_OF_CONNECTIVITY:
+		// a UE out of reach stays registered, an unexpected loss of the radio link
+		if report.LossOfConnectReason == models.LOSSOFCONNECTIVITYREASONANYOF_MAX_DETECTION_TIME_EXPIRED {
+			h.exceptions[models.ExceptionUnexpectedRadioLinkFailures] = append(h.exceptions[models.ExceptionUnexpectedRadioLinkFailures], report.TimeStamp)
+			return
+		}
+		left = true
+	}
+	if left {
+		delete(nwdaf.registered, supi)
+		sample.gone = true
+	}
+	if sample.gone || sample.ncgi.NrCellId != "" {
+		nwdaf.recordLocation(h, sample)
+	}
+	nwdaf.recordLoad(nwdaf.AmfInstanceId, report.TimeStamp, len(nwdaf.registered), nwdaf.amfCapacity)
+	nwdaf.prune(h, report.TimeStamp)
+}
+
+// ObserveSmfEvent records the PDU sessions of the UEs with their usage
+func (nwdaf *Nwdaf) ObserveSmfEvent(event models.EventNotification) {
+	supi := strings.TrimPrefix(event.GetSupi(), "imsi-")
+	if supi == "" || event.PduSeId == nil {
+		return
+	}
+
+	nwdaf.mutex.Lock()
+	defer nwdaf.mutex.Unlock()
+	h := nwdaf.ue(supi)
+	var session *commSession
+	for _, s := range h.sessions {
+		if s.id == *event.PduSeId && s.end.IsZero() {
+			session = s
+		}
+	}
+
+	switch event.Event {
+	case models.SMFEVENTANYOF_PDU_SES_EST:
+		if session != nil {
+			return
+		}
+		session = &commSession{id: *event.PduSeId, start: event.TimeStamp, reported: event.TimeStamp}
+		if event.Dnn != nil {
+			session.dnn = *event.Dnn
+		}
+		if event.Snssai != nil {
+			session.snssai = *event.Snssai
+		}
+		h.sessions = append(h.sessions, session)
+		h.exceptions[models.ExceptionTooFrequentServiceAccess] = append(h.exceptions[models.ExceptionTooFrequentServiceAccess], event.TimeStamp)
+		nwdaf.sessions++
+		key := sliceKey(session.snssai)
+		nwdaf.slices[key] = session.snssai
+		nwdaf.sliceUse[key]++
+		nwdaf.recordLoad(key, event.TimeStamp, nwdaf.sliceUse[key], nwdaf.sliceCapacity)
+	case models.SMFEVENTANYOF_PDU_SES_REL:
+		if session == nil {
+			return
+		}
+		session.end = event.TimeStamp
+		nwdaf.sessions--
+		key := sliceKey(session.snssai)
+		nwdaf.sliceUse[key]--
+		nwdaf.recordLoad(key, event.TimeStamp, nwdaf.sliceUse[key], nwdaf.sliceCapacity)
+	case models.SMFEVENTANYOF_QOS_MON:
+		if session == nil || event.CustomizedData == nil {
+			return
+		}
+		volume := event.CustomizedData.UsageReport.Volume
+		if elapsed := event.TimeStamp.Sub(session.reported).Seconds(); elapsed > 0 {
+			rate := float64(volume.Uplink+volume.Downlink-session.ulVol-session.dlVol) * 8 / elapsed
+			if rate > nwdaf.largeRate {
+				h.exceptions[models.ExceptionUnexpectedLargeRateFlow] = append(h.exceptions[models.ExceptionUnexpectedLargeRateFlow], event.TimeStamp)
+			}
+		}
+		session.ulVol, session.dlVol, session.reported = volume.Uplink, volume.Downlink, event.TimeStamp
+		return
+	default:
+		return
+	}
+	nwdaf.recordLoad(nwdaf.SmfInstanceId, event.TimeStamp, nwdaf.sessions, nwdaf.smfCapacity)
+	nwdaf.prune(h, event.TimeStamp)
+}
+
+// prune forgets the loads and the history of a UE older than the history of the analytics,
+// the last sample before the history is kept as it is still in force
+func (nwdaf *Nwdaf) prune(h *ueHistory, now time.Time) {
+	cutoff := now.Add(-nwdaf.history)
+	for id, samples := range nwdaf.loads {
+		nwdaf.loads[id] = pruneSamples(samples, func(s loadSample) time.Time { return s.at }, cutoff)
+	}
+	h.locations = pruneSamples(h.locations, func(s locationSample) time.Time { return s.at }, cutoff)
+	h.sessions = slices.DeleteFunc(h.sessions, func(s *commSession) bool { return !s.end.IsZero() && s.end.Before(cutoff) })
+	for id, occurrences := range h.exceptions {
+		h.exceptions[id] = slices.DeleteFunc(occurrences, func(at time.Time) bool { return at.Before(cutoff) })
+	}
+}
+
+func pruneSamples[T any](samples []T, at func(T) time.Time, cutoff time.Time) []T {
+	i := 0
+	for i+1 < len(samples) && !at(samples[i+1]).After(cutoff) {
+		i++
+	}
+	return samples[i:]
+}
+
+// basis returns the period of the history the analytics of a window are computed from
+func (nwdaf *Nwdaf) basis(w analyticsWindow, now time.Time) (time.Time, time.Time) {
+	if !w.prediction {
+		return w.start, w.end
+	}
+	return now.Add(-min(w.end.Sub(w.start), nwdaf.history)), now
+}
+
+// windowOf returns the window of the analytics of a reporting requirement: the offset period
+// before now for statistics or after now for predictions, or the period between the start and
+// the end. The last history is the default window.
+func (nwdaf *Nwdaf) windowOf(req *models.EventReportingRequirement, now time.Time) (analyticsWindow, error) {
+	w := analyticsWindow{start: now.Add(-nwdaf.history), end: now}
+	switch {
+	case req == nil:
+	case req.OffsetPeriod != nil:
+		offset := time.Duration(*req.OffsetPeriod) * time.Second
+		switch {
+		case offset < 0:
+			w.start = now.Add(offset)
+		case offset > 0:
+			w = analyticsWindow{start: now, end: now.Add(offset), prediction: true}
+		default:
+			return w, fmt.Errorf("the offset period is null")
+		}
+	case req.StartTs != nil || req.EndTs != nil:
+		if req.StartTs != nil {
+			w.start = *req.StartTs
+		}
+		switch {
+		case req.EndTs != nil:
+			w.end = *req.EndTs
+		case !w.start.Before(now):
+			w.end = w.start.Add(nwdaf.history)
+		}
+		if !w.end.After(w.start) {
+			return w, fmt.Errorf("the end of the analytics is not after their start")
+		}
+		// the statistics stop now, a window starting in the future is predicted
+		w.prediction = !w.start.Before(now)
+		if !w.prediction && w.end.After(now) {
+			w.end = now
+		}
+	}
+	return w, nil
+}
+
+// compute returns the analytics of a query, its reporting requirement is valid
+func (nwdaf *Nwdaf) compute(query *analyticsQuery, now time.Time) *models.AnalyticsData {
+	q := *query
+	q.window, _ = nwdaf.windowOf(q.requirement, now)
+	if q.requirement != nil && q.requirement.MaxObjectNbr != nil {
+		q.maxObjects = int(*q.requirement.MaxObjectNbr)
+	}
+	q.supis = nwdaf.resolveUes(q.tgtUe)
+
+	nwdaf.mutex.Lock()
+	defer nwdaf.mutex.Unlock()
+
+	data := &models.AnalyticsData{
+		Start:        models.PtrTime(q.window.start),
+		Expiry:       models.PtrTime(q.window.end),
+		TimeStampGen: models.PtrTime(now),
+	}
+	switch q.event {
+	case models.NwdafEventUeMobility:
+		data.UeMobs = nwdaf.ueMobility(&q, now)
+	case models.NwdafEventUeCommunication:
+		data.UeComms = nwdaf.ueCommunications(&q, now)
+	case models.NwdafEventNfLoad:
+		data.NfLoadLevelInfos = nwdaf.nfLoads(&q, now)
+	case models.NwdafEventSliceLoadLevel:
+		data.SliceLoadLevelInfos = nwdaf.sliceLoads(&q, now)
+	case models.NwdafEventAbnormalBehaviour:
+		data.AbnorBehavrs = nwdaf.abnormalBehaviours(&q, now)
+	}
+	return data
+}
+
+// empty tells whether analytics carry no data
+func empty(data *models.AnalyticsData) bool {
+	return len(data.UeMobs) == 0 && len(data.UeComms) == 0 && len(data.NfLoadLevelInfos) == 0 &&
+		len(data.SliceLoadLevelInfos) == 0 && len(data.AbnorBehavrs) == 0
+}
+
+// targets returns the target UEs of a query with a history, all the UEs when none is selected
+func (nwdaf *Nwdaf) targets(q *analyticsQuery) []string {
+	if q.supis == nil {
+		supis := make([]string, 0, len(nwdaf.ues))
+		for supi := range nwdaf.ues {
+			supis = append(supis, supi)
+		}
+		sort.Strings(supis)
+		return supis
+	}
+	return slices.DeleteFunc(slices.Clone(q.supis), func(supi string) bool { return nwdaf.ues[supi] == nil })
+}
+
+// confidence of a prediction made out of a number of observations, in percent
+func confidence(observations int) *int32 {
+	return models.PtrInt32(int32(min(100, observations*100/fullConfidenceObservations)))
+}
+
+func percent(value float64) int32 {
+	return int32(math.Round(min(max(value, 0), 100)))
+}
+
+func later(a time.Time, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earlier(a time.Time, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// locationTrends returns the cells visited by a UE between from and to: the time spent in
+// each cell, the mean spacing between the visits and the start of the last visit, with the
+// number of visits of each cell
+func (h *ueHistory) locationTrends(from time.Time, to time.Time) ([]models.UeLocationTrendsReportItem, []int) {
+	type visits struct {
+		item   models.UeLocationTrendsReportItem
+		dwell  time.Duration
+		starts []time.Time
+	}
+	cells := map[string]*visits{}
+	order := []string{}
+	for i, s := range h.locations {
+		end := to
+		if i+1 < len(h.locations) {
+			end = earlier(end, h.locations[i+1].at)
+		}
+		begin := later(s.at, from)
+		if s.gone || !end.After(begin) {
+			continue
+		}
+		v, ok := cells[s.ncgi.NrCellId]
+		if !ok {
+			tai, ncgi := s.tai, s.ncgi
+			v = &visits{item: models.UeLocationTrendsReportItem{Tai: &tai, Ncgi: &ncgi}}
+			cells[s.ncgi.NrCellId] = v
+			order = append(order, s.ncgi.NrCellId)
+		}
+		v.dwell += end.Sub(begin)
+		v.starts = append(v.starts, begin)
+	}
+	trends := make([]models.UeLocationTrendsReportItem, 0, len(cells))
+	counts := make([]int, 0, len(cells))
+	for _, cell := range order {
+		v := cells[cell]
+		v.item.Duration = int32(v.dwell.Seconds())
+		v.item.Timestamp = v.starts[len(v.starts)-1]
+		if n := len(v.starts); n > 1 {
+			v.item.Spacing = int32(v.starts[n-1].Sub(v.starts[0]).Seconds()) / int32(n-1)
+		}
+		trends = append(trends, v.item)
+		counts = append(counts, len(v.starts))
+	}
+	return trends, counts
+}
+
+// ueMobility returns the share of time the target UEs spent in each cell
+func (nwdaf *Nwdaf) ueMobility(q *analyticsQuery, now time.Time) []models.UeMobility {
+	from, to := nwdaf.basis(q.window, now)
+	dwell := map[string]int32{}
+	visits := map[string]int{}
+	locations := map[string]models.NrLocation{}
+	order := []string{}
+	var total int32
+	for _, supi := range nwdaf.targets(q) {
+		trends, counts := nwdaf.ues[supi].locationTrends(from, to)
+		for i, trend := range trends {
+			cell := trend.Ncgi.NrCellId
+			if _, ok := locations[cell]; !ok {
+				locations[cell] = models.NrLocation{Tai: *trend.Tai, Ncgi: *trend.Ncgi}
+				order = append(order, cell)
+			}
+			dwell[cell] += trend.Duration
+			visits[cell] += counts[i]
+			total += trend.Duration
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	sort.SliceStable(order, func(i, j int) bool { return dwell[order[i]] > dwell[order[j]] })
+	if q.maxObjects > 0 && len(order) > q.maxObjects {
+		order = order[:q.maxObjects]
+	}
+	mobility := models.UeMobility{
+		Ts:       models.PtrTime(q.window.start),
+		Duration: models.PtrInt32(int32(q.window.end.Sub(q.window.start).Seconds())),
+	}
+	for _, cell := range order {
+		location := locations[cell]
+		info := models.LocationInfo{
+			Loc:   models.UserLocation{NrLocation: &location},
+			Ratio: models.PtrInt32(percent(float64(dwell[cell]) * 100 / float64(total))),
+		}
+		if q.window.prediction {
+			info.Confidence = confidence(visits[cell])
+		}
+		mobility.LocInfos = append(mobility.LocInfos, info)
+	}
+	return []models.UeMobility{mobility}
+}
+
+// ueCommunications returns the PDU sessions of the target UEs by DNN and slice: their mean duration,
+// volumes and period, and the start of the first session or, for the predictions, of the next one
+func (nwdaf *Nwdaf) ueCommunications(q *analyticsQuery, now time.Time) []models.UeCommunication {
+	from, to := nwdaf.basis(q.window, now)
+	type traffic struct {
+		dnn       string
+		snssai    models.Snssai
+		sessions  int
+		duration  time.Duration
+		ulVol     int64
+		dlVol     int64
+		first     time.Time
+		last      time.Time
+		intervals []float64
+	}
+	groups := map[string]*traffic{}
+	order := []string{}
+	total := 0
+	for _, supi := range nwdaf.targets(q) {
+		lastStart := map[string]time.Time{}
+		for _, s := range nwdaf.ues[supi].sessions {
+			end := s.end
+			if end.IsZero() {
+				end = now
+			}
+			if !s.start.Before(to) || !end.After(from) ||
+				(len(q.dnns) > 0 && !slices.Contains(q.dnns, s.dnn)) ||
+				(len(q.snssais) > 0 && !slices.ContainsFunc(q.snssais, func(slice models.Snssai) bool { return sameSnssai(slice, s.snssai) })) {
+				continue
+			}
+			key := s.dnn + "/" + sliceKey(s.snssai)
+			g, ok := groups[key]
+			if !ok {
+				g = &traffic{dnn: s.dnn, snssai: s.snssai, first: s.start}
+				groups[key] = g
+				order = append(order, key)
+			}
+			g.sessions++
+			g.duration += earlier(end, to).Sub(later(s.start, from))
+			g.ulVol += s.ulVol
+			g.dlVol += s.dlVol
+			g.first, g.last = earlier(g.first, s.start), later(g.last, s.start)
+			if previous, ok := lastStart[key]; ok {
+				g.intervals = append(g.intervals, s.start.Sub(previous).Seconds())
+			}
+			lastStart[key] = s.start
+			total++
+		}
+	}
+
+	comms := []models.UeCommunication{}
+	for _, key := range order {
+		g := groups[key]
+		dnn, snssai := g.dnn, g.snssai
+		comm := models.UeCommunication{
+			CommDur: int32(g.duration.Seconds()) / int32(g.sessions),
+			Ts:      models.PtrTime(later(g.first, from)),
+			TrafChar: models.TrafficCharacterization{
+				Dnn:    &dnn,
+				Snssai: &snssai,
+				UlVol:  models.PtrInt64(g.ulVol / int64(g.sessions)),
+				DlVol:  models.PtrInt64(g.dlVol / int64(g.sessions)),
+			},
+			Ratio: models.PtrInt32(percent(float64(g.sessions) * 100 / float64(total))),
+		}
+		// the sessions are periodic when their intervals deviate by less than a quarter of their mean
+		if n := len(g.intervals); n > 0 {
+			mean, deviation := meanDeviation(g.intervals)
+			comm.PerioTime = models.PtrInt32(int32(math.Round(mean)))
+			comm.PerioCommInd = models.PtrBool(n > 1 && deviation <= mean/4)
+		}
+		if q.window.prediction {
+			// the next session follows the last one after the mean period
+			next := q.window.start
+			if comm.PerioTime != nil && *comm.PerioTime > 0 {
+				period := time.Duration(*comm.PerioTime) * time.Second
+				for next = g.last.Add(period); next.Before(q.window.start); next = next.Add(period) {
+				}
+			}
+			comm.Ts = models.PtrTime(next)
+			comm.Confidence = confidence(g.sessions)
+		}
+		comms = append(comms, comm)
+	}
+	if q.maxObjects > 0 && len(comms) > q.maxObjects {
+		comms = comms[:q.maxObjects]
+	}
+	return comms
+}
+
+func meanDeviation(values []float64) (float64, float64) {
+	var sum, squares float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)))
+}
+
+// loadLevels returns the average and the peak load of the samples between from and to, predicted
+// for the window from the linear trend of the samples, with the number of samples
+func loadLevels(samples []loadSample, w analyticsWindow, from time.Time, to time.Time) (float64, float64, int) {
+	// the points of the step function, starting with the level in force at from
+	points := []loadSample{{at: from}}
+	for _, s := range samples {
+		if !s.at.After(from) {
+			points[0].level = s.level
+		} else if s.at.Before(to) {
+			points = append(points, s)
+		}
+	}
+	if !w.prediction {
+		var area float64
+		peak := 0.0
+		for i, p := range points {
+			end := to
+			if i+1 < len(points) {
+				end = points[i+1].at
+			}
+			area += p.level * end.Sub(p.at).Seconds()
+			peak = max(peak, p.level)
+		}
+		return area / to.Sub(from).Seconds(), peak, len(points)
+	}
+
+	// least squares over the points and the level in force at the end of the history
+	points = append(points, loadSample{at: to, level: points[len(points)-1].level})
+	var sx, sy, sxx, sxy float64
+	for _, p := range points {
+		x := p.at.Sub(to).Seconds()
+		sx, sy, sxx, sxy = sx+x, sy+p.level, sxx+x*x, sxy+x*p.level
+	}
+	n := float64(len(points))
+	slope := 0.0
+	if d := n*sxx - sx*sx; d != 0 {
+		slope = (n*sxy - sx*sy) / d
+	}
+	intercept := (sy - slope*sx) / n
+	level := func(t time.Time) float64 {
+		return min(max(intercept+slope*t.Sub(to).Seconds(), 0), 100)
+	}
+	start, end := level(w.start), level(w.end)
+	return (start + end) / 2, max(start, end), len(points) - 1
+}
+
+// nfLoads returns the load of the AMF, in registered UEs, and of the SMF, in PDU sessions
+func (nwdaf *Nwdaf) nfLoads(q *analyticsQuery, now time.Time) []models.NfLoadLevelInformation {
+	from, to := nwdaf.basis(q.window, now)
+	infos := []models.NfLoadLevelInformation{}
+	for _, nf := range []struct{ nfType, instanceId string }{{"AMF", nwdaf.AmfInstanceId}, {"SMF", nwdaf.SmfInstanceId}} {
+		if (len(q.nfTypes) > 0 && !slices.Contains(q.nfTypes, nf.nfType)) ||
+			(len(q.nfInstanceIds) > 0 && !slices.Contains(q.nfInstanceIds, nf.instanceId)) {
+			continue
+		}
+		average, peak, samples := loadLevels(nwdaf.loads[nf.instanceId], q.window, from, to)
+		info := models.NfLoadLevelInformation{
+			NfType:             nf.nfType,
+			NfInstanceId:       nf.instanceId,
+			NfStatus:           &models.NfStatus{StatusRegistered: models.PtrInt32(100)},
+			NfLoadLevelAverage: models.PtrInt32(percent(average)),
+			NfLoadLevelpeak:    models.PtrInt32(percent(peak)),
+		}
+		if q.window.prediction {
+			info.Confidence = confidence(samples)
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// sliceLoads returns the load of the slices, in PDU sessions
+func (nwdaf *Nwdaf) sliceLoads(q *analyticsQuery, now time.Time) []models.SliceLoadLevelInformation {
+	from, to := nwdaf.basis(q.window, now)
+	keys := []string{}
+	if q.anySlice {
+		for key := range nwdaf.slices {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+	}
+	targets := map[string]models.Snssai{}
+	for _, key := range keys {
+		targets[key] = nwdaf.slices[key]
+	}
+	for _, snssai := range q.snssais {
+		// a slice without PDU session is not loaded
+		if key := sliceKey(snssai); !slices.Contains(keys, key) {
+			keys = append(keys, key)
+			targets[key] = snssai
+		}
+	}
+	infos := []models.SliceLoadLevelInformation{}
+	for _, key := range keys {
+		average, _, samples := loadLevels(nwdaf.loads[key], q.window, from, to)
+		info := models.SliceLoadLevelInformation{
+			LoadLevelInformation: percent(average),
+			Snssais:              []models.Snssai{targets[key]},
+		}
+		if q.window.prediction {
+			info.Confidence = confidence(samples)
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// currentLoad returns the load level in force of an NF instance or of a slice
+func (nwdaf *Nwdaf) currentLoad(id string) float64 {
+	nwdaf.mutex.Lock()
+	defer nwdaf.mutex.Unlock()
+	if samples := nwdaf.loads[id]; len(samples) > 0 {
+		return samples[len(samples)-1].level
+	}
+	return 0
+}
+
+// abnormalBehaviours returns the target UEs whose occurrences of an exception reach its threshold in the window,
+// the predictions expect the same UEs to repeat the exception
+func (nwdaf *Nwdaf) abnormalBehaviours(q *analyticsQuery, now time.Time) []models.AbnormalBehaviour {
+	from, to := nwdaf.basis(q.window, now)
+	excepIds := q.excepIds
+	if len(excepIds) == 0 {
+		excepIds = supportedExceptions
+	}
+	targets := nwdaf.targets(q)
+	behaviours := []models.AbnormalBehaviour{}
+	for _, excepId := range excepIds {
+		behaviour := models.AbnormalBehaviour{Excep: models.Exception{ExcepId: excepId}}
+		occurrences, firstHalf, secondHalf := 0, 0, 0
+		middle := from.Add(to.Sub(from) / 2)
+		for _, supi := range targets {
+			count := 0
+			for _, at := range nwdaf.ues[supi].exceptions[excepId] {
+				if at.Before(from) || !at.Before(to) {
+					continue
+				}
+				count++
+				if at.Before(middle) {
+					firstHalf++
+				} else {
+					secondHalf++
+				}
+			}
+			if count >= nwdaf.thresholds[excepId] {
+				behaviour.Supis = append(behaviour.Supis, "imsi-"+supi)
+				occurrences += count
+			}
+		}
+		if len(behaviour.Supis) == 0 {
+			continue
+		}
+		trend := models.ExceptionTrendStable
+		switch {
+		case float64(secondHalf) > 1.2*float64(firstHalf):
+			trend = models.ExceptionTrendUp
+		case float64(secondHalf) < 0.8*float64(firstHalf):
+			trend = models.ExceptionTrendDown
+		}
+		behaviour.Excep.ExcepLevel = models.PtrInt32(int32(occurrences))
+		behaviour.Excep.ExcepTrend = &trend
+		behaviour.Ratio = models.PtrInt32(percent(float64(len(behaviour.Supis)) * 100 / float64(len(targets))))
+		if q.window.prediction {
+			behaviour.Confidence = confidence(occurrences)
+		}
+		if q.maxObjects > 0 && len(behaviour.Supis) > q.maxObjects {
+			behaviour.Supis = behaviour.Supis[:q.maxObjects]
+		}
+		behaviours = append(behaviours, behaviour)
+	}
+	return behaviours
+}
+
+// resolveUes returns the SUPIs of the target UEs, nil for any UE
+func (nwdaf *Nwdaf) resolveUes(tgtUe *models.TargetUeInformation) []string {
+	if tgtUe == nil || tgtUe.GetAnyUe() {
+		return nil
+	}
+	nwdaf.mutex.Lock()
+	defer nwdaf.mutex.Unlock()
+	supis := []string{}
+	for _, supi := range tgtUe.Supis {
+		supis = append(supis, strings.TrimPrefix(supi, "imsi-"))
+	}
+	for _, gpsi := range tgtUe.Gpsis {
+		if supi, ok := nwdaf.gpsis[msisdnOf(gpsi)]; ok {
+			supis = append(supis, supi)
+		}
+	}
+	return supis
+}
+
+// notifications splits analytics into event notifications, one per slice for the slice load
+func notifications(event string, data *models.AnalyticsData) []models.NnwdafEventNotification {
+	base := models.NnwdafEventNotification{Event: event, Start: data.Start, Expiry: data.Expiry, TimeStampGen: data.TimeStampGen}
+	if event == models.NwdafEventSliceLoadLevel {
+		notifs := []models.NnwdafEventNotification{}
+		for i := range data.SliceLoadLevelInfos {
+			notif := base
+			notif.SliceLoadLevelInfo = &data.SliceLoadLevelInfos[i]
+			notifs = append(notifs, notif)
+		}
+		return notifs
+	}
+	base.UeMobs, base.UeComms, base.NfLoadLevelInfos, base.AbnorBehavrs = data.UeMobs, data.UeComms, data.NfLoadLevelInfos, data.AbnorBehavrs
+	return []models.NnwdafEventNotification{base}
+}
+
+// analytics returns the notifications of the analytics of a subscription
+func (nwdaf *Nwdaf) analytics(s *nwdafSubscription, now time.Time) []models.NnwdafEventNotification {
+	notifs := []models.NnwdafEventNotification{}
+	for _, q := range s.queries {
+		if data := nwdaf.compute(q, now); !empty(data) {
+			notifs = append(notifs, notifications(q.event, data)...)
+		}
+	}
+	return notifs
+}
+
+// detect returns the notifications of the events detected since the last check of a subscription:
+// the load thresholds crossed upwards and the new occurrences of the exceptions
+func (nwdaf *Nwdaf) detect(s *nwdafSubscription, now time.Time) []models.NnwdafEventNotification {
+	notifs := []models.NnwdafEventNotification{}
+	for i, q := range s.queries {
+		es := &s.sub.EventSubscriptions[i]
+		data := nwdaf.compute(q, now)
+		switch q.event {
+		case models.NwdafEventNfLoad:
+			crossed := []models.NfLoadLevelInformation{}
+			for _, info := range data.NfLoadLevelInfos {
+				level := nwdaf.currentLoad(info.NfInstanceId)
+				notify := false
+				for _, threshold := range es.NfLoadLvlThds {
+					key := fmt.Sprintf("%s/%d", info.NfInstanceId, *threshold.NfLoadLevel)
+					above := level >= float64(*threshold.NfLoadLevel)
+					notify = notify || (above && !s.above[key])
+					s.above[key] = above
+				}
+				if notify {
+					crossed = append(crossed, info)
+				}
+			}
+			data.NfLoadLevelInfos = crossed
+		case models.NwdafEventSliceLoadLevel:
+			crossed := []models.SliceLoadLevelInformation{}
+			for _, info := range data.SliceLoadLevelInfos {
+				key := sliceKey(info.Snssais[0])
+				above := nwdaf.currentLoad(key) >= float64(*es.LoadLevelThreshold)
+				if above && !s.above[key] {
+					crossed = append(crossed, info)
+				}
+				s.above[key] = above
+			}
+			data.SliceLoadLevelInfos = crossed
+		case models.NwdafEventAbnormalBehaviour:
+			detected := []models.AbnormalBehaviour{}
+			for _, behaviour := range data.AbnorBehavrs {
+				level := *behaviour.Excep.ExcepLevel
+				if level > s.reported[behaviour.Excep.ExcepId] {
+					detected = append(detected, behaviour)
+				}
+				s.reported[behaviour.Excep.ExcepId] = level
+			}
+			data.AbnorBehavrs = detected
+		}
+		if !empty(data) {
+			notifs = append(notifs, notifications(q.event, data)...)
+		}
+	}
+	return notifs
+}
+
+// report sends the analytics due to the subscribers and removes the subscriptions that are over
+func (nwdaf *Nwdaf) report(now time.Time) {
+	nwdaf.subMutex.Lock()
+	subs := make(map[string]*nwdafSubscription, len(nwdaf.subscriptions))
+	for subId, s := range nwdaf.subscriptions {
+		subs[subId] = s
+	}
+	nwdaf.subMutex.Unlock()
+
+	for subId, s := range subs {
+		var reports [][]models.NnwdafEventNotification
+		switch s.state.method {
+		case reportPeriodic:
+			// the analytics of the period are computed when it ends
+			if !now.Before(s.nextReport) {
+				for !s.nextReport.After(now) {
+					s.nextReport = s.nextReport.Add(s.state.period)
+				}
+				if notifs := nwdaf.analytics(s, now); len(notifs) > 0 {
+					s.state.add(notifs, now)
+				}
+			}
+			reports = s.state.flush(now)
+		case reportOneTime:
+			if notifs := nwdaf.analytics(s, now); len(notifs) > 0 {
+				reports = s.state.add(notifs, now)
+			}
+		default:
+			if notifs := nwdaf.detect(s, now); len(notifs) > 0 {
+				reports = s.state.add(notifs, now)
+			}
+		}
+		for _, notifs := range reports {
+			nwdaf.notify(subId, s, notifs)
+		}
+		if s.state.over(now) {
+			nwdaf.removeSubscription(subId, "the subscription is over")
+		}
+	}
+}
+
+// runReports computes the analytics of the subscriptions every second
+func (nwdaf *Nwdaf) runReports(stop <-chan struct{}) {
+	ticker := time.NewTicker(reportCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		advanced := nwdaf.Clock.Advanced()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		case <-advanced:
+		}
+		nwdaf.report(nwdaf.Clock.Now())
+	}
+}
+
+// notify sends analytics to the subscriber
+func (nwdaf *Nwdaf) notify(subId string, s *nwdafSubscription, notifs []models.NnwdafEventNotification) {
+	body, err := json.Marshal(&models.NnwdafEventsSubscriptionNotification{
+		EventNotifications: notifs,
+		SubscriptionId:     subId,
+		NotifCorrId:        s.sub.NotifCorrId,
+	})
+	if err != nil {
+		log.Printf("[%s] error while marshalling notification: %s", nwdaf.NwdafId, err.Error())
+		return
+	}
+	nwdaf.notifier.Notify(&Notification{
+		Source:         nwdaf.NwdafId,
+		SubscriptionId: subId,
+		Uri:            s.sub.GetNotificationURI(),
+		Body:           body,
+		OnFailure: func() {
+			nwdaf.removeSubscription(subId, "the subscriber is not reachable")
+		},
+	})
+}
+
+func (nwdaf *Nwdaf) removeSubscription(subId string, reason string) {
+	nwdaf.subMutex.Lock()
+	defer nwdaf.subMutex.Unlock()
+	if _, ok := nwdaf.subscriptions[subId]; ok {
+		delete(nwdaf.subscriptions, subId)
+		log.Printf("[%s] removed subscription %s, %s", nwdaf.NwdafId, subId, reason)
+	}
+}
+
+// GetSubscriptions returns a copy of the analytics subscriptions indexed by subscription id
+func (nwdaf *Nwdaf) GetSubscriptions() map[string]models.NnwdafEventsSubscription {
+	nwdaf.subMutex.Lock()
+	defer nwdaf.subMutex.Unlock()
+
+	subs := make(map[string]models.NnwdafEventsSubscription, len(nwdaf.subscriptions))
+	for subId, s := range nwdaf.subscriptions {
+		subs[subId] = *s.sub
+	}
+	return subs
+}
+
+// NORTHBOUND Definitions
+
+func (nwdaf *Nwdaf) reply(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("[%s] could not serialize response body: %s", nwdaf.NwdafId, err.Error())
+	}
+}
+
+// HandleGetAnalytics answers the analytics of an event, TS 29.520 clause 5.2.3.2
+func (nwdaf *Nwdaf) HandleGetAnalytics(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	invalid := func(param string, reason string) {
+		WriteProblem(w, http.StatusBadRequest, CauseInvalidQueryParam, param+" is invalid", InvalidParam(param, reason))
+	}
+	missing := func(param string) {
+		WriteProblem(w, http.StatusBadRequest, CauseMandatoryQueryParamMissing, param+" is missing", InvalidParam(param, "missing"))
+	}
+
+	q := &analyticsQuery{event: values.Get("event-id")}
+	if q.event == "" {
+		missing("event-id")
+		return
+	}
+	if !slices.Contains(supportedNwdafEvents, q.event) {
+		invalid("event-id", "unsupported event "+q.event)
+		return
+	}
+	filter := &models.EventFilter{}
+	for param, dst := range map[string]any{"ana-req": &q.requirement, "event-filter": &filter, "tgt-ue": &q.tgtUe} {
+		if raw := values.Get(param); raw != "" {
+			if err := json.Unmarshal([]byte(raw), dst); err != nil {
+				invalid(param, err.Error())
+				return
+			}
+		}
+	}
+	if filter != nil {
+		q.anySlice = filter.AnySlice != nil && *filter.AnySlice
+		q.snssais, q.dnns, q.excepIds = filter.Snssais, filter.Dnns, filter.ExcepIds
+		q.nfInstanceIds, q.nfTypes = filter.NfInstanceIds, filter.NfTypes
+	}
+	for _, excepId := range q.excepIds {
+		if !slices.Contains(supportedExceptions, excepId) {
+			invalid("event-filter", "unsupported exception "+excepId)
+			return
+		}
+	}
+	switch {
+	case (q.event == models.NwdafEventUeMobility || q.event == models.NwdafEventUeCommunication) && q.tgtUe == nil:
+		missing("tgt-ue")
+		return
+	case q.event == models.NwdafEventSliceLoadLevel && !q.anySlice && len(q.snssais) == 0:
+		missing("event-filter")
+		return
+	}
+	now := nwdaf.Clock.Now()
+	if _, err := nwdaf.windowOf(q.requirement, now); err != nil {
+		invalid("ana-req", err.Error())
+		return
+	}
+
+	data := nwdaf.compute(q, now)
+	if empty(data) {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	nwdaf.reply(w, http.StatusOK, data)
+}
+
+// validateSubscription checks an analytics subscription and builds the queries of its events, TS 29.520 clause 5.1.6.2.2
+func (nwdaf *Nwdaf) validateSubscription(w http.ResponseWriter, sub *models.NnwdafEventsSubscription, method reportMethod, now time.Time) ([]*analyticsQuery, bool) {
+	v := newValidator(nwdaf.PlmnId)
+	if v.mandatory("/notificationURI", sub.NotificationURI != nil) {
+		v.uri(true, "/notificationURI", *sub.NotificationURI)
+	}
+	v.mandatory("/eventSubscriptions", len(sub.EventSubscriptions) > 0)
+	if sub.SupportedFeatures != nil {
+		v.features(false, "/supportedFeatures", *sub.SupportedFeatures)
+	}
+	queries := []*analyticsQuery{}
+	for i, es := range sub.EventSubscriptions {
+		base := fmt.Sprintf("/eventSubscriptions/%d", i)
+		if !slices.Contains(supportedNwdafEvents, es.Event) {
+			v.incorrect(true, base+"/event", "unsupported event "+es.Event)
+			continue
+		}
+		onEvent := method == reportOnEvent
+		switch es.Event {
+		case models.NwdafEventUeMobility, models.NwdafEventUeCommunication:
+			v.mandatory(base+"/tgtUe", es.TgtUe != nil)
+			if onEvent {
+				v.incorrect(false, "/evtReq/notifMethod", es.Event+" is reported periodically or once")
+			}
+		case models.NwdafEventNfLoad:
+			if onEvent {
+				v.mandatory(base+"/nfLoadLvlThds", len(es.NfLoadLvlThds) > 0)
+			}
+			for j, threshold := range es.NfLoadLvlThds {
+				if threshold.NfLoadLevel == nil || *threshold.NfLoadLevel < 0 || *threshold.NfLoadLevel > 100 {
+					v.incorrect(false, fmt.Sprintf("%s/nfLoadLvlThds/%d/nfLoadLevel", base, j), "not in 0..100")
+				}
+			}
+		case models.NwdafEventSliceLoadLevel:
+			v.mandatory(base+"/snssais", len(es.Snssais) > 0 || (es.AnySlice != nil && *es.AnySlice))
+			if onEvent && v.mandatory(base+"/loadLevelThreshold", es.LoadLevelThreshold != nil) &&
+				(*es.LoadLevelThreshold < 0 || *es.LoadLevelThreshold > 100) {
+				v.incorrect(false, base+"/loadLevelThreshold", "not in 0..100")
+			}
+		}
+		for j, snssai := range es.Snssais {
+			v.snssai(false, fmt.Sprintf("%s/snssais/%d", base, j), snssai.Sst, snssai.Sd)
+		}
+		excepIds := []string{}
+		for j, excep := range es.ExcepRequs {
+			if !slices.Contains(supportedExceptions, excep.ExcepId) {
+				v.incorrect(false, fmt.Sprintf("%s/excepRequs/%d/excepId", base, j), "unsupported exception "+excep.ExcepId)
+			}
+			excepIds = append(excepIds, excep.ExcepId)
+		}
+		if tgtUe := es.TgtUe; tgtUe != nil {
+			for j, supi := range tgtUe.Supis {
+				v.supi(fmt.Sprintf("%s/tgtUe/supis/%d", base, j), supi)
+			}
+			for j, gpsi := range tgtUe.Gpsis {
+				v.gpsi(fmt.Sprintf("%s/tgtUe/gpsis/%d", base, j), gpsi)
+			}
+		}
+		if _, err := nwdaf.windowOf(es.ExtraReportReq, now); err != nil {
+			v.incorrect(false, base+"/extraReportReq", err.Error())
+		}
+		queries = append(queries, &analyticsQuery{
+			event:         es.Event,
+			requirement:   es.ExtraReportReq,
+			tgtUe:         es.TgtUe,
+			anySlice:      es.AnySlice != nil && *es.AnySlice,
+			snssais:       es.Snssais,
+			dnns:          es.Dnns,
+			nfInstanceIds: es.NfInstanceIds,
+			nfTypes:       es.NfTypes,
+			excepIds:      excepIds,
+		})
+	}
+	return queries, !v.reply(w, WriteProblem)
+}
+
+// HandleNewSubscription subscribes to analytics, TS 29.520 clause 5.1.3.2
+func (nwdaf *Nwdaf) HandleNewSubscription(w http.ResponseWriter, r *http.Request) {
+	sub := &models.NnwdafEventsSubscription{}
+	if !decodeRequest(w, r, sub, WriteProblem) {
+		return
+	}
+	now := nwdaf.Clock.Now()
+	evtReq := sub.EvtReq
+	if evtReq == nil {
+		evtReq = &models.ReportingInformation{}
+	}
+	method := reportOnEvent
+	if evtReq.NotifMethod != nil {
+		method = reportMethod(*evtReq.NotifMethod)
+	}
+	queries, ok := nwdaf.validateSubscription(w, sub, method, now)
+	if !ok {
+		return
+	}
+	state, err := newReportState[[]models.NnwdafEventNotification](method, evtReq.RepPeriod, evtReq.MaxReportNbr, evtReq.MonDur, now)
+	if err != nil {
+		reportModeProblem(w, err, map[string]string{
+			"method":     "/evtReq/notifMethod",
+			"repPeriod":  "/evtReq/repPeriod",
+			"maxReports": "/evtReq/maxReportNbr",
+		})
+		return
+	}
+
+	subId := uuid.NewString()
+	s := &nwdafSubscription{
+		sub:        sub,
+		queries:    queries,
+		state:      state,
+		nextReport: now.Add(state.period),
+		above:      make(map[string]bool),
+		reported:   make(map[string]int32),
+	}
+	// the immediate report is answered with the subscription
+	sub.EventNotifications = nil
+	if evtReq.ImmRep != nil && *evtReq.ImmRep {
+		if method == reportOnEvent {
+			sub.EventNotifications = nwdaf.detect(s, now)
+		} else {
+			sub.EventNotifications = nwdaf.analytics(s, now)
+		}
+		if method == reportOneTime && len(sub.EventNotifications) > 0 {
+			state.add(sub.EventNotifications, now)
+		}
+	}
+	response := *sub
+	sub.EventNotifications = nil
+
+	nwdaf.subMutex.Lock()
+	nwdaf.subscriptions[subId] = s
+	nwdaf.subMutex.Unlock()
+
+	w.Header().Set("Location", "/nnwdaf-eventssubscription/v1/subscriptions/"+subId)
+	nwdaf.reply(w, http.StatusCreated, &response)
+	log.Printf("[%s] created new subscription %s for: %s", nwdaf.NwdafId, subId, sub.GetNotificationURI())
+}
+
+// HandleDeleteSubscription unsubscribes from analytics, TS 29.520 clause 5.1.3.3
+func (nwdaf *Nwdaf) HandleDeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	subId := mux.Vars(r)["subscriptionId"]
+
+	nwdaf.subMutex.Lock()
+	_, ok := nwdaf.subscriptions[subId]
+	nwdaf.subMutex.Unlock()
+	if !ok {
+		WriteProblem(w, http.StatusNotFound, CauseSubscriptionNotFound, "subscription "+subId+" is not found")
+		return
+	}
+	nwdaf.removeSubscription(subId, "deleted by the subscriber")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (nwdaf *Nwdaf) RegisterNorthboundAPIs(r *mux.Router) {
+	r.HandleFunc("/nnwdaf-analyticsinfo/v1/analytics", nwdaf.HandleGetAnalytics).Methods(http.MethodGet)
+	r.HandleFunc("/nnwdaf-eventssubscription/v1/subscriptions", nwdaf.HandleNewSubscription).Methods(http.MethodPost)
+	r.HandleFunc("/nnwdaf-eventssubscription/v1/subscriptions/{subscriptionId}", nwdaf.HandleDeleteSubscription).Methods(http.MethodDelete)
+	log.Printf("[%s] nnwdaf-analyticsinfo and nnwdaf-eventssubscription have been registered", nwdaf.NwdafId)
+}
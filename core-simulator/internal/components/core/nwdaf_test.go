@@ -0,0 +1,233 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* NWDAF statistics and predictions learnt from the AMF and SMF events */
+
+// moveUe reports the test UE in a cell at a time, registering it with the first report
+func (n *conformanceNetwork) moveUe(eventType models.AmfEventTypeAnyOf, cell string, at time.Time) {
+	msg := models.UeToAmfMsg{
+		EventType:     eventType,
+		TimeStamp:     at,
+		Supi:          testSupi,
+		Gpsi:          "msisdn-" + testMsisdn,
+		PlmnId:        testPlmn,
+		CurrentCellId: cell,
+		AccessType:    models.ACCESSTYPE__3_GPP_ACCESS,
+		RmState:       models.RmStateRegistered,
+		CmState:       models.CmStateConnected,
+	}
+	n.amf.handleUeToAmfEvent(&msg)
+}
+
+// pduSession establishes a PDU session of the test UE at start, reports its usage and releases it after duration
+func (n *conformanceNetwork) pduSession(id int32, start time.Time, duration time.Duration, ulBytes int64, dlBytes int64) {
+	session := models.UeToSmfMsg{
+		EventType:   models.SMFEVENTANYOF_PDU_SES_EST,
+		TimeStamp:   start,
+		Supi:        testSupi,
+		PlmnId:      testPlmn,
+		AccessType:  models.ACCESSTYPE__3_GPP_ACCESS,
+		Dnn:         "internet",
+		Snssai:      models.Snssai{Sst: 1},
+		UeAddress:   "10.0.0.1",
+		PduSessType: models.PDUSESSIONTYPEANYOF_IPV4,
+		PduSessId:   id,
+	}
+	n.smf.handleUeToSmfEvent(&session)
+	if duration == 0 {
+		return
+	}
+	session.EventType, session.TimeStamp = models.SMFEVENTANYOF_QOS_MON, start.Add(duration)
+	session.UpReport = &models.UpStatsReport{UpStats: models.UpStats{PduSessId: id, TotalUlBytes: ulBytes, TotalDlBytes: dlBytes}}
+	n.smf.handleUeToSmfEvent(&session)
+	session.EventType = models.SMFEVENTANYOF_PDU_SES_REL
+	n.smf.handleUeToSmfEvent(&session)
+}
+
+func TestNwdafUeMobility(t *testing.T) {
+	n := newConformanceNetwork(t)
+	start := time.Now().Add(-40 * time.Minute)
+	// 30 minutes in the first cell and 10 minutes in the second one, ping-ponging between them
+	n.moveUe(models.AMFEVENTTYPEANYOF_REGISTRATION_STATE_REPORT, "000000001", start)
+	for i, cell := range []string{"000000002", "000000001", "000000002", "000000001"} {
+		n.moveUe(models.AMFEVENTTYPEANYOF_LOCATION_REPORT, cell, start.Add(time.Duration(10+5*i)*time.Minute))
+	}
+
+	tgtUe := "&tgt-ue=" + url.QueryEscape(`{"supis":["imsi-`+testSupi+`"]}`)
+	n.call(t, http.MethodGet, "/nnwdaf-analyticsinfo/v1/analytics?event-id=UE_MOBILITY"+tgtUe, ``, http.StatusOK, models.AnalyticsData{})
+	data := models.AnalyticsData{}
+	n.get(t, "/nnwdaf-analyticsinfo/v1/analytics?event-id=UE_MOBILITY"+tgtUe, &data)
+	if len(data.UeMobs) != 1 || len(data.UeMobs[0].LocInfos) != 2 {
+		t.Fatalf("got UE mobility %+v", data.UeMobs)
+	}
+	for i, want := range []struct {
+		cell  string
+		ratio int32
+	}{{"000000001", 75}, {"000000002", 25}} {
+		info := data.UeMobs[0].LocInfos[i]
+		if info.Loc.NrLocation.Ncgi.NrCellId != want.cell || *info.Ratio != want.ratio || info.Confidence != nil {
+			t.Errorf("got location %d %+v, want cell %s at %d%%", i, info, want.cell, want.ratio)
+		}
+	}
+
+	// the prediction of the next hour is as confident as the cell was visited in the last one
+	data = models.AnalyticsData{}
+	n.get(t, "/nnwdaf-analyticsinfo/v1/analytics?event-id=UE_MOBILITY"+tgtUe+"&ana-req="+url.QueryEscape(`{"offsetPeriod":3600}`), &data)
+	if len(data.UeMobs) != 1 || !data.UeMobs[0].Ts.After(start.Add(40*time.Minute)) || *data.UeMobs[0].LocInfos[0].Confidence != 30 {
+		t.Errorf("got predicted UE mobility %+v", data.UeMobs)
+	}
+
+	// the returns to the previous cell are ping-pongs
+	data = models.AnalyticsData{}
+	n.get(t, "/nnwdaf-analyticsinfo/v1/analytics?event-id=ABNORMAL_BEHAVIOUR&event-filter="+
+		url.QueryEscape(`{"excepIds":["PING_PONG_ACROSS_CELLS"]}`), &data)
+	if len(data.AbnorBehavrs) != 1 || data.AbnorBehavrs[0].Supis[0] != "imsi-"+testSupi || *data.AbnorBehavrs[0].Excep.ExcepLevel != 3 {
+		t.Errorf("got abnormal behaviours %+v", data.AbnorBehavrs)
+	}
+
+	// the UE is found by its GPSI, an unknown UE has no analytics
+	n.call(t, http.MethodGet, "/nnwdaf-analyticsinfo/v1/analytics?event-id=UE_MOBILITY&tgt-ue="+
+		url.QueryEscape(`{"gpsis":["msisdn-`+testMsisdn+`"]}`), ``, http.StatusOK, models.AnalyticsData{})
+	n.call(t, http.MethodGet, "/nnwdaf-analyticsinfo/v1/analytics?event-id=UE_MOBILITY&tgt-ue="+
+		url.QueryEscape(`{"supis":["imsi-001060000000002"]}`), ``, http.StatusNoContent, nil)
+
+	n.call(t, http.MethodGet, "/nnwdaf-analyticsinfo/v1/analytics", ``, http.StatusBadRequest, models.ProblemDetails{})
+	n.call(t, http.MethodGet, "/nnwdaf-analyticsinfo/v1/analytics?event-id=QOS_SUSTAINABILITY", ``, http.StatusBadRequest, models.ProblemDetails{})
+	n.call(t, http.MethodGet, "/nnwdaf-analyticsinfo/v1/analytics?event-id=UE_MOBILITY", ``, http.StatusBadRequest, models.ProblemDetails{})
+	n.call(t, http.MethodGet, "/nnwdaf-analyticsinfo/v1/analytics?event-id=UE_MOBILITY"+tgtUe+"&ana-req="+url.QueryEscape(`{"offsetPeriod":0}`),
+		``, http.StatusBadRequest, models.ProblemDetails{})
+}
+
+func TestNwdafUeCommunication(t *testing.T) {
+	n := newConformanceNetwork(t)
+	start := time.Now().Add(-30 * time.Minute)
+	// a session of 2 minutes every 10 minutes
+	for i := range 3 {
+		n.pduSession(int32(i+1), start.Add(time.Duration(10*i)*time.Minute), 2*time.Minute, 1000, 4000)
+	}
+
+	tgtUe := "&tgt-ue=" + url.QueryEscape(`{"supis":["imsi-`+testSupi+`"]}`)
+	n.call(t, http.MethodGet, "/nnwdaf-analyticsinfo/v1/analytics?event-id=UE_COMM"+tgtUe, ``, http.StatusOK, models.AnalyticsData{})
+	data := models.AnalyticsData{}
+	n.get(t, "/nnwdaf-analyticsinfo/v1/analytics?event-id=UE_COMM"+tgtUe, &data)
+	if len(data.UeComms) != 1 {
+		t.Fatalf("got UE communications %+v", data.UeComms)
+	}
+	comm := data.UeComms[0]
+	if comm.CommDur != 120 || *comm.PerioTime != 600 || !*comm.PerioCommInd || *comm.TrafChar.UlVol != 1000 || *comm.TrafChar.DlVol != 4000 ||
+		*comm.TrafChar.Dnn != "internet" || *comm.Ratio != 100 {
+		t.Errorf("got UE communication %+v", comm)
+	}
+
+	// the next session is predicted one period after the last one, in the window of the prediction
+	data = models.AnalyticsData{}
+	n.get(t, "/nnwdaf-analyticsinfo/v1/analytics?event-id=UE_COMM"+tgtUe+"&ana-req="+url.QueryEscape(`{"offsetPeriod":3600}`), &data)
+	if len(data.UeComms) != 1 || !data.UeComms[0].Ts.Equal(start.Add(40*time.Minute)) || *data.UeComms[0].Confidence != 30 {
+		t.Errorf("got predicted UE communications %+v", data.UeComms)
+	}
+
+	// the sessions of the other DNNs are filtered out
+	n.call(t, http.MethodGet, "/nnwdaf-analyticsinfo/v1/analytics?event-id=UE_COMM"+tgtUe+"&event-filter="+url.QueryEscape(`{"dnns":["ims"]}`),
+		``, http.StatusNoContent, nil)
+}
+
+func TestNwdafLoadSubscription(t *testing.T) {
+	n := newConformanceNetwork(t)
+	n.nwdaf.smfCapacity, n.nwdaf.sliceCapacity = 2, 2
+	n.call(t, http.MethodPost, "/nnwdaf-eventssubscription/v1/subscriptions", `{"notificationURI":"`+n.sinkUrl+`/nwdaf","notifCorrId":"1",
+		"eventSubscriptions":[{"event":"NF_LOAD","nfTypes":["SMF"],"nfLoadLvlThds":[{"nfLoadLevel":50}]},
+		{"event":"SLICE_LOAD_LEVEL","snssais":[{"sst":1}],"loadLevelThreshold":100}]}`,
+		http.StatusCreated, models.NnwdafEventsSubscription{})
+
+	// a session loads the SMF at 50%, two load the slice at 100%
+	n.pduSession(1, time.Now().Add(-2*time.Minute), 0, 0, 0)
+	n.nwdaf.report(time.Now())
+	n.pduSession(2, time.Now().Add(-time.Minute), 0, 0, 0)
+	n.nwdaf.report(time.Now())
+	n.notifications(t, "/nwdaf", 2, models.NnwdafEventsSubscriptionNotification{})
+	if subs := n.nwdaf.GetSubscriptions(); len(subs) != 1 {
+		t.Errorf("got subscriptions %+v", subs)
+	}
+
+	// the statistics of the load in the last minute
+	data := models.AnalyticsData{}
+	n.get(t, "/nnwdaf-analyticsinfo/v1/analytics?event-id=SLICE_LOAD_LEVEL&event-filter="+url.QueryEscape(`{"anySlice":true}`)+
+		"&ana-req="+url.QueryEscape(`{"offsetPeriod":-60}`), &data)
+	if len(data.SliceLoadLevelInfos) != 1 || data.SliceLoadLevelInfos[0].LoadLevelInformation < 99 {
+		t.Errorf("got slice loads %+v", data.SliceLoadLevelInfos)
+	}
+	data = models.AnalyticsData{}
+	n.get(t, "/nnwdaf-analyticsinfo/v1/analytics?event-id=NF_LOAD&event-filter="+url.QueryEscape(`{"nfTypes":["SMF"]}`), &data)
+	if len(data.NfLoadLevelInfos) != 1 || *data.NfLoadLevelInfos[0].NfLoadLevelpeak != 100 || data.NfLoadLevelInfos[0].NfInstanceId != n.smf.InstanceId {
+		t.Errorf("got NF loads %+v", data.NfLoadLevelInfos)
+	}
+}
+
+func TestNwdafPeriodicSubscription(t *testing.T) {
+	n := newConformanceNetwork(t)
+	n.moveUe(models.AMFEVENTTYPEANYOF_REGISTRATION_STATE_REPORT, "000000001", time.Now().Add(-time.Minute))
+	resp := n.call(t, http.MethodPost, "/nnwdaf-eventssubscription/v1/subscriptions", `{"notificationURI":"`+n.sinkUrl+`/nwdaf",
+		"eventSubscriptions":[{"event":"UE_MOBILITY","tgtUe":{"anyUe":true}},{"event":"NF_LOAD"}],
+		"evtReq":{"immRep":true,"notifMethod":"PERIODIC","repPeriod":10,"maxReportNbr":1}}`,
+		http.StatusCreated, models.NnwdafEventsSubscription{})
+
+	// the analytics of the period are notified once it is over
+	n.nwdaf.report(time.Now())
+	if len(n.sink.bodies("/nwdaf")) != 0 {
+		t.Errorf("the analytics are notified before the end of the period")
+	}
+	n.nwdaf.report(time.Now().Add(10 * time.Second))
+	n.notifications(t, "/nwdaf", 1, models.NnwdafEventsSubscriptionNotification{})
+	if subs := n.nwdaf.GetSubscriptions(); len(subs) != 0 {
+		t.Errorf("the subscription is left after its last report")
+	}
+	n.call(t, http.MethodDelete, resp.Header.Get("Location"), ``, http.StatusNotFound, models.ProblemDetails{})
+}
+
+func TestConformanceNnwdafEventsSubscription(t *testing.T) {
+	n := newConformanceNetwork(t)
+	resp := n.call(t, http.MethodPost, "/nnwdaf-eventssubscription/v1/subscriptions", `{"notificationURI":"`+n.sinkUrl+`/nwdaf",
+		"eventSubscriptions":[{"event":"ABNORMAL_BEHAVIOUR","excepRequs":[{"excepId":"TOO_FREQUENT_SERVICE_ACCESS"}]}]}`,
+		http.StatusCreated, models.NnwdafEventsSubscription{})
+	n.call(t, http.MethodDelete, resp.Header.Get("Location"), ``, http.StatusNoContent, nil)
+	n.call(t, http.MethodDelete, resp.Header.Get("Location"), ``, http.StatusNotFound, models.ProblemDetails{})
+
+	for _, body := range []string{
+		`{"eventSubscriptions":[{"event":"NF_LOAD"}]}`,
+		`{"notificationURI":"` + n.sinkUrl + `/nwdaf","eventSubscriptions":[{"event":"QOS_SUSTAINABILITY"}]}`,
+		`{"notificationURI":"` + n.sinkUrl + `/nwdaf","eventSubscriptions":[{"event":"UE_MOBILITY"}]}`,
+		`{"notificationURI":"` + n.sinkUrl + `/nwdaf","eventSubscriptions":[{"event":"UE_MOBILITY","tgtUe":{"anyUe":true}}]}`,
+		`{"notificationURI":"` + n.sinkUrl + `/nwdaf","eventSubscriptions":[{"event":"NF_LOAD"}]}`,
+		`{"notificationURI":"` + n.sinkUrl + `/nwdaf","eventSubscriptions":[{"event":"SLICE_LOAD_LEVEL","anySlice":true,"loadLevelThreshold":120}]}`,
+		`{"notificationURI":"` + n.sinkUrl + `/nwdaf","eventSubscriptions":[{"event":"NF_LOAD"}],"evtReq":{"notifMethod":"PERIODIC"}}`,
+	} {
+		n.call(t, http.MethodPost, "/nnwdaf-eventssubscription/v1/subscriptions", body, http.StatusBadRequest, models.ProblemDetails{})
+	}
+}
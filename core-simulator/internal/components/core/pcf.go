@@ -24,36 +24,64 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/giuliocarot0/gitc"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
 	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
 	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
 )
 
 type Pcf struct {
-	PlmnId        models.PlmnId
-	PcfId         string
+	PlmnId models.PlmnId
+	PcfId  string
+	// NF instance id of the PCF
+	InstanceId    string
 	Subscriptions map[string]*models.AppSessionContext
 	SubMutex      sync.RWMutex
 	ipamInstance  *utils.IPAllocator
+	// policy decisions enforced on the UEs, reverted when the app session is deleted
+	decisions map[string]*models.PcfToUeMsg
+	// serializes the routing decisions with their moves, it is taken before SubMutex
+	routingMutex sync.Mutex
+	// CHF charging the sponsored data connectivity, none when nil
+	Chf      *Chf
+	notifier *Notifier
+	// clock of the simulation, the wall clock when nil
+	Clock *utils.Clock
+	// dispatcher of the tasks of the simulation, shared with the UEs
+	Dispatcher *gitc.Dispatcher
+	// SMF anchoring the PDU sessions on its edge sites, the routing decisions are not notified when nil
+	Smf *Smf
+	// last location of the UEs reported by the AMF
+	locations map[string]models.NrLocation // supi -> location
+	stop      chan struct{}
 }
 
-func NewPcf(plmnId models.PlmnId, ipamInstance *utils.IPAllocator) *Pcf {
+func NewPcf(plmnId models.PlmnId, ipamInstance *utils.IPAllocator, notifier *Notifier) *Pcf {
 	return &Pcf{
 		PlmnId:        plmnId,
 		PcfId:         fmt.Sprintf("PCF-%s%s", plmnId.Mcc, plmnId.Mnc),
+		InstanceId:    uuid.NewString(),
 		Subscriptions: make(map[string]*models.AppSessionContext),
 		SubMutex:      sync.RWMutex{},
 		ipamInstance:  ipamInstance,
+		decisions:     make(map[string]*models.PcfToUeMsg),
+		notifier:      notifier,
+		locations:     make(map[string]models.NrLocation),
+		Dispatcher:    gitc.GlobalDispatcher,
 	}
 }
 
 func (pcf *Pcf) InitPcf() {
 	log.Printf("[%s] started", pcf.PcfId)
-	err := gitc.StartTask("PCF", func(msg gitc.Message) {
+	err := pcf.Dispatcher.StartTask("PCF", func(msg gitc.Message) {
 		switch msg.Type {
 		default:
 		}
@@ -61,6 +89,21 @@ func (pcf *Pcf) InitPcf() {
 	if err != nil {
 		log.Fatalf("[%s] could not start PCF task: %s", pcf.PcfId, err.Error())
 	}
+	pcf.stop = make(chan struct{})
+	go pcf.runRouting(pcf.stop)
+}
+
+// StopPcf terminates the PCF task
+func (pcf *Pcf) StopPcf() {
+	if pcf.stop != nil {
+		close(pcf.stop)
+		pcf.stop = nil
+	}
+	if err := pcf.Dispatcher.StopTask("PCF"); err != nil {
+		log.Printf("[%s] could not stop PCF task: %s", pcf.PcfId, err.Error())
+		return
+	}
+	log.Printf("[%s] stopped", pcf.PcfId)
 }
 
 // NORTHBOUND Definitions
@@ -71,20 +114,23 @@ func (pcf *Pcf) HandleNewSubscription(w http.ResponseWriter, r *http.Request) {
 
 		subData := &models.AppSessionContext{}
 
-		if err := json.NewDecoder(r.Body).Decode(subData); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
+		if !decodeRequest(w, r, subData, writeExtendedProblem) {
 			return
 		}
 
-		rData, ok := subData.GetAscReqDataOk()
-		if !ok || rData == nil {
-			http.Error(w, "Missing ascReqData", http.StatusBadRequest)
+		v := newValidator(pcf.PlmnId)
+		v.validateAppSessionContext(subData)
+		pcf.validateRouteToLocs(v, subData)
+		if v.reply(w, writeExtendedProblem) {
 			return
 		}
+		rData := subData.AscReqData.Get()
 
+		// the simulated PDU sessions only have IPv4 addresses
 		ueAddr, ok := rData.GetUeIpv4Ok()
 		if !ok || ueAddr == nil {
-			http.Error(w, "Missing Ue Ipv4 Address", http.StatusBadRequest)
+			writeExtendedProblem(w, http.StatusBadRequest, CauseMandatoryIeMissing, "missing UE IPv4 address",
+				InvalidParam("/ascReqData/ueIpv4", "missing"))
 			return
 		}
 
@@ -92,26 +138,71 @@ func (pcf *Pcf) HandleNewSubscription(w http.ResponseWriter, r *http.Request) {
 		supi, pduSessId, ok := pcf.ipamInstance.GetUserStringOk(*ueAddr)
 
 		if !ok {
-			http.Error(w, "requested UE is not connected to the network", http.StatusNotFound)
+			// no PDU session is bound to the address, TS 29.514 table 5.7.3-1
+			writeExtendedProblem(w, http.StatusInternalServerError, CausePduSessionNotAvailable,
+				"no PDU session of a connected UE has the address "+*ueAddr)
 			return
 		}
 		// inform the network about the new policy decision
 		log.Printf("received new policy decision for UE %s, pduSessId %d", supi, pduSessId)
 
+		decision := &models.PcfToUeMsg{
+			Supi:      supi,
+			PduSessId: pduSessId,
+			AfAppId:   rData.GetAfAppId(),
+		}
+
 		// now verify if it is a qos session or a routing decision
-		if _, ok := rData.GetMedComponentsOk(); ok {
+		if medComponents, ok := rData.GetMedComponentsOk(); ok {
 			// this is a qos session
-			// this normally results in creating a new qos flow for the target pdu session
+			// this results in creating a new qos flow for the target pdu session
+			decision.Command = models.PcfQosUpdate
+			decision.Qos = qosFromMediaComponents(*medComponents)
 		} else if _, ok := rData.GetAfRoutReqOk(); ok {
 			// this is a routing decision
-			// this normally results in reconfiguring the UP path for the target PDU session
+			// this results in reconfiguring the UP path for the target PDU session,
+			// the session stays on the central DNAI until the requirement applies
+			decision.Command = models.PcfRoutingUpdate
 		} else {
 			// this is not supported yet
-			http.Error(w, "unsupported policy request", http.StatusBadRequest)
+			writeExtendedProblem(w, http.StatusBadRequest, CauseMandatoryIeMissing, "unsupported policy request",
+				InvalidParam("/ascReqData/medComponents", "either medComponents or afRoutReq is required"),
+				InvalidParam("/ascReqData/afRoutReq", "either medComponents or afRoutReq is required"))
 			return
 		}
 
 		subId := uuid.New().String()
+		// the sponsor is charged for the PDU session, TS 29.514 clause 4.2.2.6
+		sponStatus := rData.GetSponStatus()
+		if sponsorId := rData.GetSponId(); sponsorId != "" && pcf.Chf != nil &&
+			(sponStatus.String == nil || *sponStatus.String != "SPONSOR_DISABLED") {
+			err := pcf.Chf.Sponsor(supi, pduSessId, subId, sponsorId, func(info models.OutOfCreditInformation) {
+				pcf.notifyOutOfCredit(subId, info)
+			})
+			if err != nil {
+				writeExtendedProblem(w, http.StatusForbidden, CauseUnauthorizedSponsoredDataConnectivity, err.Error())
+				return
+			}
+		}
+		decision.AppSessionId = subId
+
+		// the app session is stored before the reply, so that the AF can update or delete it right away
+		var move *upPathMove
+		pcf.routingMutex.Lock()
+		defer pcf.routingMutex.Unlock()
+		pcf.SubMutex.Lock()
+		pcf.Subscriptions[subId] = subData
+		pcf.decisions[subId] = decision
+		if decision.Command == models.PcfRoutingUpdate {
+			if pcf.Smf != nil {
+				pcf.Smf.installRouting(subId, supi, pduSessId, rData)
+			}
+			move = pcf.reroute(subId)
+		} else {
+			pcf.enforce(decision)
+		}
+		pcf.SubMutex.Unlock()
+
 		location := "/npcf-policyauthorization/v1/app-sessions/" + subId
 		w.Header().Add("Location", location)
 		w.Header().Add("Content-Type", "application/json")
@@ -119,27 +210,24 @@ func (pcf *Pcf) HandleNewSubscription(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusCreated)
 
 		if err := json.NewEncoder(w).Encode(subData); err != nil {
-			http.Error(w, "could not serialize response body", http.StatusInternalServerError)
-			return
+			log.Printf("[%s] could not serialize response body: %s", pcf.PcfId, err.Error())
 		}
 
-		pcf.SubMutex.Lock()
-		defer pcf.SubMutex.Unlock()
-		pcf.Subscriptions[subId] = subData
+		pcf.apply(move)
 
 		log.Printf("[%s] created new subscription", pcf.PcfId)
 	} else {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeExtendedProblem(w, http.StatusMethodNotAllowed, "", r.Method+" is not allowed on "+r.URL.Path)
 	}
 }
 
 func (pcf *Pcf) HandleUpdateSubscription(w http.ResponseWriter, r *http.Request) {
-	http.Error(w, "policy update is not supported yet", http.StatusNotImplemented)
+	writeExtendedProblem(w, http.StatusNotImplemented, "", "policy update is not supported yet")
 }
 
 func (pcf *Pcf) HandleDeleteSubscription(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed on this endpoint", http.StatusMethodNotAllowed)
+		writeExtendedProblem(w, http.StatusMethodNotAllowed, "", r.Method+" is not allowed on "+r.URL.Path)
 		return
 	}
 
@@ -147,22 +235,289 @@ func (pcf *Pcf) HandleDeleteSubscription(w http.ResponseWriter, r *http.Request)
 	appSessId := vars["appSessId"]
 
 	if len(appSessId) == 0 {
-		http.Error(w, "the request url is malformed", http.StatusBadRequest)
+		writeExtendedProblem(w, http.StatusBadRequest, CauseInvalidMsgFormat, "the request url is malformed")
+		return
 	}
 
+	pcf.routingMutex.Lock()
+	defer pcf.routingMutex.Unlock()
 	pcf.SubMutex.Lock()
-	defer pcf.SubMutex.Unlock()
+	if pcf.Subscriptions[appSessId] == nil {
+		pcf.SubMutex.Unlock()
+		writeExtendedProblem(w, http.StatusNotFound, CauseContextNotFound, "app-session context "+appSessId+" is not found")
+		return
+	}
+	// do operations by notifying the network about the policy change
+	delete(pcf.Subscriptions, appSessId)
+	var revert *upPathMove
+	if decision, ok := pcf.decisions[appSessId]; ok {
+		revert = &upPathMove{appSessId: appSessId, source: decision.Dnai, decision: *decision}
+		revert.decision.Remove = true
+		delete(pcf.decisions, appSessId)
+	}
+	pcf.SubMutex.Unlock()
 
-	if sub := pcf.Subscriptions[appSessId]; sub != nil {
-		// do operations by notifying the network about the policy change
-		delete(pcf.Subscriptions, appSessId)
-		log.Printf("[%s] deleted subscription ", pcf.PcfId)
+	if pcf.Chf != nil {
+		pcf.Chf.Unsponsor(appSessId)
+	}
+	if revert != nil {
+		if revert.decision.Command == models.PcfRoutingUpdate && pcf.Smf != nil {
+			// the session moving back to the central DNAI is notified before the requirement is dropped
+			pending := revert.source != ""
+			if pending {
+				pcf.Smf.earlyUpPathChange(appSessId, revert.source, "")
+			}
+			pcf.Smf.removeRouting(appSessId, pending)
+		}
+		pcf.enforce(&revert.decision)
+	}
+	log.Printf("[%s] deleted subscription ", pcf.PcfId)
+	w.WriteHeader(http.StatusNoContent)
+}
 
-	} else {
-		http.Error(w, "app-session context is not found", http.StatusNotFound)
+// notifyOutOfCredit reports to the AF the exhausted credit of the sponsor of an application
+// session, when it subscribed to the OUT_OF_CREDIT event, TS 29.514 clause 4.2.5.4
+func (pcf *Pcf) notifyOutOfCredit(appSessId string, info models.OutOfCreditInformation) {
+	pcf.SubMutex.RLock()
+	sub, ok := pcf.Subscriptions[appSessId]
+	pcf.SubMutex.RUnlock()
+	if !ok || pcf.notifier == nil {
+		return
+	}
+	rData := sub.AscReqData.Get()
+	evSubsc, ok := rData.GetEvSubscOk()
+	if !ok || !slices.ContainsFunc(evSubsc.Events, func(event models.AfEventSubscription) bool {
+		return event.Event.String != nil && *event.Event.String == "OUT_OF_CREDIT"
+	}) {
+		return
+	}
+	uri := rData.NotifUri
+	if evSubsc.NotifUri != nil {
+		uri = *evSubsc.NotifUri
+	}
+	notification := &models.EventsNotification{
+		EvSubsUri:        "/npcf-policyauthorization/v1/app-sessions/" + appSessId + "/events-subscription",
+		EvNotifs:         []models.AfEventNotification{{Event: models.AfEvent{String: models.PtrString("OUT_OF_CREDIT")}}},
+		OutOfCredReports: []models.OutOfCreditInformation{info},
+	}
+	body, err := json.Marshal(notification)
+	if err != nil {
+		log.Printf("[%s] error while marshalling notification: %s", pcf.PcfId, err.Error())
+		return
+	}
+	pcf.notifier.Notify(&Notification{
+		Source:         pcf.PcfId,
+		SubscriptionId: appSessId,
+		Uri:            uri,
+		Body:           body,
+	})
+}
+
+// validateRouteToLocs checks that the DNAIs of a routing requirement are the ones of the edge
+// sites, when the SMF declares any
+func (pcf *Pcf) validateRouteToLocs(v *validator, ctx *models.AppSessionContext) {
+	req := ctx.AscReqData.Get()
+	if req == nil || req.AfRoutReq == nil || pcf.Smf == nil || len(pcf.Smf.EdgeSites) == 0 {
 		return
 	}
+	for i, route := range req.AfRoutReq.RouteToLocs {
+		if dnai := route.GetDnai(); dnai != "" && pcf.Smf.EdgeSites.site(dnai) == nil {
+			v.incorrect(true, fmt.Sprintf("/ascReqData/afRoutReq/routeToLocs/%d/dnai", i), "no edge site has this dnai")
+		}
+	}
+}
+
+// ObserveAmfEvent records the location of the UEs and moves their PDU sessions when the spatial
+// validity of a routing requirement or the coverage of an edge site changes
+func (pcf *Pcf) ObserveAmfEvent(report models.AmfEventReport) {
+	supi := strings.TrimPrefix(report.GetSupi(), "imsi-")
+	if supi == "" || report.Location == nil || report.Location.NrLocation == nil || report.Location.NrLocation.Ncgi.NrCellId == "" {
+		return
+	}
+
+	pcf.routingMutex.Lock()
+	defer pcf.routingMutex.Unlock()
+	moves := []*upPathMove{}
+	pcf.SubMutex.Lock()
+	pcf.locations[supi] = *report.Location.NrLocation
+	for appSessId, decision := range pcf.decisions {
+		if strings.TrimPrefix(decision.Supi, "imsi-") == supi {
+			moves = append(moves, pcf.reroute(appSessId))
+		}
+	}
+	pcf.SubMutex.Unlock()
+	pcf.apply(moves...)
+}
+
+// runRouting moves the PDU sessions when the temporal validity of a routing requirement starts or ends
+func (pcf *Pcf) runRouting(stop <-chan struct{}) {
+	ticker := time.NewTicker(reportCheckInterval)
+	defer ticker.Stop()
 
+	for {
+		advanced := pcf.Clock.Advanced()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		case <-advanced:
+		}
+
+		pcf.rerouteAll()
+	}
+}
+
+// upPathMove is a move of a PDU session between two DNAIs, decided under SubMutex and
+// carried out once it is released: the SMF takes its own locks to notify the move
+type upPathMove struct {
+	appSessId string
+	source    string
+	decision  models.PcfToUeMsg
+}
+
+// rerouteAll moves the PDU sessions of all the routing decisions
+func (pcf *Pcf) rerouteAll() {
+	pcf.routingMutex.Lock()
+	defer pcf.routingMutex.Unlock()
+	moves := []*upPathMove{}
+	pcf.SubMutex.Lock()
+	for appSessId := range pcf.decisions {
+		moves = append(moves, pcf.reroute(appSessId))
+	}
+	pcf.SubMutex.Unlock()
+	pcf.apply(moves...)
+}
+
+// reroute selects the DNAI of a routing decision following the requirement of the AF, it
+// returns the move of the PDU session, nil when the session stays where it is.
+// The caller holds SubMutex.
+func (pcf *Pcf) reroute(appSessId string) *upPathMove {
+	decision, sub := pcf.decisions[appSessId], pcf.Subscriptions[appSessId]
+	if decision == nil || sub == nil || decision.Command != models.PcfRoutingUpdate {
+		return nil
+	}
+	if _, ok := pcf.ipamInstance.GetIP(decision.Supi, decision.PduSessId); !ok {
+		return nil
+	}
+	target := pcf.selectDnai(sub.AscReqData.Get().AfRoutReq, decision.Supi)
+	if target == decision.Dnai {
+		return nil
+	}
+	log.Printf("[%s] moving PDU session %d of UE %s from dnai %q to %q", pcf.PcfId, decision.PduSessId, decision.Supi, decision.Dnai, target)
+	move := &upPathMove{appSessId: appSessId, source: decision.Dnai}
+	decision.Dnai = target
+	move.decision = *decision
+	return move
+}
+
+// apply carries out the moves of the PDU sessions, the SMF notifies every move before the UE
+// reconfigures the user plane and reports it. The caller holds routingMutex but not SubMutex.
+func (pcf *Pcf) apply(moves ...*upPathMove) {
+	for _, move := range moves {
+		if move == nil {
+			continue
+		}
+		if pcf.Smf != nil {
+			pcf.Smf.earlyUpPathChange(move.appSessId, move.source, move.decision.Dnai)
+		}
+		pcf.enforce(&move.decision)
+	}
+}
+
+// selectDnai returns the DNAI where a routing requirement sends the traffic of a UE, the empty
+// central DNAI out of its temporal and spatial validity. The first DNAI whose edge site covers
+// the cell of the UE is selected, the first DNAI when the SMF declares no edge site.
+func (pcf *Pcf) selectDnai(req *models.AfRoutingRequirement, supi string) string {
+	now := pcf.Clock.Now()
+	if len(req.TempVals) > 0 && !slices.ContainsFunc(req.TempVals, func(validity models.TemporalValidity) bool {
+		return (validity.StartTime == nil || !now.Before(*validity.StartTime)) &&
+			(validity.StopTime == nil || now.Before(*validity.StopTime))
+	}) {
+		return ""
+	}
+	var location *models.NrLocation
+	if last, ok := pcf.locations[strings.TrimPrefix(supi, "imsi-")]; ok {
+		location = &last
+	}
+	if req.SpVal != nil && len(req.SpVal.PresenceInfoList) > 0 && !inPresenceArea(req.SpVal, location) {
+		return ""
+	}
+	for _, route := range req.RouteToLocs {
+		dnai := route.GetDnai()
+		if dnai == "" {
+			continue
+		}
+		if pcf.Smf == nil || len(pcf.Smf.EdgeSites) == 0 {
+			return dnai
+		}
+		if site := pcf.Smf.EdgeSites.site(dnai); site != nil && site.serves(location) {
+			return dnai
+		}
+	}
+	return ""
+}
+
+// inPresenceArea returns whether a UE is in one of the tracking areas or NR cells of a spatial validity
+func inPresenceArea(spVal *models.SpatialValidity, location *models.NrLocation) bool {
+	if location == nil {
+		return false
+	}
+	for _, info := range spVal.PresenceInfoList {
+		if slices.ContainsFunc(info.TrackingAreaList, func(tai models.Tai) bool {
+			return tai.PlmnId == location.Tai.PlmnId && tai.Tac == location.Tai.Tac
+		}) || slices.ContainsFunc(info.NcgiList, func(ncgi models.Ncgi) bool {
+			return ncgi.PlmnId == location.Ncgi.PlmnId && strings.EqualFold(ncgi.NrCellId, location.Ncgi.NrCellId)
+		}) {
+			return true
+		}
+	}
+	return false
+}
+
+// enforce sends a policy decision to the target UE
+func (pcf *Pcf) enforce(decision *models.PcfToUeMsg) {
+	decision.TimeStamp = pcf.Clock.Now()
+	if err := pcf.Dispatcher.Send("PCF", ran.RanTask, models.PcfToUeType, decision); err != nil {
+		log.Printf("[%s] could not enforce policy on UE %s: %s", pcf.PcfId, decision.Supi, err.Error())
+	}
+}
+
+// qosFromMediaComponents derives the QoS of the flow from the first media component
+func qosFromMediaComponents(components map[string]models.MediaComponent) *models.SessionQos {
+	keys := make([]string, 0, len(components))
+	for key := range components {
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	sort.Strings(keys)
+	component := components[keys[0]]
+
+	qos := &models.SessionQos{
+		Var5qi:       9,
+		MaxBitrateUl: component.GetMarBwUl(),
+		MaxBitrateDl: component.GetMarBwDl(),
+	}
+	switch component.GetMedType() {
+	case "AUDIO":
+		qos.Var5qi = 1
+	case "VIDEO":
+		qos.Var5qi = 2
+	}
+	return qos
+}
+
+// GetAppSessions returns a copy of the active application sessions indexed by app session id
+func (pcf *Pcf) GetAppSessions() map[string]models.AppSessionContext {
+	pcf.SubMutex.RLock()
+	defer pcf.SubMutex.RUnlock()
+
+	sessions := make(map[string]models.AppSessionContext, len(pcf.Subscriptions))
+	for appSessId, session := range pcf.Subscriptions {
+		sessions[appSessId] = *session
+	}
+	return sessions
 }
 
 func (pcf *Pcf) RegisterNorthboundAPIs(r *mux.Router) {
@@ -0,0 +1,275 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* PCF routing of the AF traffic to the edge sites of the SMF */
+
+var testEdgeSite = EdgeSite{
+	Dnai:       "mec-1",
+	UpfId:      "UPF-MEC-1",
+	UpfAddr:    "192.168.70.1",
+	N6Ipv4Addr: "192.168.80.10",
+	N6Port:     8080,
+	Cells:      []string{"000000002"},
+}
+
+// testAfAppId is the application of the AF in the routing requirements
+const testAfAppId = "edge-video"
+
+// createRouting creates an app session of the test UE with a routing requirement to the edge site
+func (n *conformanceNetwork) createRouting(t *testing.T, ueAddr string, validity string) string {
+	t.Helper()
+	resp := n.call(t, http.MethodPost, "/npcf-policyauthorization/v1/app-sessions", `{"ascReqData":{"notifUri":"`+n.sinkUrl+`/pcf",
+		"suppFeat":"0","ueIpv4":"`+ueAddr+`","afAppId":"`+testAfAppId+`","afRoutReq":{"routeToLocs":[{"dnai":"mec-1","routeProfId":"default"}],`+validity+`
+		"upPathChgSub":{"notificationUri":"`+n.sinkUrl+`/up-path","notifCorreId":"c1","dnaiChgType":"EARLY_LATE"},
+		"easIpReplaceInfos":[{"source":{"ip":{"ipv4Addr":"203.0.113.10"},"port":443},"target":{"ip":{"ipv4Addr":"192.168.80.20"},"port":443}}]}}}`,
+		http.StatusCreated, models.AppSessionContext{})
+	return resp.Header.Get("Location")
+}
+
+// upPathChange returns the event of the index-th UP path change notification sent to the AF
+func (n *conformanceNetwork) upPathChange(t *testing.T, index int) models.EventNotification {
+	t.Helper()
+	n.notifications(t, "/up-path", index+1, models.NsmfEventExposureNotification{})
+	notification := models.NsmfEventExposureNotification{}
+	n.decodeNotification(t, "/up-path", index, &notification)
+	if notification.NotifId != "c1" || len(notification.EventNotifs) != 1 {
+		t.Fatalf("got notification %+v", notification)
+	}
+	return notification.EventNotifs[0]
+}
+
+func dnaiChange(event models.EventNotification) string {
+	if event.DnaiChgType == nil || event.DnaiChgType.DnaiChangeTypeAnyOf == nil {
+		return ""
+	}
+	return string(*event.DnaiChgType.DnaiChangeTypeAnyOf)
+}
+
+func easTarget(event models.EventNotification) string {
+	if len(event.EasIpReplaceInfos) != 1 || event.EasIpReplaceInfos[0].Target.Ip.Interface == nil {
+		return ""
+	}
+	ip, _ := (*event.EasIpReplaceInfos[0].Target.Ip.Interface).(map[string]interface{})
+	addr, _ := ip["ipv4Addr"].(string)
+	return addr
+}
+
+// storedAppSessionWriter records whether the app session of the Location header is stored when the reply is sent
+type storedAppSessionWriter struct {
+	*httptest.ResponseRecorder
+	pcf    *Pcf
+	stored bool
+}
+
+func (w *storedAppSessionWriter) WriteHeader(status int) {
+	location := w.Header().Get("Location")
+	appSessId := location[strings.LastIndex(location, "/")+1:]
+	w.pcf.SubMutex.RLock()
+	_, subscribed := w.pcf.Subscriptions[appSessId]
+	_, decided := w.pcf.decisions[appSessId]
+	w.pcf.SubMutex.RUnlock()
+	w.stored = subscribed && decided
+	w.ResponseRecorder.WriteHeader(status)
+}
+
+func TestPcfStoresAppSessionBeforeReply(t *testing.T) {
+	n := newConformanceNetwork(t)
+	ueAddr, err := n.ipam.AllocateIP(testSupi, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := `{"ascReqData":{"notifUri":"` + n.sinkUrl + `/pcf","suppFeat":"0","ueIpv4":"` + ueAddr + `",
+		"medComponents":{"1":{"medCompN":1,"medType":"VIDEO","marBwDl":"10 Mbps"}}}}`
+	w := &storedAppSessionWriter{ResponseRecorder: httptest.NewRecorder(), pcf: n.pcf}
+	n.pcf.HandleNewSubscription(w, httptest.NewRequest(http.MethodPost, "/npcf-policyauthorization/v1/app-sessions", strings.NewReader(body)))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("got status %d, want %d: %s", w.Code, http.StatusCreated, w.Body)
+	}
+	if !w.stored {
+		t.Fatal("the app session was not stored when the reply was sent")
+	}
+}
+
+func TestPcfEdgeRoutingInValidArea(t *testing.T) {
+	n := newConformanceNetwork(t)
+	n.smf.EdgeSites = EdgeSites{testEdgeSite}
+	ueAddr, err := n.ipam.AllocateIP(testSupi, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.moveUe(models.AMFEVENTTYPEANYOF_REGISTRATION_STATE_REPORT, "000000001", time.Now())
+
+	appSession := n.createRouting(t, ueAddr, `"spVal":{"presenceInfoList":{"pra1":{"praId":"pra1",
+		"ncgiList":[{"plmnId":{"mcc":"`+testPlmn.Mcc+`","mnc":"`+testPlmn.Mnc+`"},"nrCellId":"000000002"}]}}},`)
+	appSessId := appSession[strings.LastIndex(appSession, "/")+1:]
+	if dnai := n.pcf.decisions[appSessId].Dnai; dnai != "" {
+		t.Fatalf("the PDU session out of the valid area is routed to %q", dnai)
+	}
+
+	// entering the valid area moves the session to the local UPF of the edge site
+	n.moveUe(models.AMFEVENTTYPEANYOF_LOCATION_REPORT, "000000002", time.Now())
+	early := n.upPathChange(t, 0)
+	if dnaiChange(early) != "EARLY" || early.SourceDnai != nil || early.GetTargetDnai() != "mec-1" || early.GetAppId() != testAfAppId {
+		t.Errorf("got early notification %+v of the move to the edge", early)
+	}
+	if early.UpfInfo.GetUpfId() != "UPF-MEC-1" || early.TargetTraRouting.Get().GetRouteInfo().GetIpv4Addr() != "192.168.80.10" ||
+		early.GetSourceUeIpv4Addr() != ueAddr || easTarget(early) != "192.168.80.20" {
+		t.Errorf("got early notification %+v, want the edge UPF, N6 routing and EAS", early)
+	}
+
+	// the UE reports the reconfigured user plane
+	n.smf.handleUeToSmfEvent(&models.UeToSmfMsg{
+		EventType:    models.SMFEVENTANYOF_UP_PATH_CH,
+		TimeStamp:    time.Now(),
+		Supi:         testSupi,
+		PlmnId:       testPlmn,
+		AccessType:   models.ACCESSTYPE__3_GPP_ACCESS,
+		Dnn:          "internet",
+		Snssai:       models.Snssai{Sst: 1},
+		UeAddress:    ueAddr,
+		PduSessId:    1,
+		TargetDnai:   "mec-1",
+		AppSessionId: appSessId,
+		AppId:        testAfAppId,
+	})
+	late := n.upPathChange(t, 1)
+	if dnaiChange(late) != "LATE" || late.GetTargetDnai() != "mec-1" || late.GetAppId() != testAfAppId || late.UpfInfo.GetUpfId() != "UPF-MEC-1" || easTarget(late) != "192.168.80.20" {
+		t.Errorf("got late notification %+v of the move to the edge", late)
+	}
+
+	// leaving the valid area moves the session back to the central UPF and EAS
+	n.moveUe(models.AMFEVENTTYPEANYOF_LOCATION_REPORT, "000000001", time.Now())
+	back := n.upPathChange(t, 2)
+	if dnaiChange(back) != "EARLY" || back.GetSourceDnai() != "mec-1" || back.TargetDnai != nil ||
+		back.UpfInfo.GetUpfId() != n.smf.UpfId || easTarget(back) != "203.0.113.10" {
+		t.Errorf("got early notification %+v of the move back", back)
+	}
+
+	// the deletion of the app session moves the session back before the requirement is dropped
+	n.moveUe(models.AMFEVENTTYPEANYOF_LOCATION_REPORT, "000000002", time.Now())
+	n.upPathChange(t, 3)
+	n.call(t, http.MethodPost, appSession+"/delete", ``, http.StatusNoContent, nil)
+	if back := n.upPathChange(t, 4); dnaiChange(back) != "EARLY" || back.GetSourceDnai() != "mec-1" || back.TargetDnai != nil {
+		t.Errorf("got early notification %+v of the move back of the deleted app session", back)
+	}
+	if n.smf.routing(appSessId) == nil {
+		t.Errorf("the requirement of the deleted app session is dropped before the late notification")
+	}
+
+	n.call(t, http.MethodPost, "/npcf-policyauthorization/v1/app-sessions", `{"ascReqData":{"notifUri":"`+n.sinkUrl+`/pcf",
+		"suppFeat":"0","ueIpv4":"`+ueAddr+`","afRoutReq":{"routeToLocs":[{"dnai":"mec-9","routeProfId":"default"}]}}}`,
+		http.StatusBadRequest, models.ExtendedProblemDetails{})
+	n.call(t, http.MethodPost, "/npcf-policyauthorization/v1/app-sessions", `{"ascReqData":{"notifUri":"`+n.sinkUrl+`/pcf",
+		"suppFeat":"0","ueIpv4":"`+ueAddr+`","afRoutReq":{"routeToLocs":[{"dnai":"mec-1","routeProfId":"default"}],
+		"upPathChgSub":{"notificationUri":"`+n.sinkUrl+`/up-path","notifCorreId":"c2","dnaiChgType":"SOMETIMES"}}}}`,
+		http.StatusBadRequest, models.ExtendedProblemDetails{})
+}
+
+func TestPcfEdgeRoutingLockOrder(t *testing.T) {
+	n := newConformanceNetwork(t)
+	n.smf.EdgeSites = EdgeSites{testEdgeSite}
+	ueAddr, err := n.ipam.AllocateIP(testSupi, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.moveUe(models.AMFEVENTTYPEANYOF_REGISTRATION_STATE_REPORT, "000000001", time.Now())
+	appSession := n.createRouting(t, ueAddr, `"spVal":{"presenceInfoList":{"pra1":{"praId":"pra1",
+		"ncgiList":[{"plmnId":{"mcc":"`+testPlmn.Mcc+`","mnc":"`+testPlmn.Mnc+`"},"nrCellId":"000000002"}]}}},`)
+	appSessId := appSession[strings.LastIndex(appSession, "/")+1:]
+
+	// a writer of the SMF subscriptions holds the early notification of the move
+	n.smf.SubMutex.Lock()
+	var unlock sync.Once
+	release := func() { unlock.Do(n.smf.SubMutex.Unlock) }
+	defer release()
+	moved := make(chan struct{})
+	go func() {
+		defer close(moved)
+		n.moveUe(models.AMFEVENTTYPEANYOF_LOCATION_REPORT, "000000002", time.Now())
+	}()
+	waitFor(t, func() bool {
+		if !n.pcf.SubMutex.TryRLock() {
+			return false
+		}
+		defer n.pcf.SubMutex.RUnlock()
+		return n.pcf.decisions[appSessId].Dnai == "mec-1"
+	})
+
+	// the AMF and the PCF do not wait for the SMF holding their subscriptions, the SMF
+	// reporting the usage of a sponsored session reaches the PCF in the meantime
+	released := make(chan struct{})
+	go func() {
+		defer close(released)
+		n.amf.RegisterObserver(func(models.AmfEventReport) {})
+		n.pcf.GetAppSessions()
+	}()
+	select {
+	case <-released:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the subscriptions of the AMF or of the PCF are locked during the notification of the move")
+	}
+	release()
+	<-moved
+	if early := n.upPathChange(t, 0); dnaiChange(early) != "EARLY" || early.GetTargetDnai() != "mec-1" {
+		t.Errorf("got early notification %+v of the move to the edge", early)
+	}
+}
+
+func TestPcfEdgeRoutingTemporalValidity(t *testing.T) {
+	n := newConformanceNetwork(t)
+	site := testEdgeSite
+	site.Cells = nil
+	n.smf.EdgeSites = EdgeSites{site}
+	start := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	clock := utils.NewClockAt(start)
+	n.pcf.Clock, n.smf.Clock = clock, clock
+	stop := make(chan struct{})
+	t.Cleanup(func() { close(stop) })
+	go n.pcf.runRouting(stop)
+	ueAddr, err := n.ipam.AllocateIP(testSupi, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	appSession := n.createRouting(t, ueAddr, `"tempVals":[{"startTime":"2025-06-01T09:00:00Z","stopTime":"2025-06-01T10:00:00Z"}],`)
+	clock.Advance(90 * time.Minute)
+	if early := n.upPathChange(t, 0); dnaiChange(early) != "EARLY" || early.GetTargetDnai() != "mec-1" {
+		t.Errorf("got early notification %+v at the start of the validity", early)
+	}
+	clock.Advance(time.Hour)
+	if back := n.upPathChange(t, 1); back.GetSourceDnai() != "mec-1" || back.TargetDnai != nil {
+		t.Errorf("got early notification %+v at the end of the validity", back)
+	}
+
+	n.call(t, http.MethodPost, appSession+"/delete", ``, http.StatusNoContent, nil)
+}
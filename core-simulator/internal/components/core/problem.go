@@ -0,0 +1,106 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* RFC 7807 error responses of the service based interfaces */
+
+// ProblemContentType is the media type of the SBI error responses
+const ProblemContentType = "application/problem+json"
+
+// Application errors of TS 29.500 and of the service specifications
+const (
+	CauseInvalidMsgFormat                      = "INVALID_MSG_FORMAT"
+	CauseInvalidQueryParam                     = "INVALID_QUERY_PARAM"
+	CauseMandatoryQueryParamMissing            = "MANDATORY_QUERY_PARAM_MISSING"
+	CauseMandatoryIeIncorrect                  = "MANDATORY_IE_INCORRECT"
+	CauseMandatoryIeMissing                    = "MANDATORY_IE_MISSING"
+	CauseOptionalIeIncorrect                   = "OPTIONAL_IE_INCORRECT"
+	CauseResourceUriStructureNotFound          = "RESOURCE_URI_STRUCTURE_NOT_FOUND"
+	CauseContextNotFound                       = "CONTEXT_NOT_FOUND"
+	CauseSubscriptionNotFound                  = "SUBSCRIPTION_NOT_FOUND"
+	CauseUserNotFound                          = "USER_NOT_FOUND"
+	CausePduSessionNotAvailable                = "PDU_SESSION_NOT_AVAILABLE"
+	CauseExistingBindingInfoFound              = "EXISTING_BINDING_INFO_FOUND"
+	CauseUnauthorizedSponsoredDataConnectivity = "UNAUTHORIZED_SPONSORED_DATA_CONNECTIVITY"
+	CauseSystemFailure                         = "SYSTEM_FAILURE"
+)
+
+// NewProblem builds the problem details of an error, an empty cause is omitted
+func NewProblem(status int, cause string, detail string, invalidParams ...models.InvalidParam) *models.ProblemDetails {
+	problem := &models.ProblemDetails{
+		Title:         models.PtrString(http.StatusText(status)),
+		Status:        models.PtrInt32(int32(status)),
+		Detail:        models.PtrString(detail),
+		InvalidParams: invalidParams,
+	}
+	if cause != "" {
+		problem.Cause = models.PtrString(cause)
+	}
+	return problem
+}
+
+// InvalidParam reports an invalid attribute, param is a JSON pointer in the request body
+func InvalidParam(param string, reason string) models.InvalidParam {
+	return models.InvalidParam{Param: param, Reason: models.PtrString(reason)}
+}
+
+// WriteProblem replies to a request with a ProblemDetails
+func WriteProblem(w http.ResponseWriter, status int, cause string, detail string, invalidParams ...models.InvalidParam) {
+	writeProblemBody(w, status, NewProblem(status, cause, detail, invalidParams...))
+}
+
+// writeExtendedProblem replies with the ExtendedProblemDetails of the PCF services
+func writeExtendedProblem(w http.ResponseWriter, status int, cause string, detail string, invalidParams ...models.InvalidParam) {
+	problem := NewProblem(status, cause, detail, invalidParams...)
+	writeProblemBody(w, status, &models.ExtendedProblemDetails{
+		Title:         problem.Title,
+		Status:        problem.Status,
+		Detail:        problem.Detail,
+		Cause:         problem.Cause,
+		InvalidParams: problem.InvalidParams,
+	})
+}
+
+func writeProblemBody(w http.ResponseWriter, status int, problem any) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		log.Printf("could not encode problem details: %s", err.Error())
+	}
+}
+
+// RegisterProblemHandlers answers with problem details the requests matching no SBI route
+func RegisterProblemHandlers(r *mux.Router) {
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteProblem(w, http.StatusNotFound, CauseResourceUriStructureNotFound, "no resource at "+r.URL.Path)
+	})
+	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WriteProblem(w, http.StatusMethodNotAllowed, "", r.Method+" is not allowed on "+r.URL.Path)
+	})
+}
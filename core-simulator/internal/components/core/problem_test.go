@@ -0,0 +1,95 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+const testNfId = "0b4ba5f6-8e4c-4f8c-9c1a-6f2b7e1c2d3e"
+
+func TestSbiProblemDetails(t *testing.T) {
+	plmn := models.PlmnId{Mcc: "001", Mnc: "06"}
+	ipam := utils.NewIpamService("10.0.0.0", "16")
+	notifier := NewNotifier(testNotifierConfig)
+	defer notifier.Stop()
+
+	r := mux.NewRouter()
+	RegisterProblemHandlers(r)
+	NewAmf(plmn, utils.NewPauseGate(nil), notifier).RegisterNorthboundAPIs(r)
+	NewSmf(plmn, ipam, notifier).RegisterNorthboundAPIs(r)
+	NewPcf(plmn, ipam, nil).RegisterNorthboundAPIs(r)
+	notifier.RegisterNorthboundAPIs(r)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		cause  string
+		param  string
+	}{
+		{"amf malformed body", http.MethodPost, "/namf-evts/v1/subscriptions", "{", http.StatusBadRequest, CauseInvalidMsgFormat, ""},
+		{"amf periodic without period", http.MethodPost, "/namf-evts/v1/subscriptions",
+			`{"subscription":{"eventList":[{"type":"LOCATION_REPORT"}],"eventNotifyUri":"http://af","notifyCorrelationId":"1","nfId":"` + testNfId + `","options":{"trigger":"PERIODIC"}}}`,
+			http.StatusBadRequest, CauseOptionalIeIncorrect, "/subscription/options/repPeriod"},
+		{"amf method not allowed", http.MethodGet, "/namf-evts/v1/subscriptions", "", http.StatusMethodNotAllowed, "", ""},
+		{"smf negative maximum reports", http.MethodPost, "/nsmf-event-exposure/v1/subscriptions", `{"notifUri":"http://af","notifId":"1","eventSubs":[{"event":"PDU_SES_EST"}],"maxReportNbr":-1}`, http.StatusBadRequest, CauseOptionalIeIncorrect, "/maxReportNbr"},
+		{"pcf missing ue address", http.MethodPost, "/npcf-policyauthorization/v1/app-sessions", `{"ascReqData":{"notifUri":"http://af","suppFeat":"0"}}`, http.StatusBadRequest, CauseMandatoryIeMissing, "/ascReqData/ueIpv4"},
+		{"pcf missing required property", http.MethodPost, "/npcf-policyauthorization/v1/app-sessions", `{"ascReqData":{"notifUri":"http://af","ueIpv4":"10.0.0.9"}}`, http.StatusBadRequest, CauseMandatoryIeMissing, "suppFeat"},
+		{"pcf no pdu session", http.MethodPost, "/npcf-policyauthorization/v1/app-sessions", `{"ascReqData":{"notifUri":"http://af","suppFeat":"0","ueIpv4":"10.0.0.9"}}`, http.StatusInternalServerError, CausePduSessionNotAvailable, ""},
+		{"pcf unknown app session", http.MethodPost, "/npcf-policyauthorization/v1/app-sessions/unknown/delete", "", http.StatusNotFound, CauseContextNotFound, ""},
+		{"channel without websocket", http.MethodGet, NotificationChannelPath + "af", "", http.StatusBadRequest, CauseInvalidMsgFormat, ""},
+		{"unknown resource", http.MethodGet, "/nudr-dr/v1/subscription-data", "", http.StatusNotFound, CauseResourceUriStructureNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != ProblemContentType {
+				t.Fatalf("got content type %q, want %q", ct, ProblemContentType)
+			}
+			problem := models.ProblemDetails{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+			if problem.GetStatus() != int32(tt.status) || problem.GetCause() != tt.cause {
+				t.Fatalf("got status %d and cause %q, want %d and %q", problem.GetStatus(), problem.GetCause(), tt.status, tt.cause)
+			}
+			if tt.param != "" && (len(problem.InvalidParams) == 0 || problem.InvalidParams[0].Param != tt.param) {
+				t.Fatalf("got invalid params %+v, want %s", problem.InvalidParams, tt.param)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+/* Notification methods of the event exposure subscriptions */
+
+// interval between two checks of the periodic reports and of the subscription expiries
+const reportCheckInterval = time.Second
+
+type reportMethod string
+
+const (
+	reportOnEvent  reportMethod = "ON_EVENT_DETECTION"
+	reportPeriodic reportMethod = "PERIODIC"
+	reportOneTime  reportMethod = "ONE_TIME"
+)
+
+// reportModeError is an invalid reporting mode, attr names the faulty attribute:
+// the notification method, the repetition period or the maximum number of reports
+type reportModeError struct {
+	attr string
+	msg  string
+}
+
+func (e *reportModeError) Error() string {
+	return e.msg
+}
+
+// reportState applies the notification method of a subscription: the reports are notified
+// as soon as the event is detected, or buffered and notified together every period.
+// The subscription is over after the first notification of a one time subscription,
+// after the maximum number of notifications or once expired.
+type reportState[T any] struct {
+	mutex      sync.Mutex
+	method     reportMethod
+	period     time.Duration
+	maxReports int32
+	expiry     *time.Time
+	sent       int32
+	pending    []T
+	nextFlush  time.Time
+}
+
+// newReportState builds the report state of a subscription created at now, repPeriod is required by periodic reporting
+func newReportState[T any](method reportMethod, repPeriod *int32, maxReports *int32, expiry *time.Time, now time.Time) (*reportState[T], error) {
+	s := &reportState[T]{method: method, expiry: expiry}
+	switch method {
+	case "":
+		s.method = reportOnEvent
+	case reportOnEvent, reportOneTime:
+	case reportPeriodic:
+		if repPeriod == nil || *repPeriod <= 0 {
+			return nil, &reportModeError{attr: "repPeriod", msg: "periodic reporting requires a positive repPeriod"}
+		}
+		s.period = time.Duration(*repPeriod) * time.Second
+		s.nextFlush = now.Add(s.period)
+	default:
+		return nil, &reportModeError{attr: "method", msg: fmt.Sprintf("unsupported notification method %q", method)}
+	}
+	if maxReports != nil {
+		if *maxReports < 0 {
+			return nil, &reportModeError{attr: "maxReports", msg: fmt.Sprintf("invalid maximum number of reports %d", *maxReports)}
+		}
+		s.maxReports = *maxReports
+	}
+	return s, nil
+}
+
+// reportModeProblem replies to a subscription with an invalid reporting mode,
+// params maps the attributes of the reporting mode to their pointer in the request
+func reportModeProblem(w http.ResponseWriter, err error, params map[string]string) {
+	var modeErr *reportModeError
+	if errors.As(err, &modeErr) {
+		WriteProblem(w, http.StatusBadRequest, CauseOptionalIeIncorrect, err.Error(), InvalidParam(params[modeErr.attr], modeErr.msg))
+		return
+	}
+	WriteProblem(w, http.StatusBadRequest, CauseOptionalIeIncorrect, err.Error())
+}
+
+// add records a report, it returns the reports to notify now
+func (s *reportState[T]) add(report T, now time.Time) []T {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.overLocked(now) {
+		return nil
+	}
+	if s.method == reportPeriodic {
+		s.pending = append(s.pending, report)
+		return nil
+	}
+	s.sent++
+	return []T{report}
+}
+
+// flush returns the buffered reports of a periodic subscription once its period has elapsed
+func (s *reportState[T]) flush(now time.Time) []T {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.method != reportPeriodic || now.Before(s.nextFlush) || s.overLocked(now) {
+		return nil
+	}
+	for !s.nextFlush.After(now) {
+		s.nextFlush = s.nextFlush.Add(s.period)
+	}
+	if len(s.pending) == 0 {
+		return nil
+	}
+	reports := s.pending
+	s.pending = nil
+	s.sent++
+	return reports
+}
+
+// over reports whether the subscription has to be removed
+func (s *reportState[T]) over(now time.Time) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.overLocked(now)
+}
+
+func (s *reportState[T]) overLocked(now time.Time) bool {
+	switch {
+	case s.method == reportOneTime && s.sent > 0:
+		return true
+	case s.maxReports > 0 && s.sent >= s.maxReports:
+		return true
+	case s.expiry != nil && now.After(*s.expiry):
+		return true
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+func TestReportStateOnEventDetection(t *testing.T) {
+	maxReports := int32(2)
+	now := time.Now()
+	s, err := newReportState[int](reportOnEvent, nil, &maxReports, nil, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 3 {
+		reports := s.add(i, now)
+		if want := i < 2; (len(reports) == 1) != want {
+			t.Fatalf("report %d notified: %v, want %v", i, len(reports) == 1, want)
+		}
+	}
+	if !s.over(now) {
+		t.Fatal("subscription not over after the maximum number of reports")
+	}
+}
+
+func TestReportStatePeriodic(t *testing.T) {
+	if _, err := newReportState[int](reportPeriodic, nil, nil, nil, time.Now()); err == nil {
+		t.Fatal("periodic reporting accepted without repPeriod")
+	}
+
+	period := int32(10)
+	now := time.Now()
+	s, err := newReportState[int](reportPeriodic, &period, nil, nil, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 3 {
+		if reports := s.add(i, now); len(reports) != 0 {
+			t.Fatalf("periodic report %d notified on event detection", i)
+		}
+	}
+	if reports := s.flush(now.Add(5 * time.Second)); len(reports) != 0 {
+		t.Fatal("reports flushed before the end of the period")
+	}
+	if reports := s.flush(now.Add(11 * time.Second)); len(reports) != 3 {
+		t.Fatalf("%d reports flushed at the end of the period, want 3", len(reports))
+	}
+	if reports := s.flush(now.Add(22 * time.Second)); len(reports) != 0 {
+		t.Fatal("empty period notified")
+	}
+}
+
+func TestReportStateOneTimeAndExpiry(t *testing.T) {
+	now := time.Now()
+	s, err := newAmfReportState(&models.AmfEventMode{
+		Trigger: models.AmfEventTrigger{AmfEventTriggerAnyOf: models.AMFEVENTTRIGGERANYOF_ONE_TIME.Ptr()},
+	}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.add(models.AmfEventReport{}, now)) != 1 || len(s.add(models.AmfEventReport{}, now)) != 0 {
+		t.Fatal("one time subscription notified more than once")
+	}
+
+	expiry := now.Add(time.Minute)
+	s, err = newAmfReportState(&models.AmfEventMode{Expiry: &expiry}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.over(now) || !s.over(expiry.Add(time.Second)) {
+		t.Fatal("subscription expiry not applied")
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+/* Service based interface of the network functions */
+
+// timeout of the requests of the network functions to the services of the core network
+const sbiRequestTimeout = 5 * time.Second
+
+// SbiService is a component exposing its services on the SBI
+type SbiService interface {
+	RegisterNorthboundAPIs(r *mux.Router)
+}
+
+// NewSbiRouter builds the SBI router serving the given services
+func NewSbiRouter(services ...SbiService) *mux.Router {
+	r := mux.NewRouter()
+	RegisterProblemHandlers(r)
+	for _, service := range services {
+		service.RegisterNorthboundAPIs(r)
+	}
+	return r
+}
+
+// SbiApiRoot returns the API root of a SBI served on the given address,
+// a wildcard address is reached on the loopback interface
+func SbiApiRoot(addr net.Addr) string {
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return "http://" + addr.String()
+	}
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+// sbiConsumer sends the requests of a network function to the services of the core network
+type sbiConsumer struct {
+	// root of the SBI of the core network, set once the SBI is served
+	ApiRoot string
+	client  *http.Client
+}
+
+func newSbiConsumer() sbiConsumer {
+	return sbiConsumer{client: &http.Client{Timeout: sbiRequestTimeout}}
+}
+
+// sbiError is an error response of a service of the core network
+type sbiError struct {
+	status      int
+	contentType string
+	body        []byte
+}
+
+func (e *sbiError) Error() string {
+	return fmt.Sprintf("core network replied %d: %s", e.status, e.body)
+}
+
+// call sends a request to a service of the core network, it returns the Location of the created resource
+func (c *sbiConsumer) call(method string, path string, body any) (string, error) {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return "", err
+		}
+		payload = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, c.ApiRoot+path, payload)
+	if err != nil {
+		return "", err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode >= 300 {
+		return "", &sbiError{status: resp.StatusCode, contentType: resp.Header.Get("Content-Type"), body: data}
+	}
+	return resp.Header.Get("Location"), nil
+}
+
+// relayError answers with the error response of the core network, or with a system failure
+func relayError(w http.ResponseWriter, err error) {
+	if sbiErr, ok := err.(*sbiError); ok {
+		w.Header().Set("Content-Type", sbiErr.contentType)
+		w.WriteHeader(sbiErr.status)
+		w.Write(sbiErr.body)
+		return
+	}
+	WriteProblem(w, http.StatusInternalServerError, CauseSystemFailure, err.Error())
+}
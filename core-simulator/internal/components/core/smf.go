@@ -20,41 +20,83 @@
 package core
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/giuliocarot0/gitc"
+	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
 	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
 	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
 )
 
+// SmfEventObserver is invoked for every event notification generated by the SMF,
+// regardless of the presence of subscribers.
+type SmfEventObserver func(event models.EventNotification)
+
 type Smf struct {
-	PlmnId        models.PlmnId
-	SmfId         string
-	Subscriptions map[models.SmfEventAnyOf][]string
+	PlmnId models.PlmnId
+	SmfId  string
+	// NF instance id of the SMF
+	InstanceId    string
+	Subscriptions map[string]*models.NsmfEventExposure // subId -> subscription
 	SubMutex      sync.RWMutex
+	reports       map[string]*reportState[models.EventNotification] // subId -> notification method
 	ipamInstance  *utils.IPAllocator
+	observers     []SmfEventObserver
+	notifier      *Notifier
+	stop          chan struct{}
+	// BSF where the PCF serving the PDU sessions is registered, none when nil
+	Bsf *Bsf
+	// NF instance id of the PCF serving the PDU sessions
+	PcfInstanceId string
+	bindings      map[string]string // supi/pduSessId -> binding id
+	bindingsMutex sync.Mutex
+	// CHF charging the PDU sessions, none when nil
+	Chf           *Chf
+	charging      map[string]*chargingState // supi/pduSessId -> charging data session
+	chargingIds   int32
+	chargingMutex sync.Mutex
+	// clock of the simulation, the wall clock when nil
+	Clock *utils.Clock
+	// dispatcher of the tasks of the simulation, shared with the UEs
+	Dispatcher *gitc.Dispatcher
+	// UPF anchoring the PDU sessions on the central DNAI
+	UpfId string
+	// edge sites where the AFs can route the traffic of the PDU sessions
+	EdgeSites    EdgeSites
+	routings     map[string]*upPathRouting // appSessId -> AF routing requirement
+	routingMutex sync.Mutex
 }
 
-func NewSmf(plmnId models.PlmnId, ipamInstance *utils.IPAllocator) *Smf {
+func NewSmf(plmnId models.PlmnId, ipamInstance *utils.IPAllocator, notifier *Notifier) *Smf {
 	return &Smf{
 		PlmnId:        plmnId,
 		SmfId:         fmt.Sprintf("SMF-%s%s", plmnId.Mcc, plmnId.Mnc),
-		Subscriptions: make(map[models.SmfEventAnyOf][]string),
+		InstanceId:    uuid.NewString(),
+		Subscriptions: make(map[string]*models.NsmfEventExposure),
+		reports:       make(map[string]*reportState[models.EventNotification]),
 		SubMutex:      sync.RWMutex{},
 		ipamInstance:  ipamInstance,
+		notifier:      notifier,
+		bindings:      make(map[string]string),
+		charging:      make(map[string]*chargingState),
+		UpfId:         fmt.Sprintf("UPF-%s%s", plmnId.Mcc, plmnId.Mnc),
+		routings:      make(map[string]*upPathRouting),
+		Dispatcher:    gitc.GlobalDispatcher,
 	}
 }
 
 func (smf *Smf) InitSmf() {
 	log.Printf("[%s] started", smf.SmfId)
 
-	err := gitc.StartTask("SMF", func(msg gitc.Message) {
+	err := smf.Dispatcher.StartTask("SMF", func(msg gitc.Message) {
 		switch msg.Type {
 		case models.UeToSmfType:
 			//log.Printf("[%s] Received message UeToSmfMsg from %s", smf.SmfId, msg.From)
@@ -64,6 +106,21 @@ func (smf *Smf) InitSmf() {
 	if err != nil {
 		log.Fatalf("[%s] could not start SMF task: %s", smf.SmfId, err.Error())
 	}
+	smf.stop = make(chan struct{})
+	go smf.runReports(smf.stop)
+}
+
+// StopSmf terminates the SMF task
+func (smf *Smf) StopSmf() {
+	if smf.stop != nil {
+		close(smf.stop)
+		smf.stop = nil
+	}
+	if err := smf.Dispatcher.StopTask("SMF"); err != nil {
+		log.Printf("[%s] could not stop SMF task: %s", smf.SmfId, err.Error())
+		return
+	}
+	log.Printf("[%s] stopped", smf.SmfId)
 }
 
 func (smf *Smf) handleUeToSmfEvent(msg *models.UeToSmfMsg) {
@@ -78,13 +135,15 @@ func (smf *Smf) handleUeToSmfEvent(msg *models.UeToSmfMsg) {
 		Event:     msg.EventType,
 		TimeStamp: msg.TimeStamp,
 		Supi:      models.PtrString(msg.Supi),
-		Gpsi:      models.PtrString(msg.Gpsi), // MSISDN in E.164 format
 		Dnn:       &msg.Dnn,
 		Snssai:    &msg.Snssai,
 		AccType:   &msg.AccessType,
 		PduSeId:   &msg.PduSessId,
 		PlmnId:    &msg.PlmnId,
 	}
+	if msg.Gpsi != "" {
+		smfEvent.Gpsi = models.PtrString(msg.Gpsi) // MSISDN in E.164 format
+	}
 
 	switch msg.EventType {
 	case models.SMFEVENTANYOF_PDU_SES_EST:
@@ -120,82 +179,447 @@ func (smf *Smf) handleUeToSmfEvent(msg *models.UeToSmfMsg) {
 				SeId:    msg.PduSessId,
 			},
 		}
+	case models.SMFEVENTANYOF_QFI_ALLOC:
+		smfEvent.Qfi = &msg.Qfi
+		if msg.AppId != "" {
+			smfEvent.AppId = &msg.AppId
+		}
+	case models.SMFEVENTANYOF_UP_PATH_CH:
+		// the UE reports the move once the user plane is reconfigured, its address is preserved
+		if msg.SourceDnai != "" {
+			smfEvent.SourceDnai = &msg.SourceDnai
+		}
+		if msg.TargetDnai != "" {
+			smfEvent.TargetDnai = &msg.TargetDnai
+		}
+		if msg.AppId != "" {
+			smfEvent.AppId = &msg.AppId
+		}
+		smfEvent.DnaiChgType = dnaiChangeType(models.DNAICHANGETYPEANYOF_LATE)
+		smfEvent.SourceUeIpv4Addr = &msg.UeAddress
+		smfEvent.TargetUeIpv4Addr = &msg.UeAddress
+		if routing := smf.routing(msg.AppSessionId); routing != nil {
+			smf.describeUpPath(&smfEvent, routing)
+		}
 	case models.SMFEVENTANYOF_COMM_FAIL:
 	}
 
-	// buildup the notification structure
-	amfNotification := &models.NsmfEventExposureNotification{
-		NotifId:     "test",
-		EventNotifs: []models.EventNotification{smfEvent},
+	switch msg.EventType {
+	case models.SMFEVENTANYOF_PDU_SES_EST:
+		smf.bind(msg)
+		smf.openCharging(msg)
+	case models.SMFEVENTANYOF_PDU_SES_REL:
+		smf.unbind(msg)
+		smf.releaseCharging(msg)
+		smf.dropRoutings(msg.Supi, msg.PduSessId)
+	case models.SMFEVENTANYOF_QOS_MON:
+		smf.reportUsage(msg)
 	}
-	//log.Printf("[%s] generating notification : %+v", smf.SmfId, amfNotification)
 
-	callbackBody, err := json.Marshal(amfNotification)
+	smf.publish(smfEvent, msg.Supi)
+
+	if msg.EventType == models.SMFEVENTANYOF_UP_PATH_CH {
+		if routing := smf.routing(msg.AppSessionId); routing != nil {
+			smf.notifyUpPathChange(msg.AppSessionId, routing, smfEvent)
+			if routing.removed {
+				smf.removeRouting(msg.AppSessionId, false)
+			}
+		}
+	}
+}
+
+// publish passes an event of a UE to the observers and the subscribers, the caller holds SubMutex
+func (smf *Smf) publish(event models.EventNotification, supi string) {
+	for _, observer := range smf.observers {
+		observer(event)
+	}
+
+	for subId, sub := range smf.Subscriptions {
+		if !subscribedToSmfEvent(sub, &event) || !targetsUe(sub.Supi, supi) {
+			continue
+		}
+		if events := smf.reports[subId].add(event, smf.Clock.Now()); len(events) > 0 {
+			smf.notify(subId, sub, events)
+		}
+	}
+}
+
+// bind registers the binding of an established PDU session to the PCF in the BSF
+func (smf *Smf) bind(msg *models.UeToSmfMsg) {
+	if smf.Bsf == nil {
+		return
+	}
+	smf.unbind(msg)
+	binding := models.PcfBinding{
+		Supi:     models.PtrString("imsi-" + strings.TrimPrefix(msg.Supi, "imsi-")),
+		Ipv4Addr: models.PtrString(msg.UeAddress),
+		Dnn:      msg.Dnn,
+		Snssai:   msg.Snssai,
+		PcfId:    models.PtrString(smf.PcfInstanceId),
+	}
+	if msg.Gpsi != "" {
+		binding.Gpsi = models.PtrString(formatGpsi(msg.Gpsi))
+	}
+	id, err := smf.Bsf.Register(binding)
 	if err != nil {
-		log.Printf("[%s] error while marshalling notification: %s", smf.SmfId, err.Error())
+		log.Printf("[%s] could not register the binding of PDU session %d of UE %s: %s", smf.SmfId, msg.PduSessId, msg.Supi, err.Error())
 		return
 	}
+	smf.bindingsMutex.Lock()
+	smf.bindings[pduSessionKey(msg.Supi, msg.PduSessId)] = id
+	smf.bindingsMutex.Unlock()
+}
 
-	for _, callbackUrl := range smf.Subscriptions[msg.EventType] {
-		go func(url string, data []byte) {
-			resp, err := http.Post(url, "application/json", bytes.NewBuffer(data)) // Replace nil with actual payload if needed
-			if err != nil {
-				log.Printf("Error notifying subscriber %s: %v", url, err)
-				return
-			}
-			defer func() {
-				_ = resp.Body.Close()
-			}()
-			//log.Printf("Notified subscriber %s with response status: %s", url, resp.Status)
-		}(callbackUrl, callbackBody)
+// unbind deregisters the binding of a released PDU session from the BSF
+func (smf *Smf) unbind(msg *models.UeToSmfMsg) {
+	if smf.Bsf == nil {
+		return
+	}
+	key := pduSessionKey(msg.Supi, msg.PduSessId)
+	smf.bindingsMutex.Lock()
+	id, ok := smf.bindings[key]
+	delete(smf.bindings, key)
+	smf.bindingsMutex.Unlock()
+	if ok {
+		smf.Bsf.Deregister(id)
 	}
 }
 
-// NORTHBOUND Definitions
+// chargingState is the charging data session of a PDU session, the usage is reported as the
+// difference of the cumulative userplane statistics of the UE
+type chargingState struct {
+	ref          string
+	supi         string
+	pduSessId    int32
+	established  time.Time
+	sequence     int64
+	ulBytes      int64
+	dlBytes      int64
+	reportedTime int64
+	restricted   bool
+	terminated   bool
+}
 
-func (smf *Smf) HandleNewSubscription(w http.ResponseWriter, r *http.Request) {
+// chargingRequest builds a charging data request of a PDU session with the usage since the last report
+func (smf *Smf) chargingRequest(state *chargingState, timestamp time.Time, used *models.UsedUnitContainer) *models.ChargingDataRequest {
+	usage := models.MultipleUnitUsage{RatingGroup: defaultRatingGroup, RequestedUnit: &models.RequestedUnit{}}
+	if used != nil {
+		used.LocalSequenceNumber = int32(state.sequence)
+		usage.UsedUnitContainer = []models.UsedUnitContainer{*used}
+	}
+	req := &models.ChargingDataRequest{
+		SubscriberIdentifier:     models.PtrString("imsi-" + strings.TrimPrefix(state.supi, "imsi-")),
+		NfConsumerIdentification: models.NfIdentification{NfName: models.PtrString(smf.InstanceId), NodeFunctionality: "SMF"},
+		InvocationTimeStamp:      timestamp,
+		InvocationSequenceNumber: state.sequence,
+		MultipleUnitUsage:        []models.MultipleUnitUsage{usage},
+	}
+	state.sequence++
+	return req
+}
 
-	subData := &models.NsmfEventExposure{}
+// openCharging opens the charging data session of an established PDU session in the CHF
+func (smf *Smf) openCharging(msg *models.UeToSmfMsg) {
+	if smf.Chf == nil {
+		return
+	}
+	key := pduSessionKey(msg.Supi, msg.PduSessId)
+	state := &chargingState{supi: msg.Supi, pduSessId: msg.PduSessId, established: msg.TimeStamp}
+
+	smf.chargingMutex.Lock()
+	defer smf.chargingMutex.Unlock()
+	if previous, ok := smf.charging[key]; ok {
+		smf.Chf.Release(previous.ref, smf.chargingRequest(previous, msg.TimeStamp, nil))
+	}
+	smf.chargingIds++
+	req := smf.chargingRequest(state, msg.TimeStamp, nil)
+	req.PduSessionChargingInformation = &models.PduSessionChargingInformation{
+		ChargingId: smf.chargingIds,
+		PduSessionInformation: models.ChargingPduSessionInformation{
+			PduSessionId:       msg.PduSessId,
+			DnnId:              msg.Dnn,
+			NetworkSlicingInfo: models.NetworkSlicingInfo{Snssai: msg.Snssai},
+			PduAddress:         &models.PduAddress{PduIpv4Address: models.PtrString(msg.UeAddress)},
+		},
+	}
+	ref, response := smf.Chf.Open(req, func(models.ChargingNotifyRequest) {
+		smf.reauthorize(key)
+	})
+	state.ref = ref
+	smf.charging[key] = state
+	smf.enforceCredit(state, response)
+}
+
+// reportUsage charges the usage of a PDU session since its last userplane report
+func (smf *Smf) reportUsage(msg *models.UeToSmfMsg) {
+	if smf.Chf == nil || msg.UpReport == nil {
+		return
+	}
+	key := pduSessionKey(msg.Supi, msg.PduSessId)
 
-	if err := json.NewDecoder(r.Body).Decode(subData); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	smf.chargingMutex.Lock()
+	defer smf.chargingMutex.Unlock()
+	state, ok := smf.charging[key]
+	if !ok {
 		return
 	}
+	elapsed := int64(msg.TimeStamp.Sub(state.established).Seconds())
+	used := &models.UsedUnitContainer{
+		Time:           max(elapsed-state.reportedTime, 0),
+		UplinkVolume:   max(msg.UpReport.TotalUlBytes-state.ulBytes, 0),
+		DownlinkVolume: max(msg.UpReport.TotalDlBytes-state.dlBytes, 0),
+	}
+	used.TotalVolume = used.UplinkVolume + used.DownlinkVolume
+	state.ulBytes, state.dlBytes = msg.UpReport.TotalUlBytes, msg.UpReport.TotalDlBytes
+	state.reportedTime += used.Time
 
-	subList, ok := subData.GetEventSubsOk()
+	response, ok := smf.Chf.Update(state.ref, smf.chargingRequest(state, msg.TimeStamp, used))
 	if !ok {
-		http.Error(w, "could not find event list", http.StatusBadRequest)
+		delete(smf.charging, key)
 		return
 	}
+	smf.enforceCredit(state, response)
+}
 
-	callbackUrl, ok := subData.GetNotifUriOk()
+// reauthorize requests new quotas for a PDU session, on request of the CHF
+func (smf *Smf) reauthorize(key string) {
+	smf.chargingMutex.Lock()
+	defer smf.chargingMutex.Unlock()
+	state, ok := smf.charging[key]
 	if !ok {
-		http.Error(w, "could not find callbackUri information", http.StatusBadRequest)
 		return
 	}
+	if response, ok := smf.Chf.Update(state.ref, smf.chargingRequest(state, smf.Clock.Now(), nil)); ok {
+		smf.enforceCredit(state, response)
+	}
+}
+
+// releaseCharging closes the charging data session of a released PDU session
+func (smf *Smf) releaseCharging(msg *models.UeToSmfMsg) {
+	if smf.Chf == nil {
+		return
+	}
+	key := pduSessionKey(msg.Supi, msg.PduSessId)
+
+	smf.chargingMutex.Lock()
+	defer smf.chargingMutex.Unlock()
+	if state, ok := smf.charging[key]; ok {
+		smf.Chf.Release(state.ref, smf.chargingRequest(state, msg.TimeStamp, nil))
+		delete(smf.charging, key)
+	}
+}
+
+// enforceCredit applies the final unit action of a PDU session out of credit, and lifts
+// the restriction of a session granted new units
+func (smf *Smf) enforceCredit(state *chargingState, response *models.ChargingDataResponse) {
+	for _, info := range response.MultipleUnitInformation {
+		var err error
+		switch {
+		case info.ResultCode == models.ChargingResultSuccess && state.restricted:
+			state.restricted = false
+			err = smf.ModifyPduSession(state.supi, state.pduSessId, ran.DefaultQos)
+		case info.ResultCode != models.ChargingResultQuotaLimitReached || info.FinalUnitIndication == nil:
+		case info.FinalUnitIndication.FinalUnitAction == models.FinalUnitActionTerminate && !state.terminated:
+			state.terminated = true
+			err = smf.ReleasePduSession(state.supi, state.pduSessId)
+		case info.FinalUnitIndication.FinalUnitAction == models.FinalUnitActionRestrictAccess && !state.restricted:
+			state.restricted = true
+			err = smf.ModifyPduSession(state.supi, state.pduSessId, smf.Chf.RestrictedQos)
+		}
+		if err != nil {
+			log.Printf("[%s] could not enforce the credit of PDU session %d of UE %s: %s", smf.SmfId, state.pduSessId, state.supi, err.Error())
+		}
+	}
+}
+
+// notify sends the event notifications of a subscription to its subscriber
+func (smf *Smf) notify(subId string, sub *models.NsmfEventExposure, events []models.EventNotification) {
+	smfNotification := &models.NsmfEventExposureNotification{
+		NotifId:     sub.NotifId,
+		EventNotifs: events,
+	}
+	//log.Printf("[%s] generating notification : %+v", smf.SmfId, smfNotification)
+
+	callbackBody, err := json.Marshal(smfNotification)
+	if err != nil {
+		log.Printf("[%s] error while marshalling notification: %s", smf.SmfId, err.Error())
+		return
+	}
+
+	smf.notifier.Notify(&Notification{
+		Source:         smf.SmfId,
+		SubscriptionId: subId,
+		Uri:            sub.NotifUri,
+		AltUris:        AlternateNotifUris(sub.NotifUri, sub.AltNotifIpv4Addrs, sub.AltNotifFqdns),
+		Body:           callbackBody,
+		OnFailure: func() {
+			smf.removeSubscription(subId, "the subscriber is not reachable")
+		},
+	})
+}
+
+// runReports sends the periodic reports and removes the subscriptions that are over
+func (smf *Smf) runReports(stop <-chan struct{}) {
+	ticker := time.NewTicker(reportCheckInterval)
+	defer ticker.Stop()
 
+	for {
+		advanced := smf.Clock.Advanced()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		case <-advanced:
+		}
+
+		now := smf.Clock.Now()
+		over := []string{}
+		smf.SubMutex.RLock()
+		for subId, sub := range smf.Subscriptions {
+			state := smf.reports[subId]
+			if events := state.flush(now); len(events) > 0 {
+				smf.notify(subId, sub, events)
+			}
+			if state.over(now) {
+				over = append(over, subId)
+			}
+		}
+		smf.SubMutex.RUnlock()
+
+		for _, subId := range over {
+			smf.removeSubscription(subId, "the subscription is over")
+		}
+	}
+}
+
+// ReleasePduSession performs a network initiated release of a PDU Session
+func (smf *Smf) ReleasePduSession(supi string, pduSessId int32) error {
+	msg := &models.SmfToUeMsg{
+		Command:   models.SmfPduSessionRelease,
+		TimeStamp: smf.Clock.Now(),
+		Supi:      supi,
+		PduSessId: pduSessId,
+	}
+	return smf.Dispatcher.Send("SMF", ran.RanTask, models.SmfToUeType, msg)
+}
+
+// ModifyPduSession updates the QoS of the default flow of a PDU Session
+func (smf *Smf) ModifyPduSession(supi string, pduSessId int32, qos models.SessionQos) error {
+	msg := &models.SmfToUeMsg{
+		Command:   models.SmfPduSessionModification,
+		TimeStamp: smf.Clock.Now(),
+		Supi:      supi,
+		PduSessId: pduSessId,
+		Qos:       &qos,
+	}
+	return smf.Dispatcher.Send("SMF", ran.RanTask, models.SmfToUeType, msg)
+}
+
+func subscribedToSmfEvent(sub *models.NsmfEventExposure, event *models.EventNotification) bool {
+	for _, eventSub := range sub.EventSubs {
+		if eventSub.Event == event.Event && dnaiChangeMatches(eventSub.DnaiChgType, event.DnaiChgType) {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterObserver adds an internal observer of the SMF events
+func (smf *Smf) RegisterObserver(observer SmfEventObserver) {
 	smf.SubMutex.Lock()
 	defer smf.SubMutex.Unlock()
+	smf.observers = append(smf.observers, observer)
+}
 
-	for _, event := range subList {
-		if listSub, exist := smf.Subscriptions[event.Event]; exist {
-			smf.Subscriptions[event.Event] = append(listSub, *callbackUrl)
-		} else {
-			smf.Subscriptions[event.Event] = []string{*callbackUrl}
-		}
+// NORTHBOUND Definitions
+
+func (smf *Smf) HandleNewSubscription(w http.ResponseWriter, r *http.Request) {
+
+	subData := &models.NsmfEventExposure{}
+
+	if !decodeRequest(w, r, subData, WriteProblem) {
+		return
 	}
 
+	v := newValidator(smf.PlmnId)
+	v.validateSmfEventExposure(subData)
+	if v.reply(w, WriteProblem) {
+		return
+	}
+
+	method := reportOnEvent
+	if subData.NotifMethod != nil && subData.NotifMethod.NotificationMethodAnyOf != nil {
+		method = reportMethod(*subData.NotifMethod.NotificationMethodAnyOf)
+	}
+	state, err := newReportState[models.EventNotification](method, subData.RepPeriod, subData.MaxReportNbr, subData.Expiry, smf.Clock.Now())
+	if err != nil {
+		reportModeProblem(w, err, map[string]string{
+			"method":     "/notifMethod",
+			"repPeriod":  "/repPeriod",
+			"maxReports": "/maxReportNbr",
+		})
+		return
+	}
+
+	subId := uuid.New().String()
+	subData.SubId = models.PtrString(subId)
+
+	smf.SubMutex.Lock()
+	smf.Subscriptions[subId] = subData
+	smf.reports[subId] = state
+	smf.SubMutex.Unlock()
+
+	w.Header().Set("Location", "/nsmf-event-exposure/v1/subscriptions/"+subId)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 
 	if err := json.NewEncoder(w).Encode(subData); err != nil {
-		http.Error(w, "could not encode response", http.StatusInternalServerError)
+		log.Printf("[%s] could not encode response: %s", smf.SmfId, err.Error())
+	}
+
+	log.Printf("[%s] created new subscription %s for: %s", smf.SmfId, subId, subData.NotifUri)
+}
+
+// removeSubscription deletes a subscription
+func (smf *Smf) removeSubscription(subId string, reason string) {
+	smf.SubMutex.Lock()
+	defer smf.SubMutex.Unlock()
+	if _, ok := smf.Subscriptions[subId]; ok {
+		delete(smf.Subscriptions, subId)
+		delete(smf.reports, subId)
+		log.Printf("[%s] removed subscription %s, %s", smf.SmfId, subId, reason)
 	}
+}
+
+// GetSubscriptions returns a copy of the active event subscriptions indexed by subscription id
+func (smf *Smf) GetSubscriptions() map[string]models.NsmfEventExposure {
+	smf.SubMutex.RLock()
+	defer smf.SubMutex.RUnlock()
 
-	log.Printf("[%s] created new subscription for: %s", smf.SmfId, *callbackUrl)
+	subs := make(map[string]models.NsmfEventExposure, len(smf.Subscriptions))
+	for subId, sub := range smf.Subscriptions {
+		subs[subId] = *sub
+	}
+	return subs
+}
+
+// HandleDeleteSubscription removes a subscription, TS 29.508 unsubscribe
+func (smf *Smf) HandleDeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	subId := mux.Vars(r)["subId"]
+
+	smf.SubMutex.RLock()
+	_, ok := smf.Subscriptions[subId]
+	smf.SubMutex.RUnlock()
+	if !ok {
+		WriteProblem(w, http.StatusNotFound, CauseSubscriptionNotFound, "subscription "+subId+" is not found")
+		return
+	}
+	smf.removeSubscription(subId, "deleted by the subscriber")
+	w.WriteHeader(http.StatusNoContent)
 }
 
 func (smf *Smf) RegisterNorthboundAPIs(r *mux.Router) {
-	r.HandleFunc("/nsmf-event-exposure/v1/subscriptions", smf.HandleNewSubscription)
+	r.HandleFunc("/nsmf-event-exposure/v1/subscriptions", smf.HandleNewSubscription).Methods(http.MethodPost)
+	r.HandleFunc("/nsmf-event-exposure/v1/subscriptions/{subId}", smf.HandleDeleteSubscription).Methods(http.MethodDelete)
 	log.Printf("[%s] nsmf-event-exposure has been registered", smf.SmfId)
 }
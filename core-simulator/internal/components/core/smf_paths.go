@@ -0,0 +1,291 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* Edge sites and user plane paths influenced by the AFs */
+
+var nrCellIdPattern = regexp.MustCompile(`^[0-9a-fA-F]{9}$`)
+
+// EdgeSite is a local access to the data network, identified by its DNAI, where the SMF
+// can anchor the traffic of a PDU session on a local UPF
+type EdgeSite struct {
+	Dnai string `yaml:"dnai" json:"dnai"`
+	// UPF serving the site and its IPv4 address
+	UpfId   string `yaml:"upfId" json:"upfId"`
+	UpfAddr string `yaml:"upfAddr,omitempty" json:"upfAddr,omitempty"`
+	// N6 traffic routing towards the edge application servers, none when empty
+	N6Ipv4Addr string `yaml:"n6Ipv4Addr,omitempty" json:"n6Ipv4Addr,omitempty"`
+	N6Port     int32  `yaml:"n6Port,omitempty" json:"n6Port,omitempty"`
+	// NR cells where the site is reachable, all the cells when empty
+	Cells []string `yaml:"cells,omitempty" json:"cells,omitempty"`
+}
+
+// EdgeSites are the edge sites declared in the simulation profile
+type EdgeSites []EdgeSite
+
+func (sites EdgeSites) Validate() error {
+	dnais := map[string]bool{}
+	for _, site := range sites {
+		if site.Dnai == "" {
+			return fmt.Errorf("edge site without dnai")
+		}
+		if dnais[site.Dnai] {
+			return fmt.Errorf("duplicate edge site %s", site.Dnai)
+		}
+		dnais[site.Dnai] = true
+		if site.UpfId == "" {
+			return fmt.Errorf("edge site %s: missing upf id", site.Dnai)
+		}
+		for _, addr := range []string{site.UpfAddr, site.N6Ipv4Addr} {
+			if ip := net.ParseIP(addr); addr != "" && (ip == nil || ip.To4() == nil) {
+				return fmt.Errorf("edge site %s: invalid IPv4 address %q", site.Dnai, addr)
+			}
+		}
+		if site.N6Port < 0 || site.N6Port > 65535 {
+			return fmt.Errorf("edge site %s: invalid n6 port %d", site.Dnai, site.N6Port)
+		}
+		for _, cell := range site.Cells {
+			if !nrCellIdPattern.MatchString(cell) {
+				return fmt.Errorf("edge site %s: invalid NR cell id %q", site.Dnai, cell)
+			}
+		}
+	}
+	return nil
+}
+
+// site returns the edge site of a DNAI, nil when it is not declared
+func (sites EdgeSites) site(dnai string) *EdgeSite {
+	for i := range sites {
+		if sites[i].Dnai == dnai {
+			return &sites[i]
+		}
+	}
+	return nil
+}
+
+// serves returns whether the site is reachable from the cell of the UE
+func (site *EdgeSite) serves(location *models.NrLocation) bool {
+	if len(site.Cells) == 0 {
+		return true
+	}
+	return location != nil && slices.ContainsFunc(site.Cells, func(cell string) bool {
+		return strings.EqualFold(cell, location.Ncgi.NrCellId)
+	})
+}
+
+// upPathRouting is the AF routing requirement applied to a PDU session
+type upPathRouting struct {
+	supi      string
+	gpsi      *string
+	pduSessId int32
+	ueIpv4    string
+	dnn       *string
+	snssai    *models.Snssai
+	// application of the AF, reported in the notifications of the moves
+	afAppId *string
+	req     models.AfRoutingRequirement
+	// the requirement is withdrawn, it is kept until the late notification of the move back
+	removed bool
+}
+
+// installRouting records the AF routing requirement of an application session on a PDU session
+func (smf *Smf) installRouting(appSessId string, supi string, pduSessId int32, rData *models.AppSessionContextReqData) {
+	routing := &upPathRouting{
+		supi:      supi,
+		gpsi:      rData.Gpsi,
+		pduSessId: pduSessId,
+		ueIpv4:    rData.GetUeIpv4(),
+		dnn:       rData.Dnn,
+		snssai:    rData.SliceInfo,
+		afAppId:   rData.AfAppId,
+		req:       *rData.AfRoutReq,
+	}
+	smf.routingMutex.Lock()
+	defer smf.routingMutex.Unlock()
+	smf.routings[appSessId] = routing
+}
+
+// removeRouting withdraws the AF routing requirement of an application session. A pending
+// move of the PDU session is still notified to the AF before the requirement is dropped.
+func (smf *Smf) removeRouting(appSessId string, pending bool) {
+	smf.routingMutex.Lock()
+	defer smf.routingMutex.Unlock()
+	if routing := smf.routings[appSessId]; routing != nil && pending {
+		routing.removed = true
+		return
+	}
+	delete(smf.routings, appSessId)
+}
+
+// dropRoutings forgets the AF routing requirements of a released PDU session
+func (smf *Smf) dropRoutings(supi string, pduSessId int32) {
+	smf.routingMutex.Lock()
+	defer smf.routingMutex.Unlock()
+	for appSessId, routing := range smf.routings {
+		if pduSessionKey(routing.supi, routing.pduSessId) == pduSessionKey(supi, pduSessId) {
+			delete(smf.routings, appSessId)
+		}
+	}
+}
+
+func (smf *Smf) routing(appSessId string) *upPathRouting {
+	smf.routingMutex.Lock()
+	defer smf.routingMutex.Unlock()
+	return smf.routings[appSessId]
+}
+
+// earlyUpPathChange notifies the upcoming move of a PDU session between two DNAIs, before the
+// user plane is reconfigured, TS 23.502 clause 4.3.6.3
+func (smf *Smf) earlyUpPathChange(appSessId string, source string, target string) {
+	routing := smf.routing(appSessId)
+	if routing == nil {
+		return
+	}
+	event := models.EventNotification{
+		Event:            models.SMFEVENTANYOF_UP_PATH_CH,
+		TimeStamp:        smf.Clock.Now(),
+		Supi:             models.PtrString(routing.supi),
+		Gpsi:             routing.gpsi,
+		Dnn:              routing.dnn,
+		Snssai:           routing.snssai,
+		PduSeId:          &routing.pduSessId,
+		PlmnId:           &smf.PlmnId,
+		AppId:            routing.afAppId,
+		DnaiChgType:      dnaiChangeType(models.DNAICHANGETYPEANYOF_EARLY),
+		SourceUeIpv4Addr: models.PtrString(routing.ueIpv4),
+		TargetUeIpv4Addr: models.PtrString(routing.ueIpv4),
+	}
+	if source != "" {
+		event.SourceDnai = &source
+	}
+	if target != "" {
+		event.TargetDnai = &target
+	}
+	smf.describeUpPath(&event, routing)
+
+	smf.SubMutex.RLock()
+	defer smf.SubMutex.RUnlock()
+	smf.publish(event, routing.supi)
+	smf.notifyUpPathChange(appSessId, routing, event)
+}
+
+// describeUpPath completes the event of a move with the N6 traffic routing of the DNAIs, the UPF
+// of the target DNAI and the EAS addresses to replace
+func (smf *Smf) describeUpPath(event *models.EventNotification, routing *upPathRouting) {
+	source, target := event.GetSourceDnai(), event.GetTargetDnai()
+	if route := smf.trafficRouting(routing, source); route != nil {
+		event.SourceTraRouting.Set(route)
+	}
+	if route := smf.trafficRouting(routing, target); route != nil {
+		event.TargetTraRouting.Set(route)
+	}
+
+	upf := models.UpfInformation{UpfId: models.PtrString(smf.UpfId)}
+	if site := smf.EdgeSites.site(target); site != nil {
+		upf.UpfId = models.PtrString(site.UpfId)
+		if site.UpfAddr != "" {
+			upf.UpfAddr = &models.AddrFqdn{IpAddr: ipv4Addr(site.UpfAddr)}
+		}
+	}
+	event.UpfInfo = &upf
+
+	// the traffic moving back to the central DNAI is redirected to the original EAS
+	for _, info := range routing.req.EasIpReplaceInfos {
+		if target == "" {
+			info.Source, info.Target = info.Target, info.Source
+		}
+		event.EasIpReplaceInfos = append(event.EasIpReplaceInfos, info)
+	}
+}
+
+// trafficRouting returns the N6 traffic routing of a DNAI, the one requested by the AF or else
+// the one of the edge site, nil for the central DNAI
+func (smf *Smf) trafficRouting(routing *upPathRouting, dnai string) *models.RouteToLocation {
+	if dnai == "" {
+		return nil
+	}
+	for _, route := range routing.req.RouteToLocs {
+		if route.GetDnai() == dnai && route.GetRouteInfo() != nil {
+			return models.NewRouteToLocation(dnai, route.GetRouteInfo())
+		}
+	}
+	if site := smf.EdgeSites.site(dnai); site != nil && site.N6Ipv4Addr != "" {
+		return models.NewRouteToLocation(dnai, &models.RouteInformation{
+			Ipv4Addr:   models.PtrString(site.N6Ipv4Addr),
+			PortNumber: site.N6Port,
+		})
+	}
+	return nil
+}
+
+// notifyUpPathChange sends the event of a move to the AF subscribed in the routing requirement,
+// TS 29.508 clause 4.2.2.1
+func (smf *Smf) notifyUpPathChange(appSessId string, routing *upPathRouting, event models.EventNotification) {
+	sub, ok := routing.req.GetUpPathChgSubOk()
+	if !ok || sub == nil || !dnaiChangeMatches(&sub.DnaiChgType, event.DnaiChgType) {
+		return
+	}
+	body, err := json.Marshal(&models.NsmfEventExposureNotification{
+		NotifId:     sub.NotifCorreId,
+		EventNotifs: []models.EventNotification{event},
+	})
+	if err != nil {
+		log.Printf("[%s] error while marshalling notification: %s", smf.SmfId, err.Error())
+		return
+	}
+	smf.notifier.Notify(&Notification{
+		Source:         smf.SmfId,
+		SubscriptionId: appSessId,
+		Uri:            sub.NotificationUri,
+		Body:           body,
+	})
+}
+
+// dnaiChangeMatches returns whether the notifications of a DNAI change type are subscribed,
+// all of them when the subscription has no type or is EARLY_LATE
+func dnaiChangeMatches(subscribed *models.DnaiChangeType, changeType *models.DnaiChangeType) bool {
+	if subscribed == nil || subscribed.DnaiChangeTypeAnyOf == nil || changeType == nil || changeType.DnaiChangeTypeAnyOf == nil {
+		return true
+	}
+	return *subscribed.DnaiChangeTypeAnyOf == models.DNAICHANGETYPEANYOF_EARLY_LATE ||
+		*subscribed.DnaiChangeTypeAnyOf == *changeType.DnaiChangeTypeAnyOf
+}
+
+func dnaiChangeType(changeType models.DnaiChangeTypeAnyOf) *models.DnaiChangeType {
+	return &models.DnaiChangeType{DnaiChangeTypeAnyOf: &changeType}
+}
+
+// ipv4Addr wraps an IPv4 address in the IpAddr choice of the models
+func ipv4Addr(addr string) *models.IpAddr {
+	var ip interface{} = map[string]interface{}{"ipv4Addr": addr}
+	return &models.IpAddr{Interface: &ip}
+}
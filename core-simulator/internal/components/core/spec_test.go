@@ -0,0 +1,365 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* Validation of the SBI documents against the OpenAPI specifications of 3GPP */
+
+// The conformance tests check the documents against the generated models, which only catches
+// the regressions of the simulator. The documents are also validated against the specifications
+// vendored in testdata/openapi, see testdata/openapi/README.md.
+
+type specSchema struct {
+	file string
+	name string
+}
+
+// specSchemas are the schemas of the specifications matching the models of the conformance tests
+var specSchemas = map[reflect.Type]specSchema{
+	reflect.TypeOf(models.AmfCreatedEventSubscription{}):          {"TS29518_Namf_EventExposure.yaml", "AmfCreatedEventSubscription"},
+	reflect.TypeOf(models.AmfEventNotification{}):                 {"TS29518_Namf_EventExposure.yaml", "AmfEventNotification"},
+	reflect.TypeOf(models.NsmfEventExposure{}):                    {"TS29508_Nsmf_EventExposure.yaml", "NsmfEventExposure"},
+	reflect.TypeOf(models.NsmfEventExposureNotification{}):        {"TS29508_Nsmf_EventExposure.yaml", "NsmfEventExposureNotification"},
+	reflect.TypeOf(models.AppSessionContext{}):                    {"TS29514_Npcf_PolicyAuthorization.yaml", "AppSessionContext"},
+	reflect.TypeOf(models.ExtendedProblemDetails{}):               {"TS29514_Npcf_PolicyAuthorization.yaml", "ExtendedProblemDetails"},
+	reflect.TypeOf(models.EventsNotification{}):                   {"TS29514_Npcf_PolicyAuthorization.yaml", "EventsNotification"},
+	reflect.TypeOf(models.ProblemDetails{}):                       {"TS29571_CommonData.yaml", "ProblemDetails"},
+	reflect.TypeOf(models.PcfBinding{}):                           {"TS29521_Nbsf_Management.yaml", "PcfBinding"},
+	reflect.TypeOf(models.ChargingDataResponse{}):                 {"TS32291_Nchf_ConvergedCharging.yaml", "ChargingDataResponse"},
+	reflect.TypeOf(models.AnalyticsData{}):                        {"TS29520_Nnwdaf_AnalyticsInfo.yaml", "AnalyticsData"},
+	reflect.TypeOf(models.NnwdafEventsSubscription{}):             {"TS29520_Nnwdaf_EventsSubscription.yaml", "NnwdafEventsSubscription"},
+	reflect.TypeOf(models.AccessAndMobilitySubscriptionData{}):    {"TS29503_Nudm_SDM.yaml", "AccessAndMobilitySubscriptionData"},
+	reflect.TypeOf(models.Amf3GppAccessRegistration{}):            {"TS29503_Nudm_UECM.yaml", "Amf3GppAccessRegistration"},
+	reflect.TypeOf(models.CreatedEeSubscription{}):                {"TS29503_Nudm_EE.yaml", "CreatedEeSubscription"},
+	reflect.TypeOf(models.MonitoringEventSubscription{}):          {"TS29122_MonitoringEvent.yaml", "MonitoringEventSubscription"},
+	reflect.TypeOf(models.AsSessionWithQoSSubscription{}):         {"TS29122_AsSessionWithQoS.yaml", "AsSessionWithQoSSubscription"},
+	reflect.TypeOf(models.TrafficInfluSub{}):                      {"TS29522_TrafficInfluence.yaml", "TrafficInfluSub"},
+	reflect.TypeOf(models.TrafficInfluNotification{}):             {"TS29522_TrafficInfluence.yaml", "EventNotification"},
+	reflect.TypeOf(models.MonitoringNotification{}):               {"TS29122_MonitoringEvent.yaml", "MonitoringNotification"},
+	reflect.TypeOf(models.UserPlaneNotificationData{}):            {"TS29122_AsSessionWithQoS.yaml", "UserPlaneNotificationData"},
+	reflect.TypeOf(models.NnwdafEventsSubscriptionNotification{}): {"TS29520_Nnwdaf_EventsSubscription.yaml", "NnwdafEventsSubscriptionNotification"},
+	reflect.TypeOf(models.SmfRegistrationInfo{}):                  {"TS29503_Nudm_UECM.yaml", "SmfRegistrationInfo"},
+	reflect.TypeOf(models.SessionManagementSubscriptionData{}):    {"TS29503_Nudm_SDM.yaml", "SessionManagementSubscriptionData"},
+	reflect.TypeOf(models.IdTranslationResult{}):                  {"TS29503_Nudm_SDM.yaml", "IdTranslationResult"},
+}
+
+// specValidator validates the documents against the specifications of a directory
+type specValidator struct {
+	dir   string
+	mutex sync.Mutex
+	docs  map[string]*openapi3.T
+}
+
+func newSpecValidator(dir string) *specValidator {
+	return &specValidator{dir: dir, docs: make(map[string]*openapi3.T)}
+}
+
+var sbiSpecs = newSpecValidator(filepath.Join("testdata", "openapi"))
+
+func init() {
+	// the specifications identify the NF instances by uuid, which kin-openapi does not check by default
+	openapi3.DefineStringFormatValidator("uuid", openapi3.NewRegexpFormatValidator(openapi3.FormatOfStringForUUIDOfRFC4122))
+}
+
+// load parses a specification and the specifications it references, the error wraps
+// fs.ErrNotExist when the specification is not vendored
+func (v *specValidator) load(file string) (*openapi3.T, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if doc, ok := v.docs[file]; ok {
+		return doc, nil
+	}
+	path := filepath.Join(v.dir, file)
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	loader := openapi3.NewLoader()
+	loader.IsExternalRefsAllowed = true
+	doc, err := loader.LoadFromFile(path)
+	if err != nil {
+		// a missing reference is a broken vendoring, not a missing specification
+		return nil, fmt.Errorf("loading %s: %s", path, err.Error())
+	}
+	v.docs[file] = doc
+	return doc, nil
+}
+
+// validate returns the violations of a JSON document to a schema of the specifications,
+// or to an array of the schema when list is true
+func (v *specValidator) validate(body []byte, schema specSchema, list bool) ([]string, error) {
+	doc, err := v.load(schema.file)
+	if err != nil {
+		return nil, err
+	}
+	ref, ok := doc.Components.Schemas[schema.name]
+	if !ok || ref.Value == nil {
+		return nil, fmt.Errorf("no schema %s in %s", schema.name, schema.file)
+	}
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return []string{err.Error()}, nil
+	}
+	target := ref.Value
+	if list {
+		target = openapi3.NewArraySchema().WithItems(ref.Value)
+	}
+	err = target.VisitJSON(value, openapi3.MultiErrors())
+	if err == nil {
+		return nil, nil
+	}
+	var multi openapi3.MultiError
+	if !errors.As(err, &multi) {
+		return []string{err.Error()}, nil
+	}
+	violations := []string{}
+	for _, violation := range multi {
+		violations = append(violations, violation.Error())
+	}
+	return violations, nil
+}
+
+// specExtensions are the attributes the simulator sends on top of the specifications, as
+// <schema>.<attribute>
+var specExtensions = map[string]bool{
+	// the customized data of the SMF events, see the NsmfEventExposure notifications of the NEF
+	"EventNotification.customized_data": true,
+	// the RAN identities of the UE, used by the monitoring of the RAN
+	"AmfEventReport.suci":        true,
+	"AmfEventReport.amfUeNgapId": true,
+	"AmfEventReport.ranUeNgapId": true,
+	// the slice of the PDU sessions, which TS 29.122 does not report
+	"PdnConnectionInformation.snssai": true,
+}
+
+// undeclared returns the attributes of a JSON document that the schema of the specifications
+// does not declare, except the extensions of specExtensions
+func (v *specValidator) undeclared(body []byte, schema specSchema) ([]string, error) {
+	doc, err := v.load(schema.file)
+	if err != nil {
+		return nil, err
+	}
+	ref, ok := doc.Components.Schemas[schema.name]
+	if !ok || ref.Value == nil {
+		return nil, fmt.Errorf("no schema %s in %s", schema.name, schema.file)
+	}
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, err
+	}
+	attributes := []string{}
+	walkUndeclared(value, ref.Value, schema.name, "", &attributes)
+	sort.Strings(attributes)
+	return attributes, nil
+}
+
+// walkUndeclared appends the path of the attributes of value not declared by the schema, name
+// being the name of the schema in the components of the specifications
+func walkUndeclared(value any, schema *openapi3.Schema, name string, path string, attributes *[]string) {
+	switch value := value.(type) {
+	case []any:
+		for i, item := range value {
+			for _, items := range collectSchemas(schema, func(s *openapi3.Schema) *openapi3.SchemaRef { return s.Items }) {
+				walkUndeclared(item, items.Value, schemaName(items, name), fmt.Sprintf("%s[%d]", path, i), attributes)
+			}
+		}
+	case map[string]any:
+		properties := map[string]*openapi3.SchemaRef{}
+		for _, s := range collectSchemas(schema, nil) {
+			for key, property := range s.Value.Properties {
+				properties[key] = property
+			}
+		}
+		additional := collectSchemas(schema, func(s *openapi3.Schema) *openapi3.SchemaRef { return s.AdditionalProperties.Schema })
+		for key, attribute := range value {
+			if property, ok := properties[key]; ok {
+				walkUndeclared(attribute, property.Value, schemaName(property, name), path+"/"+key, attributes)
+				continue
+			}
+			if len(additional) > 0 {
+				for _, s := range additional {
+					walkUndeclared(attribute, s.Value, schemaName(s, name), path+"/"+key, attributes)
+				}
+				continue
+			}
+			if !specExtensions[name+"."+key] {
+				*attributes = append(*attributes, path+"/"+key)
+			}
+		}
+	}
+}
+
+// collectSchemas returns the schema and the schemas of its allOf, anyOf and oneOf, or the
+// schemas selected by child in them when child is not nil
+func collectSchemas(schema *openapi3.Schema, child func(*openapi3.Schema) *openapi3.SchemaRef) []*openapi3.SchemaRef {
+	schemas := []*openapi3.SchemaRef{}
+	var collect func(ref *openapi3.SchemaRef)
+	collect = func(ref *openapi3.SchemaRef) {
+		if ref == nil || ref.Value == nil {
+			return
+		}
+		if child == nil {
+			schemas = append(schemas, ref)
+		} else if selected := child(ref.Value); selected != nil && selected.Value != nil {
+			schemas = append(schemas, selected)
+		}
+		for _, composed := range [][]*openapi3.SchemaRef{ref.Value.AllOf, ref.Value.AnyOf, ref.Value.OneOf} {
+			for _, s := range composed {
+				collect(s)
+			}
+		}
+	}
+	collect(openapi3.NewSchemaRef("", schema))
+	return schemas
+}
+
+// schemaName returns the name of a referenced schema, or parent for an inline schema
+func schemaName(ref *openapi3.SchemaRef, parent string) string {
+	if ref.Ref == "" {
+		return parent
+	}
+	return ref.Ref[strings.LastIndex(ref.Ref, "/")+1:]
+}
+
+// checkSpec validates a document of the conformance tests against the specifications and
+// reports the attributes the specifications do not declare
+func checkSpec(t *testing.T, what string, body []byte, model any) {
+	t.Helper()
+	typ := reflect.TypeOf(model)
+	list := typ.Kind() == reflect.Slice
+	if list {
+		typ = typ.Elem()
+	}
+	schema, ok := specSchemas[typ]
+	if !ok {
+		t.Fatalf("%s: no schema of the specifications for %T", what, model)
+	}
+	violations, err := sbiSpecs.validate(body, schema, list)
+	if err != nil {
+		t.Fatalf("%s: %s", what, err.Error())
+	}
+	if len(violations) > 0 {
+		t.Errorf("%s: document does not conform to %s of %s:\n%s\n%s", what, schema.name, schema.file, strings.Join(violations, "\n"), body)
+	}
+	undeclared, err := sbiSpecs.undeclared(body, schema)
+	if err != nil {
+		t.Fatalf("%s: %s", what, err.Error())
+	}
+	if len(undeclared) > 0 {
+		t.Errorf("%s: attributes not declared by %s of %s: %s\n%s", what, schema.name, schema.file, strings.Join(undeclared, ", "), body)
+	}
+}
+
+func TestSpecsVendored(t *testing.T) {
+	missing := map[string]bool{}
+	for _, schema := range specSchemas {
+		if _, err := os.Stat(filepath.Join(sbiSpecs.dir, schema.file)); err != nil {
+			missing[schema.file] = true
+		}
+	}
+	if len(missing) > 0 {
+		files := []string{}
+		for file := range missing {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+		t.Fatalf("specifications not vendored in %s: %s", sbiSpecs.dir, strings.Join(files, ", "))
+	}
+	for _, schema := range specSchemas {
+		doc, err := sbiSpecs.load(schema.file)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if err := doc.Validate(context.Background()); err != nil {
+			t.Errorf("%s: %s", schema.file, err.Error())
+		}
+		if ref, ok := doc.Components.Schemas[schema.name]; !ok || ref.Value == nil {
+			t.Errorf("no schema %s in %s", schema.name, schema.file)
+		}
+	}
+}
+
+func TestSpecValidatorDetectsViolations(t *testing.T) {
+	// a minimal specification referencing a common data specification, as the 3GPP ones do
+	dir := t.TempDir()
+	writeSpec := func(file string, content string) {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeSpec("Common.yaml", `openapi: 3.0.0
+info: {title: Common, version: "1"}
+paths: {}
+components:
+  schemas:
+    DateTime: {type: string, format: date-time}
+`)
+	writeSpec("Events.yaml", `openapi: 3.0.0
+info: {title: Events, version: "1"}
+paths: {}
+components:
+  schemas:
+    Report:
+      type: object
+      required: [type, timeStamp]
+      properties:
+        type: {type: string, enum: [REGISTRATION_STATE_REPORT]}
+        timeStamp: {$ref: 'Common.yaml#/components/schemas/DateTime'}
+`)
+	v := newSpecValidator(dir)
+	schema := specSchema{"Events.yaml", "Report"}
+
+	violations, err := v.validate([]byte(`{"type":"REGISTRATION_STATE_REPORT","timeStamp":"2025-01-01T00:00:00Z"}`), schema, false)
+	if err != nil || len(violations) != 0 {
+		t.Fatalf("got violations %v and error %v, want a valid document", violations, err)
+	}
+	violations, err = v.validate([]byte(`{"type":"REGISTRATION_STATE_REPORT","timeStamp":"1735689600"}`), schema, false)
+	if err != nil || len(violations) != 1 {
+		t.Fatalf("got violations %v and error %v, want the epoch timestamp", violations, err)
+	}
+	violations, err = v.validate([]byte(`[{"type":"REGISTRATION_STATE_REPORT","timeStamp":"2025-01-01T00:00:00Z"},{"type":"LOCATION_REPORT"}]`), schema, true)
+	if err != nil || len(violations) == 0 {
+		t.Fatalf("got violations %v and error %v, want the invalid item", violations, err)
+	}
+	undeclared, err := v.undeclared([]byte(`{"type":"REGISTRATION_STATE_REPORT","timeStamp":"2025-01-01T00:00:00Z","state":"REGISTERED"}`), schema)
+	if err != nil || !reflect.DeepEqual(undeclared, []string{"/state"}) {
+		t.Fatalf("got undeclared attributes %v and error %v, want /state", undeclared, err)
+	}
+	if _, err := v.validate([]byte(`{}`), specSchema{"Missing.yaml", "Report"}, false); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("got error %v, want a missing specification", err)
+	}
+}
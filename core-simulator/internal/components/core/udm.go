@@ -0,0 +1,589 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* Unified Data Management, subscriber data, UE context management and event exposure of TS 29.503 */
+
+var (
+	groupIdPattern     = regexp.MustCompile(`^[A-Fa-f0-9]{8}-[0-9]{3}-[0-9]{2,3}-([A-Fa-f0-9][A-Fa-f0-9]){1,10}$`)
+	defaultUeAmbr      = models.Ambr{Uplink: "1 Gbps", Downlink: "2 Gbps"}
+	defaultSessionAmbr = models.Ambr{Uplink: "500 Mbps", Downlink: "1 Gbps"}
+)
+
+// UdmConfig describes the subscription of the UEs provisioned in the UDM. The UEs subscribe
+// to the DNN and the slice of the simulation profile, in addition to the configured ones.
+type UdmConfig struct {
+	// optional subscribed UE-AMBR, 1 Gbps uplink and 2 Gbps downlink by default
+	UeAmbr *models.Ambr `yaml:"ueAmbr,omitempty" json:"ueAmbr,omitempty"`
+	// optional session AMBR of the subscribed DNNs, 500 Mbps uplink and 1 Gbps downlink by default
+	SessionAmbr *models.Ambr `yaml:"sessionAmbr,omitempty" json:"sessionAmbr,omitempty"`
+	// optional additional DNNs and slices
+	Dnns   []string        `yaml:"dnns,omitempty" json:"dnns,omitempty"`
+	Slices []models.Snssai `yaml:"slices,omitempty" json:"slices,omitempty"`
+	// optional internal group ids of the subscribers by device class
+	GroupIds map[string][]string `yaml:"groupIds,omitempty" json:"groupIds,omitempty"`
+}
+
+func (cfg *UdmConfig) Validate() error {
+	for _, ambr := range []*models.Ambr{cfg.UeAmbr, cfg.SessionAmbr} {
+		if ambr != nil && (!bitRatePattern.MatchString(ambr.Uplink) || !bitRatePattern.MatchString(ambr.Downlink)) {
+			return fmt.Errorf("invalid AMBR %s/%s", ambr.Uplink, ambr.Downlink)
+		}
+	}
+	for _, dnn := range cfg.Dnns {
+		if dnn == "" {
+			return fmt.Errorf("empty DNN")
+		}
+	}
+	for _, slice := range cfg.Slices {
+		if slice.Sst < 0 || slice.Sst > 255 || (slice.Sd != nil && !sdPattern.MatchString(*slice.Sd)) {
+			return fmt.Errorf("invalid slice %d/%s", slice.Sst, sdOf(slice))
+		}
+	}
+	for deviceClass, groupIds := range cfg.GroupIds {
+		for _, groupId := range groupIds {
+			if !groupIdPattern.MatchString(groupId) {
+				return fmt.Errorf("device class %s: invalid internal group id %q", deviceClass, groupId)
+			}
+		}
+	}
+	return nil
+}
+
+// subscriber is the record of a UE provisioned in the UDM
+type subscriber struct {
+	supi     string
+	gpsi     string
+	groupIds []string
+}
+
+// udmEeSubscription is an event exposure subscription forwarded to the AMF
+type udmEeSubscription struct {
+	supi         string
+	subscription models.EeSubscription
+	// AMF subscriptions of the monitoring configurations
+	amfSubscriptions []string
+}
+
+// A Udm holds the subscriber records of the UEs of the simulation and the registrations of
+// their serving AMF and SMF, learnt from the events of the network functions. The monitoring
+// configurations of the event exposure subscriptions are forwarded to the AMF, which notifies
+// the subscribers directly.
+type Udm struct {
+	PlmnId models.PlmnId
+	UdmId  string
+	// NF instances serving the UEs
+	AmfInstanceId string
+	SmfInstanceId string
+	// consumer of the AMF services
+	sbiConsumer
+
+	instanceId  string
+	ueAmbr      models.Ambr
+	sessionAmbr models.Ambr
+	dnns        []string
+	slices      []models.Snssai
+	groupIds    map[string][]string
+
+	subscribers      map[string]*subscriber // supi -> record
+	gpsis            map[string]string      // GPSI without prefix -> supi
+	amfRegistrations map[string]*models.Amf3GppAccessRegistration
+	smfRegistrations map[string]map[int32]*models.SmfRegistration
+	eeSubscriptions  map[string]*udmEeSubscription
+	mutex            sync.RWMutex
+}
+
+// NewUdm creates a UDM provisioning the DNN and the slice of the network, a nil configuration takes the defaults
+func NewUdm(plmnId models.PlmnId, slice models.Snssai, dnn string, config *UdmConfig) *Udm {
+	if config == nil {
+		config = &UdmConfig{}
+	}
+	udm := &Udm{
+		PlmnId:           plmnId,
+		UdmId:            fmt.Sprintf("UDM-%s%s", plmnId.Mcc, plmnId.Mnc),
+		sbiConsumer:      newSbiConsumer(),
+		instanceId:       uuid.NewString(),
+		ueAmbr:           defaultUeAmbr,
+		sessionAmbr:      defaultSessionAmbr,
+		dnns:             []string{dnn},
+		slices:           []models.Snssai{slice},
+		groupIds:         config.GroupIds,
+		subscribers:      make(map[string]*subscriber),
+		gpsis:            make(map[string]string),
+		amfRegistrations: make(map[string]*models.Amf3GppAccessRegistration),
+		smfRegistrations: make(map[string]map[int32]*models.SmfRegistration),
+		eeSubscriptions:  make(map[string]*udmEeSubscription),
+	}
+	if config.UeAmbr != nil {
+		udm.ueAmbr = *config.UeAmbr
+	}
+	if config.SessionAmbr != nil {
+		udm.sessionAmbr = *config.SessionAmbr
+	}
+	for _, extra := range config.Dnns {
+		if !slices.Contains(udm.dnns, extra) {
+			udm.dnns = append(udm.dnns, extra)
+		}
+	}
+	for _, extra := range config.Slices {
+		if !slices.ContainsFunc(udm.slices, func(s models.Snssai) bool { return sameSnssai(s, extra) }) {
+			udm.slices = append(udm.slices, extra)
+		}
+	}
+	return udm
+}
+
+func sameSnssai(a models.Snssai, b models.Snssai) bool {
+	return a.Sst == b.Sst && strings.EqualFold(sdOf(a), sdOf(b))
+}
+
+func sdOf(s models.Snssai) string {
+	if s.Sd == nil {
+		return ""
+	}
+	return *s.Sd
+}
+
+// Provision creates the record of a subscriber, the GPSI is a MSISDN or an external identifier
+func (udm *Udm) Provision(supi string, gpsi string, deviceClass string) {
+	udm.mutex.Lock()
+	defer udm.mutex.Unlock()
+
+	supi = strings.TrimPrefix(supi, "imsi-")
+	udm.subscribers[supi] = &subscriber{
+		supi:     supi,
+		gpsi:     gpsi,
+		groupIds: udm.groupIds[deviceClass],
+	}
+	if gpsi != "" {
+		udm.gpsis[gpsiKey(gpsi)] = supi
+	}
+}
+
+// gpsiKey returns a GPSI without its type prefix and the + of the MSISDNs
+func gpsiKey(gpsi string) string {
+	gpsi = strings.TrimPrefix(strings.TrimPrefix(gpsi, "msisdn-"), "extid-")
+	return strings.TrimPrefix(gpsi, "+")
+}
+
+// formatGpsi returns a GPSI with its type prefix, TS 29.571 clause 5.3.2
+func formatGpsi(gpsi string) string {
+	if strings.Contains(gpsi, "@") {
+		return "extid-" + gpsi
+	}
+	return "msisdn-" + strings.TrimPrefix(gpsi, "+")
+}
+
+// lookup returns the subscriber identified by a SUPI or a GPSI, the local identifier
+// of an external identifier also matches the MSISDN of the subscriber
+func (udm *Udm) lookup(ueId string) (*subscriber, bool) {
+	udm.mutex.RLock()
+	defer udm.mutex.RUnlock()
+
+	if sub, ok := udm.subscribers[strings.TrimPrefix(ueId, "imsi-")]; ok {
+		return sub, true
+	}
+	key := gpsiKey(ueId)
+	if supi, ok := udm.gpsis[key]; ok {
+		return udm.subscribers[supi], true
+	}
+	if local, _, ok := strings.Cut(key, "@"); ok {
+		if supi, ok := udm.gpsis[local]; ok {
+			return udm.subscribers[supi], true
+		}
+	}
+	return nil, false
+}
+
+// ResolveUe returns the SUPI of the subscriber with the given MSISDN or external identifier
+func (udm *Udm) ResolveUe(id string) (string, bool) {
+	sub, ok := udm.lookup(id)
+	if !ok {
+		return "", false
+	}
+	return sub.supi, true
+}
+
+// ObserveAmfEvent keeps the registration of the serving AMF of the UEs
+func (udm *Udm) ObserveAmfEvent(report models.AmfEventReport) {
+	supi := strings.TrimPrefix(report.GetSupi(), "imsi-")
+	registered, deregistered := false, false
+	switch report.Type {
+	case models.AMFEVENTTYPEANYOF_REGISTRATION_STATE_REPORT:
+		if len(report.RmInfoList) == 0 {
+			return
+		}
+		registered = report.RmInfoList[0].RmState == models.RmStateRegistered
+		deregistered = !registered
+	case models.AMFEVENTTYPEANYOF_LOSS_OF_CONNECTIVITY:
+		// a UE out of reach stays registered until it is deregistered
+		deregistered = report.LossOfConnectReason != models.LOSSOFCONNECTIVITYREASONANYOF_MAX_DETECTION_TIME_EXPIRED
+	default:
+		return
+	}
+
+	udm.mutex.Lock()
+	defer udm.mutex.Unlock()
+	_, known := udm.subscribers[supi]
+	_, wasRegistered := udm.amfRegistrations[supi]
+	switch {
+	case registered && known:
+		udm.amfRegistrations[supi] = &models.Amf3GppAccessRegistration{
+			AmfInstanceId: udm.AmfInstanceId,
+			// the UDM never deregisters the AMF, the callback is not served
+			DeregCallbackUri:       udm.ApiRoot + "/namf-callback/v1/imsi-" + supi + "/dereg-notify",
+			Supi:                   models.PtrString("imsi-" + supi),
+			Pei:                    report.Pei,
+			InitialRegistrationInd: models.PtrBool(!wasRegistered),
+			Guami: models.Guami{
+				PlmnId: models.PlmnIdNid{Mcc: udm.PlmnId.Mcc, Mnc: udm.PlmnId.Mnc},
+				AmfId:  amfIdentifier,
+			},
+			RatType:          "NR",
+			RegistrationTime: report.TimeStamp,
+		}
+	case deregistered:
+		delete(udm.amfRegistrations, supi)
+	}
+}
+
+// ObserveSmfEvent keeps the registrations of the SMF serving the PDU sessions of the UEs
+func (udm *Udm) ObserveSmfEvent(event models.EventNotification) {
+	supi := strings.TrimPrefix(event.GetSupi(), "imsi-")
+	if event.PduSeId == nil {
+		return
+	}
+
+	udm.mutex.Lock()
+	defer udm.mutex.Unlock()
+	switch event.Event {
+	case models.SMFEVENTANYOF_PDU_SES_EST:
+		if _, known := udm.subscribers[supi]; !known {
+			return
+		}
+		registration := &models.SmfRegistration{
+			SmfInstanceId:    udm.SmfInstanceId,
+			PduSessionId:     *event.PduSeId,
+			Dnn:              event.Dnn,
+			PlmnId:           udm.PlmnId,
+			RegistrationTime: event.TimeStamp,
+		}
+		if event.Snssai != nil {
+			registration.SingleNssai = *event.Snssai
+		}
+		if udm.smfRegistrations[supi] == nil {
+			udm.smfRegistrations[supi] = make(map[int32]*models.SmfRegistration)
+		}
+		udm.smfRegistrations[supi][*event.PduSeId] = registration
+	case models.SMFEVENTANYOF_PDU_SES_REL:
+		delete(udm.smfRegistrations[supi], *event.PduSeId)
+		if len(udm.smfRegistrations[supi]) == 0 {
+			delete(udm.smfRegistrations, supi)
+		}
+	}
+}
+
+// NORTHBOUND Definitions
+
+// subscriberOf returns the subscriber of the request, it answers USER_NOT_FOUND if there is none
+func (udm *Udm) subscriberOf(w http.ResponseWriter, r *http.Request) (*subscriber, bool) {
+	ueId := mux.Vars(r)["ueId"]
+	sub, ok := udm.lookup(ueId)
+	if !ok {
+		WriteProblem(w, http.StatusNotFound, CauseUserNotFound, "no subscriber is identified by "+ueId)
+	}
+	return sub, ok
+}
+
+func (udm *Udm) reply(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("[%s] could not serialize response body: %s", udm.UdmId, err.Error())
+	}
+}
+
+// HandleGetAmData answers with the access and mobility subscription data, TS 29.503 clause 6.1.3.5
+func (udm *Udm) HandleGetAmData(w http.ResponseWriter, r *http.Request) {
+	sub, ok := udm.subscriberOf(w, r)
+	if !ok {
+		return
+	}
+	data := &models.AccessAndMobilitySubscriptionData{
+		InternalGroupIds: sub.groupIds,
+		SubscribedUeAmbr: &udm.ueAmbr,
+		Nssai: &models.Nssai{
+			DefaultSingleNssais: udm.slices[:1],
+			SingleNssais:        udm.slices[1:],
+		},
+	}
+	if sub.gpsi != "" {
+		data.Gpsis = []string{formatGpsi(sub.gpsi)}
+	}
+	udm.reply(w, http.StatusOK, data)
+}
+
+// HandleGetSmData answers with the session management subscription data of the slices,
+// filtered by the single-nssai and dnn query parameters, TS 29.503 clause 6.1.3.7
+func (udm *Udm) HandleGetSmData(w http.ResponseWriter, r *http.Request) {
+	sub, ok := udm.subscriberOf(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	var filter *models.Snssai
+	if raw := query.Get("single-nssai"); raw != "" {
+		filter = &models.Snssai{}
+		if err := json.Unmarshal([]byte(raw), filter); err != nil {
+			WriteProblem(w, http.StatusBadRequest, CauseInvalidQueryParam, "single-nssai is not a S-NSSAI",
+				InvalidParam("single-nssai", err.Error()))
+			return
+		}
+	}
+	dnn := query.Get("dnn")
+
+	data := []models.SessionManagementSubscriptionData{}
+	for _, slice := range udm.slices {
+		if filter != nil && !sameSnssai(slice, *filter) {
+			continue
+		}
+		configurations := make(map[string]models.DnnConfiguration)
+		for _, subscribed := range udm.dnns {
+			if dnn == "" || dnn == subscribed {
+				configurations[subscribed] = udm.dnnConfiguration()
+			}
+		}
+		data = append(data, models.SessionManagementSubscriptionData{
+			SingleNssai:       slice,
+			DnnConfigurations: configurations,
+			InternalGroupIds:  sub.groupIds,
+		})
+	}
+	udm.reply(w, http.StatusOK, data)
+}
+
+// dnnConfiguration is the subscription to a DNN: IPv4 sessions with the default QoS of TS 23.501
+func (udm *Udm) dnnConfiguration() models.DnnConfiguration {
+	return models.DnnConfiguration{
+		PduSessionTypes: models.PduSessionTypes{DefaultSessionType: "IPV4", AllowedSessionTypes: []string{"IPV4"}},
+		SscModes:        models.SscModes{DefaultSscMode: "SSC_MODE_1", AllowedSscModes: []string{"SSC_MODE_1"}},
+		Var5gQosProfile: &models.SubscribedDefaultQos{
+			Var5qi: 9,
+			Arp:    models.SubscribedArp{PriorityLevel: 8, PreemptCap: "NOT_PREEMPT", PreemptVuln: "PREEMPTABLE"},
+		},
+		SessionAmbr: &udm.sessionAmbr,
+	}
+}
+
+// HandleGetIdTranslation maps a GPSI to the SUPI of the subscriber, TS 29.503 clause 6.1.3.8
+func (udm *Udm) HandleGetIdTranslation(w http.ResponseWriter, r *http.Request) {
+	sub, ok := udm.subscriberOf(w, r)
+	if !ok {
+		return
+	}
+	result := &models.IdTranslationResult{Supi: "imsi-" + sub.supi}
+	if sub.gpsi != "" {
+		result.Gpsi = models.PtrString(formatGpsi(sub.gpsi))
+	}
+	udm.reply(w, http.StatusOK, result)
+}
+
+// HandleGetAmfRegistration answers with the registration of the serving AMF, TS 29.503 clause 6.2.3.2
+func (udm *Udm) HandleGetAmfRegistration(w http.ResponseWriter, r *http.Request) {
+	sub, ok := udm.subscriberOf(w, r)
+	if !ok {
+		return
+	}
+	udm.mutex.RLock()
+	registration, registered := udm.amfRegistrations[sub.supi]
+	var body models.Amf3GppAccessRegistration
+	if registered {
+		body = *registration
+	}
+	udm.mutex.RUnlock()
+	if !registered {
+		WriteProblem(w, http.StatusNotFound, CauseContextNotFound, "the UE is not registered")
+		return
+	}
+	udm.reply(w, http.StatusOK, &body)
+}
+
+// HandleGetSmfRegistrations answers with the registrations of the SMF serving the PDU
+// sessions, TS 29.503 clause 6.2.3.4
+func (udm *Udm) HandleGetSmfRegistrations(w http.ResponseWriter, r *http.Request) {
+	sub, ok := udm.subscriberOf(w, r)
+	if !ok {
+		return
+	}
+	udm.mutex.RLock()
+	info := &models.SmfRegistrationInfo{SmfRegistrationList: []models.SmfRegistration{}}
+	for _, registration := range udm.smfRegistrations[sub.supi] {
+		info.SmfRegistrationList = append(info.SmfRegistrationList, *registration)
+	}
+	udm.mutex.RUnlock()
+	if len(info.SmfRegistrationList) == 0 {
+		WriteProblem(w, http.StatusNotFound, CauseContextNotFound, "the UE has no PDU session")
+		return
+	}
+	slices.SortFunc(info.SmfRegistrationList, func(a, b models.SmfRegistration) int {
+		return int(a.PduSessionId - b.PduSessionId)
+	})
+	udm.reply(w, http.StatusOK, info)
+}
+
+// eeAmfEvents maps the monitoring configurations of the UDM event exposure to the AMF events
+var eeAmfEvents = map[models.EeMonitoringType]models.AmfEventTypeAnyOf{
+	models.EeMonitoringTypeLossOfConnectivity:    models.AMFEVENTTYPEANYOF_LOSS_OF_CONNECTIVITY,
+	models.EeMonitoringTypeUeReachabilityForData: models.AMFEVENTTYPEANYOF_CONNECTIVITY_STATE_REPORT,
+	models.EeMonitoringTypeLocationReporting:     models.AMFEVENTTYPEANYOF_LOCATION_REPORT,
+}
+
+// HandleNewEeSubscription forwards the monitoring configurations of an event exposure subscription
+// to the AMF, TS 29.503 clause 6.4.3.2
+func (udm *Udm) HandleNewEeSubscription(w http.ResponseWriter, r *http.Request) {
+	eeSub := &models.EeSubscription{}
+	if !decodeRequest(w, r, eeSub, WriteProblem) {
+		return
+	}
+
+	v := newValidator(udm.PlmnId)
+	if v.mandatory("/callbackReference", eeSub.CallbackReference != "") {
+		v.uri(true, "/callbackReference", eeSub.CallbackReference)
+	}
+	references := make([]string, 0, len(eeSub.MonitoringConfigurations))
+	for reference := range eeSub.MonitoringConfigurations {
+		references = append(references, reference)
+	}
+	slices.Sort(references)
+	if v.mandatory("/monitoringConfigurations", len(references) > 0) {
+		for _, reference := range references {
+			if _, ok := eeAmfEvents[eeSub.MonitoringConfigurations[reference].EventType]; !ok {
+				v.incorrect(true, "/monitoringConfigurations/"+reference+"/eventType", "not supported")
+			}
+		}
+	}
+	if options := eeSub.ReportingOptions; options != nil && options.MaxNumOfReports != nil && *options.MaxNumOfReports < 1 {
+		v.incorrect(false, "/reportingOptions/maxNumOfReports", "not a positive number")
+	}
+	if eeSub.SupportedFeatures != nil {
+		v.features(false, "/supportedFeatures", *eeSub.SupportedFeatures)
+	}
+	if v.reply(w, WriteProblem) {
+		return
+	}
+	sub, ok := udm.subscriberOf(w, r)
+	if !ok {
+		return
+	}
+
+	id := uuid.NewString()
+	record := &udmEeSubscription{supi: sub.supi, subscription: *eeSub}
+	for _, reference := range references {
+		location, err := udm.subscribeAmf(sub.supi, reference, eeSub, eeAmfEvents[eeSub.MonitoringConfigurations[reference].EventType])
+		if err != nil {
+			udm.release(record)
+			relayError(w, err)
+			return
+		}
+		record.amfSubscriptions = append(record.amfSubscriptions, location)
+	}
+
+	udm.mutex.Lock()
+	udm.eeSubscriptions[id] = record
+	udm.mutex.Unlock()
+
+	w.Header().Set("Location", fmt.Sprintf("/nudm-ee/v1/%s/ee-subscriptions/%s", mux.Vars(r)["ueId"], id))
+	udm.reply(w, http.StatusCreated, &models.CreatedEeSubscription{EeSubscription: *eeSub})
+	log.Printf("[%s] created event exposure subscription %s", udm.UdmId, id)
+}
+
+// subscribeAmf subscribes the consumer to the AMF event of a monitoring configuration,
+// the reference id of the configuration is the correlation id of the notifications
+func (udm *Udm) subscribeAmf(supi string, reference string, eeSub *models.EeSubscription, eventType models.AmfEventTypeAnyOf) (string, error) {
+	trigger := models.AMFEVENTTRIGGERANYOF_CONTINUOUS
+	options := &models.AmfEventMode{Trigger: models.AmfEventTrigger{AmfEventTriggerAnyOf: &trigger}}
+	if reporting := eeSub.ReportingOptions; reporting != nil {
+		options.MaxReports, options.Expiry = reporting.MaxNumOfReports, reporting.Expiry
+	}
+	create := &models.AmfCreateEventSubscription{
+		Subscription: models.AmfEventSubscription{
+			EventList:           []models.AmfEvent{{Type: eventType}},
+			EventNotifyUri:      eeSub.CallbackReference,
+			NotifyCorrelationId: reference,
+			NfId:                udm.instanceId,
+			Supi:                models.PtrString("imsi-" + supi),
+			Options:             options,
+		},
+	}
+	location, err := udm.call(http.MethodPost, "/namf-evts/v1/subscriptions", create)
+	return strings.TrimPrefix(location, udm.ApiRoot), err
+}
+
+// release deletes the AMF subscriptions of an event exposure subscription
+func (udm *Udm) release(record *udmEeSubscription) {
+	for _, location := range record.amfSubscriptions {
+		// the AMF may have removed the subscription already, e.g. after its last report
+		if _, err := udm.call(http.MethodDelete, location, nil); err != nil {
+			log.Printf("[%s] could not release %s: %s", udm.UdmId, location, err.Error())
+		}
+	}
+}
+
+// HandleDeleteEeSubscription removes an event exposure subscription, TS 29.503 clause 6.4.3.3
+func (udm *Udm) HandleDeleteEeSubscription(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["subscriptionId"]
+	udm.mutex.Lock()
+	record, ok := udm.eeSubscriptions[id]
+	delete(udm.eeSubscriptions, id)
+	udm.mutex.Unlock()
+	if !ok {
+		WriteProblem(w, http.StatusNotFound, CauseSubscriptionNotFound, "subscription "+id+" is not found")
+		return
+	}
+	udm.release(record)
+	w.WriteHeader(http.StatusNoContent)
+	log.Printf("[%s] deleted event exposure subscription %s", udm.UdmId, id)
+}
+
+func (udm *Udm) RegisterNorthboundAPIs(r *mux.Router) {
+	r.HandleFunc("/nudm-sdm/v2/{ueId}/am-data", udm.HandleGetAmData).Methods(http.MethodGet)
+	r.HandleFunc("/nudm-sdm/v2/{ueId}/sm-data", udm.HandleGetSmData).Methods(http.MethodGet)
+	r.HandleFunc("/nudm-sdm/v2/{ueId}/id-translation-result", udm.HandleGetIdTranslation).Methods(http.MethodGet)
+	r.HandleFunc("/nudm-uecm/v1/{ueId}/registrations/amf-3gpp-access", udm.HandleGetAmfRegistration).Methods(http.MethodGet)
+	r.HandleFunc("/nudm-uecm/v1/{ueId}/registrations/smf-registrations", udm.HandleGetSmfRegistrations).Methods(http.MethodGet)
+	r.HandleFunc("/nudm-ee/v1/{ueId}/ee-subscriptions", udm.HandleNewEeSubscription).Methods(http.MethodPost)
+	r.HandleFunc("/nudm-ee/v1/{ueId}/ee-subscriptions/{subscriptionId}", udm.HandleDeleteEeSubscription).Methods(http.MethodDelete)
+	log.Printf("[%s] nudm-sdm, nudm-uecm and nudm-ee have been registered", udm.UdmId)
+}
@@ -0,0 +1,186 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* UDM subscriber data, UE context management and event exposure */
+
+// get decodes the body of a successful GET on the SBI
+func (n *conformanceNetwork) get(t *testing.T, path string, dst any) {
+	t.Helper()
+	resp, err := http.Get(n.server.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s: got status %d", path, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUdmSubscriberData(t *testing.T) {
+	n := newConformanceNetwork(t)
+	n.udm.Provision("imsi-"+testSupi, "+"+testMsisdn, "")
+
+	n.call(t, http.MethodGet, "/nudm-sdm/v2/imsi-"+testSupi+"/am-data", ``, http.StatusOK, models.AccessAndMobilitySubscriptionData{})
+	amData := models.AccessAndMobilitySubscriptionData{}
+	n.get(t, "/nudm-sdm/v2/msisdn-"+testMsisdn+"/am-data", &amData)
+	if len(amData.Gpsis) != 1 || amData.Gpsis[0] != "msisdn-"+testMsisdn || amData.SubscribedUeAmbr.Uplink != "1 Gbps" {
+		t.Errorf("got access and mobility data %+v", amData)
+	}
+
+	n.call(t, http.MethodGet, "/nudm-sdm/v2/imsi-"+testSupi+"/sm-data", ``, http.StatusOK, []models.SessionManagementSubscriptionData{})
+	smData := []models.SessionManagementSubscriptionData{}
+	n.get(t, "/nudm-sdm/v2/imsi-"+testSupi+"/sm-data?dnn=internet", &smData)
+	if len(smData) != 1 || len(smData[0].DnnConfigurations) != 1 || smData[0].DnnConfigurations["internet"].SessionAmbr == nil {
+		t.Errorf("got session management data %+v", smData)
+	}
+	// the filters exclude the data of the other slices and DNNs
+	n.get(t, "/nudm-sdm/v2/imsi-"+testSupi+"/sm-data?single-nssai="+url.QueryEscape(`{"sst":2}`), &smData)
+	if len(smData) != 0 {
+		t.Errorf("got session management data %+v of another slice", smData)
+	}
+	n.get(t, "/nudm-sdm/v2/imsi-"+testSupi+"/sm-data?dnn=ims", &smData)
+	if len(smData) != 1 || len(smData[0].DnnConfigurations) != 0 {
+		t.Errorf("got session management data %+v of another DNN", smData)
+	}
+
+	n.call(t, http.MethodGet, "/nudm-sdm/v2/msisdn-"+testMsisdn+"/id-translation-result", ``, http.StatusOK, models.IdTranslationResult{})
+	result := models.IdTranslationResult{}
+	n.get(t, "/nudm-sdm/v2/msisdn-"+testMsisdn+"/id-translation-result", &result)
+	if result.Supi != "imsi-"+testSupi {
+		t.Errorf("got SUPI %q, want imsi-%s", result.Supi, testSupi)
+	}
+	if supi, ok := n.udm.ResolveUe(testMsisdn + "@coresim.org"); !ok || supi != testSupi {
+		t.Errorf("got SUPI %q of the external identifier, want %q", supi, testSupi)
+	}
+
+	n.call(t, http.MethodGet, "/nudm-sdm/v2/imsi-001060000000002/am-data", ``, http.StatusNotFound, models.ProblemDetails{})
+	n.call(t, http.MethodGet, "/nudm-sdm/v2/imsi-"+testSupi+"/sm-data?single-nssai=1", ``, http.StatusBadRequest, models.ProblemDetails{})
+}
+
+func TestUdmUeContextManagement(t *testing.T) {
+	n := newConformanceNetwork(t)
+	n.udm.Provision(testSupi, "+"+testMsisdn, "")
+	n.amf.RegisterObserver(n.udm.ObserveAmfEvent)
+	n.smf.RegisterObserver(n.udm.ObserveSmfEvent)
+	registrations := "/nudm-uecm/v1/imsi-" + testSupi + "/registrations/"
+
+	n.call(t, http.MethodGet, registrations+"amf-3gpp-access", ``, http.StatusNotFound, models.ProblemDetails{})
+	ue := models.UeToAmfMsg{
+		EventType:     models.AMFEVENTTYPEANYOF_REGISTRATION_STATE_REPORT,
+		TimeStamp:     time.Now(),
+		Supi:          testSupi,
+		PlmnId:        testPlmn,
+		CurrentCellId: "000000001",
+		AccessType:    models.ACCESSTYPE__3_GPP_ACCESS,
+		RmState:       models.RmStateRegistered,
+		CmState:       models.CmStateConnected,
+	}
+	n.amf.handleUeToAmfEvent(&ue)
+	n.call(t, http.MethodGet, registrations+"amf-3gpp-access", ``, http.StatusOK, models.Amf3GppAccessRegistration{})
+
+	session := models.UeToSmfMsg{
+		EventType:   models.SMFEVENTANYOF_PDU_SES_EST,
+		TimeStamp:   time.Now(),
+		Supi:        testSupi,
+		PlmnId:      testPlmn,
+		AccessType:  models.ACCESSTYPE__3_GPP_ACCESS,
+		Dnn:         "internet",
+		Snssai:      models.Snssai{Sst: 1},
+		UeAddress:   "10.0.0.1",
+		PduSessType: models.PDUSESSIONTYPEANYOF_IPV4,
+		PduSessId:   1,
+	}
+	n.smf.handleUeToSmfEvent(&session)
+	n.call(t, http.MethodGet, registrations+"smf-registrations", ``, http.StatusOK, models.SmfRegistrationInfo{})
+
+	session.EventType = models.SMFEVENTANYOF_PDU_SES_REL
+	n.smf.handleUeToSmfEvent(&session)
+	n.call(t, http.MethodGet, registrations+"smf-registrations", ``, http.StatusNotFound, models.ProblemDetails{})
+
+	// a UE out of reach stays registered until the AMF deregisters it
+	ue.EventType, ue.LossReason = models.AMFEVENTTYPEANYOF_LOSS_OF_CONNECTIVITY, models.LOSSOFCONNECTIVITYREASONANYOF_MAX_DETECTION_TIME_EXPIRED
+	n.amf.handleUeToAmfEvent(&ue)
+	n.call(t, http.MethodGet, registrations+"amf-3gpp-access", ``, http.StatusOK, models.Amf3GppAccessRegistration{})
+	ue.EventType, ue.RmState = models.AMFEVENTTYPEANYOF_REGISTRATION_STATE_REPORT, models.RmStateDeregistered
+	n.amf.handleUeToAmfEvent(&ue)
+	n.call(t, http.MethodGet, registrations+"amf-3gpp-access", ``, http.StatusNotFound, models.ProblemDetails{})
+}
+
+func TestUdmEventExposure(t *testing.T) {
+	n := newConformanceNetwork(t)
+	n.udm.Provision(testSupi, "+"+testMsisdn, "")
+
+	resp := n.call(t, http.MethodPost, "/nudm-ee/v1/msisdn-"+testMsisdn+"/ee-subscriptions", `{"callbackReference":"`+n.sinkUrl+`/ee",
+		"monitoringConfigurations":{"1":{"eventType":"LOSS_OF_CONNECTIVITY","immediateFlag":false}},
+		"reportingOptions":{"maxNumOfReports":5}}`,
+		http.StatusCreated, models.CreatedEeSubscription{})
+	subscription := resp.Header.Get("Location")
+	if len(n.amf.GetSubscriptions()) != 1 {
+		t.Fatalf("got %d AMF subscriptions, want 1", len(n.amf.GetSubscriptions()))
+	}
+
+	// the AMF notifies the consumer directly with the reference id of the monitoring configuration
+	n.amf.handleUeToAmfEvent(&models.UeToAmfMsg{
+		EventType:     models.AMFEVENTTYPEANYOF_LOSS_OF_CONNECTIVITY,
+		TimeStamp:     time.Now(),
+		Supi:          testSupi,
+		PlmnId:        testPlmn,
+		CurrentCellId: "000000001",
+		AccessType:    models.ACCESSTYPE__3_GPP_ACCESS,
+		RmState:       models.RmStateDeregistered,
+		CmState:       models.CmStateIdle,
+		LossReason:    models.LOSSOFCONNECTIVITYREASONANYOF_PURGED,
+	})
+	n.notifications(t, "/ee", 1, models.AmfEventNotification{})
+	notification := models.AmfEventNotification{}
+	n.decodeNotification(t, "/ee", 0, &notification)
+	if notification.GetNotifyCorrelationId() != "1" {
+		t.Errorf("got correlation id %q, want the reference id 1", notification.GetNotifyCorrelationId())
+	}
+
+	n.call(t, http.MethodDelete, subscription, ``, http.StatusNoContent, nil)
+	if len(n.amf.GetSubscriptions()) != 0 {
+		t.Errorf("the AMF subscription of the deleted event exposure subscription is left")
+	}
+	n.call(t, http.MethodDelete, subscription, ``, http.StatusNotFound, models.ProblemDetails{})
+
+	n.call(t, http.MethodPost, "/nudm-ee/v1/imsi-001060000000002/ee-subscriptions", `{"callbackReference":"`+n.sinkUrl+`/ee",
+		"monitoringConfigurations":{"1":{"eventType":"LOSS_OF_CONNECTIVITY"}}}`,
+		http.StatusNotFound, models.ProblemDetails{})
+	n.call(t, http.MethodPost, "/nudm-ee/v1/imsi-"+testSupi+"/ee-subscriptions", `{"callbackReference":"`+n.sinkUrl+`/ee",
+		"monitoringConfigurations":{"1":{"eventType":"ROAMING_STATUS"}}}`,
+		http.StatusBadRequest, models.ProblemDetails{})
+}
@@ -0,0 +1,418 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* Validation of the SBI requests against the mandatory attributes and formats of the specifications */
+
+var (
+	sdPattern        = regexp.MustCompile(`^[A-Fa-f0-9]{6}$`)
+	mccPattern       = regexp.MustCompile(`^[0-9]{3}$`)
+	mncPattern       = regexp.MustCompile(`^[0-9]{2,3}$`)
+	imsiPattern      = regexp.MustCompile(`^(imsi-)?([0-9]{5,15})$`)
+	msisdnPattern    = regexp.MustCompile(`^msisdn-[0-9]{5,15}$`)
+	extIdPattern     = regexp.MustCompile(`^extid-[^@]+@[^@]+$`)
+	featuresPattern  = regexp.MustCompile(`^[A-Fa-f0-9]*$`)
+	bitRatePattern   = regexp.MustCompile(`^\d+(\.\d+)? (bps|Kbps|Mbps|Gbps|Tbps)$`)
+	supportedMedType = []string{"AUDIO", "VIDEO", "DATA", "APPLICATION", "CONTROL", "TEXT", "MESSAGE", "OTHER"}
+)
+
+// validator collects the invalid attributes of a request. The attributes are named by
+// their JSON pointer in the request body, the cause of the error is the one of the
+// first invalid attribute.
+type validator struct {
+	plmn   models.PlmnId
+	cause  string
+	params []models.InvalidParam
+}
+
+func newValidator(plmn models.PlmnId) *validator {
+	return &validator{plmn: plmn}
+}
+
+func (v *validator) fail(cause string, param string, reason string) {
+	if v.cause == "" {
+		v.cause = cause
+	}
+	v.params = append(v.params, InvalidParam(param, reason))
+}
+
+// incorrect reports an attribute with an invalid value
+func (v *validator) incorrect(mandatory bool, param string, reason string) {
+	if mandatory {
+		v.fail(CauseMandatoryIeIncorrect, param, reason)
+	} else {
+		v.fail(CauseOptionalIeIncorrect, param, reason)
+	}
+}
+
+// mandatory reports a missing mandatory attribute, it returns whether the attribute is present
+func (v *validator) mandatory(param string, present bool) bool {
+	if !present {
+		v.fail(CauseMandatoryIeMissing, param, "missing")
+	}
+	return present
+}
+
+// reply answers with the invalid attributes of the request, it returns false if the request is valid
+func (v *validator) reply(w http.ResponseWriter, write func(http.ResponseWriter, int, string, string, ...models.InvalidParam)) bool {
+	if v.cause == "" {
+		return false
+	}
+	write(w, http.StatusBadRequest, v.cause, fmt.Sprintf("%d invalid attributes", len(v.params)), v.params...)
+	return true
+}
+
+func (v *validator) uri(mandatory bool, param string, value string) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.incorrect(mandatory, param, "not an absolute http uri")
+	}
+}
+
+func (v *validator) nfInstanceId(param string, value string) {
+	if _, err := uuid.Parse(value); err != nil {
+		v.incorrect(true, param, "not a UUID")
+	}
+}
+
+// plmnId checks the format of a PLMN, the simulated network serves a single PLMN
+func (v *validator) plmnId(mandatory bool, param string, mcc string, mnc string) {
+	switch {
+	case !mccPattern.MatchString(mcc):
+		v.incorrect(mandatory, param+"/mcc", "not a MCC")
+	case !mncPattern.MatchString(mnc):
+		v.incorrect(mandatory, param+"/mnc", "not a MNC")
+	case mcc != v.plmn.Mcc || mnc != v.plmn.Mnc:
+		v.incorrect(mandatory, param, fmt.Sprintf("PLMN %s%s is not served by the network", mcc, mnc))
+	}
+}
+
+func (v *validator) snssai(mandatory bool, param string, sst int32, sd *string) {
+	if sst < 0 || sst > 255 {
+		v.incorrect(mandatory, param+"/sst", "not in 0..255")
+	}
+	if sd != nil && !sdPattern.MatchString(*sd) {
+		v.incorrect(mandatory, param+"/sd", "not 6 hexadecimal digits")
+	}
+}
+
+// supi checks a SUPI, the IMSIs are accepted with or without the imsi- prefix and
+// must belong to the PLMN of the network
+func (v *validator) supi(param string, value string) {
+	if imsi := imsiPattern.FindStringSubmatch(value); imsi != nil {
+		if !strings.HasPrefix(imsi[2], v.plmn.Mcc+v.plmn.Mnc) {
+			v.incorrect(false, param, "IMSI out of the PLMN of the network")
+		}
+		return
+	}
+	for _, prefix := range []string{"nai-", "gci-", "gli-"} {
+		if strings.HasPrefix(value, prefix) && len(value) > len(prefix) {
+			return
+		}
+	}
+	v.incorrect(false, param, "not a SUPI")
+}
+
+func (v *validator) gpsi(param string, value string) {
+	if !msisdnPattern.MatchString(value) && !extIdPattern.MatchString(value) {
+		v.incorrect(false, param, "not a GPSI")
+	}
+}
+
+func (v *validator) features(mandatory bool, param string, value string) {
+	if !featuresPattern.MatchString(value) {
+		v.incorrect(mandatory, param, "not an hexadecimal string")
+	}
+}
+
+// extensibleEnum checks the value of an extensible enumeration: a value outside of the
+// enumeration is accepted by the models but not supported by the network functions
+func (v *validator) extensibleEnum(mandatory bool, param string, value json.Marshaler, known bool) {
+	if known {
+		return
+	}
+	raw, _ := value.MarshalJSON()
+	if len(raw) == 0 {
+		if mandatory {
+			v.mandatory(param, false)
+		}
+		return
+	}
+	v.incorrect(mandatory, param, "unsupported value "+string(raw))
+}
+
+// decodeRequest decodes the body of a request, on failure it answers with the attribute of the
+// wrong type or the required property missing, as checked by the models
+func decodeRequest(w http.ResponseWriter, r *http.Request, dst any, write func(http.ResponseWriter, int, string, string, ...models.InvalidParam)) bool {
+	err := json.NewDecoder(r.Body).Decode(dst)
+	if err == nil {
+		return true
+	}
+	var typeErr *json.UnmarshalTypeError
+	if property, ok := strings.CutPrefix(err.Error(), "no value given for required property "); ok {
+		write(w, http.StatusBadRequest, CauseMandatoryIeMissing, "invalid request body: "+err.Error(), InvalidParam(property, "missing"))
+	} else if errors.As(err, &typeErr) && typeErr.Field != "" {
+		write(w, http.StatusBadRequest, CauseInvalidMsgFormat, "invalid request body: "+err.Error(),
+			InvalidParam("/"+strings.ReplaceAll(typeErr.Field, ".", "/"), "expected "+typeErr.Type.String()))
+	} else {
+		write(w, http.StatusBadRequest, CauseInvalidMsgFormat, "invalid request body: "+err.Error())
+	}
+	return false
+}
+
+// validateAmfEventSubscription checks a Namf_EventExposure subscription, TS 29.518 clause 6.2.6.2.3
+func (v *validator) validateAmfEventSubscription(sub *models.AmfEventSubscription) {
+	const base = "/subscription"
+	if v.mandatory(base+"/eventList", len(sub.EventList) > 0) {
+		for i, event := range sub.EventList {
+			param := fmt.Sprintf("%s/eventList/%d", base, i)
+			for j, area := range event.AreaList {
+				areaParam := fmt.Sprintf("%s/areaList/%d", param, j)
+				if area.PresenceInfo != nil {
+					for k, tai := range area.PresenceInfo.TrackingAreaList {
+						v.plmnId(false, fmt.Sprintf("%s/presenceInfo/trackingAreaList/%d/plmnId", areaParam, k), tai.PlmnId.Mcc, tai.PlmnId.Mnc)
+					}
+				}
+				if area.SNssai != nil {
+					v.snssai(false, areaParam+"/sNssai", area.SNssai.Sst, area.SNssai.Sd)
+				}
+			}
+			for j, snssai := range event.SnssaiFilter {
+				v.snssai(false, fmt.Sprintf("%s/snssaiFilter/%d", param, j), snssai.Sst, snssai.Sd)
+			}
+		}
+	}
+	if v.mandatory(base+"/eventNotifyUri", sub.EventNotifyUri != "") {
+		v.uri(true, base+"/eventNotifyUri", sub.EventNotifyUri)
+	}
+	v.mandatory(base+"/notifyCorrelationId", sub.NotifyCorrelationId != "")
+	if v.mandatory(base+"/nfId", sub.NfId != "") {
+		v.nfInstanceId(base+"/nfId", sub.NfId)
+	}
+	if sub.SubsChangeNotifyUri != nil {
+		v.uri(false, base+"/subsChangeNotifyUri", *sub.SubsChangeNotifyUri)
+	}
+	if sub.Supi != nil {
+		v.supi(base+"/supi", *sub.Supi)
+	}
+	if sub.Gpsi != nil {
+		v.gpsi(base+"/gpsi", *sub.Gpsi)
+	}
+	if options := sub.Options; options != nil {
+		v.extensibleEnum(true, base+"/options/trigger", &options.Trigger, options.Trigger.AmfEventTriggerAnyOf != nil)
+	}
+}
+
+// validateAmfCreateEventSubscription checks the body of a Namf_EventExposure subscribe request
+func (v *validator) validateAmfCreateEventSubscription(create *models.AmfCreateEventSubscription) {
+	v.validateAmfEventSubscription(&create.Subscription)
+	if create.SupportedFeatures != nil {
+		v.features(false, "/supportedFeatures", *create.SupportedFeatures)
+	}
+	if guami := create.OldGuami; guami != nil {
+		v.plmnId(false, "/oldGuami/plmnId", guami.PlmnId.Mcc, guami.PlmnId.Mnc)
+	}
+}
+
+// validateSmfEventExposure checks a Nsmf_EventExposure subscription, TS 29.508 clause 5.6.2.2
+func (v *validator) validateSmfEventExposure(sub *models.NsmfEventExposure) {
+	v.mandatory("/notifId", sub.NotifId != "")
+	if v.mandatory("/notifUri", sub.NotifUri != "") {
+		v.uri(true, "/notifUri", sub.NotifUri)
+	}
+	if v.mandatory("/eventSubs", len(sub.EventSubs) > 0) {
+		for i, event := range sub.EventSubs {
+			if event.DnaiChgType != nil {
+				v.extensibleEnum(false, fmt.Sprintf("/eventSubs/%d/dnaiChgType", i), event.DnaiChgType, event.DnaiChgType.DnaiChangeTypeAnyOf != nil)
+			}
+		}
+	}
+	if sub.Supi != nil {
+		v.supi("/supi", *sub.Supi)
+	}
+	if sub.Gpsi != nil {
+		v.gpsi("/gpsi", *sub.Gpsi)
+	}
+	if sub.PduSeId != nil && (*sub.PduSeId < 0 || *sub.PduSeId > 255) {
+		v.incorrect(false, "/pduSeId", "not in 0..255")
+	}
+	if sub.Dnn != nil && *sub.Dnn == "" {
+		v.incorrect(false, "/dnn", "empty")
+	}
+	if sub.Snssai != nil {
+		v.snssai(false, "/snssai", sub.Snssai.Sst, sub.Snssai.Sd)
+	}
+	for i, addr := range sub.AltNotifIpv4Addrs {
+		if ip := net.ParseIP(addr); ip == nil || ip.To4() == nil {
+			v.incorrect(false, "/altNotifIpv4Addrs/"+strconv.Itoa(i), "not an IPv4 address")
+		}
+	}
+	if sub.NotifMethod != nil {
+		v.extensibleEnum(false, "/notifMethod", sub.NotifMethod, sub.NotifMethod.NotificationMethodAnyOf != nil)
+	}
+	if sub.Guami != nil {
+		v.plmnId(false, "/guami/plmnId", sub.Guami.PlmnId.Mcc, sub.Guami.PlmnId.Mnc)
+	}
+	if sub.SupportedFeatures != nil {
+		v.features(false, "/supportedFeatures", *sub.SupportedFeatures)
+	}
+}
+
+// validateAppSessionContext checks a Npcf_PolicyAuthorization application session, TS 29.514 clause 5.6.2.3
+func (v *validator) validateAppSessionContext(ctx *models.AppSessionContext) {
+	req := ctx.AscReqData.Get()
+	if !v.mandatory("/ascReqData", req != nil) {
+		return
+	}
+	const base = "/ascReqData"
+	if v.mandatory(base+"/notifUri", req.NotifUri != "") {
+		v.uri(true, base+"/notifUri", req.NotifUri)
+	}
+	if v.mandatory(base+"/suppFeat", req.SuppFeat != "") {
+		v.features(true, base+"/suppFeat", req.SuppFeat)
+	}
+	if req.UeIpv4 == nil && req.UeIpv6 == nil && req.UeMac == nil {
+		v.fail(CauseMandatoryIeMissing, base+"/ueIpv4", "one of ueIpv4, ueIpv6 or ueMac is required")
+	}
+	if req.UeIpv4 != nil {
+		if ip := net.ParseIP(*req.UeIpv4); ip == nil || ip.To4() == nil {
+			v.incorrect(true, base+"/ueIpv4", "not an IPv4 address")
+		}
+	}
+	if req.Supi != nil {
+		v.supi(base+"/supi", *req.Supi)
+	}
+	if req.Gpsi != nil {
+		v.gpsi(base+"/gpsi", *req.Gpsi)
+	}
+	if req.Dnn != nil && *req.Dnn == "" {
+		v.incorrect(false, base+"/dnn", "empty")
+	}
+	if req.SliceInfo != nil {
+		v.snssai(false, base+"/sliceInfo", req.SliceInfo.Sst, req.SliceInfo.Sd)
+	}
+	if req.SponId != nil {
+		// the application service provider is required with the sponsor, TS 29.514 clause 4.2.2.6
+		v.mandatory(base+"/aspId", req.AspId != nil)
+	}
+	if status := req.SponStatus; status != nil {
+		v.extensibleEnum(false, base+"/sponStatus", status,
+			status.String != nil && slices.Contains([]string{"SPONSOR_DISABLED", "SPONSOR_ENABLED"}, *status.String))
+	}
+	if req.MedComponents != nil {
+		keys := slices.Sorted(maps.Keys(*req.MedComponents))
+		for _, key := range keys {
+			component := (*req.MedComponents)[key]
+			param := base + "/medComponents/" + key
+			if key != strconv.Itoa(int(component.MedCompN)) {
+				v.incorrect(false, param+"/medCompN", "does not match the key of the media component")
+			}
+			if component.MedType != nil && !slices.Contains(supportedMedType, *component.MedType) {
+				v.incorrect(false, param+"/medType", "unsupported value "+*component.MedType)
+			}
+			bitRates := []struct {
+				name  string
+				value *string
+			}{
+				{"marBwDl", component.MarBwDl}, {"marBwUl", component.MarBwUl},
+				{"mirBwDl", component.MirBwDl}, {"mirBwUl", component.MirBwUl},
+			}
+			for _, bitRate := range bitRates {
+				if bitRate.value != nil && !bitRatePattern.MatchString(*bitRate.value) {
+					v.incorrect(false, param+"/"+bitRate.name, "not a bit rate")
+				}
+			}
+		}
+	}
+	if routReq := req.AfRoutReq; routReq != nil {
+		v.validateAfRoutingRequirement(base+"/afRoutReq", routReq)
+	}
+}
+
+// validateAfRoutingRequirement checks the routing requirement of an application session, TS 29.514 clause 5.6.2.13
+func (v *validator) validateAfRoutingRequirement(base string, req *models.AfRoutingRequirement) {
+	for i, route := range req.RouteToLocs {
+		param := fmt.Sprintf("%s/routeToLocs/%d", base, i)
+		v.mandatory(param+"/dnai", route.GetDnai() != "")
+		if info := route.GetRouteInfo(); info != nil && info.Ipv4Addr != nil {
+			if ip := net.ParseIP(*info.Ipv4Addr); ip == nil || ip.To4() == nil {
+				v.incorrect(false, param+"/routeInfo/ipv4Addr", "not an IPv4 address")
+			}
+		}
+	}
+	for i, validity := range req.TempVals {
+		if validity.StartTime != nil && validity.StopTime != nil && !validity.StopTime.After(*validity.StartTime) {
+			v.incorrect(false, fmt.Sprintf("%s/tempVals/%d/stopTime", base, i), "not after the start time")
+		}
+	}
+	if req.SpVal != nil {
+		for praId, info := range req.SpVal.PresenceInfoList {
+			if len(info.TrackingAreaList) == 0 && len(info.NcgiList) == 0 {
+				v.incorrect(false, base+"/spVal/presenceInfoList/"+praId, "only tracking areas and NR cells are supported")
+			}
+		}
+	}
+	if sub, ok := req.GetUpPathChgSubOk(); ok && sub != nil {
+		param := base + "/upPathChgSub"
+		if v.mandatory(param+"/notificationUri", sub.NotificationUri != "") {
+			v.uri(true, param+"/notificationUri", sub.NotificationUri)
+		}
+		v.mandatory(param+"/notifCorreId", sub.NotifCorreId != "")
+		v.extensibleEnum(true, param+"/dnaiChgType", sub.DnaiChgType, sub.DnaiChgType.DnaiChangeTypeAnyOf != nil)
+	}
+	for i, info := range req.EasIpReplaceInfos {
+		param := fmt.Sprintf("%s/easIpReplaceInfos/%d", base, i)
+		v.easServerAddress(param+"/source", info.Source)
+		v.easServerAddress(param+"/target", info.Target)
+	}
+}
+
+// easServerAddress checks the address of an EAS, the simulated user plane only carries IPv4
+func (v *validator) easServerAddress(param string, addr models.EasServerAddress) {
+	var ipv4 string
+	if addr.Ip.Interface != nil {
+		if ip, ok := (*addr.Ip.Interface).(map[string]interface{}); ok {
+			ipv4, _ = ip["ipv4Addr"].(string)
+		}
+	}
+	if ip := net.ParseIP(ipv4); ip == nil || ip.To4() == nil {
+		v.incorrect(false, param+"/ip/ipv4Addr", "not an IPv4 address")
+	}
+	if addr.Port < 0 || addr.Port > 65535 {
+		v.incorrect(false, param+"/port", "not in 0..65535")
+	}
+}
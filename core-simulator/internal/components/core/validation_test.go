@@ -0,0 +1,169 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package core
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+var testPlmn = models.PlmnId{Mcc: "001", Mnc: "06"}
+
+func invalidParams(v *validator) []string {
+	params := []string{}
+	for _, param := range v.params {
+		params = append(params, param.Param)
+	}
+	return params
+}
+
+func TestValidateAmfSubscription(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		cause  string
+		params []string
+	}{
+		{"valid", `{"subscription":{"eventList":[{"type":"REGISTRATION_STATE_REPORT"}],"eventNotifyUri":"http://af:8080/notify",
+			"notifyCorrelationId":"1","nfId":"` + testNfId + `","supi":"imsi-001060000000001","options":{"trigger":"CONTINUOUS"}}}`, "", nil},
+		{"missing mandatory attributes", `{"subscription":{"eventList":[]}}`, CauseMandatoryIeMissing,
+			[]string{"/subscription/eventList", "/subscription/eventNotifyUri", "/subscription/notifyCorrelationId", "/subscription/nfId"}},
+		{"incorrect mandatory attributes", `{"subscription":{"eventList":[{"type":"LOCATION_REPORT"}],"eventNotifyUri":"/notify",
+			"notifyCorrelationId":"1","nfId":"af"}}`, CauseMandatoryIeIncorrect, []string{"/subscription/eventNotifyUri", "/subscription/nfId"}},
+		{"unsupported trigger", `{"subscription":{"eventList":[{"type":"LOCATION_REPORT"}],"eventNotifyUri":"http://af",
+			"notifyCorrelationId":"1","nfId":"` + testNfId + `","options":{"trigger":"SOMETIMES"}}}`, CauseMandatoryIeIncorrect,
+			[]string{"/subscription/options/trigger"}},
+		{"foreign plmn", `{"subscription":{"eventList":[{"type":"LOCATION_REPORT","areaList":[{"presenceInfo":{"trackingAreaList":[{"plmnId":{"mcc":"208","mnc":"93"},"tac":"000001"}]},
+			"sNssai":{"sst":1,"sd":"xyz"}}]}],"eventNotifyUri":"http://af","notifyCorrelationId":"1","nfId":"` + testNfId + `","supi":"imsi-208930000000001"}}`,
+			CauseOptionalIeIncorrect, []string{
+				"/subscription/eventList/0/areaList/0/presenceInfo/trackingAreaList/0/plmnId",
+				"/subscription/eventList/0/areaList/0/sNssai/sd",
+				"/subscription/supi",
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			create := models.AmfCreateEventSubscription{}
+			if err := json.Unmarshal([]byte(tt.body), &create); err != nil {
+				t.Fatal(err)
+			}
+			v := newValidator(testPlmn)
+			v.validateAmfCreateEventSubscription(&create)
+			if v.cause != tt.cause {
+				t.Errorf("got cause %q, want %q", v.cause, tt.cause)
+			}
+			if got := invalidParams(v); !slices.Equal(got, append([]string{}, tt.params...)) {
+				t.Errorf("got invalid params %v, want %v", got, tt.params)
+			}
+		})
+	}
+}
+
+func TestValidateSmfEventExposure(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		cause  string
+		params []string
+	}{
+		{"valid", `{"notifId":"1","notifUri":"http://af","eventSubs":[{"event":"PDU_SES_EST"}],"snssai":{"sst":1,"sd":"000001"},
+			"notifMethod":"PERIODIC","repPeriod":10}`, "", nil},
+		{"missing mandatory attributes", `{"eventSubs":[]}`, CauseMandatoryIeMissing, []string{"/notifId", "/notifUri", "/eventSubs"}},
+		{"incorrect optional attributes", `{"notifId":"1","notifUri":"http://af","eventSubs":[{"event":"UP_PATH_CH","dnaiChgType":"OFTEN"}],
+			"gpsi":"12345","snssai":{"sst":300},"notifMethod":"NEVER","altNotifIpv4Addrs":["af.example.com"]}`, CauseOptionalIeIncorrect,
+			[]string{"/eventSubs/0/dnaiChgType", "/gpsi", "/snssai/sst", "/altNotifIpv4Addrs/0", "/notifMethod"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub := models.NsmfEventExposure{}
+			if err := json.Unmarshal([]byte(tt.body), &sub); err != nil {
+				t.Fatal(err)
+			}
+			v := newValidator(testPlmn)
+			v.validateSmfEventExposure(&sub)
+			if v.cause != tt.cause {
+				t.Errorf("got cause %q, want %q", v.cause, tt.cause)
+			}
+			if got := invalidParams(v); !slices.Equal(got, append([]string{}, tt.params...)) {
+				t.Errorf("got invalid params %v, want %v", got, tt.params)
+			}
+		})
+	}
+}
+
+func TestValidateAppSessionContext(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		cause  string
+		params []string
+	}{
+		{"valid", `{"ascReqData":{"notifUri":"http://af","suppFeat":"4","ueIpv4":"10.0.0.1",
+			"medComponents":{"1":{"medCompN":1,"medType":"VIDEO","marBwDl":"5 Mbps"}}}}`, "", nil},
+		{"missing request data", `{}`, CauseMandatoryIeMissing, []string{"/ascReqData"}},
+		{"missing ue address", `{"ascReqData":{"notifUri":"http://af","suppFeat":"4"}}`, CauseMandatoryIeMissing, []string{"/ascReqData/ueIpv4"}},
+		{"incorrect attributes", `{"ascReqData":{"notifUri":"af","suppFeat":"xyz","ueIpv4":"10.0.0.1","sliceInfo":{"sst":1,"sd":"12"},
+			"medComponents":{"1":{"medCompN":2,"medType":"HOLOGRAM","mirBwUl":"fast"}},"afRoutReq":{"routeToLocs":[{"routeProfId":"edge"}]}}}`,
+			CauseMandatoryIeIncorrect, []string{
+				"/ascReqData/notifUri",
+				"/ascReqData/suppFeat",
+				"/ascReqData/sliceInfo/sd",
+				"/ascReqData/medComponents/1/medCompN",
+				"/ascReqData/medComponents/1/medType",
+				"/ascReqData/medComponents/1/mirBwUl",
+				"/ascReqData/afRoutReq/routeToLocs/0/dnai",
+			}},
+		{"incorrect routing requirement", `{"ascReqData":{"notifUri":"http://af","suppFeat":"0","ueIpv4":"10.0.0.1","afRoutReq":{
+			"routeToLocs":[{"dnai":"edge","routeInfo":{"ipv4Addr":"10.0.0","portNumber":80}}],
+			"tempVals":[{"startTime":"2025-06-01T10:00:00Z","stopTime":"2025-06-01T09:00:00Z"}],
+			"spVal":{"presenceInfoList":{"pra1":{"praId":"pra1"}}},
+			"upPathChgSub":{"notificationUri":"af","notifCorreId":"","dnaiChgType":"OFTEN"},
+			"easIpReplaceInfos":[{"source":{"ip":{"ipv6Addr":"2001:db8::1"},"port":443},"target":{"ip":{"ipv4Addr":"10.0.0.2"},"port":70000}}]}}}`,
+			CauseOptionalIeIncorrect, []string{
+				"/ascReqData/afRoutReq/routeToLocs/0/routeInfo/ipv4Addr",
+				"/ascReqData/afRoutReq/tempVals/0/stopTime",
+				"/ascReqData/afRoutReq/spVal/presenceInfoList/pra1",
+				"/ascReqData/afRoutReq/upPathChgSub/notificationUri",
+				"/ascReqData/afRoutReq/upPathChgSub/notifCorreId",
+				"/ascReqData/afRoutReq/upPathChgSub/dnaiChgType",
+				"/ascReqData/afRoutReq/easIpReplaceInfos/0/source/ip/ipv4Addr",
+				"/ascReqData/afRoutReq/easIpReplaceInfos/0/target/port",
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := models.AppSessionContext{}
+			if err := json.Unmarshal([]byte(tt.body), &ctx); err != nil {
+				t.Fatal(err)
+			}
+			v := newValidator(testPlmn)
+			v.validateAppSessionContext(&ctx)
+			if v.cause != tt.cause {
+				t.Errorf("got cause %q, want %q", v.cause, tt.cause)
+			}
+			if got := invalidParams(v); !slices.Equal(got, append([]string{}, tt.params...)) {
+				t.Errorf("got invalid params %v, want %v", got, tt.params)
+			}
+		})
+	}
+}
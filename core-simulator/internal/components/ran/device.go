@@ -0,0 +1,117 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package ran
+
+import (
+	"fmt"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/trafficgen"
+)
+
+/* Device classes of the simulated UEs */
+
+const (
+	DeviceClassSmartphone = "Smartphone"
+	DeviceClassIoT        = "IoT"
+)
+
+type Direction string
+
+const (
+	Uplink   Direction = "UL"
+	Downlink Direction = "DL"
+)
+
+// Application is a traffic flow started when the UE establishes its PDU Session.
+// Each application of the device class mix is started with the given probability.
+type Application struct {
+	Profile     string    `yaml:"profile" json:"profile"`
+	Direction   Direction `yaml:"direction" json:"direction"`
+	Probability *float64  `yaml:"probability,omitempty" json:"probability,omitempty"`
+}
+
+func (a *Application) Uplink() bool {
+	return a.Direction == Uplink
+}
+
+// DeviceClasses maps each device class to its application mix
+type DeviceClasses map[string][]Application
+
+var defaultDeviceClasses = DeviceClasses{
+	DeviceClassSmartphone: {
+		{Profile: "video", Direction: Downlink},
+		{Profile: "sip", Direction: Uplink},
+	},
+	DeviceClassIoT: {
+		{Profile: "iot", Direction: Uplink},
+	},
+}
+
+// NewDeviceClasses validates the configured application mixes against the traffic
+// profiles and merges them with the default device classes
+func NewDeviceClasses(custom DeviceClasses, catalog *trafficgen.Catalog) (DeviceClasses, error) {
+	classes := DeviceClasses{}
+	for class, apps := range defaultDeviceClasses {
+		classes[class] = apps
+	}
+
+	for class, apps := range custom {
+		for i, app := range apps {
+			if !catalog.IsProfileSupported(app.Profile) {
+				return nil, fmt.Errorf("device class %s: unknown traffic profile %q", class, app.Profile)
+			}
+			if app.Direction != Uplink && app.Direction != Downlink {
+				return nil, fmt.Errorf("device class %s: application %d direction must be UL or DL", class, i)
+			}
+			if app.Probability != nil && (*app.Probability < 0 || *app.Probability > 1) {
+				return nil, fmt.Errorf("device class %s: application %d probability must be in [0, 1]", class, i)
+			}
+		}
+		classes[class] = apps
+	}
+	return classes, nil
+}
+
+// IsDeviceClassSupported reports whether the device class is known to the simulator
+func (d DeviceClasses) IsDeviceClassSupported(deviceClass string) bool {
+	_, ok := d[deviceClass]
+	return ok
+}
+
+// Applications returns the application mix of a device class,
+// unknown classes behave as smartphones
+func (d DeviceClasses) Applications(deviceClass string) []Application {
+	if apps, ok := d[deviceClass]; ok {
+		return apps
+	}
+	return d[DeviceClassSmartphone]
+}
+
+// drawApplications selects the applications started by a new PDU Session
+func drawApplications(random *utils.Random, apps []Application) []Application {
+	selected := []Application{}
+	for _, app := range apps {
+		if app.Probability == nil || random.Float64() < *app.Probability {
+			selected = append(selected, app)
+		}
+	}
+	return selected
+}
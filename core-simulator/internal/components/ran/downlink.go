@@ -0,0 +1,274 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package ran
+
+import (
+	"log"
+
+	"github.com/giuliocarot0/gitc"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/monitoring"
+)
+
+/* Procedures initiated by the network functions */
+
+// DefaultQos is the QoS of the default flow of a new PDU Session
+var DefaultQos = models.SessionQos{Var5qi: 9}
+
+// downlinkSupi returns the UE targeted by a message of the network functions
+func downlinkSupi(payload interface{}) string {
+	switch msg := payload.(type) {
+	case *models.AmfToUeMsg:
+		return msg.Supi
+	case *models.SmfToUeMsg:
+		return msg.Supi
+	case *models.PcfToUeMsg:
+		return msg.Supi
+	}
+	return ""
+}
+
+// handleDownlink runs the procedure requested by a network function on the shard of the UE
+func (ue *Ue) handleDownlink(msg *gitc.Message) {
+	switch msg.Type {
+	case models.AmfToUeType:
+		ue.handleAmfToUe(msg.Payload.(*models.AmfToUeMsg))
+	case models.SmfToUeType:
+		ue.handleSmfToUe(msg.Payload.(*models.SmfToUeMsg))
+	case models.PcfToUeType:
+		ue.handlePcfToUe(msg.Payload.(*models.PcfToUeMsg))
+	default:
+		log.Printf("[%s] unsupported message type %d from %s", ue.Imsi, msg.Type, msg.From)
+	}
+}
+
+func (ue *Ue) handleAmfToUe(msg *models.AmfToUeMsg) {
+	switch msg.Command {
+	case models.AmfPaging:
+		rmState, cmState := ue.GetStatus()
+		if rmState != models.RmStateRegistered || cmState != models.CmStateIdle {
+			log.Printf("[%s] ignoring paging, ue is not in idle mode", ue.Imsi)
+			return
+		}
+		ue.WakeUp(true)
+		ue.alignState()
+	case models.AmfDeregistration, models.AmfImplicitDeregistration:
+		ue.networkDeregister(msg.Reason, true)
+	case models.AmfRegistrationReject:
+		log.Printf("[%s] registration rejected by the network", ue.Imsi)
+		ue.networkDeregister(msg.Reason, false)
+	default:
+		log.Printf("[%s] unsupported AMF command %s", ue.Imsi, msg.Command)
+	}
+}
+
+// networkDeregister moves the UE to RM-DEREGISTERED and CM-IDLE on request of the AMF.
+// The loss of connectivity with its reason and the registration state are reported
+// unless the registration was rejected, in which case the AMF never registered the UE.
+func (ue *Ue) networkDeregister(reason models.LossOfConnectivityReasonAnyOf, report bool) {
+	ue.statusMutex.Lock()
+	defer ue.statusMutex.Unlock()
+
+	if ue.RmStatus != models.RmStateRegistered {
+		log.Printf("[%s] ignoring network deregistration, ue is not registered", ue.Imsi)
+		return
+	}
+	ue.CmStatus = models.CmStateIdle
+	ue.RmStatus = models.RmStateDeregistered
+	ue.ueState = models.Deregistered
+	log.Printf("[%s] deregistered by the network, reason %s", ue.Imsi, reason)
+
+	if report {
+		for _, eventType := range []models.AmfEventTypeAnyOf{
+			models.AMFEVENTTYPEANYOF_LOSS_OF_CONNECTIVITY,
+			models.AMFEVENTTYPEANYOF_REGISTRATION_STATE_REPORT,
+		} {
+			msg := &models.UeToAmfMsg{
+				EventType:     eventType,
+				TimeStamp:     ue.now(),
+				RmState:       ue.RmStatus,
+				CmState:       ue.CmStatus,
+				Supi:          ue.Imsi,
+				Gpsi:          ue.Msidn,
+				PlmnId:        ue.PlmnId,
+				CurrentCellId: ue.CurrentCellId,
+				AccessType:    ue.accessType,
+				LossReason:    reason,
+			}
+			if err := ue.engine.Dispatcher.Send(ue.Imsi, "AMF", models.UeToAmfType, msg); err != nil {
+				log.Printf("Error sending UeToAmfMsg for UE %s: %v", ue.Imsi, err)
+			}
+		}
+	}
+
+	monitoring.UEsTotal.WithLabelValues(ue.simId, string(models.RmStateRegistered)).Dec()
+	monitoring.UEsTotal.WithLabelValues(ue.simId, string(models.RmStateDeregistered)).Inc()
+
+	for sessionId := range ue.PduSessions {
+		ue.releasePduSession(sessionId)
+	}
+}
+
+func (ue *Ue) handleSmfToUe(msg *models.SmfToUeMsg) {
+	if !ue.HasPduSession(msg.PduSessId) {
+		log.Printf("[%s] ignoring %s, unknown pduSessionId %d", ue.Imsi, msg.Command, msg.PduSessId)
+		return
+	}
+	// session management commands reach idle UEs through paging
+	ue.WakeUp(true)
+
+	switch msg.Command {
+	case models.SmfPduSessionRelease:
+		log.Printf("[%s] network initiated release of PDU Session %d", ue.Imsi, msg.PduSessId)
+		ue.ReleasePduSession(msg.PduSessId)
+	case models.SmfPduSessionModification:
+		if msg.Qos == nil {
+			return
+		}
+		ue.statusMutex.Lock()
+		pduSess := ue.PduSessions[msg.PduSessId]
+		pduSess.Qos = *msg.Qos
+		ue.PduSessions[msg.PduSessId] = pduSess
+		ue.LastActivityTime = ue.now()
+		ue.statusMutex.Unlock()
+		log.Printf("[%s] PDU Session %d modified, qos %+v", ue.Imsi, msg.PduSessId, *msg.Qos)
+	default:
+		log.Printf("[%s] unsupported SMF command %s", ue.Imsi, msg.Command)
+	}
+	ue.alignState()
+}
+
+func (ue *Ue) handlePcfToUe(msg *models.PcfToUeMsg) {
+	if !ue.HasPduSession(msg.PduSessId) {
+		log.Printf("[%s] ignoring %s, unknown pduSessionId %d", ue.Imsi, msg.Command, msg.PduSessId)
+		return
+	}
+	ue.WakeUp(true)
+
+	ue.statusMutex.Lock()
+	defer ue.statusMutex.Unlock()
+	ue.LastActivityTime = ue.now()
+	pduSess := ue.PduSessions[msg.PduSessId]
+
+	report := &models.UeToSmfMsg{
+		TimeStamp:    ue.now(),
+		Dnn:          pduSess.Dnn,
+		Snssai:       pduSess.Snssai,
+		PduSessType:  models.PDUSESSIONTYPEANYOF_IPV4,
+		UeAddress:    pduSess.Ipv4,
+		Supi:         ue.Imsi,
+		Gpsi:         ue.Msidn,
+		PlmnId:       ue.PlmnId,
+		PduSessId:    msg.PduSessId,
+		AccessType:   ue.accessType,
+		AppSessionId: msg.AppSessionId,
+		AppId:        msg.AfAppId,
+	}
+
+	switch msg.Command {
+	case models.PcfQosUpdate:
+		if msg.Remove {
+			pduSess.QosFlows = removeQosFlow(pduSess.QosFlows, msg.AppSessionId)
+			ue.PduSessions[msg.PduSessId] = pduSess
+			log.Printf("[%s] released qos flow of app session %s on PDU Session %d", ue.Imsi, msg.AppSessionId, msg.PduSessId)
+			return
+		}
+		flow := models.QosFlow{
+			Qfi:          nextQfi(pduSess.QosFlows),
+			AppSessionId: msg.AppSessionId,
+		}
+		if msg.Qos != nil {
+			flow.Qos = *msg.Qos
+		}
+		pduSess.QosFlows = append(removeQosFlow(pduSess.QosFlows, msg.AppSessionId), flow)
+		log.Printf("[%s] allocated qos flow %d for app session %s on PDU Session %d", ue.Imsi, flow.Qfi, msg.AppSessionId, msg.PduSessId)
+		report.EventType = models.SMFEVENTANYOF_QFI_ALLOC
+		report.Qfi = flow.Qfi
+
+	case models.PcfRoutingUpdate:
+		target := msg.Dnai
+		if msg.Remove {
+			target = ""
+		}
+		if target == pduSess.Dnai {
+			return
+		}
+		report.EventType = models.SMFEVENTANYOF_UP_PATH_CH
+		report.SourceDnai = pduSess.Dnai
+		report.TargetDnai = target
+		pduSess.Dnai = target
+		log.Printf("[%s] PDU Session %d rerouted to dnai %q", ue.Imsi, msg.PduSessId, target)
+
+	default:
+		log.Printf("[%s] unsupported PCF command %s", ue.Imsi, msg.Command)
+		return
+	}
+
+	ue.PduSessions[msg.PduSessId] = pduSess
+	if err := ue.engine.Dispatcher.Send(ue.Imsi, "SMF", models.UeToSmfType, report); err != nil {
+		log.Printf("Error sending UeToSmfType for UE %s: %v", ue.Imsi, err)
+	}
+}
+
+// alignState keeps the markov state of the UE aligned with a network initiated procedure
+func (ue *Ue) alignState() {
+	ue.statusMutex.Lock()
+	defer ue.statusMutex.Unlock()
+
+	if ue.RmStatus != models.RmStateRegistered {
+		return
+	}
+	switch {
+	case ue.CmStatus == models.CmStateIdle:
+		ue.ueState = models.Idle
+	case len(ue.PduSessions) > 0:
+		ue.ueState = models.Connected
+	default:
+		ue.ueState = models.Attached
+	}
+}
+
+// nextQfi returns the first QFI not used by the flows, QFI 1 is the default flow
+func nextQfi(flows []models.QosFlow) int32 {
+	qfi := int32(2)
+	for {
+		used := false
+		for _, flow := range flows {
+			if flow.Qfi == qfi {
+				used = true
+				break
+			}
+		}
+		if !used {
+			return qfi
+		}
+		qfi++
+	}
+}
+
+func removeQosFlow(flows []models.QosFlow, appSessionId string) []models.QosFlow {
+	kept := []models.QosFlow{}
+	for _, flow := range flows {
+		if flow.AppSessionId != appSessionId {
+			kept = append(kept, flow)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,389 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package ran
+
+import (
+	"container/heap"
+	"context"
+	"hash/fnv"
+	"log"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/giuliocarot0/gitc"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+)
+
+/* Sharded event engine driving the UE behaviours */
+
+const (
+	// interval between two steps of the UE markov process
+	markovPeriod = time.Second
+	// interval between two userplane reports of a PDU Session
+	userplaneReportPeriod = 5 * time.Second
+	// maximum sleep of an idle shard
+	idleWait = time.Second
+)
+
+// RanTask is the gitc task delivering the messages of the network functions to the UEs
+const RanTask = "RAN"
+
+type ueEventKind int
+
+const (
+	markovStep ueEventKind = iota
+	inactivityCheck
+	userplaneReport
+	downlinkMessage
+	procedure
+)
+
+// ueEvent is a UE timer waiting in the queue of its shard
+type ueEvent struct {
+	at   time.Time // on the shard clock
+	ue   *Ue
+	kind ueEventKind
+	// the event is dropped once ctx is cancelled
+	ctx       context.Context
+	sessionId int32
+	msg       *gitc.Message
+	run       func()
+	seq       uint64 // events due at the same time are processed in order
+	index     int
+}
+
+// An Engine runs the timers of all the UEs of a simulation on a fixed number of shards.
+// Every UE is bound to a shard by its IMSI, so that its events are processed in order by a
+// single routine: the number of routines does not depend on the number of UEs.
+// The shard clocks follow the clock of the simulation and stop while the simulation is paused.
+// The downlink messages of the network functions and the procedures dispatched to the UEs
+// are queued to the shard of their UE as well.
+type Engine struct {
+	shards []*shard
+	clock  *utils.Clock
+	// Dispatcher delivers the messages between the UEs and the network functions of the simulation
+	Dispatcher *gitc.Dispatcher
+	// Random is the source of the draws of the UEs of the simulation, the process source when nil
+	Random *utils.Random
+	// powered on UEs, reachable by the network
+	ues      map[string]*Ue
+	uesMutex sync.RWMutex
+	// closed once Run returns
+	stopped chan struct{}
+}
+
+type shard struct {
+	mutex     sync.Mutex
+	queue     ueEventQueue
+	wakeup    chan struct{}
+	pause     *utils.PauseGate
+	source    *utils.Clock
+	processed uint64
+	nextSeq   uint64
+}
+
+// NewEngine creates an engine with the given number of shards, driven by the clock and frozen by the pause gate.
+// A non positive number of shards defaults to the number of CPUs.
+func NewEngine(shards int, pause *utils.PauseGate, clock *utils.Clock) *Engine {
+	if shards <= 0 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+	e := &Engine{
+		shards:     make([]*shard, shards),
+		clock:      clock,
+		Dispatcher: gitc.GlobalDispatcher,
+		ues:        make(map[string]*Ue),
+		stopped:    make(chan struct{}),
+	}
+	for i := range e.shards {
+		e.shards[i] = &shard{
+			wakeup: make(chan struct{}, 1),
+			pause:  pause,
+			source: clock,
+		}
+	}
+	return e
+}
+
+// Shards returns the number of shards of the engine
+func (e *Engine) Shards() int {
+	return len(e.shards)
+}
+
+// Clock returns the clock of the simulation driving the engine
+func (e *Engine) Clock() *utils.Clock {
+	return e.clock
+}
+
+// Due returns the number of UE events due by now and not processed yet
+func (e *Engine) Due() int {
+	total := 0
+	for _, s := range e.shards {
+		s.mutex.Lock()
+		now := s.clock()
+		for _, ev := range s.queue {
+			if !ev.at.After(now) {
+				total++
+			}
+		}
+		s.mutex.Unlock()
+	}
+	return total
+}
+
+// Len returns the number of pending UE events
+func (e *Engine) Len() int {
+	total := 0
+	for _, s := range e.shards {
+		s.mutex.Lock()
+		total += s.queue.Len()
+		s.mutex.Unlock()
+	}
+	return total
+}
+
+// Run drives the shards and the RAN task until ctx is cancelled
+func (e *Engine) Run(ctx context.Context) {
+	defer close(e.stopped)
+	if err := e.Dispatcher.StartTask(RanTask, e.deliver, 4096); err != nil {
+		log.Printf("could not start %s task: %v", RanTask, err)
+	} else {
+		defer func() {
+			if err := e.Dispatcher.StopTask(RanTask); err != nil {
+				log.Printf("could not stop %s task: %v", RanTask, err)
+			}
+		}()
+	}
+
+	var wg sync.WaitGroup
+	for _, s := range e.shards {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.run(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// shardOf returns the shard processing the events of a UE
+func (e *Engine) shardOf(imsi string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(imsi))
+	return e.shards[h.Sum32()%uint32(len(e.shards))]
+}
+
+// register makes a powered on UE reachable by the network
+func (e *Engine) register(ue *Ue) {
+	e.uesMutex.Lock()
+	defer e.uesMutex.Unlock()
+	e.ues[ue.Imsi] = ue
+}
+
+func (e *Engine) unregister(ue *Ue) {
+	e.uesMutex.Lock()
+	defer e.uesMutex.Unlock()
+	if e.ues[ue.Imsi] == ue {
+		delete(e.ues, ue.Imsi)
+	}
+}
+
+// deliver queues a message of the network functions to the shard of the target UE
+func (e *Engine) deliver(msg gitc.Message) {
+	supi := downlinkSupi(msg.Payload)
+
+	e.uesMutex.RLock()
+	ue, ok := e.ues[supi]
+	e.uesMutex.RUnlock()
+	if !ok {
+		log.Printf("[%s] ue is not reachable, dropping message from %s", supi, msg.From)
+		return
+	}
+	ue.shard.post(ue, ue.context(), &msg)
+}
+
+// schedule queues a UE event due after d, which is dropped once ctx is cancelled
+func (s *shard) schedule(ue *Ue, ctx context.Context, kind ueEventKind, sessionId int32, d time.Duration) {
+	s.push(&ueEvent{
+		ue:        ue,
+		kind:      kind,
+		ctx:       ctx,
+		sessionId: sessionId,
+	}, d)
+}
+
+// post queues a downlink message, processed after the events already due
+func (s *shard) post(ue *Ue, ctx context.Context, msg *gitc.Message) {
+	s.push(&ueEvent{
+		ue:   ue,
+		kind: downlinkMessage,
+		ctx:  ctx,
+		msg:  msg,
+	}, 0)
+}
+
+// dispatch queues a procedure due after d, processed after the events already due at that time
+func (s *shard) dispatch(ue *Ue, ctx context.Context, run func(), d time.Duration) {
+	s.push(&ueEvent{
+		ue:   ue,
+		kind: procedure,
+		ctx:  ctx,
+		run:  run,
+	}, d)
+}
+
+func (s *shard) push(ev *ueEvent, d time.Duration) {
+	s.mutex.Lock()
+	ev.at = s.clock().Add(d)
+	ev.seq = s.nextSeq
+	s.nextSeq++
+	heap.Push(&s.queue, ev)
+	s.mutex.Unlock()
+
+	s.notify()
+}
+
+// clock returns the current time of the shard, frozen while the simulation is paused
+func (s *shard) clock() time.Time {
+	return s.source.Now().Add(-s.pause.Paused())
+}
+
+func (s *shard) run(ctx context.Context) {
+	timer := time.NewTimer(idleWait)
+	defer timer.Stop()
+
+	for {
+		if !s.pause.Wait(ctx) {
+			return
+		}
+
+		s.mutex.Lock()
+		now := s.clock()
+		s.mutex.Unlock()
+		s.runDue(now)
+
+		advanced := s.source.Advanced()
+		s.mutex.Lock()
+		wait := idleWait
+		if s.queue.Len() > 0 {
+			wait = min(s.queue[0].at.Sub(s.clock()), wait)
+		}
+		s.mutex.Unlock()
+
+		timer.Reset(wait)
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-s.wakeup:
+		case <-advanced:
+		}
+	}
+}
+
+// runDue processes the events due by now, it stops early if the simulation gets paused.
+// Periodic events are queued again one period after their due time.
+func (s *shard) runDue(now time.Time) {
+	for !s.pause.IsPaused() {
+		s.mutex.Lock()
+		if s.queue.Len() == 0 || s.queue[0].at.After(now) {
+			s.mutex.Unlock()
+			return
+		}
+		ev := heap.Pop(&s.queue).(*ueEvent)
+		s.processed++
+		s.mutex.Unlock()
+
+		// events of turned off UEs and released PDU Sessions are dropped
+		if ev.ctx.Err() != nil {
+			switch ev.kind {
+			case userplaneReport:
+				log.Printf("[%s] stopped userplane report for PDU Session %d", ev.ue.Imsi, ev.sessionId)
+			case downlinkMessage:
+				log.Printf("[%s] ue turned off, dropping message from %s", ev.ue.Imsi, ev.msg.From)
+			}
+			continue
+		}
+
+		var next time.Duration
+		switch ev.kind {
+		case markovStep:
+			ev.ue.step()
+			next = markovPeriod
+		case inactivityCheck:
+			next = ev.ue.checkInactivity()
+		case userplaneReport:
+			ev.ue.reportUserplane(ev.sessionId)
+			next = userplaneReportPeriod
+		case downlinkMessage:
+			ev.ue.handleDownlink(ev.msg)
+		case procedure:
+			ev.run()
+		}
+		if next <= 0 {
+			continue
+		}
+
+		ev.at = ev.at.Add(next)
+		s.mutex.Lock()
+		heap.Push(&s.queue, ev)
+		s.mutex.Unlock()
+	}
+}
+
+func (s *shard) notify() {
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// ueEventQueue is a min heap of UE events ordered by due time
+type ueEventQueue []*ueEvent
+
+func (q ueEventQueue) Len() int { return len(q) }
+func (q ueEventQueue) Less(i, j int) bool {
+	if q[i].at.Equal(q[j].at) {
+		return q[i].seq < q[j].seq
+	}
+	return q[i].at.Before(q[j].at)
+}
+
+func (q ueEventQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *ueEventQueue) Push(x any) {
+	ev := x.(*ueEvent)
+	ev.index = len(*q)
+	*q = append(*q, ev)
+}
+
+func (q *ueEventQueue) Pop() any {
+	old := *q
+	ev := old[len(old)-1]
+	old[len(old)-1] = nil
+	ev.index = -1
+	*q = old[:len(old)-1]
+	return ev
+}
@@ -0,0 +1,413 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package ran
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/giuliocarot0/gitc"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/trafficgen"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	// the network functions only drain the UE messages
+	for _, nf := range []string{"AMF", "SMF"} {
+		if err := gitc.StartTask(nf, func(msg gitc.Message) {}, 4096); err != nil {
+			log.Fatal(err)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+// testPopulation builds UEs bound to an engine whose shards are not running
+type testPopulation struct {
+	engine *Engine
+	pause  *utils.PauseGate
+	ipam   *utils.IPAllocator
+	gnbs   *GnbPool
+	config UeConfig
+}
+
+func newTestPopulation(t testing.TB, shards int) *testPopulation {
+	t.Helper()
+	catalog, err := trafficgen.NewCatalog(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := utils.NewClock()
+	pause := utils.NewPauseGate(clock)
+	engine := NewEngine(shards, pause, clock)
+	return &testPopulation{
+		engine: engine,
+		pause:  pause,
+		ipam:   utils.NewIpamService("10.0.0.0", "16"),
+		gnbs:   NewGnbPool(10),
+		config: UeConfig{
+			Dnn:          "internet",
+			Snssai:       models.Snssai{Sst: 1},
+			Type:         DeviceClassSmartphone,
+			Traffic:      catalog,
+			Applications: defaultDeviceClasses[DeviceClassSmartphone],
+			Scheduler:    trafficgen.NewScheduler(pause, clock),
+			Engine:       engine,
+		},
+	}
+}
+
+func (p *testPopulation) newUe(index int) *Ue {
+	cfg := p.config
+	cfg.Imsi = fmt.Sprintf("001060%09d", index)
+	return NewUserEquipment(context.Background(), cfg, p.ipam, "test", p.gnbs)
+}
+
+// runUntil processes the events of all the shards due by at
+func (p *testPopulation) runUntil(at time.Time) {
+	for _, s := range p.engine.shards {
+		s.runDue(at)
+	}
+}
+
+func TestEngineRepeatedAttachesShareOneTimer(t *testing.T) {
+	p := newTestPopulation(t, 1)
+	ue := p.newUe(1)
+	ue.Pin(true)
+	ue.PowerUp()
+	ue.Register()
+	for range 100 {
+		ue.Attach(10 * time.Second)
+	}
+
+	// the markov step and a single inactivity timer
+	if n := p.engine.Len(); n != 2 {
+		t.Fatalf("%d pending events after 100 attaches, want 2", n)
+	}
+
+	// the timer survives the expiration and is armed again by the next power cycle only
+	ue.TurnOff(false)
+	ue.PowerUp()
+	ue.Register()
+	ue.Attach(10 * time.Second)
+	p.runUntil(time.Now().Add(time.Minute))
+	if n := p.engine.Len(); n != 2 {
+		t.Fatalf("%d pending events after a power cycle, want 2", n)
+	}
+}
+
+func TestEngineDropsEventsOfTurnedOffUes(t *testing.T) {
+	p := newTestPopulation(t, 2)
+	ues := []*Ue{}
+	for i := range 50 {
+		ue := p.newUe(i)
+		ue.Pin(true)
+		ue.PowerUp()
+		ue.Register()
+		ue.Attach(10 * time.Second)
+		ue.NewPduSession(1, "internet", models.Snssai{Sst: 1}, true)
+		ues = append(ues, ue)
+	}
+	if n := p.engine.Len(); n != 150 {
+		t.Fatalf("%d pending events, want 150", n)
+	}
+
+	for _, ue := range ues {
+		ue.TurnOff(false)
+	}
+	p.runUntil(time.Now().Add(time.Minute))
+	if n := p.engine.Len(); n != 0 {
+		t.Fatalf("%d pending events left by turned off UEs", n)
+	}
+}
+
+func TestEngineInactivityTimer(t *testing.T) {
+	p := newTestPopulation(t, 1)
+	ue := p.newUe(1)
+	ue.Pin(true)
+	ue.PowerUp()
+	ue.Register()
+	ue.Attach(10 * time.Millisecond)
+	ue.statusMutex.Lock()
+	ue.LastActivityTime = time.Now().Add(-time.Second)
+	ue.statusMutex.Unlock()
+
+	p.runUntil(time.Now().Add(20 * time.Millisecond))
+	if _, cm := ue.GetStatus(); cm != models.CmStateIdle {
+		t.Fatalf("UE is %s after the inactivity timer, want %s", cm, models.CmStateIdle)
+	}
+}
+
+func TestEngineDispatchAfter(t *testing.T) {
+	p := newTestPopulation(t, 1)
+	ue := p.newUe(1)
+	ue.Pin(true)
+	ue.PowerUp()
+
+	done := false
+	ue.DispatchAfter(time.Minute, func() { done = true })
+	p.runUntil(time.Now().Add(30 * time.Second))
+	if done {
+		t.Fatal("procedure ran before its delay")
+	}
+	p.runUntil(time.Now().Add(2 * time.Minute))
+	if !done {
+		t.Fatal("procedure did not run after its delay")
+	}
+
+	// the procedures of a turned off UE are dropped
+	done = false
+	ue.DispatchAfter(time.Minute, func() { done = true })
+	ue.TurnOff(false)
+	p.runUntil(time.Now().Add(2 * time.Minute))
+	if done {
+		t.Fatal("procedure ran after the UE was turned off")
+	}
+}
+
+func TestEngineDispatchWait(t *testing.T) {
+	p := newTestPopulation(t, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.engine.Run(ctx)
+
+	ue := p.newUe(1)
+	ue.Pin(true)
+	ue.PowerUp()
+
+	done := false
+	if !ue.DispatchWait(func() { done = true }) || !done {
+		t.Fatal("procedure did not run before the wait returned")
+	}
+	// a procedure turning the UE off completes
+	if !ue.DispatchWait(func() { ue.TurnOff(false) }) {
+		t.Fatal("procedure turning the UE off reported as dropped")
+	}
+	// the procedures of a turned off UE are dropped
+	done = false
+	if ue.DispatchWait(func() { done = true }) || done {
+		t.Fatal("procedure ran after the UE was turned off")
+	}
+
+	// the wait is given up once the engine stops
+	ue.PowerUp()
+	p.pause.Pause()
+	go cancel()
+	if ue.DispatchWait(func() { done = true }) || done {
+		t.Fatal("procedure ran after the engine stopped")
+	}
+}
+
+func TestEngineRoutinesDoNotGrowWithUes(t *testing.T) {
+	p := newTestPopulation(t, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	before := runtime.NumGoroutine()
+	go p.engine.Run(ctx)
+	for i := range 10000 {
+		p.newUe(i).PowerUp()
+	}
+	time.Sleep(1500 * time.Millisecond)
+
+	// one routine per shard plus the one waiting for them and the RAN task
+	if grown := runtime.NumGoroutine() - before; grown > p.engine.Shards()+2 {
+		t.Fatalf("%d routines for 10000 UEs on %d shards", grown, p.engine.Shards())
+	}
+	processed := uint64(0)
+	for _, s := range p.engine.shards {
+		s.mutex.Lock()
+		processed += s.processed
+		s.mutex.Unlock()
+	}
+	if processed < 10000 {
+		t.Fatalf("%d markov steps processed for 10000 UEs", processed)
+	}
+}
+
+func TestEnginePause(t *testing.T) {
+	p := newTestPopulation(t, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.engine.Run(ctx)
+
+	p.pause.Pause()
+	p.newUe(1).PowerUp()
+	time.Sleep(1500 * time.Millisecond)
+	s := p.engine.shards[0]
+	s.mutex.Lock()
+	processed := s.processed
+	s.mutex.Unlock()
+	if processed != 0 {
+		t.Fatalf("%d events processed while paused", processed)
+	}
+	p.pause.Resume()
+}
+
+func TestEnginePauseOnVirtualClock(t *testing.T) {
+	p := newTestPopulation(t, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.engine.Run(ctx)
+
+	s := p.engine.shards[0]
+	s.mutex.Lock()
+	before := s.clock()
+	s.mutex.Unlock()
+
+	// the clock moved forward while paused does not advance the shard clock
+	p.pause.Pause()
+	p.engine.clock.Advance(time.Hour)
+	time.Sleep(100 * time.Millisecond)
+	p.pause.Resume()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.mutex.Lock()
+		paused, now := p.pause.Paused(), s.clock()
+		s.mutex.Unlock()
+		if paused >= time.Hour {
+			if elapsed := now.Sub(before); elapsed > time.Minute {
+				t.Fatalf("shard clock moved by %s across the pause", elapsed)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %s accounted as paused, want at least 1h", paused)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestEngineDeliversDownlinkMessages(t *testing.T) {
+	p := newTestPopulation(t, 2)
+	ue := p.newUe(1)
+	ue.Pin(true)
+	ue.PowerUp()
+	ue.Register()
+	ue.Attach(10 * time.Second)
+	ue.NewPduSession(1, "internet", models.Snssai{Sst: 1}, true)
+	ue.Sleep(true)
+
+	send := func(msgType gitc.MessageType, payload interface{}) {
+		p.engine.deliver(gitc.Message{From: "test", To: RanTask, Type: msgType, Payload: payload})
+		p.runUntil(time.Now())
+	}
+
+	send(models.AmfToUeType, &models.AmfToUeMsg{Command: models.AmfPaging, Supi: ue.Imsi})
+	if _, cm := ue.GetStatus(); cm != models.CmStateConnected {
+		t.Fatalf("UE is %s after paging, want %s", cm, models.CmStateConnected)
+	}
+
+	qos := models.SessionQos{Var5qi: 2, MaxBitrateDl: "5 Mbps"}
+	send(models.PcfToUeType, &models.PcfToUeMsg{Command: models.PcfQosUpdate, Supi: ue.Imsi, PduSessId: 1, AppSessionId: "app", Qos: &qos})
+	send(models.PcfToUeType, &models.PcfToUeMsg{Command: models.PcfRoutingUpdate, Supi: ue.Imsi, PduSessId: 1, AppSessionId: "app", Dnai: "edge"})
+	pduSess := ue.GetContext().PduSessions[0]
+	if len(pduSess.QosFlows) != 1 || pduSess.QosFlows[0].Qfi != 2 || pduSess.QosFlows[0].Qos != qos {
+		t.Errorf("got qos flows %+v after the qos update", pduSess.QosFlows)
+	}
+	if pduSess.Dnai != "edge" {
+		t.Errorf("got dnai %q after the routing update, want edge", pduSess.Dnai)
+	}
+
+	send(models.PcfToUeType, &models.PcfToUeMsg{Command: models.PcfQosUpdate, Supi: ue.Imsi, PduSessId: 1, AppSessionId: "app", Remove: true})
+	if flows := ue.GetContext().PduSessions[0].QosFlows; len(flows) != 0 {
+		t.Errorf("got qos flows %+v after the app session removal", flows)
+	}
+
+	send(models.SmfToUeType, &models.SmfToUeMsg{Command: models.SmfPduSessionRelease, Supi: ue.Imsi, PduSessId: 1})
+	if ue.HasPduSession(1) {
+		t.Fatal("PDU Session not released by the network")
+	}
+
+	// turned off UEs are not reachable
+	ue.TurnOff(false)
+	p.engine.deliver(gitc.Message{Type: models.AmfToUeType, Payload: &models.AmfToUeMsg{Command: models.AmfPaging, Supi: ue.Imsi}})
+	if n := p.engine.Len(); n > 3 {
+		t.Fatalf("%d pending events, downlink message queued for a turned off UE", n)
+	}
+}
+
+func TestEngineNetworkDeregistration(t *testing.T) {
+	p := newTestPopulation(t, 1)
+	ue := p.newUe(1)
+	ue.Pin(true)
+	ue.PowerUp()
+	ue.Register()
+	ue.Attach(10 * time.Second)
+	ue.NewPduSession(1, "internet", models.Snssai{Sst: 1}, true)
+	ue.Sleep(true)
+
+	p.engine.deliver(gitc.Message{Type: models.AmfToUeType, Payload: &models.AmfToUeMsg{
+		Command: models.AmfImplicitDeregistration,
+		Supi:    ue.Imsi,
+		Reason:  models.LOSSOFCONNECTIVITYREASONANYOF_MAX_DETECTION_TIME_EXPIRED,
+	}})
+	p.runUntil(time.Now())
+
+	if rm, cm := ue.GetStatus(); rm != models.RmStateDeregistered || cm != models.CmStateIdle {
+		t.Fatalf("UE is %s/%s after the implicit deregistration", rm, cm)
+	}
+	if ue.HasPduSession(1) {
+		t.Fatal("PDU Session kept after the implicit deregistration")
+	}
+	ue.statusMutex.Lock()
+	state := ue.ueState
+	ue.statusMutex.Unlock()
+	if state != models.Deregistered {
+		t.Fatalf("UE in markov state %v after the implicit deregistration", state)
+	}
+}
+
+// BenchmarkEngine runs one second of markov process of the whole population per
+// operation. The cost of a UE step and the number of routines do not depend on the
+// number of UEs: the time per operation grows linearly with the population.
+func BenchmarkEngine(b *testing.B) {
+	for _, numOfUe := range []int{1000, 10000, 100000, 1000000} {
+		b.Run(fmt.Sprintf("ues=%d", numOfUe), func(b *testing.B) {
+			if numOfUe > 100000 && testing.Short() {
+				b.Skip("skipping the million UEs profile in short mode")
+			}
+			p := newTestPopulation(b, 0)
+			for i := range numOfUe {
+				p.newUe(i).PowerUp()
+			}
+			runtime.GC()
+			start := time.Now()
+			routines := runtime.NumGoroutine()
+
+			b.ResetTimer()
+			for i := range b.N {
+				p.runUntil(start.Add(time.Duration(i+1) * markovPeriod))
+			}
+			b.StopTimer()
+
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*numOfUe), "ns/ue-step")
+			b.ReportMetric(float64(runtime.NumGoroutine()-routines), "routines")
+		})
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package ran
+
+import (
+	"fmt"
+	"sync"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+)
+
+// NCI is 36 bits max (values: 0 .. 2^36-1)
+const maxNCI = (1 << 36) - 1
+
+// A GnbPool holds the NR cells the UEs can camp on.
+// Cells can be added and decommissioned while the simulation runs.
+type GnbPool struct {
+	cells  []string
+	nextId uint64
+	mutex  sync.RWMutex
+}
+
+// NewGnbPool creates a pool with numOfGnb cells
+func NewGnbPool(numOfGnb int) *GnbPool {
+	pool := &GnbPool{
+		cells: []string{},
+	}
+	pool.Add(numOfGnb)
+	return pool
+}
+
+// Add spawns count new cells and returns their NR cell ids
+func (p *GnbPool) Add(count int) []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	added := []string{}
+	for i := 0; i < count && p.nextId <= maxNCI; i++ {
+		// %09x ensures 9 hex digits, lower-case, zero-padded
+		cellId := fmt.Sprintf("%09x", p.nextId)
+		p.nextId++
+		p.cells = append(p.cells, cellId)
+		added = append(added, cellId)
+	}
+	return added
+}
+
+// Remove decommissions a cell, it returns false if the cell is unknown
+func (p *GnbPool) Remove(cellId string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for i, cell := range p.cells {
+		if cell == cellId {
+			p.cells = append(p.cells[:i], p.cells[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Contains reports whether the cell is part of the pool
+func (p *GnbPool) Contains(cellId string) bool {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	for _, cell := range p.cells {
+		if cell == cellId {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the cell at the given position in the pool
+func (p *GnbPool) Get(index int) (string, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if index < 0 || index >= len(p.cells) {
+		return "", false
+	}
+	return p.cells[index], true
+}
+
+// List returns a copy of the cells of the pool
+func (p *GnbPool) List() []string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return append([]string{}, p.cells...)
+}
+
+func (p *GnbPool) Len() int {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	return len(p.cells)
+}
+
+// PickRandom returns a random cell different from exclude,
+// or an empty string if no such cell exists.
+func (p *GnbPool) PickRandom(random *utils.Random, exclude string) string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	candidates := len(p.cells)
+	for _, cell := range p.cells {
+		if cell == exclude {
+			candidates--
+		}
+	}
+	if candidates == 0 {
+		return ""
+	}
+
+	for {
+		idx := random.Intn(len(p.cells))
+		if p.cells[idx] != exclude {
+			return p.cells[idx]
+		}
+	}
+}
@@ -20,8 +20,7 @@
 package ran
 
 import (
-	"math/rand/v2"
-
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
 	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
 )
 
@@ -59,8 +58,8 @@ var transitions = map[models.UeState][]models.Transition{
 	},
 }
 
-func NextState(current models.UeState) (models.UeState, models.UeProcedure) {
-	rnd := rand.Float64()
+func NextState(random *utils.Random, current models.UeState) (models.UeState, models.UeProcedure) {
+	rnd := random.Float64()
 	cumulative := 0.0
 	for _, t := range transitions[current] {
 		cumulative += t.Probability
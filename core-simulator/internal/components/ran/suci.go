@@ -0,0 +1,201 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package ran
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* Subscription Concealed Identifier (TS 23.003 clause 2.2B, TS 33.501 Annex C) */
+
+const (
+	SuciNullScheme = "NULL"
+	SuciProfileA   = "PROFILE_A"
+	SuciProfileB   = "PROFILE_B"
+)
+
+// SuciConfig selects the protection scheme used by the UEs to conceal their SUPI.
+// The ECIES profiles require the public key of the home network, so that the SUCIs
+// can be deconcealed with its private key.
+type SuciConfig struct {
+	ProtectionScheme       string `yaml:"protectionScheme" json:"protectionScheme"`
+	HomeNetworkPublicKeyId int    `yaml:"homeNetworkPublicKeyId,omitempty" json:"homeNetworkPublicKeyId,omitempty"`
+	HomeNetworkPublicKey   string `yaml:"homeNetworkPublicKey,omitempty" json:"homeNetworkPublicKey,omitempty"`
+	RoutingIndicator       string `yaml:"routingIndicator,omitempty" json:"routingIndicator,omitempty"`
+
+	publicKey *ecdh.PublicKey
+	// generates the ephemeral keys, a fresh random key by default
+	generateKey func(curve ecdh.Curve) (*ecdh.PrivateKey, error)
+}
+
+// Validate checks the configuration and loads the home network public key
+func (c *SuciConfig) Validate() error {
+	if c.RoutingIndicator == "" {
+		c.RoutingIndicator = "0000"
+	}
+	if len(c.RoutingIndicator) > 4 || strings.Trim(c.RoutingIndicator, "0123456789") != "" {
+		return fmt.Errorf("routing indicator must be 1 to 4 digits")
+	}
+	if c.HomeNetworkPublicKeyId < 0 || c.HomeNetworkPublicKeyId > 255 {
+		return fmt.Errorf("home network public key id must be in [0, 255]")
+	}
+
+	var curve ecdh.Curve
+	switch c.ProtectionScheme {
+	case SuciNullScheme, "":
+		c.ProtectionScheme = SuciNullScheme
+		return nil
+	case SuciProfileA:
+		curve = ecdh.X25519()
+	case SuciProfileB:
+		curve = ecdh.P256()
+	default:
+		return fmt.Errorf("unknown protection scheme %q", c.ProtectionScheme)
+	}
+
+	if c.HomeNetworkPublicKey == "" {
+		return fmt.Errorf("%s requires a home network public key", c.ProtectionScheme)
+	}
+	keyBytes, err := hex.DecodeString(c.HomeNetworkPublicKey)
+	if err != nil {
+		return fmt.Errorf("home network public key must be hex encoded")
+	}
+	if c.ProtectionScheme == SuciProfileB && len(keyBytes) == 33 {
+		return fmt.Errorf("profile B home network public key must be uncompressed")
+	}
+	c.publicKey, err = curve.NewPublicKey(keyBytes)
+	if err != nil {
+		return fmt.Errorf("invalid home network public key: %w", err)
+	}
+	return nil
+}
+
+// Conceal builds the SUCI of an IMSI of the given PLMN, formatted as
+// suci-0-<mcc>-<mnc>-<routing indicator>-<scheme>-<key id>-<scheme output>
+func (c *SuciConfig) Conceal(plmn models.PlmnId, imsi string) (string, error) {
+	msin, ok := strings.CutPrefix(imsi, plmn.Mcc+plmn.Mnc)
+	if !ok || msin == "" || strings.Trim(msin, "0123456789") != "" {
+		return "", fmt.Errorf("imsi %s is not part of plmn %s-%s", imsi, plmn.Mcc, plmn.Mnc)
+	}
+
+	schemeId, keyId, output := 0, 0, msin
+	if c.ProtectionScheme != SuciNullScheme {
+		if c.publicKey == nil {
+			return "", fmt.Errorf("suci configuration not validated")
+		}
+		concealed, err := c.eciesEncrypt(bcdEncode(msin))
+		if err != nil {
+			return "", err
+		}
+		schemeId, keyId, output = 1, c.HomeNetworkPublicKeyId, concealed
+		if c.ProtectionScheme == SuciProfileB {
+			schemeId = 2
+		}
+	}
+
+	return fmt.Sprintf("suci-0-%s-%s-%s-%d-%d-%s", plmn.Mcc, plmn.Mnc, c.RoutingIndicator, schemeId, keyId, output), nil
+}
+
+// eciesEncrypt implements the ECIES scheme of TS 33.501 Annex C.3, it returns the
+// hex encoded ephemeral public key, ciphertext and MAC tag
+func (c *SuciConfig) eciesEncrypt(plaintext []byte) (string, error) {
+	generateKey := c.generateKey
+	if generateKey == nil {
+		generateKey = func(curve ecdh.Curve) (*ecdh.PrivateKey, error) {
+			return curve.GenerateKey(rand.Reader)
+		}
+	}
+	ephemeral, err := generateKey(c.publicKey.Curve())
+	if err != nil {
+		return "", fmt.Errorf("could not generate ephemeral key: %w", err)
+	}
+	sharedKey, err := ephemeral.ECDH(c.publicKey)
+	if err != nil {
+		return "", fmt.Errorf("could not derive shared key: %w", err)
+	}
+
+	ephemeralKey := ephemeral.PublicKey().Bytes()
+	if c.ProtectionScheme == SuciProfileB {
+		ephemeralKey = compressP256(ephemeralKey)
+	}
+
+	// enc key (16 bytes) | initial counter block (16 bytes) | mac key (32 bytes)
+	keys := ansiX963Kdf(sharedKey, ephemeralKey, 64)
+	block, err := aes.NewCipher(keys[:16])
+	if err != nil {
+		return "", err
+	}
+	ciphertext := make([]byte, len(plaintext))
+	cipher.NewCTR(block, keys[16:32]).XORKeyStream(ciphertext, plaintext)
+
+	mac := hmac.New(sha256.New, keys[32:])
+	mac.Write(ciphertext)
+	tag := mac.Sum(nil)[:8]
+
+	return hex.EncodeToString(ephemeralKey) + hex.EncodeToString(ciphertext) + hex.EncodeToString(tag), nil
+}
+
+// ansiX963Kdf derives length bytes from the shared secret with SHA-256
+func ansiX963Kdf(secret []byte, sharedInfo []byte, length int) []byte {
+	out := []byte{}
+	counter := make([]byte, 4)
+	for i := uint32(1); len(out) < length; i++ {
+		binary.BigEndian.PutUint32(counter, i)
+		h := sha256.New()
+		h.Write(secret)
+		h.Write(counter)
+		h.Write(sharedInfo)
+		out = h.Sum(out)
+	}
+	return out[:length]
+}
+
+// compressP256 converts an uncompressed P-256 point to its compressed form
+func compressP256(point []byte) []byte {
+	compressed := make([]byte, 33)
+	compressed[0] = 0x02 | (point[64] & 1)
+	copy(compressed[1:], point[1:33])
+	return compressed
+}
+
+// bcdEncode packs the digits two per byte, swapped, padding with 0xF
+func bcdEncode(digits string) []byte {
+	out := make([]byte, (len(digits)+1)/2)
+	for i := range out {
+		low := digits[2*i] - '0'
+		high := byte(0xF)
+		if 2*i+1 < len(digits) {
+			high = digits[2*i+1] - '0'
+		}
+		out[i] = high<<4 | low
+	}
+	return out
+}
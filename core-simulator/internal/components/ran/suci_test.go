@@ -0,0 +1,159 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package ran
+
+import (
+	"crypto/ecdh"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+// test vectors of the ECIES protection schemes, TS 33.501 Annex C.4
+var suciTestVectors = []struct {
+	scheme         string
+	schemeId       string
+	homePrivateKey string
+	// the home network public key as printed in the annex, compressed for profile B
+	homePublicKey string
+	ephemeralKey  string
+	// the plaintext block 00012080f6 is the BCD encoding of the MSIN
+	msin   string
+	output string
+}{
+	{
+		scheme:         SuciProfileA,
+		schemeId:       "1",
+		homePrivateKey: "c53c22208b61860b06c62e5406a7b330c2b577aa5558981510d128247d38bd1d",
+		homePublicKey:  "5a8d38864820197c3394b92613b20b91633cbd897119273bf8e4a6f4eec0a650",
+		ephemeralKey:   "c80949f13ebe61af4ebdbd293ea4f942696b9e815d7e8f0096bbf6ed7de62256",
+		msin:           "001002086",
+		output: "b2e92f836055a255837debf850b528997ce0201cb82adfe4be1f587d07d8457d" +
+			"cb02352410" + "cddd9e730ef3fa87",
+	},
+	{
+		scheme:         SuciProfileB,
+		schemeId:       "2",
+		homePrivateKey: "f1ab1074477ebcc7f554ea1c5fc368b1616730155e0041ac447d6301975fecda",
+		homePublicKey:  "0272da71976234ce833a6907425867b82e074d44ef907dfb4b3e21c1c2256ebcd1",
+		ephemeralKey:   "99798858a1dc6a2c68637149a4b1dbfd1fdff5addd62a2142f06699ed7602529",
+		msin:           "001002086",
+		output: "039aab8376597021e855679a9778ea0b67396e68c66df32c0f41e9acca2da9b9d1" +
+			"46a33fc271" + "6ac7dae96aa30a4d",
+	},
+}
+
+// homeNetworkPublicKey returns the hex encoded, uncompressed, public key of a home network private key
+func homeNetworkPublicKey(t *testing.T, scheme string, privateKey string) string {
+	t.Helper()
+	curve := ecdh.X25519()
+	if scheme == SuciProfileB {
+		curve = ecdh.P256()
+	}
+	keyBytes, _ := hex.DecodeString(privateKey)
+	key, err := curve.NewPrivateKey(keyBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hex.EncodeToString(key.PublicKey().Bytes())
+}
+
+func TestSuciEciesTestVectors(t *testing.T) {
+	plmn := models.PlmnId{Mcc: "001", Mnc: "01"}
+	for _, vector := range suciTestVectors {
+		t.Run(vector.scheme, func(t *testing.T) {
+			// the configuration takes the uncompressed key
+			homePublicKey, _ := hex.DecodeString(homeNetworkPublicKey(t, vector.scheme, vector.homePrivateKey))
+			printed := homePublicKey
+			if vector.scheme == SuciProfileB {
+				printed = compressP256(homePublicKey)
+			}
+			if hex.EncodeToString(printed) != vector.homePublicKey {
+				t.Fatalf("got home network public key %x, want %s", printed, vector.homePublicKey)
+			}
+
+			cfg := &SuciConfig{
+				ProtectionScheme:       vector.scheme,
+				HomeNetworkPublicKeyId: 3,
+				HomeNetworkPublicKey:   hex.EncodeToString(homePublicKey),
+			}
+			if err := cfg.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			ephemeralKey, _ := hex.DecodeString(vector.ephemeralKey)
+			cfg.generateKey = func(curve ecdh.Curve) (*ecdh.PrivateKey, error) {
+				return curve.NewPrivateKey(ephemeralKey)
+			}
+
+			suci, err := cfg.Conceal(plmn, plmn.Mcc+plmn.Mnc+vector.msin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := "suci-0-001-01-0000-" + vector.schemeId + "-3-" + vector.output
+			if suci != want {
+				t.Fatalf("got suci %s, want %s", suci, want)
+			}
+		})
+	}
+}
+
+func TestSuciNullScheme(t *testing.T) {
+	cfg := &SuciConfig{}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	suci, err := cfg.Conceal(models.PlmnId{Mcc: "001", Mnc: "06"}, "001060000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "suci-0-001-06-0000-0-0-0000000001"; suci != want {
+		t.Fatalf("got suci %s, want %s", suci, want)
+	}
+}
+
+func TestSuciRequiresHomeNetworkPublicKey(t *testing.T) {
+	for _, scheme := range []string{SuciProfileA, SuciProfileB} {
+		cfg := &SuciConfig{ProtectionScheme: scheme}
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "public key") {
+			t.Fatalf("%s: got error %v, want a missing public key", scheme, err)
+		}
+	}
+}
+
+func TestSuciRejectsImsiOutsideOfPlmn(t *testing.T) {
+	for _, scheme := range []string{SuciNullScheme, SuciProfileA, SuciProfileB} {
+		cfg := &SuciConfig{ProtectionScheme: scheme}
+		for _, vector := range suciTestVectors {
+			if vector.scheme == scheme {
+				cfg.HomeNetworkPublicKey = homeNetworkPublicKey(t, vector.scheme, vector.homePrivateKey)
+			}
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		for _, imsi := range []string{"208930000000001", "00106", "00106abc"} {
+			if suci, err := cfg.Conceal(models.PlmnId{Mcc: "001", Mnc: "06"}, imsi); err == nil || !strings.Contains(err.Error(), "plmn") {
+				t.Fatalf("%s: got suci %q for imsi %s, want an error", scheme, suci, imsi)
+			}
+		}
+	}
+}
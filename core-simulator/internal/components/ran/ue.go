@@ -22,11 +22,11 @@ package ran
 import (
 	"context"
 	"log"
-	"math/rand"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/giuliocarot0/gitc"
 	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
 	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
 	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/monitoring"
@@ -39,6 +39,7 @@ import (
 type Ue struct {
 	ctx       context.Context
 	cancelFun context.CancelFunc
+	parentCtx context.Context
 
 	// identifiers
 	Imsi  string
@@ -70,9 +71,48 @@ type Ue struct {
 	IncactivityTimer time.Duration
 
 	//simulation variables
-	Profile string
-	simId   string
-	gnbList []string
+	Profile   string
+	simId     string
+	gnbs      *GnbPool
+	engine    *Engine
+	shard     *shard
+	suci      *SuciConfig
+	traffic   *trafficgen.Catalog
+	scheduler *trafficgen.Scheduler
+	apps      []Application
+	pinned    bool
+	poweredOn bool
+	// a single inactivity timer runs per power cycle, whatever the number of attaches
+	inactivityArmed bool
+}
+
+// UeContext is a point in time view of the UE status
+type UeContext struct {
+	Imsi             string              `json:"imsi"`
+	Msisdn           string              `json:"msisdn"`
+	Imei             string              `json:"imei"`
+	Profile          string              `json:"profile"`
+	RmState          models.RmState      `json:"rmState"`
+	CmState          models.CmState      `json:"cmState"`
+	CurrentCellId    string              `json:"currentCellId"`
+	Pinned           bool                `json:"pinned"`
+	PoweredOn        bool                `json:"poweredOn"`
+	LastActivityTime time.Time           `json:"lastActivityTime"`
+	PduSessions      []PduSessionContext `json:"pduSessions"`
+}
+
+type PduSessionContext struct {
+	Id           int32             `json:"pduSessionId"`
+	Ipv4         string            `json:"ipv4Addr"`
+	Dnn          string            `json:"dnn"`
+	Snssai       models.Snssai     `json:"snssai"`
+	Qos          models.SessionQos `json:"qos"`
+	QosFlows     []models.QosFlow  `json:"qosFlows,omitempty"`
+	Dnai         string            `json:"dnai,omitempty"`
+	TotalUlBytes int64             `json:"totalUlBytes"`
+	TotalDlBytes int64             `json:"totalDlBytes"`
+	NumUlPackets int64             `json:"numUlPackets"`
+	NumDlPackets int64             `json:"numDlPackets"`
 }
 
 type UeConfig struct {
@@ -83,17 +123,27 @@ type UeConfig struct {
 	Snssai models.Snssai
 	Type   string
 	Plmn   models.PlmnId
+	// optional SUPI concealment, SUCIs are reported at registration
+	Suci *SuciConfig
+	// traffic profiles and application mix of the device class
+	Traffic      *trafficgen.Catalog
+	Applications []Application
+	// scheduler driving the traffic sessions of the simulation
+	Scheduler *trafficgen.Scheduler
+	// engine running the markov process and the timers of the UE
+	Engine *Engine
 }
 
 // NewUserEquipement creates a Ue instance with the provided configuration
 // It takes a UeConfig type as input
 // It retruns a pointer to the initialized Ue instance
-func NewUserEquipment(ctx context.Context, cfg UeConfig, ipManager *utils.IPAllocator, simulationId string, gnbs []string) *Ue {
+func NewUserEquipment(ctx context.Context, cfg UeConfig, ipManager *utils.IPAllocator, simulationId string, gnbs *GnbPool) *Ue {
 	ueCtx, ueCancelFunc := context.WithCancel(ctx)
 
 	return &Ue{
 		ctx:              ueCtx,
 		cancelFun:        ueCancelFunc,
+		parentCtx:        ctx,
 		PlmnId:           cfg.Plmn,
 		Imsi:             cfg.Imsi,
 		Msidn:            cfg.Msidn,
@@ -108,12 +158,18 @@ func NewUserEquipment(ctx context.Context, cfg UeConfig, ipManager *utils.IPAllo
 		statusMutex:      sync.RWMutex{},
 		statsMutex:       sync.RWMutex{},
 		HasUplinkData:    false,
-		LastActivityTime: time.Now(),
+		LastActivityTime: cfg.Engine.clock.Now(),
 		// set ACCESS TYPE to 3GPP by default
 		accessType: models.ACCESSTYPE__3_GPP_ACCESS,
 		ipManager:  ipManager,
 		simId:      simulationId,
-		gnbList:    gnbs,
+		gnbs:       gnbs,
+		engine:     cfg.Engine,
+		shard:      cfg.Engine.shardOf(cfg.Imsi),
+		suci:       cfg.Suci,
+		traffic:    cfg.Traffic,
+		scheduler:  cfg.Scheduler,
+		apps:       cfg.Applications,
 	}
 }
 
@@ -123,7 +179,19 @@ func (ue *Ue) Register() {
 	ue.statusMutex.Lock()
 	defer ue.statusMutex.Unlock()
 
-	ue.CurrentCellId = ue.pickRandomNRCellID()
+	if ue.RmStatus == models.RmStateRegistered {
+		log.Printf("[%s] ue is already registered to the network", ue.Imsi)
+		return
+	}
+
+	cellId := ue.gnbs.PickRandom(ue.engine.Random, "")
+	if cellId == "" {
+		// all the cells have been decommissioned
+		log.Printf("[%s] no cell available, cannot register", ue.Imsi)
+		ue.ueState = models.Deregistered
+		return
+	}
+	ue.CurrentCellId = cellId
 
 	ue.RmStatus = models.RmStateRegistered
 	log.Printf("[%s] successfully registered to the network, cellId: %s", ue.Imsi, ue.CurrentCellId)
@@ -131,7 +199,7 @@ func (ue *Ue) Register() {
 	/*prepare gitc message for AMF*/
 	msg := &models.UeToAmfMsg{
 		EventType:     models.AMFEVENTTYPEANYOF_REGISTRATION_STATE_REPORT,
-		TimeStamp:     time.Now(),
+		TimeStamp:     ue.now(),
 		RmState:       ue.RmStatus,
 		CmState:       ue.CmStatus,
 		Supi:          ue.Imsi,
@@ -139,15 +207,24 @@ func (ue *Ue) Register() {
 		PlmnId:        ue.PlmnId,
 		CurrentCellId: ue.CurrentCellId,
 		AccessType:    ue.accessType,
+		Pei:           ue.Imei,
 	}
-	if err := gitc.Send(ue.Imsi, "AMF", models.UeToAmfType, msg); err != nil {
+	if ue.suci != nil {
+		// profile A and B produce a fresh SUCI at every registration
+		suci, err := ue.suci.Conceal(ue.PlmnId, ue.Imsi)
+		if err != nil {
+			log.Printf("[%s] could not conceal supi: %v", ue.Imsi, err)
+		}
+		msg.Suci = suci
+	}
+	if err := ue.engine.Dispatcher.Send(ue.Imsi, "AMF", models.UeToAmfType, msg); err != nil {
 		log.Printf("Error sending UeToAmfMsg for UE %s: %v", ue.Imsi, err)
 	}
 
 	/*prepare gitc message for AMF*/
 	msg2 := &models.UeToAmfMsg{
 		EventType:     models.AMFEVENTTYPEANYOF_LOCATION_REPORT,
-		TimeStamp:     time.Now(),
+		TimeStamp:     ue.now(),
 		RmState:       ue.RmStatus,
 		CmState:       ue.CmStatus,
 		Supi:          ue.Imsi,
@@ -156,7 +233,7 @@ func (ue *Ue) Register() {
 		CurrentCellId: ue.CurrentCellId,
 		AccessType:    ue.accessType,
 	}
-	if err := gitc.Send(ue.Imsi, "AMF", models.UeToAmfType, msg2); err != nil {
+	if err := ue.engine.Dispatcher.Send(ue.Imsi, "AMF", models.UeToAmfType, msg2); err != nil {
 		log.Printf("Error sending UeToAmfMsg for UE %s: %v", ue.Imsi, err)
 	}
 
@@ -169,7 +246,7 @@ func (ue *Ue) Register() {
 // It triggers to connectivity report event which is logged by the simulation core.
 // It checks if the UE is registered before allowing the attach.
 // If the UE is not registered, it logs an error message and does not proceed with the attach.
-// It also starts the inactivity monitor to manage the UE's activity status.
+// It also arms the inactivity timer to manage the UE's activity status, unless it is already running.
 func (ue *Ue) Attach(inactivityTimer time.Duration) {
 	ue.statusMutex.Lock()
 	defer ue.statusMutex.Unlock()
@@ -184,7 +261,7 @@ func (ue *Ue) Attach(inactivityTimer time.Duration) {
 	/*prepare gitc message for AMF*/
 	msg := &models.UeToAmfMsg{
 		EventType:     models.AMFEVENTTYPEANYOF_CONNECTIVITY_STATE_REPORT,
-		TimeStamp:     time.Now(),
+		TimeStamp:     ue.now(),
 		RmState:       ue.RmStatus,
 		CmState:       ue.CmStatus,
 		Supi:          ue.Imsi,
@@ -193,7 +270,7 @@ func (ue *Ue) Attach(inactivityTimer time.Duration) {
 		CurrentCellId: ue.CurrentCellId,
 		AccessType:    ue.accessType,
 	}
-	if err := gitc.Send(ue.Imsi, "AMF", models.UeToAmfType, msg); err != nil {
+	if err := ue.engine.Dispatcher.Send(ue.Imsi, "AMF", models.UeToAmfType, msg); err != nil {
 		log.Printf("Error sending UeToAmfMsg for UE %s: %v", ue.Imsi, err)
 	}
 
@@ -201,19 +278,71 @@ func (ue *Ue) Attach(inactivityTimer time.Duration) {
 	//monitoring.UEsTotal.WithLabelValues(ue.simId, string(models.CmStateIdle)).Dec()
 
 	/*start here the Inactivity Timer*/
-	go ue.inactivityMonitor(inactivityTimer)
+	ue.IncactivityTimer = inactivityTimer
+	if !ue.inactivityArmed {
+		ue.inactivityArmed = true
+		ue.shard.schedule(ue, ue.ctx, inactivityCheck, 0, inactivityTimer)
+	}
+}
+
+// Deregister performs a UE initiated deregistration.
+// It releases all the PDU Sessions and triggers the registration state report event.
+// If the UE is not registered, it logs an error message and does not proceed with the deregistration.
+func (ue *Ue) Deregister() {
+	ue.statusMutex.Lock()
+	defer ue.statusMutex.Unlock()
+
+	if ue.RmStatus != models.RmStateRegistered {
+		log.Printf("[%s] ue is not registered to the network, cannot deregister", ue.Imsi)
+		return
+	}
+
+	ue.CmStatus = models.CmStateIdle
+	ue.RmStatus = models.RmStateDeregistered
+	ue.ueState = models.Deregistered
+	log.Printf("[%s] successfully deregistered from the network", ue.Imsi)
+
+	/*prepare gitc message for AMF*/
+	msg := &models.UeToAmfMsg{
+		EventType:     models.AMFEVENTTYPEANYOF_REGISTRATION_STATE_REPORT,
+		TimeStamp:     ue.now(),
+		RmState:       ue.RmStatus,
+		CmState:       ue.CmStatus,
+		Supi:          ue.Imsi,
+		Gpsi:          ue.Msidn,
+		PlmnId:        ue.PlmnId,
+		CurrentCellId: ue.CurrentCellId,
+		AccessType:    ue.accessType,
+	}
+	if err := ue.engine.Dispatcher.Send(ue.Imsi, "AMF", models.UeToAmfType, msg); err != nil {
+		log.Printf("Error sending UeToAmfMsg for UE %s: %v", ue.Imsi, err)
+	}
+
+	monitoring.UEsTotal.WithLabelValues(ue.simId, string(models.RmStateRegistered)).Dec()
+	monitoring.UEsTotal.WithLabelValues(ue.simId, string(models.RmStateDeregistered)).Inc()
+
+	for sessionId := range ue.PduSessions {
+		ue.releasePduSession(sessionId)
+	}
 }
 
 // It kills the UE RF.
 // To trigger the loss of connectivity event, lossOfConnection must be set to true.
 func (ue *Ue) LossOfConnection(isGracefully bool) {
+	ue.statusMutex.Lock()
+	defer ue.statusMutex.Unlock()
+
+	if ue.RmStatus != models.RmStateRegistered {
+		log.Printf("[%s] ue is not registered to the network, no connection to lose", ue.Imsi)
+		return
+	}
 
 	log.Printf("[%s] connection lost", ue.Imsi)
 
 	/*prepare gitc message for AMF*/
 	msg := &models.UeToAmfMsg{
 		EventType:     models.AMFEVENTTYPEANYOF_LOSS_OF_CONNECTIVITY,
-		TimeStamp:     time.Now(),
+		TimeStamp:     ue.now(),
 		RmState:       ue.RmStatus,
 		CmState:       ue.CmStatus,
 		Supi:          ue.Imsi,
@@ -222,19 +351,17 @@ func (ue *Ue) LossOfConnection(isGracefully bool) {
 		CurrentCellId: ue.CurrentCellId,
 		AccessType:    ue.accessType,
 	}
-	if err := gitc.Send(ue.Imsi, "AMF", models.UeToAmfType, msg); err != nil {
+	if err := ue.engine.Dispatcher.Send(ue.Imsi, "AMF", models.UeToAmfType, msg); err != nil {
 		log.Printf("Error sending UeToAmfMsg for UE %s: %v", ue.Imsi, err)
 	}
 
-	ue.statusMutex.Lock()
-	defer ue.statusMutex.Unlock()
 	ue.CmStatus = models.CmStateIdle
 	ue.RmStatus = models.RmStateDeregistered
 
 	if isGracefully {
 		msg := &models.UeToAmfMsg{
 			EventType:     models.AMFEVENTTYPEANYOF_REGISTRATION_STATE_REPORT,
-			TimeStamp:     time.Now(),
+			TimeStamp:     ue.now(),
 			RmState:       ue.RmStatus,
 			CmState:       ue.CmStatus,
 			Supi:          ue.Imsi,
@@ -243,7 +370,7 @@ func (ue *Ue) LossOfConnection(isGracefully bool) {
 			CurrentCellId: ue.CurrentCellId,
 			AccessType:    ue.accessType,
 		}
-		if err := gitc.Send(ue.Imsi, "AMF", models.UeToAmfType, msg); err != nil {
+		if err := ue.engine.Dispatcher.Send(ue.Imsi, "AMF", models.UeToAmfType, msg); err != nil {
 			log.Printf("Error sending UeToAmfMsg for UE %s: %v", ue.Imsi, err)
 		}
 	}
@@ -254,8 +381,8 @@ func (ue *Ue) LossOfConnection(isGracefully bool) {
 	//monitoring.UEsTotal.WithLabelValues(ue.simId, string(models.CmStateConnected)).Dec()
 	//monitoring.UEsTotal.WithLabelValues(ue.simId, string(models.CmStateIdle)).Inc()
 
-	for i := range len(ue.PduSessions) {
-		ue.ReleasePduSession(int32(i + 1))
+	for sessionId := range ue.PduSessions {
+		ue.releasePduSession(sessionId)
 	}
 
 }
@@ -268,7 +395,7 @@ func (ue *Ue) LossOfConnection(isGracefully bool) {
 func (ue *Ue) NewPduSession(sessionId int32, dnn string, snssai models.Snssai, enableReport bool) {
 	ue.statusMutex.Lock()
 	defer ue.statusMutex.Unlock()
-	ue.LastActivityTime = time.Now()
+	ue.LastActivityTime = ue.now()
 
 	if ue.CmStatus != models.CmStateConnected {
 		log.Printf("[%s] ue is not attached to the network, cannot establish PDU Session", ue.Imsi)
@@ -287,6 +414,7 @@ func (ue *Ue) NewPduSession(sessionId int32, dnn string, snssai models.Snssai, e
 		Ipv4:         ip,
 		Snssai:       snssai,
 		Dnn:          dnn,
+		Qos:          DefaultQos,
 		Ctx:          pduCtx,
 		CtxCancelFun: pduCancelFunc,
 	}
@@ -297,7 +425,7 @@ func (ue *Ue) NewPduSession(sessionId int32, dnn string, snssai models.Snssai, e
 	/*prepare gitc message for SMF*/
 	msg := &models.UeToSmfMsg{
 		EventType:   models.SMFEVENTANYOF_PDU_SES_EST,
-		TimeStamp:   time.Now(),
+		TimeStamp:   ue.now(),
 		Dnn:         dnn,
 		Snssai:      snssai,
 		PduSessType: models.PDUSESSIONTYPEANYOF_IPV4,
@@ -309,13 +437,15 @@ func (ue *Ue) NewPduSession(sessionId int32, dnn string, snssai models.Snssai, e
 		AccessType:  ue.accessType,
 	}
 
-	if err := gitc.Send(ue.Imsi, "SMF", models.UeToSmfType, msg); err != nil {
+	if err := ue.engine.Dispatcher.Send(ue.Imsi, "SMF", models.UeToSmfType, msg); err != nil {
 		log.Printf("Error sending UeToSmfType for UE %s: %v", ue.Imsi, err)
 	}
 
 	if enableReport {
-		ue.UpStats[sessionId] = models.NewUpStats(sessionId)
-		go ue.userplaneReport(pduCtx, sessionId)
+		ue.statsMutex.Lock()
+		ue.UpStats[sessionId] = models.NewUpStats(sessionId, ue.now())
+		ue.statsMutex.Unlock()
+		ue.shard.schedule(ue, pduCtx, userplaneReport, sessionId, userplaneReportPeriod)
 
 	}
 
@@ -328,9 +458,17 @@ func (ue *Ue) NewPduSession(sessionId int32, dnn string, snssai models.Snssai, e
 // It accepts a sessionId as input and removes the session from the PDU Sessions map.
 // It does not perform any additional actions or checks.
 func (ue *Ue) ReleasePduSession(sessionId int32) {
+	ue.statusMutex.Lock()
+	defer ue.statusMutex.Unlock()
+	ue.releasePduSession(sessionId)
+}
+
+// releasePduSession releases a PDU Session, the caller holds the status mutex
+func (ue *Ue) releasePduSession(sessionId int32) {
 	pduSess, exists := ue.PduSessions[sessionId]
 	if !exists {
 		log.Printf("[%s] invalid pduSessionId %d, cannot release", ue.Imsi, sessionId)
+		return
 	}
 
 	err := ue.ipManager.ReleaseIP(ue.Imsi, sessionId)
@@ -341,7 +479,7 @@ func (ue *Ue) ReleasePduSession(sessionId int32) {
 	/*prepare gitc message for SMF*/
 	msg := &models.UeToSmfMsg{
 		EventType:   models.SMFEVENTANYOF_PDU_SES_REL,
-		TimeStamp:   time.Now(),
+		TimeStamp:   ue.now(),
 		Dnn:         pduSess.Dnn,
 		Snssai:      pduSess.Snssai,
 		PduSessType: models.PDUSESSIONTYPEANYOF_IPV4,
@@ -360,7 +498,7 @@ func (ue *Ue) ReleasePduSession(sessionId int32) {
 	delete(ue.PduSessions, sessionId)
 	log.Printf("[%s] released pduSessionId %d", ue.Imsi, sessionId)
 
-	if err := gitc.Send(ue.Imsi, "SMF", models.UeToSmfType, msg); err != nil {
+	if err := ue.engine.Dispatcher.Send(ue.Imsi, "SMF", models.UeToSmfType, msg); err != nil {
 		log.Printf("Error sending UeToSmfType for UE %s: %v", ue.Imsi, err)
 	}
 
@@ -376,13 +514,18 @@ func (ue *Ue) Sleep(force bool) {
 	ue.statusMutex.Lock()
 	defer ue.statusMutex.Unlock()
 
+	if ue.RmStatus != models.RmStateRegistered || ue.CmStatus != models.CmStateConnected {
+		log.Printf("[%s] ue is not connected to the network, cannot activate idle mode", ue.Imsi)
+		return
+	}
+
 	ue.CmStatus = models.CmStateIdle
 	log.Printf("[%s] successfully activated idle mode", ue.Imsi)
 
 	/*prepare gitc message for AMF*/
 	msg := &models.UeToAmfMsg{
 		EventType:     models.AMFEVENTTYPEANYOF_CONNECTIVITY_STATE_REPORT,
-		TimeStamp:     time.Now(),
+		TimeStamp:     ue.now(),
 		RmState:       ue.RmStatus,
 		CmState:       ue.CmStatus,
 		Supi:          ue.Imsi,
@@ -391,7 +534,7 @@ func (ue *Ue) Sleep(force bool) {
 		CurrentCellId: ue.CurrentCellId,
 		AccessType:    ue.accessType,
 	}
-	if err := gitc.Send(ue.Imsi, "AMF", models.UeToAmfType, msg); err != nil {
+	if err := ue.engine.Dispatcher.Send(ue.Imsi, "AMF", models.UeToAmfType, msg); err != nil {
 		log.Printf("Error sending UeToAmfMsg for UE %s: %v", ue.Imsi, err)
 	}
 
@@ -415,7 +558,7 @@ func (ue *Ue) WakeUp(isPaging bool) {
 		return
 	}
 	if ue.CmStatus != models.CmStateConnected {
-		ue.LastActivityTime = time.Now()
+		ue.LastActivityTime = ue.now()
 		if isPaging {
 			log.Printf("[%s] paging", ue.Imsi)
 		} else {
@@ -425,7 +568,7 @@ func (ue *Ue) WakeUp(isPaging bool) {
 		/*prepare gitc message for AMF*/
 		msg := &models.UeToAmfMsg{
 			EventType:     models.AMFEVENTTYPEANYOF_CONNECTIVITY_STATE_REPORT,
-			TimeStamp:     time.Now(),
+			TimeStamp:     ue.now(),
 			RmState:       ue.RmStatus,
 			CmState:       ue.CmStatus,
 			Supi:          ue.Imsi,
@@ -434,7 +577,7 @@ func (ue *Ue) WakeUp(isPaging bool) {
 			CurrentCellId: ue.CurrentCellId,
 			AccessType:    ue.accessType,
 		}
-		if err := gitc.Send(ue.Imsi, "AMF", models.UeToAmfType, msg); err != nil {
+		if err := ue.engine.Dispatcher.Send(ue.Imsi, "AMF", models.UeToAmfType, msg); err != nil {
 			log.Printf("Error sending UeToAmfMsg for UE %s: %v", ue.Imsi, err)
 		}
 	}
@@ -446,87 +589,77 @@ func (ue *Ue) WakeUp(isPaging bool) {
 // StartTrafficSession starts a traffic session for the specified PDU Session ID.
 // It accepts a sessionId and a trafficProfile as input.
 // It checks if the PDU Session exists in the PDU Sessions map and logs an error message if it does not.
-// It initializes a timer for the traffic session duration and starts a goroutine to generate traffic.
-// The traffic generation is simulated using a traffic generator, which can be customized based on the traffic profile.
-// The goroutine continuously generates packets until the timer expires or the context is done.
-// It updates the uplink statistics for the session, including the number of packets, total bytes, packet rate, and bitrate.
-// If the timer expires, it logs the end of the traffic session and resets the uplink data status.
+// When durationSec is zero the duration is drawn from the traffic profile, a zero duration lasts
+// until the PDU Session is released.
+// The traffic generator built from the traffic profile is driven by the traffic scheduler of the
+// simulation, which reports the emitted packets in batches until the session ends or the PDU
+// Session is released.
 func (ue *Ue) StartTrafficSession(sessionId int32, ul bool, trafficProfile string, durationSec uint) {
+	ue.statusMutex.RLock()
 	pduSess, exists := ue.PduSessions[sessionId]
+	ue.statusMutex.RUnlock()
 	if !exists {
 		log.Printf("[%s] invalid pduSessionId %d, cannot start traffic", ue.Imsi, sessionId)
+		return
 	}
 
-	var timerChannel <-chan time.Time
-
-	if durationSec > 0 {
-		timer := time.NewTimer(time.Duration(10) * time.Second)
-		timerChannel = timer.C
-	} else {
-		timerChannel = nil
-	}
-
-	go func(ctx context.Context) {
-		var trafficGen trafficgen.TrafficGenerator
-		switch trafficProfile {
-		case "web":
-			trafficGen = trafficgen.NewWebTraffic(2e6, 1200, 20*time.Second, 40*time.Second)
-		case "video":
-			trafficGen = trafficgen.NewVideoTraffic(8e6, 1300)
-		case "iot":
-			trafficGen = trafficgen.NewIoTTraffic(1000, 15*time.Second)
-		case "sip":
-			trafficGen = trafficgen.NewVoIPTraffic(600, 50)
-		default:
-			log.Printf("[%s] unknown traffic profile %s, using default web traffic", ue.Imsi, trafficProfile)
-			// Default to web traffic if no profile is specified
-			trafficGen = trafficgen.NewWebTraffic(2e6, 1200, 6*time.Second, 10*time.Second)
-		}
-		//trafficGen := trafficgen.NewVideoTraffic(8e6, 1300)
-		//trafficGen := trafficgen.NewIoTTraffic(1000, 15*time.Second)
-
-		for {
-			select {
-			case <-timerChannel:
-				log.Printf("[%s] traffic session ended for UE %d", ue.Imsi, sessionId)
-				return
-
-			case <-ctx.Done():
-				log.Printf("[%s] traffic session cancelled for UE %d", ue.Imsi, sessionId)
-				return
-			default:
-				//log.Printf("[%s] traffic session ongoing for session %d", ue.Imsi, sessionId)
-				now := time.Now()
-				pkt := trafficGen.NextPacket(now)
-
-				if pkt != nil {
-
-					ue.WakeUp(false)
-					ue.statusMutex.Lock()
-					ue.LastActivityTime = now
-					ue.statusMutex.Unlock()
-
-					ue.statsMutex.Lock()
-					if stats, exists := ue.UpStats[sessionId]; exists {
-						stats.NewPacket(ul, int64(pkt.SizeBytes), now)
-						if ul {
-							monitoring.TrafficPackets.WithLabelValues(ue.simId, ue.Imsi, "UL").Inc()
-							monitoring.TrafficBytes.WithLabelValues(ue.simId, ue.Imsi, "UL").Add(float64(pkt.SizeBytes))
-							monitoring.TotalTraffic.WithLabelValues(ue.simId, "UL").Add(float64(pkt.SizeBytes))
-						} else {
-							monitoring.TrafficPackets.WithLabelValues(ue.simId, ue.Imsi, "DL").Inc()
-							monitoring.TrafficBytes.WithLabelValues(ue.simId, ue.Imsi, "DL").Add(float64(pkt.SizeBytes))
-							monitoring.TotalTraffic.WithLabelValues(ue.simId, "UL").Add(float64(pkt.SizeBytes))
-						}
-					}
-					ue.statsMutex.Unlock()
-
-				}
-
-				time.Sleep(1 * time.Millisecond) // Simulate ongoing traffic
-			}
-		}
-	}(pduSess.Ctx)
+	profile, ok := ue.traffic.Get(trafficProfile)
+	if !ok {
+		log.Printf("[%s] unknown traffic profile %s, using default web traffic", ue.Imsi, trafficProfile)
+		// Default to web traffic if no profile is specified
+		profile, _ = ue.traffic.Get("web")
+	}
+	trafficGen, err := profile.NewGenerator(ue.engine.Random)
+	if err != nil {
+		log.Printf("[%s] cannot start %s traffic: %v", ue.Imsi, trafficProfile, err)
+		return
+	}
+
+	duration := time.Duration(durationSec) * time.Second
+	if duration == 0 {
+		duration = profile.SessionDuration(ue.engine.Random)
+	}
+
+	ue.scheduler.Start(pduSess.Ctx, trafficgen.SessionConfig{
+		Generator: trafficGen,
+		Uplink:    ul,
+		Duration:  duration,
+		Report: func(batch trafficgen.Batch) {
+			ue.reportTraffic(sessionId, batch)
+		},
+		OnEnd: func(reason trafficgen.EndReason) {
+			log.Printf("[%s] traffic session %s for PDU Session %d", ue.Imsi, reason, sessionId)
+		},
+	})
+}
+
+// reportTraffic accounts the packets emitted by a traffic session
+func (ue *Ue) reportTraffic(sessionId int32, batch trafficgen.Batch) {
+	ue.WakeUp(false)
+	ue.statusMutex.Lock()
+	if last := batch.Last(); last.After(ue.LastActivityTime) {
+		ue.LastActivityTime = last
+	}
+	ue.statusMutex.Unlock()
+
+	ue.statsMutex.Lock()
+	defer ue.statsMutex.Unlock()
+	stats, exists := ue.UpStats[sessionId]
+	if !exists {
+		return
+	}
+	if batch.UlPackets > 0 {
+		stats.AddPackets(true, batch.UlPackets, batch.UlBytes, batch.LastUl)
+		monitoring.TrafficPackets.WithLabelValues(ue.simId, ue.Imsi, "UL").Add(float64(batch.UlPackets))
+		monitoring.TrafficBytes.WithLabelValues(ue.simId, ue.Imsi, "UL").Add(float64(batch.UlBytes))
+		monitoring.TotalTraffic.WithLabelValues(ue.simId, "UL").Add(float64(batch.UlBytes))
+	}
+	if batch.DlPackets > 0 {
+		stats.AddPackets(false, batch.DlPackets, batch.DlBytes, batch.LastDl)
+		monitoring.TrafficPackets.WithLabelValues(ue.simId, ue.Imsi, "DL").Add(float64(batch.DlPackets))
+		monitoring.TrafficBytes.WithLabelValues(ue.simId, ue.Imsi, "DL").Add(float64(batch.DlBytes))
+		monitoring.TotalTraffic.WithLabelValues(ue.simId, "DL").Add(float64(batch.DlBytes))
+	}
 }
 
 // DoHandover performs a handover for the UE to a target cell.
@@ -539,14 +672,21 @@ func (ue *Ue) DoHandover(targetCellId string) {
 	ue.statusMutex.Lock()
 	defer ue.statusMutex.Unlock()
 
+	if ue.RmStatus != models.RmStateRegistered {
+		log.Printf("[%s] ue is not registered to the network, cannot hand over", ue.Imsi)
+		return
+	}
+
 	// freeze all the curent procedures
 
 	log.Printf("[%s] handover to cell %s", ue.Imsi, targetCellId)
+	ue.LastActivityTime = ue.now()
+	ue.CurrentCellId = targetCellId
 
 	/*prepare gitc message for AMF*/
 	msg := &models.UeToAmfMsg{
 		EventType:     models.AMFEVENTTYPEANYOF_LOCATION_REPORT,
-		TimeStamp:     time.Now(),
+		TimeStamp:     ue.now(),
 		RmState:       ue.RmStatus,
 		CmState:       ue.CmStatus,
 		Supi:          ue.Imsi,
@@ -555,174 +695,346 @@ func (ue *Ue) DoHandover(targetCellId string) {
 		CurrentCellId: ue.CurrentCellId,
 		AccessType:    ue.accessType,
 	}
-	if err := gitc.Send(ue.Imsi, "AMF", models.UeToAmfType, msg); err != nil {
+	if err := ue.engine.Dispatcher.Send(ue.Imsi, "AMF", models.UeToAmfType, msg); err != nil {
 		log.Printf("Error sending UeToAmfMsg for UE %s: %v", ue.Imsi, err)
 	}
+}
 
-	ue.LastActivityTime = time.Now()
-	ue.CurrentCellId = targetCellId
+/* ue inactivity timer, it returns the delay of the next check or zero once disarmed */
+func (ue *Ue) checkInactivity() time.Duration {
+	ue.statusMutex.Lock()
+	defer ue.statusMutex.Unlock()
 
-}
+	inactivityTimer := ue.IncactivityTimer
+	if inactivityTimer <= 0 {
+		ue.inactivityArmed = false
+		return 0
+	}
+	maxTolleratedInactivity := ue.now().Add(-inactivityTimer)
 
-/* ue inactivity monitor routine*/
-func (ue *Ue) inactivityMonitor(inactivityTimer time.Duration) {
-	for {
-		select {
-		case <-ue.ctx.Done():
-			return
-		case <-time.After(inactivityTimer):
-			maxTolleratedInactivity := time.Now().Add(-inactivityTimer)
-
-			ue.statusMutex.Lock()
-
-			if ue.CmStatus == models.CmStateConnected && ue.LastActivityTime.Before(maxTolleratedInactivity) {
-				log.Printf("[%s] ue inactivity timer expired, idle mode", ue.Imsi)
-				ue.CmStatus = models.CmStateIdle
-				ue.ueState = models.Idle
-				/*prepare gitc message for AMF*/
-				msg := &models.UeToAmfMsg{
-					EventType:     models.AMFEVENTTYPEANYOF_CONNECTIVITY_STATE_REPORT,
-					TimeStamp:     time.Now(),
-					RmState:       ue.RmStatus,
-					CmState:       ue.CmStatus,
-					Supi:          ue.Imsi,
-					Gpsi:          ue.Msidn,
-					PlmnId:        ue.PlmnId,
-					CurrentCellId: ue.CurrentCellId,
-					AccessType:    ue.accessType,
-				}
-				if err := gitc.Send(ue.Imsi, "AMF", models.UeToAmfType, msg); err != nil {
-					log.Printf("Error sending UeToAmfMsg for UE %s: %v", ue.Imsi, err)
-				}
-			}
-
-			//monitoring.UEsTotal.WithLabelValues(ue.simId, string(models.CmStateIdle)).Inc()
-			//monitoring.UEsTotal.WithLabelValues(ue.simId, string(models.CmStateConnected)).Dec()
-
-			ue.statusMutex.Unlock()
+	if ue.CmStatus == models.CmStateConnected && ue.LastActivityTime.Before(maxTolleratedInactivity) {
+		log.Printf("[%s] ue inactivity timer expired, idle mode", ue.Imsi)
+		ue.CmStatus = models.CmStateIdle
+		ue.ueState = models.Idle
+		/*prepare gitc message for AMF*/
+		msg := &models.UeToAmfMsg{
+			EventType:     models.AMFEVENTTYPEANYOF_CONNECTIVITY_STATE_REPORT,
+			TimeStamp:     ue.now(),
+			RmState:       ue.RmStatus,
+			CmState:       ue.CmStatus,
+			Supi:          ue.Imsi,
+			Gpsi:          ue.Msidn,
+			PlmnId:        ue.PlmnId,
+			CurrentCellId: ue.CurrentCellId,
+			AccessType:    ue.accessType,
+		}
+		if err := ue.engine.Dispatcher.Send(ue.Imsi, "AMF", models.UeToAmfType, msg); err != nil {
+			log.Printf("Error sending UeToAmfMsg for UE %s: %v", ue.Imsi, err)
 		}
 	}
+
+	//monitoring.UEsTotal.WithLabelValues(ue.simId, string(models.CmStateIdle)).Inc()
+	//monitoring.UEsTotal.WithLabelValues(ue.simId, string(models.CmStateConnected)).Dec()
+
+	return inactivityTimer
 }
 
-/* pdu session qos monitoring routine */
-func (ue *Ue) userplaneReport(ctx context.Context, pduSessId int32) {
-	for {
-		select {
-		case <-ctx.Done():
-			log.Printf("[%s] stopped userplane report for PDU Session %d", ue.Imsi, pduSessId)
-			return
-		case <-time.After(5 * time.Second):
-			ue.statusMutex.Lock()
-			report := ue.UpStats[pduSessId].GenerateReport()
-			session := ue.PduSessions[pduSessId]
-
-			/*prepare gitc message for SMF*/
-			msg := &models.UeToSmfMsg{
-				EventType:   models.SMFEVENTANYOF_QOS_MON,
-				TimeStamp:   time.Now(),
-				Dnn:         session.Dnn,
-				Snssai:      session.Snssai,
-				PduSessType: models.PDUSESSIONTYPEANYOF_IPV4,
-				UeAddress:   session.Ipv4,
-				Supi:        ue.Imsi,
-				Gpsi:        ue.Msidn,
-				PlmnId:      ue.PlmnId,
-				PduSessId:   pduSessId,
-				AccessType:  ue.accessType,
-				UpReport:    report,
-			}
-			if err := gitc.Send(ue.Imsi, "SMF", models.UeToSmfType, msg); err != nil {
-				log.Printf("Error sending UeToSmfType for UE %s: %v", ue.Imsi, err)
-			}
-			ue.statusMutex.Unlock()
-			//log.Printf("[%s] created userplane report for PDU Session %d\n", ue.Imsi, pduSessId)
+/* pdu session qos monitoring */
+func (ue *Ue) reportUserplane(pduSessId int32) {
+	ue.statusMutex.Lock()
+	defer ue.statusMutex.Unlock()
 
-		}
+	ue.statsMutex.Lock()
+	stats, exists := ue.UpStats[pduSessId]
+	if !exists {
+		ue.statsMutex.Unlock()
+		return
 	}
+	report := stats.GenerateReport(ue.now())
+	ue.statsMutex.Unlock()
+	session := ue.PduSessions[pduSessId]
+
+	/*prepare gitc message for SMF*/
+	msg := &models.UeToSmfMsg{
+		EventType:   models.SMFEVENTANYOF_QOS_MON,
+		TimeStamp:   ue.now(),
+		Dnn:         session.Dnn,
+		Snssai:      session.Snssai,
+		PduSessType: models.PDUSESSIONTYPEANYOF_IPV4,
+		UeAddress:   session.Ipv4,
+		Supi:        ue.Imsi,
+		Gpsi:        ue.Msidn,
+		PlmnId:      ue.PlmnId,
+		PduSessId:   pduSessId,
+		AccessType:  ue.accessType,
+		UpReport:    report,
+	}
+	if err := ue.engine.Dispatcher.Send(ue.Imsi, "SMF", models.UeToSmfType, msg); err != nil {
+		log.Printf("Error sending UeToSmfType for UE %s: %v", ue.Imsi, err)
+	}
+	//log.Printf("[%s] created userplane report for PDU Session %d\n", ue.Imsi, pduSessId)
 }
 
-// The UeSimulationRouting handles one Ue equipment and orchestrate its behaviors
-// Based on a Markovian Process, it will change the states inside the users to simulate
+// PowerUp turns on the UE radio and hands the UE over to the engine of the simulation.
+// Based on a Markovian Process, the engine will change the states inside the users to simulate
 // real world beahvior in terms of signalling and user plane traffic
 func (ue *Ue) PowerUp() {
+	ue.statusMutex.Lock()
+	if ue.poweredOn {
+		ue.statusMutex.Unlock()
+		return
+	}
+	// a UE turned off can be powered up again with a fresh context
+	if ue.ctx.Err() != nil {
+		ue.ctx, ue.cancelFun = context.WithCancel(ue.parentCtx)
+	}
+	ctx := ue.ctx
+	ue.poweredOn = true
+	ue.ueState = models.Deregistered
+	ue.statusMutex.Unlock()
 
 	monitoring.UEsTotal.WithLabelValues(ue.simId, string(models.RmStateDeregistered)).Inc()
 	//monitoring.UEsTotal.WithLabelValues(ue.simId, string(models.CmStateIdle)).Inc()
 
-	err := gitc.StartTask(ue.Imsi, func(msg gitc.Message) {
-		log.Printf("UE Received message from the network")
-	}, 1024)
-	if err != nil {
-		log.Printf("Error starting GITC task for UE %s: %v", ue.Imsi, err)
+	ue.engine.register(ue)
+	ue.shard.schedule(ue, ctx, markovStep, 0, markovPeriod)
+}
+
+// step runs one transition of the markov process of the UE
+func (ue *Ue) step() {
+	ue.statusMutex.Lock()
+	if ue.pinned {
+		// scripted UEs are driven from outside the markov loop
+		ue.statusMutex.Unlock()
 		return
 	}
-
-	go func() {
-		ticker := time.NewTicker(1 * time.Second)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				ue.statusMutex.Lock()
-				var procedure models.UeProcedure
-				ue.ueState, procedure = NextState(ue.ueState)
-				//log.Printf("%d, %s", ue.ueState, procedure)
-				ue.statusMutex.Unlock()
-
-				switch procedure {
-				case models.Registration:
-					ue.Register()
-				case models.Attach:
-					ue.Attach(10 * time.Second)
-				case models.PduSessionEstablishement:
-					ue.NewPduSession(1, ue.defautlDnn, ue.defaultSnssai, true)
-					ue.StartTrafficSession(1, false, "video", 0) // 0 = infinite duration
-					ue.StartTrafficSession(1, true, "sip", 0)    // 0 = infinite duration
-				case models.PduSessionFailure:
-					// no actions for the UE
-				case models.PduSessionRelease:
-					ue.ReleasePduSession(1)
-				case models.LossOfConnection:
-					// Kill RF is loss of connection
-					ue.LossOfConnection(false)
-				case models.Sleep:
-					// sleep is handled by inactivity timer
-				case models.Paging:
-					// Idle->Connected is handled here for paging, while service request is handled by the traffic routine
-					ue.WakeUp(true)
-					ue.StartTrafficSession(1, false, "sip", 0)
-				case models.HandoverSuccessful:
-
-					ue.DoHandover(ue.pickRandomNRCellID())
-				case models.HandoverFailure:
-					// Loss of connection if HO fails
-					ue.LossOfConnection(false)
-				case models.HandoverInitiated:
-					// No actions for the UE
-				default:
-
-				}
-			case <-ue.ctx.Done():
-				return
-			}
+	var procedure models.UeProcedure
+	ue.ueState, procedure = NextState(ue.engine.Random, ue.ueState)
+	//log.Printf("%d, %s", ue.ueState, procedure)
+	ue.statusMutex.Unlock()
+
+	switch procedure {
+	case models.Registration:
+		ue.Register()
+	case models.Attach:
+		ue.Attach(10 * time.Second)
+	case models.PduSessionEstablishement:
+		ue.NewPduSession(1, ue.defautlDnn, ue.defaultSnssai, true)
+		for _, app := range drawApplications(ue.engine.Random, ue.apps) {
+			ue.StartTrafficSession(1, app.Uplink(), app.Profile, 0) // 0 = profile duration
 		}
-	}()
+	case models.PduSessionFailure:
+		// no actions for the UE
+	case models.PduSessionRelease:
+		ue.ReleasePduSession(1)
+	case models.LossOfConnection:
+		// Kill RF is loss of connection
+		ue.LossOfConnection(false)
+	case models.Sleep:
+		// sleep is handled by inactivity timer
+	case models.Paging:
+		// Idle->Connected is handled here for paging, while service request is handled by the traffic routine
+		ue.WakeUp(true)
+		ue.StartTrafficSession(1, false, "sip", 0)
+	case models.HandoverSuccessful:
+		// a single cell does not allow any handover
+		if targetCellId := ue.pickRandomNRCellID(); targetCellId != "" {
+			ue.DoHandover(targetCellId)
+		}
+	case models.HandoverFailure:
+		// Loss of connection if HO fails
+		ue.LossOfConnection(false)
+	case models.HandoverInitiated:
+		// No actions for the UE
+	default:
+
+	}
 }
 
-func (ue *Ue) TurnOff(isGracefully bool) {
-	ue.cancelFun()
-	ue.LossOfConnection(isGracefully)
+// Dispatch runs a procedure on the shard of the UE, after the events already due, so that
+// it does not interleave with the markov process. The procedure is dropped if the UE is turned
+// off in the meantime and it is delayed while the simulation is paused.
+func (ue *Ue) Dispatch(procedure func()) {
+	ue.shard.dispatch(ue, ue.context(), procedure, 0)
 }
 
-func (ue *Ue) pickRandomNRCellID() string {
-	if len(ue.gnbList) <= 1 {
-		return ""
+// DispatchWait runs a procedure on the shard of the UE like Dispatch and waits for its completion.
+// It returns false if the procedure is dropped because the UE is turned off or the engine stops
+// in the meantime.
+func (ue *Ue) DispatchWait(procedure func()) bool {
+	ctx := ue.context()
+	// the procedure only runs if it is claimed before the wait is given up
+	var claimed atomic.Bool
+	done := make(chan struct{})
+	ue.shard.dispatch(ue, ctx, func() {
+		if !claimed.CompareAndSwap(false, true) {
+			return
+		}
+		procedure()
+		close(done)
+	}, 0)
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+	case <-ue.engine.stopped:
 	}
+	if claimed.CompareAndSwap(false, true) {
+		return false
+	}
+	// the procedure is running, it may be the one turning the UE off
+	<-done
+	return true
+}
+
+// DispatchAfter runs a procedure on the shard of the UE once d has elapsed on the simulation
+// clock, not counting the time spent in pause. The procedure is dropped if the UE is turned
+// off in the meantime.
+func (ue *Ue) DispatchAfter(d time.Duration, procedure func()) {
+	ue.shard.dispatch(ue, ue.context(), procedure, d)
+}
+
+// Pin detaches the UE from the markov process, so that its behaviour can be scripted.
+// Unpinning the UE resumes the stochastic behaviour from the last state set via SetState.
+func (ue *Ue) Pin(pinned bool) {
+	ue.statusMutex.Lock()
+	defer ue.statusMutex.Unlock()
+	ue.pinned = pinned
+}
+
+// IsPinned reports whether the UE is currently driven by a script.
+func (ue *Ue) IsPinned() bool {
+	ue.statusMutex.RLock()
+	defer ue.statusMutex.RUnlock()
+	return ue.pinned
+}
+
+// GetStatus returns the registration and connection management states of the UE.
+func (ue *Ue) GetStatus() (models.RmState, models.CmState) {
+	ue.statusMutex.RLock()
+	defer ue.statusMutex.RUnlock()
+	return ue.RmStatus, ue.CmStatus
+}
 
-	for {
-		idx := rand.Intn(len(ue.gnbList))
-		if ue.gnbList[idx] != ue.CurrentCellId {
-			return ue.gnbList[idx]
+// HasPduSession reports whether the PDU Session identified by sessionId is established.
+func (ue *Ue) HasPduSession(sessionId int32) bool {
+	ue.statusMutex.RLock()
+	defer ue.statusMutex.RUnlock()
+	_, exists := ue.PduSessions[sessionId]
+	return exists
+}
+
+// GetContext returns a snapshot of the UE status and of its PDU Sessions, sorted by PDU Session id
+func (ue *Ue) GetContext() UeContext {
+	ue.statusMutex.RLock()
+	ueCtx := UeContext{
+		Imsi:             ue.Imsi,
+		Msisdn:           ue.Msidn,
+		Imei:             ue.Imei,
+		Profile:          ue.Profile,
+		RmState:          ue.RmStatus,
+		CmState:          ue.CmStatus,
+		CurrentCellId:    ue.CurrentCellId,
+		Pinned:           ue.pinned,
+		PoweredOn:        ue.poweredOn,
+		LastActivityTime: ue.LastActivityTime,
+		PduSessions:      make([]PduSessionContext, 0, len(ue.PduSessions)),
+	}
+	for _, pduSess := range ue.PduSessions {
+		ueCtx.PduSessions = append(ueCtx.PduSessions, PduSessionContext{
+			Id:       pduSess.Id,
+			Ipv4:     pduSess.Ipv4,
+			Dnn:      pduSess.Dnn,
+			Snssai:   pduSess.Snssai,
+			Qos:      pduSess.Qos,
+			QosFlows: append([]models.QosFlow{}, pduSess.QosFlows...),
+			Dnai:     pduSess.Dnai,
+		})
+	}
+	ue.statusMutex.RUnlock()
+
+	sort.Slice(ueCtx.PduSessions, func(i, j int) bool {
+		return ueCtx.PduSessions[i].Id < ueCtx.PduSessions[j].Id
+	})
+
+	ue.statsMutex.RLock()
+	defer ue.statsMutex.RUnlock()
+	for i := range ueCtx.PduSessions {
+		if stats, exists := ue.UpStats[ueCtx.PduSessions[i].Id]; exists {
+			ueCtx.PduSessions[i].TotalUlBytes = stats.TotalUlBytes
+			ueCtx.PduSessions[i].TotalDlBytes = stats.TotalDlBytes
+			ueCtx.PduSessions[i].NumUlPackets = stats.NumUlPackets
+			ueCtx.PduSessions[i].NumDlPackets = stats.NumDlPackets
 		}
 	}
+	return ueCtx
+}
+
+// SetState aligns the markov state of the UE with a procedure executed outside of the markov loop.
+func (ue *Ue) SetState(state models.UeState) {
+	ue.statusMutex.Lock()
+	defer ue.statusMutex.Unlock()
+	ue.ueState = state
+}
+
+// TurnOff switches off the UE radio: a registered UE loses the connection
+// (gracefully deregistering if requested) and its tasks are terminated.
+func (ue *Ue) TurnOff(isGracefully bool) {
+	ue.statusMutex.Lock()
+	if !ue.poweredOn {
+		ue.statusMutex.Unlock()
+		return
+	}
+	ue.poweredOn = false
+	ue.inactivityArmed = false
+	ue.cancelFun()
+	rmState := ue.RmStatus
+	ue.statusMutex.Unlock()
+
+	if rmState == models.RmStateRegistered {
+		ue.LossOfConnection(isGracefully)
+	}
+	ue.SetState(models.Deregistered)
+	ue.engine.unregister(ue)
+
+	monitoring.UEsTotal.WithLabelValues(ue.simId, string(models.RmStateDeregistered)).Dec()
+}
+
+// now returns the current time of the simulation
+func (ue *Ue) now() time.Time {
+	return ue.engine.clock.Now()
+}
+
+// ShiftActivity postpones the last activity of the UE, so that the
+// time spent in pause does not count towards the inactivity timer
+func (ue *Ue) ShiftActivity(d time.Duration) {
+	ue.statusMutex.Lock()
+	defer ue.statusMutex.Unlock()
+	ue.LastActivityTime = ue.LastActivityTime.Add(d)
+}
+
+// IsPoweredOn reports whether the UE radio is on
+func (ue *Ue) IsPoweredOn() bool {
+	ue.statusMutex.RLock()
+	defer ue.statusMutex.RUnlock()
+	return ue.poweredOn
+}
+
+// GetCurrentCellId returns the cell the UE is camping on
+func (ue *Ue) GetCurrentCellId() string {
+	ue.statusMutex.RLock()
+	defer ue.statusMutex.RUnlock()
+	return ue.CurrentCellId
+}
+
+// context returns the context of the current power cycle of the UE
+func (ue *Ue) context() context.Context {
+	ue.statusMutex.RLock()
+	defer ue.statusMutex.RUnlock()
+	return ue.ctx
+}
+
+func (ue *Ue) pickRandomNRCellID() string {
+	return ue.gnbs.PickRandom(ue.engine.Random, ue.CurrentCellId)
 }
@@ -0,0 +1,116 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package ran
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+func TestUeReleasesAllPduSessions(t *testing.T) {
+	p := newTestPopulation(t, 1)
+	for name, release := range map[string]func(ue *Ue){
+		"deregistration":     func(ue *Ue) { ue.Deregister() },
+		"loss of connection": func(ue *Ue) { ue.LossOfConnection(false) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			ue := p.newUe(1)
+			ue.Pin(true)
+			ue.PowerUp()
+			defer ue.TurnOff(false)
+			ue.Register()
+			ue.Attach(10 * time.Second)
+			// the session ids are not contiguous
+			for _, sessionId := range []int32{2, 5} {
+				ue.NewPduSession(sessionId, "internet", models.Snssai{Sst: 1}, false)
+			}
+
+			release(ue)
+			if sessions := ue.GetContext().PduSessions; len(sessions) != 0 {
+				t.Fatalf("got %d PDU Sessions left, want none", len(sessions))
+			}
+			for _, sessionId := range []int32{2, 5} {
+				if ip, ok := p.ipam.GetIP(ue.Imsi, sessionId); ok {
+					t.Fatalf("address %s of PDU Session %d not released", ip, sessionId)
+				}
+			}
+		})
+	}
+}
+
+func TestUeProceduresCheckTheState(t *testing.T) {
+	p := newTestPopulation(t, 1)
+	ue := p.newUe(1)
+	ue.Pin(true)
+	ue.PowerUp()
+	defer ue.TurnOff(false)
+
+	// the procedures of a deregistered UE are ignored
+	ue.DoHandover("000000002")
+	ue.Sleep(true)
+	ue.LossOfConnection(false)
+	if ctx := ue.GetContext(); ctx.RmState != models.RmStateDeregistered || ctx.CurrentCellId != "" {
+		t.Fatalf("got %s UE on cell %q, want a deregistered UE on no cell", ctx.RmState, ctx.CurrentCellId)
+	}
+
+	ue.Register()
+	cellId := ue.GetCurrentCellId()
+	for range 20 {
+		ue.Register()
+	}
+	if got := ue.GetCurrentCellId(); got != cellId {
+		t.Fatalf("registered again on cell %s, want %s", got, cellId)
+	}
+
+}
+
+func TestUeReleaseRacesWithInspection(t *testing.T) {
+	p := newTestPopulation(t, 1)
+	ue := p.newUe(1)
+	ue.Pin(true)
+	ue.PowerUp()
+	ue.Register()
+	ue.Attach(10 * time.Second)
+	for sessionId := int32(1); sessionId <= 15; sessionId++ {
+		ue.NewPduSession(sessionId, "internet", models.Snssai{Sst: 1}, true)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range 100 {
+			ue.GetContext()
+		}
+	}()
+	for sessionId := int32(1); sessionId <= 15; sessionId++ {
+		ue.ReleasePduSession(sessionId)
+	}
+	// releasing an unknown session is a no-op
+	ue.ReleasePduSession(1)
+	wg.Wait()
+
+	if ue.HasPduSession(1) || len(p.ipam.Allocations()) != 0 {
+		t.Fatalf("got sessions %+v, want none", ue.GetContext().PduSessions)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A Clock is the time source of a simulation. It runs with the wall clock from its
+// start time and can be moved forward with Advance, which wakes up the routines
+// waiting on it so that the timers due in the skipped time fire right away.
+// A nil Clock follows the wall clock.
+type Clock struct {
+	mutex    sync.Mutex
+	offset   time.Duration
+	advanced chan struct{}
+}
+
+// NewClock creates a clock following the wall clock
+func NewClock() *Clock {
+	return &Clock{advanced: make(chan struct{})}
+}
+
+// NewClockAt creates a clock starting at the given time
+func NewClockAt(start time.Time) *Clock {
+	c := NewClock()
+	c.offset = time.Until(start)
+	return c
+}
+
+// Now returns the current time of the clock
+func (c *Clock) Now() time.Time {
+	if c == nil {
+		return time.Now()
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return time.Now().Add(c.offset)
+}
+
+// Since returns the time elapsed on the clock since t
+func (c *Clock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Advance moves the clock forward by d, negative durations are ignored
+func (c *Clock) Advance(d time.Duration) {
+	if c == nil || d <= 0 {
+		return
+	}
+	c.mutex.Lock()
+	c.offset += d
+	close(c.advanced)
+	c.advanced = make(chan struct{})
+	c.mutex.Unlock()
+}
+
+// Advanced returns a channel closed the next time the clock is moved forward.
+// The channel of a nil Clock is never closed.
+func (c *Clock) Advanced() <-chan struct{} {
+	if c == nil {
+		return nil
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.advanced
+}
+
+// Sleep blocks until d has elapsed on the clock. It returns false if
+// the context is cancelled in the meantime.
+func (c *Clock) Sleep(ctx context.Context, d time.Duration) bool {
+	return c.SleepUntil(ctx, c.Now().Add(d))
+}
+
+// SleepUntil blocks until the clock reaches deadline. It returns false if
+// the context is cancelled in the meantime.
+func (c *Clock) SleepUntil(ctx context.Context, deadline time.Time) bool {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	<-timer.C
+
+	for {
+		advanced := c.Advanced()
+		wait := deadline.Sub(c.Now())
+		if wait <= 0 {
+			return ctx.Err() == nil
+		}
+		timer.Reset(wait)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-timer.C:
+		case <-advanced:
+			if !timer.Stop() {
+				<-timer.C
+			}
+		}
+	}
+}
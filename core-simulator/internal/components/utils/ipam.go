@@ -24,14 +24,26 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 type IPAllocator struct {
+	subnet       string
+	size         int
 	availableIPs []string
 	allocated    map[string]string // userID -> IP
 	ipToUser     map[string]string // IP -> userID
+	mutex        sync.RWMutex
+}
+
+// IPAllocation describes an address assigned to a PDU Session
+type IPAllocation struct {
+	Ip        string `json:"ip"`
+	Supi      string `json:"supi"`
+	PduSessId int32  `json:"pduSessionId"`
 }
 
 func NewIpamService(subnet string, netmask string) *IPAllocator {
@@ -51,6 +63,8 @@ func NewIpamService(subnet string, netmask string) *IPAllocator {
 	}
 
 	allocator := &IPAllocator{
+		subnet:       ipnet.String(),
+		size:         len(ips),
 		availableIPs: ips,
 		allocated:    make(map[string]string),
 		ipToUser:     make(map[string]string),
@@ -60,6 +74,9 @@ func NewIpamService(subnet string, netmask string) *IPAllocator {
 }
 
 func (a *IPAllocator) AllocateIP(supi string, pduSessId int32) (string, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
 	userString := fmt.Sprintf("%s-%d", supi, pduSessId)
 	if ip, ok := a.allocated[userString]; ok {
 		return ip, nil // user already has an IP
@@ -78,6 +95,9 @@ func (a *IPAllocator) AllocateIP(supi string, pduSessId int32) (string, error) {
 }
 
 func (a *IPAllocator) ReleaseIP(supi string, pduSessId int32) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
 	userString := fmt.Sprintf("%s-%d", supi, pduSessId)
 	ip, ok := a.allocated[userString]
 	if !ok {
@@ -92,13 +112,18 @@ func (a *IPAllocator) ReleaseIP(supi string, pduSessId int32) error {
 }
 
 func (a *IPAllocator) GetIP(supi string, pduSessId int32) (string, bool) {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
 	userString := fmt.Sprintf("%s-%d", supi, pduSessId)
 	ip, ok := a.allocated[userString]
 	return ip, ok
 }
 
 func (a *IPAllocator) GetUserStringOk(ip string) (string, int32, bool) {
+	a.mutex.RLock()
 	userString, ok := a.ipToUser[ip]
+	a.mutex.RUnlock()
 	if !ok {
 		log.Printf("user not found %s, %+v", ip, a.ipToUser)
 		return "", 0, false
@@ -112,6 +137,51 @@ func (a *IPAllocator) GetUserStringOk(ip string) (string, int32, bool) {
 	return user, int32(pduSessId), ok
 }
 
+// Subnet returns the address pool managed by the allocator in CIDR notation
+func (a *IPAllocator) Subnet() string {
+	return a.subnet
+}
+
+// Size returns the number of assignable addresses of the pool
+func (a *IPAllocator) Size() int {
+	return a.size
+}
+
+// Allocations returns the addresses currently assigned, sorted by address
+func (a *IPAllocator) Allocations() []IPAllocation {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	allocations := make([]IPAllocation, 0, len(a.ipToUser))
+	for ip, userString := range a.ipToUser {
+		userStringSplitted := strings.Split(userString, "-")
+		pduSessId, err := strconv.Atoi(userStringSplitted[1])
+		if err != nil {
+			continue
+		}
+		allocations = append(allocations, IPAllocation{
+			Ip:        ip,
+			Supi:      userStringSplitted[0],
+			PduSessId: int32(pduSessId),
+		})
+	}
+
+	sort.Slice(allocations, func(i, j int) bool {
+		return ipLess(net.ParseIP(allocations[i].Ip), net.ParseIP(allocations[j].Ip))
+	})
+	return allocations
+}
+
+func ipLess(a, b net.IP) bool {
+	a, b = a.To16(), b.To16()
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
+
 func inc(ip net.IP) {
 	for j := len(ip) - 1; j >= 0; j-- {
 		ip[j]++
@@ -0,0 +1,116 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package utils
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// A PauseGate freezes the simulation routines: while the gate is closed
+// every routine calling Wait is blocked until the gate is opened again.
+// The time spent in pause is measured on the clock of the simulation.
+type PauseGate struct {
+	mutex    sync.Mutex
+	resumed  chan struct{}
+	pausedAt time.Time
+	// time spent in the previous pauses
+	total time.Duration
+	clock *Clock
+}
+
+// NewPauseGate creates an open gate measuring the pauses on the given clock, the wall clock when nil
+func NewPauseGate(clock *Clock) *PauseGate {
+	return &PauseGate{clock: clock}
+}
+
+// Pause closes the gate, it returns false if the gate is already closed
+func (g *PauseGate) Pause() bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.resumed != nil {
+		return false
+	}
+	g.resumed = make(chan struct{})
+	g.pausedAt = g.clock.Now()
+	return true
+}
+
+// Resume opens the gate, it returns false if the gate is already open
+func (g *PauseGate) Resume() bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.resumed == nil {
+		return false
+	}
+	close(g.resumed)
+	g.resumed = nil
+	g.total += g.clock.Since(g.pausedAt)
+	return true
+}
+
+// PausedFor returns for how long the gate has been closed
+func (g *PauseGate) PausedFor() time.Duration {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.resumed == nil {
+		return 0
+	}
+	return g.clock.Since(g.pausedAt)
+}
+
+// Paused returns the time spent in pause since the gate was created, the ongoing pause included
+func (g *PauseGate) Paused() time.Duration {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.resumed == nil {
+		return g.total
+	}
+	return g.total + g.clock.Since(g.pausedAt)
+}
+
+func (g *PauseGate) IsPaused() bool {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.resumed != nil
+}
+
+// Wait blocks while the gate is closed. It returns false if the context
+// is cancelled in the meantime.
+func (g *PauseGate) Wait(ctx context.Context) bool {
+	g.mutex.Lock()
+	resumed := g.resumed
+	g.mutex.Unlock()
+
+	if resumed == nil {
+		return ctx.Err() == nil
+	}
+	select {
+	case <-resumed:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
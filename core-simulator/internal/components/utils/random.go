@@ -0,0 +1,78 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package utils
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// A Random is the source of the random draws of a simulation, so that a seeded simulation
+// draws the same sequence of values. The draws are reproducible, the interleaving of the UEs
+// running concurrently on the engine shards may still vary between runs.
+// A nil Random draws from a source shared by the process, seeded with the current time.
+type Random struct {
+	mutex sync.Mutex
+	rand  *rand.Rand
+}
+
+var defaultRandom = NewRandom(time.Now().UnixNano())
+
+// NewRandom creates a random source with the given seed
+func NewRandom(seed int64) *Random {
+	return &Random{rand: rand.New(rand.NewSource(seed))}
+}
+
+func (r *Random) lock() *Random {
+	if r == nil {
+		r = defaultRandom
+	}
+	r.mutex.Lock()
+	return r
+}
+
+// Float64 returns a uniform value in [0.0,1.0)
+func (r *Random) Float64() float64 {
+	r = r.lock()
+	defer r.mutex.Unlock()
+	return r.rand.Float64()
+}
+
+// ExpFloat64 returns an exponentially distributed value with rate 1
+func (r *Random) ExpFloat64() float64 {
+	r = r.lock()
+	defer r.mutex.Unlock()
+	return r.rand.ExpFloat64()
+}
+
+// NormFloat64 returns a normally distributed value with mean 0 and standard deviation 1
+func (r *Random) NormFloat64() float64 {
+	r = r.lock()
+	defer r.mutex.Unlock()
+	return r.rand.NormFloat64()
+}
+
+// Intn returns a uniform value in [0,n), it panics if n <= 0
+func (r *Random) Intn(n int) int {
+	r = r.lock()
+	defer r.mutex.Unlock()
+	return r.rand.Intn(n)
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package models
+
+/* BSF data types, TS 29.521 */
+
+// PcfBinding is the binding of a PDU session to the PCF serving it, TS 29.521 clause 5.6.2.2
+type PcfBinding struct {
+	Supi       *string `json:"supi,omitempty"`
+	Gpsi       *string `json:"gpsi,omitempty"`
+	Ipv4Addr   *string `json:"ipv4Addr,omitempty"`
+	Ipv6Prefix *string `json:"ipv6Prefix,omitempty"`
+	IpDomain   *string `json:"ipDomain,omitempty"`
+	Dnn        string  `json:"dnn"`
+	Snssai     Snssai  `json:"snssai"`
+	PcfFqdn    *string `json:"pcfFqdn,omitempty"`
+	PcfId      *string `json:"pcfId,omitempty"`
+	SuppFeat   *string `json:"suppFeat,omitempty"`
+}
+
+// GetSupi returns the Supi field value if set, zero value otherwise
+func (o *PcfBinding) GetSupi() string {
+	if o == nil || o.Supi == nil {
+		return ""
+	}
+	return *o.Supi
+}
+
+// GetGpsi returns the Gpsi field value if set, zero value otherwise
+func (o *PcfBinding) GetGpsi() string {
+	if o == nil || o.Gpsi == nil {
+		return ""
+	}
+	return *o.Gpsi
+}
+
+// GetIpDomain returns the IpDomain field value if set, zero value otherwise
+func (o *PcfBinding) GetIpDomain() string {
+	if o == nil || o.IpDomain == nil {
+		return ""
+	}
+	return *o.IpDomain
+}
+
+// GetPcfId returns the PcfId field value if set, zero value otherwise
+func (o *PcfBinding) GetPcfId() string {
+	if o == nil || o.PcfId == nil {
+		return ""
+	}
+	return *o.PcfId
+}
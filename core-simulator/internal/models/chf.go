@@ -0,0 +1,152 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package models
+
+import "time"
+
+/* CHF converged charging data types, TS 32.291 */
+
+// Result codes of the multiple unit information
+const (
+	ChargingResultSuccess           = "SUCCESS"
+	ChargingResultQuotaLimitReached = "QUOTA_LIMIT_REACHED"
+)
+
+// Final unit actions applied by the consumer once the credit is exhausted
+const (
+	FinalUnitActionTerminate      = "TERMINATE"
+	FinalUnitActionRestrictAccess = "RESTRICT_ACCESS"
+)
+
+// Notification types of the CHF
+const (
+	ChargingNotificationReauthorization = "REAUTHORIZATION"
+	ChargingNotificationAbortCharging   = "ABORT_CHARGING"
+)
+
+// ChargingDataRequest opens, updates or releases a charging data session
+type ChargingDataRequest struct {
+	SubscriberIdentifier          *string                        `json:"subscriberIdentifier,omitempty"`
+	NfConsumerIdentification      NfIdentification               `json:"nfConsumerIdentification"`
+	InvocationTimeStamp           time.Time                      `json:"invocationTimeStamp"`
+	InvocationSequenceNumber      int64                          `json:"invocationSequenceNumber"`
+	NotifyUri                     *string                        `json:"notifyUri,omitempty"`
+	MultipleUnitUsage             []MultipleUnitUsage            `json:"multipleUnitUsage,omitempty"`
+	PduSessionChargingInformation *PduSessionChargingInformation `json:"pDUSessionChargingInformation,omitempty"`
+}
+
+// NfIdentification identifies the consumer of the CHF
+type NfIdentification struct {
+	NfName            *string `json:"nFName,omitempty"`
+	NodeFunctionality string  `json:"nodeFunctionality"`
+}
+
+// MultipleUnitUsage requests units and reports the usage of a rating group
+type MultipleUnitUsage struct {
+	RatingGroup       int32               `json:"ratingGroup"`
+	RequestedUnit     *RequestedUnit      `json:"requestedUnit,omitempty"`
+	UsedUnitContainer []UsedUnitContainer `json:"usedUnitContainer,omitempty"`
+}
+
+// RequestedUnit is the volume requested by the consumer, zero for the default quota of the CHF
+type RequestedUnit struct {
+	TotalVolume *int64 `json:"totalVolume,omitempty"`
+}
+
+// UsedUnitContainer is the usage since the previous report, the time in seconds
+type UsedUnitContainer struct {
+	Time                int64 `json:"time"`
+	TotalVolume         int64 `json:"totalVolume"`
+	UplinkVolume        int64 `json:"uplinkVolume"`
+	DownlinkVolume      int64 `json:"downlinkVolume"`
+	LocalSequenceNumber int32 `json:"localSequenceNumber"`
+}
+
+// PduSessionChargingInformation identifies the charged PDU session
+type PduSessionChargingInformation struct {
+	ChargingId            int32                         `json:"chargingId"`
+	PduSessionInformation ChargingPduSessionInformation `json:"pduSessionInformation"`
+}
+
+// ChargingPduSessionInformation describes the charged PDU session
+type ChargingPduSessionInformation struct {
+	PduSessionId       int32              `json:"pduSessionID"`
+	DnnId              string             `json:"dnnId"`
+	NetworkSlicingInfo NetworkSlicingInfo `json:"networkSlicingInfo"`
+	PduAddress         *PduAddress        `json:"pduAddress,omitempty"`
+}
+
+type NetworkSlicingInfo struct {
+	Snssai Snssai `json:"sNSSAI"`
+}
+
+type PduAddress struct {
+	PduIpv4Address *string `json:"pduIPv4Address,omitempty"`
+}
+
+// ChargingDataResponse grants the units of the rating groups
+type ChargingDataResponse struct {
+	InvocationTimeStamp      time.Time                 `json:"invocationTimeStamp"`
+	InvocationSequenceNumber int64                     `json:"invocationSequenceNumber"`
+	MultipleUnitInformation  []MultipleUnitInformation `json:"multipleUnitInformation,omitempty"`
+}
+
+// MultipleUnitInformation is the quota of a rating group
+type MultipleUnitInformation struct {
+	ResultCode          string               `json:"resultCode"`
+	RatingGroup         int32                `json:"ratingGroup"`
+	GrantedUnit         *GrantedUnit         `json:"grantedUnit,omitempty"`
+	FinalUnitIndication *FinalUnitIndication `json:"finalUnitIndication,omitempty"`
+}
+
+type GrantedUnit struct {
+	TotalVolume *int64 `json:"totalVolume,omitempty"`
+}
+
+// FinalUnitIndication is the action of the consumer once the final granted units are used
+type FinalUnitIndication struct {
+	FinalUnitAction string `json:"finalUnitAction"`
+}
+
+// ChargingNotifyRequest asks the consumer to re-authorize or to stop the charging data session
+type ChargingNotifyRequest struct {
+	NotificationType       string                   `json:"notificationType"`
+	ReauthorizationDetails []ReauthorizationDetails `json:"reauthorizationDetails,omitempty"`
+}
+
+type ReauthorizationDetails struct {
+	RatingGroup int32 `json:"ratingGroup"`
+}
+
+// GetSubscriberIdentifier returns the SubscriberIdentifier field value if set, zero value otherwise
+func (o *ChargingDataRequest) GetSubscriberIdentifier() string {
+	if o == nil || o.SubscriberIdentifier == nil {
+		return ""
+	}
+	return *o.SubscriberIdentifier
+}
+
+// GetNotifyUri returns the NotifyUri field value if set, zero value otherwise
+func (o *ChargingDataRequest) GetNotifyUri() string {
+	if o == nil || o.NotifyUri == nil {
+		return ""
+	}
+	return *o.NotifyUri
+}
@@ -44,6 +44,11 @@ type UeToAmfMsg struct {
 	PlmnId        PlmnId
 	CurrentCellId string
 	AccessType    AccessType
+	// identities reported at registration only
+	Pei  string // IMEI
+	Suci string
+	// reason of a loss of connectivity, defaults to DEREGISTERED
+	LossReason LossOfConnectivityReasonAnyOf
 }
 
 type UeToSmfMsg struct {
@@ -60,14 +65,72 @@ type UeToSmfMsg struct {
 	PduSessId   int32
 	DddsState   DlDataDeliveryStatusAnyOf
 	UpReport    *UpStatsReport
+	// QoS flow allocated by the PCF for an application session, and the application of the AF
+	Qfi          int32
+	AppSessionId string
+	AppId        string
+	// user plane path change
+	SourceDnai string
+	TargetDnai string
 }
 
+/* downlink commands, delivered to the UEs through the RAN task */
+
+type AmfToUeCommand string
+
+const (
+	AmfPaging                 AmfToUeCommand = "PAGING"
+	AmfDeregistration         AmfToUeCommand = "DEREGISTRATION"
+	AmfImplicitDeregistration AmfToUeCommand = "IMPLICIT_DEREGISTRATION"
+	AmfRegistrationReject     AmfToUeCommand = "REGISTRATION_REJECT"
+)
+
 type AmfToUeMsg struct {
+	Command   AmfToUeCommand
+	TimeStamp time.Time
+	Supi      string
+	// reason reported by the UE, for deregistrations only
+	Reason LossOfConnectivityReasonAnyOf
 }
 
+type SmfToUeCommand string
+
+const (
+	SmfPduSessionRelease      SmfToUeCommand = "PDU_SESSION_RELEASE"
+	SmfPduSessionModification SmfToUeCommand = "PDU_SESSION_MODIFICATION"
+)
+
 type SmfToUeMsg struct {
+	Command   SmfToUeCommand
+	TimeStamp time.Time
+	Supi      string
+	PduSessId int32
+	// QoS of the default flow, for modifications only
+	Qos *SessionQos
 }
+
+type PcfToUeCommand string
+
+const (
+	PcfQosUpdate     PcfToUeCommand = "QOS_UPDATE"
+	PcfRoutingUpdate PcfToUeCommand = "ROUTING_UPDATE"
+)
+
+// PcfToUeMsg carries the policy decision of an application session.
+// A removal reverts the decision once the application session is deleted.
 type PcfToUeMsg struct {
+	Command      PcfToUeCommand
+	TimeStamp    time.Time
+	Supi         string
+	PduSessId    int32
+	AppSessionId string
+	// application of the AF, reported in the events of the application session
+	AfAppId string
+	Remove  bool
+	// QoS of the flow of the application, for QoS updates
+	Qos *SessionQos
+	// target DNAI of the application traffic, for routing updates
+	Dnai string
 }
 type UeToPcfMsg struct {
 }
@@ -57,7 +57,7 @@ func (dst *AfEvent) UnmarshalJSON(data []byte) error {
 }
 
 // Marshal data from the first non-nil pointers in the struct to JSON
-func (src *AfEvent) MarshalJSON() ([]byte, error) {
+func (src AfEvent) MarshalJSON() ([]byte, error) {
 	if src.String != nil {
 		return json.Marshal(&src.String)
 	}
@@ -283,7 +283,7 @@ func (o *AmfEventMode) SetNotifFlag(v NotificationFlag) {
 func (o AmfEventMode) MarshalJSON() ([]byte, error) {
 	toSerialize := map[string]interface{}{}
 	if true {
-		toSerialize["trigger"] = o.Trigger
+		toSerialize["trigger"] = &o.Trigger
 	}
 	if o.MaxReports != nil {
 		toSerialize["maxReports"] = o.MaxReports
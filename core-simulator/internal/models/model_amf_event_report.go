@@ -76,6 +76,8 @@ type AmfEventReport struct {
 	// Added to find the correspondance between AMF and RAN UE NGAP Id's
 	AmfUeNgapId *int64  `json:"amfUeNgapId,omitempty"`
 	RanUeNgapId *uint32 `json:"ranUeNgapId,omitempty"`
+	// Added to expose the concealed identity used by the UE at registration
+	Suci *string `json:"suci,omitempty"`
 }
 
 // NewAmfEventReport instantiates a new AmfEventReport object
@@ -1053,7 +1055,7 @@ func (o AmfEventReport) MarshalJSON() ([]byte, error) {
 		toSerialize["state"] = o.State
 	}
 	if true {
-		toSerialize["timeStamp"] = o.TimeStamp.Unix() // added
+		toSerialize["timeStamp"] = o.TimeStamp.Format(time.RFC3339Nano)
 	}
 	if o.SubscriptionId != nil {
 		toSerialize["subscriptionId"] = o.SubscriptionId
@@ -1101,7 +1103,9 @@ func (o AmfEventReport) MarshalJSON() ([]byte, error) {
 		toSerialize["commFailure"] = o.CommFailure
 	}
 
-	toSerialize["lossOfConnectReason"] = o.LossOfConnectReason
+	if o.LossOfConnectReason != "" {
+		toSerialize["lossOfConnectReason"] = o.LossOfConnectReason
+	}
 
 	if o.NumberOfUes != nil {
 		toSerialize["numberOfUes"] = o.NumberOfUes
@@ -1146,6 +1150,9 @@ func (o AmfEventReport) MarshalJSON() ([]byte, error) {
 	if o.RanUeNgapId != nil {
 		toSerialize["ranUeNgapId"] = o.RanUeNgapId
 	}
+	if o.Suci != nil {
+		toSerialize["suci"] = o.Suci
+	}
 	return json.Marshal(toSerialize)
 }
 
@@ -1177,46 +1184,20 @@ func NewNullableAmfEventReport(val *AmfEventReport) *NullableAmfEventReport {
 }
 
 func (v NullableAmfEventReport) MarshalJSON() ([]byte, error) {
-
-	// custom marshaling for timestamp: time.Time => int64 (preferred)
-	type AmfEventReportAlias AmfEventReport
-	return json.Marshal(&struct {
-		TimeStamp int64 `json:"timeStamp"`
-		*AmfEventReportAlias
-	}{
-		AmfEventReportAlias: (*AmfEventReportAlias)(v.value),
-		TimeStamp:           v.value.TimeStamp.Unix(),
-	})
+	return json.Marshal(v.value)
 }
 
 func (v *NullableAmfEventReport) UnmarshalJSON(src []byte) error {
 	v.isSet = true
-
-	// custom unmarshaling for timestamp: int64 (as used in AMF) => time.Time
-	type AmfEventReportAlias AmfEventReport
-	alias := &struct {
-		TimeStamp int64 `json:"timeStamp"`
-		*AmfEventReportAlias
-	}{
-		AmfEventReportAlias: (*AmfEventReportAlias)(v.value),
-	}
-	err := json.Unmarshal(src, alias)
-	if err != nil {
-		return err
-	}
-	v.value.TimeStamp = time.Unix(alias.TimeStamp, 0)
-	return nil
-
-	// basic way produces parsing error
-	// return json.Unmarshal(src, &v.value)
+	return json.Unmarshal(src, &v.value)
 }
 
 // Unmarshaling of the Report with timestamp. This method is called by json.Unmarshal
 func (v *AmfEventReport) UnmarshalJSON(src []byte) error {
-	// custom unmarshaling for timestamp: int64 (as used in AMF) => time.Time
+	// custom unmarshaling for timestamp: RFC 3339 DateTime => time.Time
 	type AmfEventReportAlias AmfEventReport
 	alias := &struct {
-		TimeStamp int64 `json:"timeStamp"`
+		TimeStamp string `json:"timeStamp"`
 		*AmfEventReportAlias
 	}{
 		AmfEventReportAlias: (*AmfEventReportAlias)(v),
@@ -1225,9 +1206,6 @@ func (v *AmfEventReport) UnmarshalJSON(src []byte) error {
 	if err != nil {
 		return err
 	}
-	v.TimeStamp = time.Unix(alias.TimeStamp, 0)
-	return nil
-
-	// basic way produces parsing error
-	// return json.Unmarshal(src, &v.value)
+	v.TimeStamp, err = time.Parse(time.RFC3339, alias.TimeStamp)
+	return err
 }
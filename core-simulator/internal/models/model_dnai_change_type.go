@@ -71,7 +71,7 @@ func (dst *DnaiChangeType) UnmarshalJSON(data []byte) error {
 }
 
 // Marshal data from the first non-nil pointers in the struct to JSON
-func (src *DnaiChangeType) MarshalJSON() ([]byte, error) {
+func (src DnaiChangeType) MarshalJSON() ([]byte, error) {
 	if src.DnaiChangeTypeAnyOf != nil {
 		return json.Marshal(&src.DnaiChangeTypeAnyOf)
 	}
@@ -29,8 +29,6 @@ package models
 
 import (
 	"encoding/json"
-	"fmt"
-	"strconv"
 	"time"
 )
 
@@ -59,6 +57,8 @@ type EventNotification struct {
 	TargetUeIpv6Prefix *Ipv6Prefix             `json:"targetUeIpv6Prefix,omitempty"`
 	SourceTraRouting   NullableRouteToLocation `json:"sourceTraRouting,omitempty"`
 	TargetTraRouting   NullableRouteToLocation `json:"targetTraRouting,omitempty"`
+	// EAS IP replacement information of the application relocation, from the AF routing requirement.
+	EasIpReplaceInfos []EasIpReplacementInfo `json:"easIpReplaceInfos,omitempty"`
 	// String identifying a MAC address formatted in the hexadecimal notation according to clause 1.1 and clause 2.1 of RFC 7042
 	UeMac *string `json:"ueMac,omitempty"`
 	// String identifying a IPv4 address formatted in the 'dotted decimal' notation as defined in RFC 1166.
@@ -1795,7 +1795,7 @@ func (o EventNotification) MarshalJSON() ([]byte, error) {
 		toSerialize["event"] = o.Event
 	}
 	if true {
-		toSerialize["timeStamp"] = fmt.Sprintf("%d", o.TimeStamp.Unix()) // added timestamp as int64 (preferred)
+		toSerialize["timeStamp"] = o.TimeStamp.Format(time.RFC3339Nano)
 	}
 	if o.Supi != nil {
 		toSerialize["supi"] = o.Supi
@@ -1836,6 +1836,9 @@ func (o EventNotification) MarshalJSON() ([]byte, error) {
 	if o.TargetTraRouting.IsSet() {
 		toSerialize["targetTraRouting"] = o.TargetTraRouting.Get()
 	}
+	if o.EasIpReplaceInfos != nil {
+		toSerialize["easIpReplaceInfos"] = o.EasIpReplaceInfos
+	}
 	if o.UeMac != nil {
 		toSerialize["ueMac"] = o.UeMac
 	}
@@ -1978,42 +1981,17 @@ func NewNullableEventNotification(val *EventNotification) *NullableEventNotifica
 }
 
 func (v NullableEventNotification) MarshalJSON() ([]byte, error) {
-	// custom marshaling for timestamp: time.Time => int64 (preferred)
-	type EventNotificationAlias EventNotification
-	return json.Marshal(&struct {
-		TimeStamp int64 `json:"timeStamp"`
-		*EventNotificationAlias
-	}{
-		EventNotificationAlias: (*EventNotificationAlias)(v.value),
-		TimeStamp:              v.value.TimeStamp.Unix(),
-	})
+	return json.Marshal(v.value)
 }
 
 func (v *NullableEventNotification) UnmarshalJSON(src []byte) error {
 	v.isSet = true
-	// custom unmarshaling for timestamp: string (as used in SMF) => time.Time
-	type EventNotificationAlias EventNotification
-	alias := &struct {
-		TimeStamp string `json:"timeStamp"`
-		*EventNotificationAlias
-	}{
-		EventNotificationAlias: (*EventNotificationAlias)(v.value),
-	}
-	err := json.Unmarshal(src, alias)
-	if err != nil {
-		return err
-	}
-	timeStampInt, err := strconv.Atoi(alias.TimeStamp)
-	if err != nil {
-		return err
-	}
-	v.value.TimeStamp = time.Unix(int64(timeStampInt), 0)
-	return nil
+	return json.Unmarshal(src, &v.value)
 }
 
 // Unmarshaling of the Report with timestamp. This method is called by json.Unmarshal
 func (v *EventNotification) UnmarshalJSON(src []byte) error {
-	// custom unmarshaling for timestamp: string (as used in SMF) => time.Time
+	// custom unmarshaling for timestamp: RFC 3339 DateTime => time.Time
 	type EventNotificationAlias EventNotification
 	alias := &struct {
 		TimeStamp string `json:"timeStamp"`
@@ -2025,10 +2003,6 @@ func (v *EventNotification) UnmarshalJSON(src []byte) error {
 	if err != nil {
 		return err
 	}
-	timeStampInt, err := strconv.Atoi(alias.TimeStamp)
-	if err != nil {
-		return err
-	}
-	v.TimeStamp = time.Unix(int64(timeStampInt), 0)
-	return nil
+	v.TimeStamp, err = time.Parse(time.RFC3339, alias.TimeStamp)
+	return err
 }
@@ -56,7 +56,7 @@ func (dst *FinalUnitAction) UnmarshalJSON(data []byte) error {
 }
 
 // Marshal data from the first non-nil pointers in the struct to JSON
-func (src *FinalUnitAction) MarshalJSON() ([]byte, error) {
+func (src FinalUnitAction) MarshalJSON() ([]byte, error) {
 	if src.String != nil {
 		return json.Marshal(&src.String)
 	}
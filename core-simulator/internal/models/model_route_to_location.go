@@ -70,6 +70,48 @@ func (src *RouteToLocation) MarshalJSON() ([]byte, error) {
 	return nil, nil // no data in anyOf schemas
 }
 
+// GetDnai returns the DNAI of the location, or an empty string if it is not set
+func (src *RouteToLocation) GetDnai() string {
+	if src.routeInterface == nil {
+		return ""
+	}
+	route, ok := (*src.routeInterface).(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	dnai, _ := route["dnai"].(string)
+	return dnai
+}
+
+// NewRouteToLocation builds the route to a DNAI, with its N6 traffic routing information when not nil
+func NewRouteToLocation(dnai string, routeInfo *RouteInformation) *RouteToLocation {
+	var route interface{} = map[string]interface{}{"dnai": dnai}
+	if routeInfo != nil {
+		route.(map[string]interface{})["routeInfo"] = routeInfo
+	}
+	return &RouteToLocation{routeInterface: &route}
+}
+
+// GetRouteInfo returns the N6 traffic routing information of the route, nil when absent
+func (src *RouteToLocation) GetRouteInfo() *RouteInformation {
+	if src.routeInterface == nil {
+		return nil
+	}
+	route, ok := (*src.routeInterface).(map[string]interface{})
+	if !ok || route["routeInfo"] == nil {
+		return nil
+	}
+	data, err := json.Marshal(route["routeInfo"])
+	if err != nil {
+		return nil
+	}
+	routeInfo := &RouteInformation{}
+	if err := json.Unmarshal(data, routeInfo); err != nil {
+		return nil
+	}
+	return routeInfo
+}
+
 type NullableRouteToLocation struct {
 	value *RouteToLocation
 	isSet bool
@@ -0,0 +1,170 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package models
+
+import "time"
+
+/* NEF northbound data types, TS 29.522 and TS 29.122 */
+
+// MonitoringType of a monitoring event subscription, TS 29.122 clause 5.3.2.4.3
+type MonitoringType string
+
+const (
+	MonitoringTypeLossOfConnectivity   MonitoringType = "LOSS_OF_CONNECTIVITY"
+	MonitoringTypeUeReachability       MonitoringType = "UE_REACHABILITY"
+	MonitoringTypeLocationReporting    MonitoringType = "LOCATION_REPORTING"
+	MonitoringTypePdnConnectivity      MonitoringType = "PDN_CONNECTIVITY_STATUS"
+	MonitoringTypeDownlinkDataDelivery MonitoringType = "DOWNLINK_DATA_DELIVERY_STATUS"
+)
+
+// MonitoringEventSubscription is the resource of the 3gpp-monitoring-event API
+type MonitoringEventSubscription struct {
+	Self                    *string        `json:"self,omitempty"`
+	SupportedFeatures       *string        `json:"supportedFeatures,omitempty"`
+	ExternalId              *string        `json:"externalId,omitempty"`
+	Msisdn                  *string        `json:"msisdn,omitempty"`
+	ExternalGroupId         *string        `json:"externalGroupId,omitempty"`
+	NotificationDestination string         `json:"notificationDestination"`
+	MonitoringType          MonitoringType `json:"monitoringType"`
+	MaximumNumberOfReports  *int32         `json:"maximumNumberOfReports,omitempty"`
+	MonitorExpireTime       *time.Time     `json:"monitorExpireTime,omitempty"`
+	// period of the periodic reports in seconds
+	RepPeriod        *int32  `json:"repPeriod,omitempty"`
+	ReachabilityType *string `json:"reachabilityType,omitempty"`
+	LocationType     *string `json:"locationType,omitempty"`
+}
+
+// MonitoringNotification relays the monitoring event reports to the AF
+type MonitoringNotification struct {
+	Subscription      string                  `json:"subscription"`
+	MonitoringReports []MonitoringEventReport `json:"monitoringReports,omitempty"`
+	CancelInd         *bool                   `json:"cancelInd,omitempty"`
+}
+
+// MonitoringEventReport is a monitoring event detected for a UE
+type MonitoringEventReport struct {
+	ExternalId     *string        `json:"externalId,omitempty"`
+	Msisdn         *string        `json:"msisdn,omitempty"`
+	MonitoringType MonitoringType `json:"monitoringType"`
+	EventTime      *time.Time     `json:"eventTime,omitempty"`
+	// TS 29.336 Loss-Of-Connectivity-Reason
+	LossOfConnectReason *int32                     `json:"lossOfConnectReason,omitempty"`
+	ReachabilityType    *string                    `json:"reachabilityType,omitempty"`
+	LocationInfo        *MonitoringLocationInfo    `json:"locationInfo,omitempty"`
+	PdnConnInfoList     []PdnConnectionInformation `json:"pdnConnInfoList,omitempty"`
+	DddStatus           *string                    `json:"dddStatus,omitempty"`
+}
+
+// MonitoringLocationInfo is the LocationInfo of TS 29.122
+type MonitoringLocationInfo struct {
+	CellId         *string `json:"cellId,omitempty"`
+	TrackingAreaId *string `json:"trackingAreaId,omitempty"`
+	PlmnId         *PlmnId `json:"plmnId,omitempty"`
+}
+
+// PdnConnectionInformation describes a PDU session of the UE
+type PdnConnectionInformation struct {
+	Status   string  `json:"status"`
+	Apn      *string `json:"apn,omitempty"`
+	PdnType  string  `json:"pdnType"`
+	Ipv4Addr *string `json:"ipv4Addr,omitempty"`
+	Snssai   *Snssai `json:"snssai,omitempty"`
+}
+
+// FlowInfo is an IP flow of an AF session
+type FlowInfo struct {
+	FlowId           int32    `json:"flowId"`
+	FlowDescriptions []string `json:"flowDescriptions,omitempty"`
+}
+
+// AsSessionWithQoSSubscription is the resource of the 3gpp-as-session-with-qos API
+type AsSessionWithQoSSubscription struct {
+	Self                    *string         `json:"self,omitempty"`
+	SupportedFeatures       *string         `json:"supportedFeatures,omitempty"`
+	Dnn                     *string         `json:"dnn,omitempty"`
+	Snssai                  *Snssai         `json:"snssai,omitempty"`
+	NotificationDestination string          `json:"notificationDestination"`
+	FlowInfo                []FlowInfo      `json:"flowInfo,omitempty"`
+	QosReference            *string         `json:"qosReference,omitempty"`
+	UeIpv4Addr              *string         `json:"ueIpv4Addr,omitempty"`
+	UeIpv6Addr              *string         `json:"ueIpv6Addr,omitempty"`
+	MacAddr                 *string         `json:"macAddr,omitempty"`
+	UsageThreshold          *UsageThreshold `json:"usageThreshold,omitempty"`
+}
+
+// UserPlaneEvent of an AS session with QoS
+type UserPlaneEvent string
+
+const (
+	UserPlaneEventSessionTermination UserPlaneEvent = "SESSION_TERMINATION"
+	UserPlaneEventUsageReport        UserPlaneEvent = "USAGE_REPORT"
+)
+
+// UserPlaneNotificationData relays the user plane events of an AS session to the AF
+type UserPlaneNotificationData struct {
+	Transaction  string                 `json:"transaction"`
+	EventReports []UserPlaneEventReport `json:"eventReports"`
+}
+
+type UserPlaneEventReport struct {
+	Event            UserPlaneEvent    `json:"event"`
+	AccumulatedUsage *AccumulatedUsage `json:"accumulatedUsage,omitempty"`
+}
+
+// TrafficInfluSub is the resource of the 3gpp-traffic-influence API
+type TrafficInfluSub struct {
+	Self                    *string            `json:"self,omitempty"`
+	AfServiceId             *string            `json:"afServiceId,omitempty"`
+	AfAppId                 *string            `json:"afAppId,omitempty"`
+	AfTransId               *string            `json:"afTransId,omitempty"`
+	AppReloInd              *bool              `json:"appReloInd,omitempty"`
+	Dnn                     *string            `json:"dnn,omitempty"`
+	Snssai                  *Snssai            `json:"snssai,omitempty"`
+	ExternalGroupId         *string            `json:"externalGroupId,omitempty"`
+	AnyUeInd                *bool              `json:"anyUeInd,omitempty"`
+	SubscribedEvents        []string           `json:"subscribedEvents,omitempty"`
+	Gpsi                    *string            `json:"gpsi,omitempty"`
+	Ipv4Addr                *string            `json:"ipv4Addr,omitempty"`
+	Ipv6Addr                *string            `json:"ipv6Addr,omitempty"`
+	MacAddr                 *string            `json:"macAddr,omitempty"`
+	DnaiChgType             *DnaiChangeType    `json:"dnaiChgType,omitempty"`
+	NotificationDestination *string            `json:"notificationDestination,omitempty"`
+	TrafficFilters          []FlowInfo         `json:"trafficFilters,omitempty"`
+	TrafficRoutes           []RouteToLocation  `json:"trafficRoutes,omitempty"`
+	TempValidities          []TemporalValidity `json:"tempValidities,omitempty"`
+	ValidGeoZoneIds         []string           `json:"validGeoZoneIds,omitempty"`
+	SuppFeat                *string            `json:"suppFeat,omitempty"`
+}
+
+// TrafficInfluEvent of a traffic influence subscription
+const TrafficInfluEventUpPathChange = "UP_PATH_CHANGE"
+
+// TrafficInfluNotification is the EventNotification of TS 29.522 relaying
+// the user plane path changes to the AF
+type TrafficInfluNotification struct {
+	AfTransId       *string `json:"afTransId,omitempty"`
+	DnaiChgType     string  `json:"dnaiChgType"`
+	SourceDnai      *string `json:"sourceDnai,omitempty"`
+	TargetDnai      *string `json:"targetDnai,omitempty"`
+	Gpsi            *string `json:"gpsi,omitempty"`
+	SrcUeIpv4Addr   *string `json:"srcUeIpv4Addr,omitempty"`
+	TgtUeIpv4Addr   *string `json:"tgtUeIpv4Addr,omitempty"`
+	SubscribedEvent string  `json:"subscribedEvent"`
+}
@@ -0,0 +1,235 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package models
+
+import "time"
+
+/* NWDAF analytics data types, TS 29.520 */
+
+// Analytics events of the NWDAF
+const (
+	NwdafEventUeMobility        = "UE_MOBILITY"
+	NwdafEventUeCommunication   = "UE_COMM"
+	NwdafEventNfLoad            = "NF_LOAD"
+	NwdafEventSliceLoadLevel    = "SLICE_LOAD_LEVEL"
+	NwdafEventAbnormalBehaviour = "ABNORMAL_BEHAVIOUR"
+)
+
+// Exceptions of the abnormal behaviour analytics
+const (
+	ExceptionPingPongAcrossCells         = "PING_PONG_ACROSS_CELLS"
+	ExceptionTooFrequentServiceAccess    = "TOO_FREQUENT_SERVICE_ACCESS"
+	ExceptionUnexpectedRadioLinkFailures = "UNEXPECTED_RADIO_LINK_FAILURES"
+	ExceptionUnexpectedLargeRateFlow     = "UNEXPECTED_LARGE_RATE_FLOW"
+)
+
+// Trends of the exceptions
+const (
+	ExceptionTrendUp     = "UP"
+	ExceptionTrendDown   = "DOWN"
+	ExceptionTrendStable = "STABLE"
+)
+
+// EventReportingRequirement is the time window of the analytics: statistics for a past window,
+// predictions for a future one. The offset period, in seconds, is relative to the report time.
+type EventReportingRequirement struct {
+	StartTs      *time.Time `json:"startTs,omitempty"`
+	EndTs        *time.Time `json:"endTs,omitempty"`
+	OffsetPeriod *int32     `json:"offsetPeriod,omitempty"`
+	MaxObjectNbr *int32     `json:"maxObjectNbr,omitempty"`
+}
+
+// TargetUeInformation selects the UEs of the analytics
+type TargetUeInformation struct {
+	AnyUe *bool    `json:"anyUe,omitempty"`
+	Supis []string `json:"supis,omitempty"`
+	Gpsis []string `json:"gpsis,omitempty"`
+}
+
+// EventFilter narrows the analytics of a query
+type EventFilter struct {
+	AnySlice      *bool    `json:"anySlice,omitempty"`
+	Snssais       []Snssai `json:"snssais,omitempty"`
+	Dnns          []string `json:"dnns,omitempty"`
+	NfInstanceIds []string `json:"nfInstanceIds,omitempty"`
+	NfTypes       []string `json:"nfTypes,omitempty"`
+	ExcepIds      []string `json:"excepIds,omitempty"`
+}
+
+// ThresholdLevel is a load level triggering a notification, in percent
+type ThresholdLevel struct {
+	NfLoadLevel *int32 `json:"nfLoadLevel,omitempty"`
+}
+
+// NwdafEventSubscription is the subscription to the analytics of an event
+type NwdafEventSubscription struct {
+	Event              string                     `json:"event"`
+	AnySlice           *bool                      `json:"anySlice,omitempty"`
+	Snssais            []Snssai                   `json:"snssais,omitempty"`
+	Dnns               []string                   `json:"dnns,omitempty"`
+	NfInstanceIds      []string                   `json:"nfInstanceIds,omitempty"`
+	NfTypes            []string                   `json:"nfTypes,omitempty"`
+	ExcepRequs         []Exception                `json:"excepRequs,omitempty"`
+	LoadLevelThreshold *int32                     `json:"loadLevelThreshold,omitempty"`
+	NfLoadLvlThds      []ThresholdLevel           `json:"nfLoadLvlThds,omitempty"`
+	TgtUe              *TargetUeInformation       `json:"tgtUe,omitempty"`
+	ExtraReportReq     *EventReportingRequirement `json:"extraReportReq,omitempty"`
+}
+
+// ReportingInformation is the notification method of a subscription, the repetition period in seconds
+type ReportingInformation struct {
+	ImmRep       *bool      `json:"immRep,omitempty"`
+	NotifMethod  *string    `json:"notifMethod,omitempty"`
+	MaxReportNbr *int32     `json:"maxReportNbr,omitempty"`
+	MonDur       *time.Time `json:"monDur,omitempty"`
+	RepPeriod    *int32     `json:"repPeriod,omitempty"`
+}
+
+// NnwdafEventsSubscription subscribes to the analytics of the NWDAF
+type NnwdafEventsSubscription struct {
+	EventSubscriptions []NwdafEventSubscription  `json:"eventSubscriptions"`
+	EvtReq             *ReportingInformation     `json:"evtReq,omitempty"`
+	NotificationURI    *string                   `json:"notificationURI,omitempty"`
+	NotifCorrId        *string                   `json:"notifCorrId,omitempty"`
+	SupportedFeatures  *string                   `json:"supportedFeatures,omitempty"`
+	EventNotifications []NnwdafEventNotification `json:"eventNotifications,omitempty"`
+}
+
+// NnwdafEventsSubscriptionNotification carries the analytics of a subscription
+type NnwdafEventsSubscriptionNotification struct {
+	EventNotifications []NnwdafEventNotification `json:"eventNotifications"`
+	SubscriptionId     string                    `json:"subscriptionId"`
+	NotifCorrId        *string                   `json:"notifCorrId,omitempty"`
+}
+
+// NnwdafEventNotification is the analytics of an event
+type NnwdafEventNotification struct {
+	Event              string                     `json:"event"`
+	Start              *time.Time                 `json:"start,omitempty"`
+	Expiry             *time.Time                 `json:"expiry,omitempty"`
+	TimeStampGen       *time.Time                 `json:"timeStampGen,omitempty"`
+	NfLoadLevelInfos   []NfLoadLevelInformation   `json:"nfLoadLevelInfos,omitempty"`
+	SliceLoadLevelInfo *SliceLoadLevelInformation `json:"sliceLoadLevelInfo,omitempty"`
+	UeMobs             []UeMobility               `json:"ueMobs,omitempty"`
+	UeComms            []UeCommunication          `json:"ueComms,omitempty"`
+	AbnorBehavrs       []AbnormalBehaviour        `json:"abnorBehavrs,omitempty"`
+}
+
+// AnalyticsData is the answer to an analytics query
+type AnalyticsData struct {
+	Start               *time.Time                  `json:"start,omitempty"`
+	Expiry              *time.Time                  `json:"expiry,omitempty"`
+	TimeStampGen        *time.Time                  `json:"timeStampGen,omitempty"`
+	SliceLoadLevelInfos []SliceLoadLevelInformation `json:"sliceLoadLevelInfos,omitempty"`
+	NfLoadLevelInfos    []NfLoadLevelInformation    `json:"nfLoadLevelInfos,omitempty"`
+	UeMobs              []UeMobility                `json:"ueMobs,omitempty"`
+	UeComms             []UeCommunication           `json:"ueComms,omitempty"`
+	AbnorBehavrs        []AbnormalBehaviour         `json:"abnorBehavrs,omitempty"`
+	SuppFeat            *string                     `json:"suppFeat,omitempty"`
+}
+
+// SliceLoadLevelInformation is the load level of network slices, in percent
+type SliceLoadLevelInformation struct {
+	LoadLevelInformation int32    `json:"loadLevelInformation"`
+	Snssais              []Snssai `json:"snssais"`
+	// confidence of a prediction, in percent
+	Confidence *int32 `json:"confidence,omitempty"`
+}
+
+// NfStatus is the share of time an NF spent in each status, in percent
+type NfStatus struct {
+	StatusRegistered     *int32 `json:"statusRegistered,omitempty"`
+	StatusUnregistered   *int32 `json:"statusUnregistered,omitempty"`
+	StatusUndiscoverable *int32 `json:"statusUndiscoverable,omitempty"`
+}
+
+// NfLoadLevelInformation is the load of an NF instance, in percent
+type NfLoadLevelInformation struct {
+	NfType             string    `json:"nfType"`
+	NfInstanceId       string    `json:"nfInstanceId"`
+	NfStatus           *NfStatus `json:"nfStatus,omitempty"`
+	NfLoadLevelAverage *int32    `json:"nfLoadLevelAverage,omitempty"`
+	NfLoadLevelpeak    *int32    `json:"nfLoadLevelpeak,omitempty"`
+	Confidence         *int32    `json:"confidence,omitempty"`
+}
+
+// UeMobility is the share of time spent by the UEs in each location, the duration in seconds
+type UeMobility struct {
+	Ts       *time.Time     `json:"ts,omitempty"`
+	Duration *int32         `json:"duration,omitempty"`
+	LocInfos []LocationInfo `json:"locInfos"`
+}
+
+// LocationInfo is a location of the UEs, the ratio and the confidence in percent
+type LocationInfo struct {
+	Loc        UserLocation `json:"loc"`
+	Ratio      *int32       `json:"ratio,omitempty"`
+	Confidence *int32       `json:"confidence,omitempty"`
+}
+
+// UeCommunication is the communication pattern of the UEs, the durations and periods in seconds
+type UeCommunication struct {
+	CommDur      int32                   `json:"commDur"`
+	PerioTime    *int32                  `json:"perioTime,omitempty"`
+	Ts           *time.Time              `json:"ts,omitempty"`
+	TrafChar     TrafficCharacterization `json:"trafChar"`
+	Ratio        *int32                  `json:"ratio,omitempty"`
+	PerioCommInd *bool                   `json:"perioCommInd,omitempty"`
+	Confidence   *int32                  `json:"confidence,omitempty"`
+}
+
+// TrafficCharacterization is the traffic of a DNN and a slice, the volumes in bytes
+type TrafficCharacterization struct {
+	Dnn    *string `json:"dnn,omitempty"`
+	Snssai *Snssai `json:"snssai,omitempty"`
+	UlVol  *int64  `json:"ulVol,omitempty"`
+	DlVol  *int64  `json:"dlVol,omitempty"`
+}
+
+// Exception is an abnormal behaviour, its level is the number of occurrences
+type Exception struct {
+	ExcepId    string  `json:"excepId"`
+	ExcepLevel *int32  `json:"excepLevel,omitempty"`
+	ExcepTrend *string `json:"excepTrend,omitempty"`
+}
+
+// AbnormalBehaviour lists the UEs showing an exception, the ratio and the confidence in percent
+type AbnormalBehaviour struct {
+	Supis      []string  `json:"supis,omitempty"`
+	Excep      Exception `json:"excep"`
+	Ratio      *int32    `json:"ratio,omitempty"`
+	Confidence *int32    `json:"confidence,omitempty"`
+}
+
+// GetAnyUe returns the AnyUe field value if set, zero value otherwise
+func (o *TargetUeInformation) GetAnyUe() bool {
+	if o == nil || o.AnyUe == nil {
+		return false
+	}
+	return *o.AnyUe
+}
+
+// GetNotificationURI returns the NotificationURI field value if set, zero value otherwise
+func (o *NnwdafEventsSubscription) GetNotificationURI() string {
+	if o == nil || o.NotificationURI == nil {
+		return ""
+	}
+	return *o.NotificationURI
+}
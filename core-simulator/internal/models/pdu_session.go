@@ -27,7 +27,26 @@ type PduSessionInfo struct {
 	Snssai   Snssai
 	DlStatus string
 	Dnn      string
+	// QoS of the default flow and of the flows added by the PCF
+	Qos      SessionQos
+	QosFlows []QosFlow
+	// DNAI the traffic is routed to, empty for the default path
+	Dnai string
 
 	Ctx          context.Context
 	CtxCancelFun context.CancelFunc
 }
+
+// SessionQos describes the QoS enforced on a QoS flow
+type SessionQos struct {
+	Var5qi       int32  `yaml:"5qi,omitempty" json:"5qi,omitempty"`
+	MaxBitrateUl string `yaml:"maxBitrateUl,omitempty" json:"maxBitrateUl,omitempty"`
+	MaxBitrateDl string `yaml:"maxBitrateDl,omitempty" json:"maxBitrateDl,omitempty"`
+}
+
+// QosFlow is a QoS flow allocated on a PDU Session for an application session
+type QosFlow struct {
+	Qfi          int32      `json:"qfi"`
+	AppSessionId string     `json:"appSessionId"`
+	Qos          SessionQos `json:"qos"`
+}
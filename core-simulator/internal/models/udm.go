@@ -0,0 +1,146 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package models
+
+import "time"
+
+/* UDM data types, TS 29.503 */
+
+// Ambr is an aggregate maximum bit rate, e.g. "100 Mbps"
+type Ambr struct {
+	Uplink   string `yaml:"uplink" json:"uplink"`
+	Downlink string `yaml:"downlink" json:"downlink"`
+}
+
+// Nssai lists the slices subscribed by a UE
+type Nssai struct {
+	DefaultSingleNssais []Snssai `json:"defaultSingleNssais"`
+	SingleNssais        []Snssai `json:"singleNssais,omitempty"`
+}
+
+// AccessAndMobilitySubscriptionData is the am-data of a subscriber
+type AccessAndMobilitySubscriptionData struct {
+	Gpsis            []string `json:"gpsis,omitempty"`
+	InternalGroupIds []string `json:"internalGroupIds,omitempty"`
+	SubscribedUeAmbr *Ambr    `json:"subscribedUeAmbr,omitempty"`
+	Nssai            *Nssai   `json:"nssai,omitempty"`
+}
+
+// SessionManagementSubscriptionData is the sm-data of a subscriber for a slice
+type SessionManagementSubscriptionData struct {
+	SingleNssai       Snssai                      `json:"singleNssai"`
+	DnnConfigurations map[string]DnnConfiguration `json:"dnnConfigurations,omitempty"`
+	InternalGroupIds  []string                    `json:"internalGroupIds,omitempty"`
+}
+
+// DnnConfiguration is the subscription of a UE to a DNN
+type DnnConfiguration struct {
+	PduSessionTypes PduSessionTypes       `json:"pduSessionTypes"`
+	SscModes        SscModes              `json:"sscModes"`
+	Var5gQosProfile *SubscribedDefaultQos `json:"5gQosProfile,omitempty"`
+	SessionAmbr     *Ambr                 `json:"sessionAmbr,omitempty"`
+}
+
+type PduSessionTypes struct {
+	DefaultSessionType  string   `json:"defaultSessionType"`
+	AllowedSessionTypes []string `json:"allowedSessionTypes,omitempty"`
+}
+
+type SscModes struct {
+	DefaultSscMode  string   `json:"defaultSscMode"`
+	AllowedSscModes []string `json:"allowedSscModes,omitempty"`
+}
+
+// SubscribedDefaultQos is the QoS of the default QoS flow of the PDU sessions
+type SubscribedDefaultQos struct {
+	Var5qi int32         `json:"5qi"`
+	Arp    SubscribedArp `json:"arp"`
+}
+
+// SubscribedArp is the allocation and retention priority of TS 29.571
+type SubscribedArp struct {
+	PriorityLevel int32  `json:"priorityLevel"`
+	PreemptCap    string `json:"preemptCap"`
+	PreemptVuln   string `json:"preemptVuln"`
+}
+
+// IdTranslationResult maps a GPSI to the SUPI of the subscriber
+type IdTranslationResult struct {
+	Supi string  `json:"supi"`
+	Gpsi *string `json:"gpsi,omitempty"`
+}
+
+// Amf3GppAccessRegistration is the registration of the AMF serving a UE
+type Amf3GppAccessRegistration struct {
+	AmfInstanceId          string    `json:"amfInstanceId"`
+	DeregCallbackUri       string    `json:"deregCallbackUri"`
+	Supi                   *string   `json:"supi,omitempty"`
+	Pei                    *string   `json:"pei,omitempty"`
+	InitialRegistrationInd *bool     `json:"initialRegistrationInd,omitempty"`
+	Guami                  Guami     `json:"guami"`
+	RatType                string    `json:"ratType"`
+	RegistrationTime       time.Time `json:"registrationTime"`
+}
+
+// SmfRegistration is the registration of the SMF serving a PDU session
+type SmfRegistration struct {
+	SmfInstanceId    string    `json:"smfInstanceId"`
+	PduSessionId     int32     `json:"pduSessionId"`
+	SingleNssai      Snssai    `json:"singleNssai"`
+	Dnn              *string   `json:"dnn,omitempty"`
+	PlmnId           PlmnId    `json:"plmnId"`
+	RegistrationTime time.Time `json:"registrationTime"`
+}
+
+type SmfRegistrationInfo struct {
+	SmfRegistrationList []SmfRegistration `json:"smfRegistrationList"`
+}
+
+// EeMonitoringType is the event of a monitoring configuration of the UDM event exposure
+type EeMonitoringType string
+
+const (
+	EeMonitoringTypeLossOfConnectivity    EeMonitoringType = "LOSS_OF_CONNECTIVITY"
+	EeMonitoringTypeUeReachabilityForData EeMonitoringType = "UE_REACHABILITY_FOR_DATA"
+	EeMonitoringTypeLocationReporting     EeMonitoringType = "LOCATION_REPORTING"
+)
+
+// EeSubscription is a subscription to the events of a UE, the monitoring
+// configurations are indexed by reference id
+type EeSubscription struct {
+	CallbackReference        string                             `json:"callbackReference"`
+	MonitoringConfigurations map[string]MonitoringConfiguration `json:"monitoringConfigurations"`
+	ReportingOptions         *EeReportingOptions                `json:"reportingOptions,omitempty"`
+	SupportedFeatures        *string                            `json:"supportedFeatures,omitempty"`
+}
+
+type MonitoringConfiguration struct {
+	EventType     EeMonitoringType `json:"eventType"`
+	ImmediateFlag *bool            `json:"immediateFlag,omitempty"`
+}
+
+type EeReportingOptions struct {
+	MaxNumOfReports *int32     `json:"maxNumOfReports,omitempty"`
+	Expiry          *time.Time `json:"expiry,omitempty"`
+}
+
+type CreatedEeSubscription struct {
+	EeSubscription EeSubscription `json:"eeSubscription"`
+}
@@ -38,7 +38,7 @@ type UeInfo struct {
 
 type Snssai struct {
 	Sst int32   `yaml:"sst" json:"sst"`
-	Sd  *string `yaml:"sd" json:"sd"`
+	Sd  *string `yaml:"sd" json:"sd,omitempty"`
 }
 
 type pduSesEst struct {
@@ -46,7 +46,8 @@ type UpStatsReport struct {
 	DlPacketRate float64
 }
 
-func NewUpStats(sessionId int32) *UpStats {
+// NewUpStats creates the userplane statistics of a PDU Session established at now
+func NewUpStats(sessionId int32, now time.Time) *UpStats {
 	return &UpStats{
 		PduSessId:    sessionId,
 		NumOfPackets: 0,
@@ -55,8 +56,8 @@ func NewUpStats(sessionId int32) *UpStats {
 		TotalDlBytes: 0,
 		NumUlPackets: 0,
 		NumDlPackets: 0,
-		LastDlUpdate: time.Now(),
-		LastUlUpdate: time.Now(),
+		LastDlUpdate: now,
+		LastUlUpdate: now,
 	}
 }
 
@@ -65,29 +66,30 @@ func (stats *UpStatsReport) Dumps() string {
 		stats.PduSessId, stats.NumOfPackets, stats.TotalBytes, stats.UlBitrate, stats.UlPacketRate, stats.DlBitrate, stats.DlPacketRate)
 }
 
-func (stats *UpStats) NewPacket(ul bool, size int64, timestamp time.Time) {
-	stats.NumOfPackets++
+// AddPackets accounts a batch of packets, timestamp is the arrival of the last packet
+func (stats *UpStats) AddPackets(ul bool, packets int64, size int64, timestamp time.Time) {
+	stats.NumOfPackets += packets
 	stats.TotalBytes += size
 	if ul {
-		stats.NumUlPackets++
+		stats.NumUlPackets += packets
 		stats.TotalUlBytes += size
 		stats.LastUlSizeArrived = size
 		stats.LastUlUpdate = timestamp
 	} else {
-		stats.NumDlPackets++
+		stats.NumDlPackets += packets
 		stats.TotalDlBytes += size
 		stats.LastDlSizeArrived = size
 		stats.LastDlUpdate = timestamp
 	}
-
 }
 
-func (stats *UpStats) GenerateReport() *UpStatsReport {
+// GenerateReport computes the rates of the last packets received up to now
+func (stats *UpStats) GenerateReport(now time.Time) *UpStatsReport {
 	return &UpStatsReport{
 		UpStats:      *stats,
-		DlBitrate:    float64(stats.LastDlSizeArrived) / float64(time.Since(stats.LastDlUpdate).Seconds()) * 8,
-		DlPacketRate: 1.00 / float64(time.Since(stats.LastDlUpdate).Seconds()),
-		UlBitrate:    float64(stats.LastUlSizeArrived) / float64(time.Since(stats.LastUlUpdate).Seconds()) * 8,
-		UlPacketRate: 1.00 / float64(time.Since(stats.LastUlUpdate).Seconds()),
+		DlBitrate:    float64(stats.LastDlSizeArrived) / float64(now.Sub(stats.LastDlUpdate).Seconds()) * 8,
+		DlPacketRate: 1.00 / float64(now.Sub(stats.LastDlUpdate).Seconds()),
+		UlBitrate:    float64(stats.LastUlSizeArrived) / float64(now.Sub(stats.LastUlUpdate).Seconds()) * 8,
+		UlPacketRate: 1.00 / float64(now.Sub(stats.LastUlUpdate).Seconds()),
 	}
 }
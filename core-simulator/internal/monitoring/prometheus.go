@@ -73,10 +73,33 @@ var (
 		},
 		[]string{"simulationId", "imsi", "ip"},
 	)
+	Notifications = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "notifications_total",
+			Help: "Event notifications by network function and delivery result",
+		},
+		[]string{"nf", "result"},
+	)
+	NotificationsPending = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "notifications_pending",
+			Help: "Event notifications waiting in the subscriber queues",
+		},
+		[]string{"nf"},
+	)
+	NotificationLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "notification_delivery_seconds",
+			Help:    "Time to deliver an event notification, retries included",
+			Buckets: prometheus.ExponentialBuckets(0.001, 4, 10),
+		},
+		[]string{"nf"},
+	)
 )
 
 func init() {
 	prometheus.MustRegister(UEsTotal, PduSessionsTotal, TrafficBytes, TrafficPackets, TotalTraffic, UEIPInfo)
+	prometheus.MustRegister(Notifications, NotificationsPending, NotificationLatency)
 	//prometheus.MustRegister(TotalTraffic)
 }
 
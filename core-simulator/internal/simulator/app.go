@@ -21,13 +21,16 @@ package simulator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"sync"
 	"syscall"
+	"time"
 
 	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/monitoring"
 )
@@ -37,12 +40,19 @@ import (
 type SimulationStatus string
 
 const (
-	CONFIGURED SimulationStatus = "CONFIGURED"
-	STARTED    SimulationStatus = "STARTED"
-	STOPPED    SimulationStatus = "STOPPED"
-	ERROR      SimulationStatus = "ERROR"
+	UNCONFIGURED SimulationStatus = "UNCONFIGURED"
+	CONFIGURED   SimulationStatus = "CONFIGURED"
+	STARTED      SimulationStatus = "STARTED"
+	PAUSED       SimulationStatus = "PAUSED"
+	STOPPED      SimulationStatus = "STOPPED"
+	ERROR        SimulationStatus = "ERROR"
 )
 
+// time granted to the SBI server to complete the ongoing requests on reset
+const shutdownTimeout = 5 * time.Second
+
+var ErrInvalidTransition = errors.New("invalid simulation state transition")
+
 type SimulationStatusResponse struct {
 	Status SimulationStatus
 }
@@ -60,13 +70,21 @@ type CoreSimulatorApp struct {
 func NewCoreSimulatorApp(configPath string) *CoreSimulatorApp {
 	return &CoreSimulatorApp{
 		currentInstance: nil,
-		status:          STOPPED,
+		status:          UNCONFIGURED,
 		instanceMutex:   sync.RWMutex{},
 		wg:              sync.WaitGroup{},
 		config:          InitConfig(configPath),
 	}
 }
 
+// checkTransition verifies that the operation is allowed in the current status
+func (app *CoreSimulatorApp) checkTransition(operation string, allowed ...SimulationStatus) error {
+	if slices.Contains(allowed, app.status) {
+		return nil
+	}
+	return fmt.Errorf("%w: cannot %s a simulation in status %s", ErrInvalidTransition, operation, app.status)
+}
+
 func (app *CoreSimulatorApp) InitNewSimulation(config *NetworkConfig) error {
 
 	if config == nil {
@@ -76,37 +94,32 @@ func (app *CoreSimulatorApp) InitNewSimulation(config *NetworkConfig) error {
 	app.instanceMutex.Lock()
 	defer app.instanceMutex.Unlock()
 
-	if app.currentInstance != nil {
-		return fmt.Errorf("could not initialize the simulation instance, please stop or reset the current instance")
+	if err := app.checkTransition("configure", UNCONFIGURED); err != nil {
+		return fmt.Errorf("%w, please reset the current instance", err)
 	}
 
-	app.currentInstance = NewNetworkInstance(app.config.SbiPort, config)
-	if app.currentInstance == nil {
+	instance := NewNetworkInstance(app.config.SbiPort, config)
+	if instance == nil {
 		return fmt.Errorf("could not initialize the simulation instance")
 	}
 
-	err := app.currentInstance.InitNetworkInstance()
+	err := instance.InitNetworkInstance()
 	if err != nil {
-		return fmt.Errorf("could not initialize the simulation instance")
+		return fmt.Errorf("could not initialize the simulation instance: %w", err)
 	}
 
+	app.currentInstance = instance
 	app.status = CONFIGURED
 	return nil
 }
 
+// StartSimulation starts a configured simulation, or restarts a stopped one
 func (app *CoreSimulatorApp) StartSimulation() error {
 	app.instanceMutex.Lock()
 	defer app.instanceMutex.Unlock()
 
-	if app.currentInstance == nil {
-		return fmt.Errorf("please configure the simulation via /configure")
-	}
-
-	// If already started, it's a restart - stop first
-	if app.status == STARTED {
-		if err := app.currentInstance.Stop(); err != nil {
-			log.Printf("Warning: error stopping instance for restart: %s", err.Error())
-		}
+	if err := app.checkTransition("start", CONFIGURED, STOPPED); err != nil {
+		return err
 	}
 
 	if err := app.currentInstance.Start(); err != nil {
@@ -125,25 +138,127 @@ func (app *CoreSimulatorApp) GetCurrentSimulationStatus() SimulationStatus {
 	return app.status
 }
 
+// StopSimulation powers off all the UEs, the instance is kept so that it can be started again
 func (app *CoreSimulatorApp) StopSimulation() error {
 	app.instanceMutex.Lock()
 	defer app.instanceMutex.Unlock()
 
-	if app.status == STOPPED || app.currentInstance == nil {
-		return fmt.Errorf("no running instance")
+	if err := app.checkTransition("stop", STARTED, PAUSED); err != nil {
+		return err
 	}
 
-	if app.status == STARTED {
-		if err := app.currentInstance.Stop(); err != nil {
-			return fmt.Errorf("could not stop the simulation instance")
-		}
+	if err := app.currentInstance.Stop(); err != nil {
+		app.status = ERROR
+		return fmt.Errorf("could not stop the simulation instance")
 	}
 
-	// Don't set currentInstance to nil - keep it so we can restart
 	app.status = STOPPED
 	return nil
 }
 
+// PauseSimulation freezes a running simulation without losing its state
+func (app *CoreSimulatorApp) PauseSimulation() error {
+	app.instanceMutex.Lock()
+	defer app.instanceMutex.Unlock()
+
+	if err := app.checkTransition("pause", STARTED); err != nil {
+		return err
+	}
+
+	if err := app.currentInstance.Pause(); err != nil {
+		return err
+	}
+
+	app.status = PAUSED
+	return nil
+}
+
+func (app *CoreSimulatorApp) ResumeSimulation() error {
+	app.instanceMutex.Lock()
+	defer app.instanceMutex.Unlock()
+
+	if err := app.checkTransition("resume", PAUSED); err != nil {
+		return err
+	}
+
+	if err := app.currentInstance.Resume(); err != nil {
+		return err
+	}
+
+	app.status = STARTED
+	return nil
+}
+
+// ResetSimulation tears down the current instance, after a reset
+// the simulator must be configured again
+func (app *CoreSimulatorApp) ResetSimulation() error {
+	app.instanceMutex.Lock()
+	defer app.instanceMutex.Unlock()
+
+	if err := app.checkTransition("reset", CONFIGURED, STARTED, PAUSED, STOPPED, ERROR); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := app.currentInstance.Shutdown(ctx); err != nil {
+		app.status = ERROR
+		return fmt.Errorf("could not reset the simulation instance: %w", err)
+	}
+
+	app.currentInstance = nil
+	app.status = UNCONFIGURED
+	return nil
+}
+
+// getInstance returns the current network instance, if configured
+func (app *CoreSimulatorApp) getInstance() (*NetworkInstance, error) {
+	app.instanceMutex.RLock()
+	defer app.instanceMutex.RUnlock()
+
+	if app.currentInstance == nil {
+		return nil, fmt.Errorf("no simulation instance")
+	}
+	return app.currentInstance, nil
+}
+
+func (app *CoreSimulatorApp) LoadScenario(scenario *Scenario) error {
+	app.instanceMutex.Lock()
+	defer app.instanceMutex.Unlock()
+
+	if app.currentInstance == nil {
+		return fmt.Errorf("please configure the simulation via /configure")
+	}
+
+	return app.currentInstance.LoadScenario(scenario)
+}
+
+func (app *CoreSimulatorApp) UnloadScenario() error {
+	app.instanceMutex.Lock()
+	defer app.instanceMutex.Unlock()
+
+	if app.currentInstance == nil {
+		return fmt.Errorf("no simulation instance")
+	}
+
+	return app.currentInstance.UnloadScenario()
+}
+
+func (app *CoreSimulatorApp) GetScenarioReport() (*ScenarioReport, error) {
+	app.instanceMutex.RLock()
+	defer app.instanceMutex.RUnlock()
+
+	if app.currentInstance == nil {
+		return nil, fmt.Errorf("no simulation instance")
+	}
+
+	report, ok := app.currentInstance.ScenarioReport()
+	if !ok {
+		return nil, fmt.Errorf("no scenario loaded")
+	}
+	return report, nil
+}
+
 func (app *CoreSimulatorApp) Run() {
 
 	var cancel context.CancelFunc
@@ -157,7 +272,7 @@ func (app *CoreSimulatorApp) Run() {
 		log.Printf("bootstraping simulation instance")
 		err := app.InitNewSimulation(app.config.NetConfig)
 		if err != nil {
-			log.Fatalf("could not initialize the simulator on startup")
+			log.Fatalf("could not initialize the simulator on startup: %v", err)
 		}
 
 		// Auto-start the simulation after initialization
@@ -0,0 +1,130 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package simulator
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/arrival"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
+)
+
+/* UE arrivals and departures */
+
+// runArrivals spawns the UEs of the simulation profile following the arrival process.
+// Once the whole population has been spawned, the arrivals power on again the UEs
+// switched off by the departure process.
+func (n *NetworkInstance) runArrivals(ctx context.Context, process arrival.Process) {
+	// the process runs on the simulated time, which does not advance in pause
+	elapsed := time.Duration(0)
+	spawned := 0
+
+	for {
+		if spawned >= n.config.NumOfUe && n.config.Departure == nil {
+			// no UE will ever leave, the population is complete
+			return
+		}
+
+		delay, ok := process.Next(elapsed)
+		if !ok {
+			log.Printf("simulation %s: no arrival left", n.simId)
+			return
+		}
+		elapsed += delay
+
+		if !n.clock.Sleep(ctx, delay) {
+			return
+		}
+		// arrivals are frozen while the simulation is paused
+		if !n.pause.Wait(ctx) {
+			return
+		}
+
+		if spawned < n.config.NumOfUe {
+			spawned++
+			n.spawnUe(spawned)
+			continue
+		}
+		n.rearrive()
+	}
+}
+
+// spawnUe creates and powers up the UE with the given index
+func (n *NetworkInstance) spawnUe(index int) {
+	n.ueListMutex.Lock()
+	defer n.ueListMutex.Unlock()
+
+	// UEs pinned by a scenario are spawned by the scenario itself
+	if _, exists := n.UeList[n.imsiFromIndex(index)]; exists {
+		return
+	}
+
+	ue, err := n.newUe(index, ran.DeviceClassSmartphone)
+	if err != nil {
+		log.Printf("simulation %s: could not spawn ue: %s", n.simId, err.Error())
+		return
+	}
+	ue.PowerUp()
+	n.UeList[ue.Imsi] = ue
+	n.scheduleDeparture(ue)
+}
+
+// rearrive powers on a random UE among the ones that departed
+func (n *NetworkInstance) rearrive() {
+	n.ueListMutex.Lock()
+	defer n.ueListMutex.Unlock()
+
+	for len(n.departed) > 0 {
+		idx := n.random.Intn(len(n.departed))
+		imsi := n.departed[idx]
+		n.departed[idx] = n.departed[len(n.departed)-1]
+		n.departed = n.departed[:len(n.departed)-1]
+
+		// the UE may have been removed or powered on via OAM in the meantime
+		ue, exists := n.UeList[imsi]
+		if !exists || ue.IsPoweredOn() || ue.IsPinned() {
+			continue
+		}
+		ue.PowerUp()
+		n.scheduleDeparture(ue)
+		return
+	}
+}
+
+// scheduleDeparture powers the UE off at the end of its on time. The departure is a timer of
+// the UE on the engine, dropped if the UE is turned off before.
+func (n *NetworkInstance) scheduleDeparture(ue *ran.Ue) {
+	if n.config.Departure == nil {
+		return
+	}
+	ue.DispatchAfter(n.config.Departure.OnTime(n.random), func() {
+		// UEs driven by a scenario do not leave
+		if ue.IsPinned() || !ue.IsPoweredOn() {
+			return
+		}
+		ue.TurnOff(true)
+
+		n.ueListMutex.Lock()
+		n.departed = append(n.departed, ue.Imsi)
+		n.ueListMutex.Unlock()
+	})
+}
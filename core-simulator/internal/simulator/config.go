@@ -22,8 +22,13 @@ package simulator
 import (
 	"log"
 	"os"
+	"time"
 
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/arrival"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/core"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
 	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/trafficgen"
 	"gopkg.in/yaml.v3"
 )
 
@@ -46,6 +51,36 @@ type NetworkConfig struct {
 	NumOfGnb    int           `yaml:"numOfgNB" json:"numOfgNB"`
 	NumOfUe     int           `yaml:"numOfUe" json:"numOfUe"`
 	ArrivalRate float32       `yaml:"arrivalRate" json:"arrivalRate"`
+	// optional arrival model, poisson arrivals with arrivalRate by default
+	Arrival *arrival.Config `yaml:"arrival,omitempty" json:"arrival,omitempty"`
+	// optional departure process, UEs stay powered on by default
+	Departure *arrival.DepartureConfig `yaml:"departure,omitempty" json:"departure,omitempty"`
+	// optional UE identities generation, SUPIs and MSISDNs are derived from the UE index by default
+	Identities *IdentityConfig `yaml:"identities,omitempty" json:"identities,omitempty"`
+	// optional traffic profiles, added to or overriding the default web, video, iot and sip profiles
+	TrafficProfiles map[string]*trafficgen.Profile `yaml:"trafficProfiles,omitempty" json:"trafficProfiles,omitempty"`
+	// optional application mix of the device classes, added to or overriding Smartphone and IoT
+	DeviceClasses ran.DeviceClasses `yaml:"deviceClasses,omitempty" json:"deviceClasses,omitempty"`
+	// optional number of shards of the UE engine, one per CPU by default
+	UeEngineShards int `yaml:"ueEngineShards,omitempty" json:"ueEngineShards,omitempty"`
+	// optional mobile reachable timer of the AMF in seconds, 3480 by default, negative to disable the implicit deregistration
+	MobileReachableTimerSec int `yaml:"mobileReachableTimerSec,omitempty" json:"mobileReachableTimerSec,omitempty"`
+	// optional delivery settings of the event notifications
+	Notifications *core.NotifierConfig `yaml:"notifications,omitempty" json:"notifications,omitempty"`
+	// optional scripted scenario executed when the simulation starts
+	ScenarioFile string `yaml:"scenarioFile,omitempty" json:"scenarioFile,omitempty"`
+	// optional subscription data of the UEs provisioned in the UDM
+	Udm *core.UdmConfig `yaml:"udm,omitempty" json:"udm,omitempty"`
+	// optional CHF charging the PDU sessions, disabled by default
+	Chf *core.ChfConfig `yaml:"chf,omitempty" json:"chf,omitempty"`
+	// optional NWDAF learning the analytics of the network from the AMF and SMF events, disabled by default
+	Nwdaf *core.NwdafConfig `yaml:"nwdaf,omitempty" json:"nwdaf,omitempty"`
+	// optional NEF exposing the northbound APIs of TS 29.522 on the SBI, disabled by default
+	Nef *core.NefConfig `yaml:"nef,omitempty" json:"nef,omitempty"`
+	// optional edge sites where the AF routing requirements move the PDU sessions, none by default
+	EdgeSites core.EdgeSites `yaml:"edgeSites,omitempty" json:"edgeSites,omitempty"`
+	// optional seed of the random draws, a different sequence at every run by default
+	Seed *int64 `yaml:"seed,omitempty" json:"seed,omitempty"`
 }
 
 func InitConfig(configPath string) *AppConfig {
@@ -76,3 +111,25 @@ func (cfg *AppConfig) Dumps() string {
 	return string(d)
 
 }
+
+// mobileReachableTimer returns the mobile reachable timer of the AMF, zero when disabled
+func (cfg *NetworkConfig) mobileReachableTimer() time.Duration {
+	switch {
+	case cfg.MobileReachableTimerSec < 0:
+		return 0
+	case cfg.MobileReachableTimerSec == 0:
+		return core.DefaultMobileReachableTimer
+	}
+	return time.Duration(cfg.MobileReachableTimerSec) * time.Second
+}
+
+// arrivalConfig returns the arrival model of the simulation profile
+func (cfg *NetworkConfig) arrivalConfig() *arrival.Config {
+	if cfg.Arrival != nil {
+		return cfg.Arrival
+	}
+	return &arrival.Config{
+		Model: arrival.Poisson,
+		Rate:  float64(cfg.ArrivalRate),
+	}
+}
@@ -0,0 +1,339 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package simulator
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* UE identities generation */
+
+// IdentityConfig describes how the permanent and public identities of the UEs are built.
+// The UE with index i (starting from 1) gets the i-th identity of each source.
+type IdentityConfig struct {
+	Supi *IdentitySource `yaml:"supi,omitempty" json:"supi,omitempty"`
+	Gpsi *GpsiConfig     `yaml:"gpsi,omitempty" json:"gpsi,omitempty"`
+	Pei  *PeiConfig      `yaml:"pei,omitempty" json:"pei,omitempty"`
+	Suci *ran.SuciConfig `yaml:"suci,omitempty" json:"suci,omitempty"`
+}
+
+// IdentitySource lists identities explicitly, from a file with one identity per line,
+// and as numeric ranges. File entries come first, followed by the ranges in order.
+type IdentitySource struct {
+	File   string          `yaml:"file,omitempty" json:"file,omitempty"`
+	Ranges []IdentityRange `yaml:"ranges,omitempty" json:"ranges,omitempty"`
+}
+
+// IdentityRange is a range of numeric identities, bounds included. An empty end
+// leaves the range open, which is only allowed for the last range of a source.
+// The width of start is preserved, e.g. "001060000000001".
+type IdentityRange struct {
+	Start string `yaml:"start" json:"start"`
+	End   string `yaml:"end,omitempty" json:"end,omitempty"`
+}
+
+// GpsiConfig builds MSISDNs, or external identifiers when a domain is set.
+// With a domain, the values of the source (or "ue<index>") are the local identifiers.
+type GpsiConfig struct {
+	IdentitySource   `yaml:",inline"`
+	ExternalIdDomain string `yaml:"externalIdDomain,omitempty" json:"externalIdDomain,omitempty"`
+}
+
+// PeiConfig lists the Type Allocation Codes assigned to each device class
+type PeiConfig struct {
+	Tacs map[string][]string `yaml:"tacs,omitempty" json:"tacs,omitempty"`
+}
+
+// Type Allocation Codes used when none is configured for a device class
+var defaultTacs = map[string][]string{
+	ran.DeviceClassSmartphone: {"35332811", "35391110", "35467209", "35881007"},
+	ran.DeviceClassIoT:        {"86068703", "86442104", "35907807"},
+}
+
+type identitySequence struct {
+	values []string
+	ranges []numericRange
+}
+
+type numericRange struct {
+	start uint64
+	end   uint64
+	open  bool
+	width int
+}
+
+// identityGenerator assigns the identities of the UEs from their index
+type identityGenerator struct {
+	plmn  models.PlmnId
+	supis *identitySequence
+	gpsis *identitySequence
+	// external identifier domain of the GPSIs, empty for MSISDNs
+	domain string
+	tacs   map[string][]string
+}
+
+func newIdentityGenerator(cfg *IdentityConfig, plmn models.PlmnId) (*identityGenerator, error) {
+	gen := &identityGenerator{
+		plmn: plmn,
+		tacs: defaultTacs,
+	}
+	if cfg == nil {
+		cfg = &IdentityConfig{}
+	}
+
+	if cfg.Supi != nil {
+		seq, err := newIdentitySequence(cfg.Supi, 15)
+		if err != nil {
+			return nil, fmt.Errorf("invalid supi configuration: %w", err)
+		}
+		for _, imsi := range seq.values {
+			if err := checkImsi(imsi, plmn); err != nil {
+				return nil, fmt.Errorf("invalid supi configuration: %w", err)
+			}
+		}
+		for i, r := range cfg.Supi.Ranges {
+			for _, bound := range []string{r.Start, r.End} {
+				if bound == "" {
+					continue
+				}
+				if err := checkImsi(bound, plmn); err != nil {
+					return nil, fmt.Errorf("invalid supi configuration: range %d: %w", i, err)
+				}
+			}
+		}
+		gen.supis = seq
+	}
+
+	if cfg.Gpsi != nil {
+		gen.domain = cfg.Gpsi.ExternalIdDomain
+		source := &cfg.Gpsi.IdentitySource
+		// external identifiers may be derived from the UE index only
+		if gen.domain == "" || source.File != "" || len(source.Ranges) > 0 {
+			seq, err := newIdentitySequence(source, 15)
+			if err != nil {
+				return nil, fmt.Errorf("invalid gpsi configuration: %w", err)
+			}
+			gen.gpsis = seq
+		}
+	}
+
+	if cfg.Pei != nil && len(cfg.Pei.Tacs) > 0 {
+		gen.tacs = map[string][]string{}
+		for class, tacs := range defaultTacs {
+			gen.tacs[class] = tacs
+		}
+		for class, tacs := range cfg.Pei.Tacs {
+			for _, tac := range tacs {
+				if len(tac) != 8 || !isDigits(tac) {
+					return nil, fmt.Errorf("invalid pei configuration: tac %q must be 8 digits", tac)
+				}
+			}
+			if len(tacs) > 0 {
+				gen.tacs[class] = tacs
+			}
+		}
+	}
+
+	if cfg.Suci != nil {
+		if err := cfg.Suci.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid suci configuration: %w", err)
+		}
+	}
+
+	return gen, nil
+}
+
+// supi returns the IMSI of the UE with the given index
+func (g *identityGenerator) supi(index int) (string, error) {
+	if g.supis == nil {
+		prefix := g.plmn.Mcc + g.plmn.Mnc
+		return fmt.Sprintf("%s%0*d", prefix, 15-len(prefix), index), nil
+	}
+	imsi, ok := g.supis.get(index)
+	if !ok {
+		return "", fmt.Errorf("no supi left for ue %d", index)
+	}
+	// an open range may run past the MSINs of the PLMN
+	if err := checkImsi(imsi, g.plmn); err != nil {
+		return "", fmt.Errorf("no supi left for ue %d: %w", index, err)
+	}
+	return imsi, nil
+}
+
+// gpsi returns the MSISDN, or the external identifier, of the UE with the given index
+func (g *identityGenerator) gpsi(index int) (string, error) {
+	if g.gpsis == nil && g.domain == "" {
+		return fmt.Sprintf("+336%09d", 100000000+index-1), nil
+	}
+
+	value := fmt.Sprintf("ue%d", index)
+	if g.gpsis != nil {
+		var ok bool
+		if value, ok = g.gpsis.get(index); !ok {
+			return "", fmt.Errorf("no gpsi left for ue %d", index)
+		}
+	}
+
+	switch {
+	case strings.Contains(value, "@"):
+		// explicit external identifier
+		return value, nil
+	case g.domain != "":
+		return value + "@" + g.domain, nil
+	case !strings.HasPrefix(value, "+"):
+		return "+" + value, nil
+	default:
+		return value, nil
+	}
+}
+
+// pei returns the IMEI of the UE: a TAC of its device class, a serial
+// number derived from the index and the Luhn check digit
+func (g *identityGenerator) pei(index int, deviceClass string) string {
+	tacs, ok := g.tacs[deviceClass]
+	if !ok {
+		tacs = g.tacs[ran.DeviceClassSmartphone]
+	}
+	tac := tacs[(index-1)%len(tacs)]
+	snr := fmt.Sprintf("%06d", ((index-1)/len(tacs))%1000000)
+
+	imei14 := tac + snr
+	return imei14 + luhnCheckDigit(imei14)
+}
+
+func newIdentitySequence(source *IdentitySource, maxWidth int) (*identitySequence, error) {
+	seq := &identitySequence{}
+
+	if source.File != "" {
+		values, err := readIdentityFile(source.File)
+		if err != nil {
+			return nil, err
+		}
+		seq.values = values
+	}
+
+	for i, r := range source.Ranges {
+		start := strings.TrimPrefix(r.Start, "+")
+		end := strings.TrimPrefix(r.End, "+")
+		if !isDigits(start) || (end != "" && !isDigits(end)) {
+			return nil, fmt.Errorf("range %d: bounds must be numeric", i)
+		}
+		if maxWidth > 0 && len(start) > maxWidth {
+			return nil, fmt.Errorf("range %d: identities are limited to %d digits", i, maxWidth)
+		}
+		if end != "" && len(end) != len(start) {
+			return nil, fmt.Errorf("range %d: bounds must have the same number of digits", i)
+		}
+		if end == "" && i != len(source.Ranges)-1 {
+			return nil, fmt.Errorf("range %d: only the last range can be left open", i)
+		}
+
+		nr := numericRange{width: len(start), open: end == ""}
+		nr.start, _ = strconv.ParseUint(start, 10, 64)
+		if !nr.open {
+			nr.end, _ = strconv.ParseUint(end, 10, 64)
+			if nr.end < nr.start {
+				return nil, fmt.Errorf("range %d: end precedes start", i)
+			}
+		}
+		seq.ranges = append(seq.ranges, nr)
+	}
+
+	if len(seq.values) == 0 && len(seq.ranges) == 0 {
+		return nil, fmt.Errorf("either a file or a range is required")
+	}
+	return seq, nil
+}
+
+// get returns the identity at the given position, starting from 1
+func (s *identitySequence) get(index int) (string, bool) {
+	if index < 1 {
+		return "", false
+	}
+	if index <= len(s.values) {
+		return s.values[index-1], true
+	}
+
+	offset := uint64(index - len(s.values) - 1)
+	for _, r := range s.ranges {
+		if r.open || offset <= r.end-r.start {
+			value := fmt.Sprintf("%0*d", r.width, r.start+offset)
+			if len(value) > r.width {
+				return "", false
+			}
+			return value, true
+		}
+		offset -= r.end - r.start + 1
+	}
+	return "", false
+}
+
+// readIdentityFile reads one identity per line, ignoring empty lines and # comments
+func readIdentityFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open identity file: %w", err)
+	}
+	defer file.Close()
+
+	values := []string{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		values = append(values, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read identity file: %w", err)
+	}
+	return values, nil
+}
+
+// checkImsi checks that the IMSI has 15 digits and belongs to the PLMN
+func checkImsi(imsi string, plmn models.PlmnId) error {
+	if len(imsi) != 15 || !isDigits(imsi) {
+		return fmt.Errorf("imsi %q must be 15 digits", imsi)
+	}
+	if !strings.HasPrefix(imsi, plmn.Mcc+plmn.Mnc) {
+		return fmt.Errorf("imsi %s does not belong to plmn %s%s", imsi, plmn.Mcc, plmn.Mnc)
+	}
+	return nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,171 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package simulator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+var testPlmn = models.PlmnId{Mcc: "001", Mnc: "06"}
+
+// writeIdentityFile writes the identities, one per line, to a file removed when the test ends
+func writeIdentityFile(t *testing.T, identities ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "identities.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(identities, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestIdentityGeneratorRejectsInvalidConfigs(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *IdentityConfig
+		want string
+	}{
+		{"file imsi outside of plmn", &IdentityConfig{Supi: &IdentitySource{File: writeIdentityFile(t, "001060000000001", "208930000000001")}}, "does not belong"},
+		{"short file imsi", &IdentityConfig{Supi: &IdentitySource{File: writeIdentityFile(t, "00106000000001")}}, "15 digits"},
+		{"non numeric file imsi", &IdentityConfig{Supi: &IdentitySource{File: writeIdentityFile(t, "00106000000000a")}}, "15 digits"},
+		{"range start outside of plmn", &IdentityConfig{Supi: &IdentitySource{Ranges: []IdentityRange{{Start: "208930000000001"}}}}, "does not belong"},
+		{"range end outside of plmn", &IdentityConfig{Supi: &IdentitySource{Ranges: []IdentityRange{{Start: "001069999999990", End: "001070000000009"}}}}, "does not belong"},
+		{"short range", &IdentityConfig{Supi: &IdentitySource{Ranges: []IdentityRange{{Start: "0010600001", End: "0010600009"}}}}, "15 digits"},
+		{"open range before the last", &IdentityConfig{Supi: &IdentitySource{Ranges: []IdentityRange{{Start: "001060000000001"}, {Start: "001060000001000"}}}}, "left open"},
+		{"empty supi source", &IdentityConfig{Supi: &IdentitySource{}}, "file or a range"},
+		{"non numeric gpsi range", &IdentityConfig{Gpsi: &GpsiConfig{IdentitySource: IdentitySource{Ranges: []IdentityRange{{Start: "336abc"}}}}}, "numeric"},
+		{"short tac", &IdentityConfig{Pei: &PeiConfig{Tacs: map[string][]string{ran.DeviceClassIoT: {"8606870"}}}}, "8 digits"},
+		{"suci without home network key", &IdentityConfig{Suci: &ran.SuciConfig{ProtectionScheme: ran.SuciProfileA}}, "public key"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := newIdentityGenerator(test.cfg, testPlmn)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("got error %v, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestIdentityGeneratorSupis(t *testing.T) {
+	gen, err := newIdentityGenerator(&IdentityConfig{Supi: &IdentitySource{
+		File: writeIdentityFile(t, "# provisioned subscribers", "001060000000042", "", "001060000000007"),
+		Ranges: []IdentityRange{
+			{Start: "001061000000000", End: "001061000000001"},
+			{Start: "001069999999999"},
+		},
+	}}, testPlmn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for index, want := range []string{"001060000000042", "001060000000007", "001061000000000", "001061000000001", "001069999999999"} {
+		if got, err := gen.supi(index + 1); err != nil || got != want {
+			t.Fatalf("ue %d: got supi %s (%v), want %s", index+1, got, err, want)
+		}
+	}
+	// the open range runs past the MSINs of the plmn
+	if got, err := gen.supi(6); err == nil {
+		t.Fatalf("got supi %s, want no supi left", got)
+	}
+}
+
+func TestIdentityGeneratorDefaults(t *testing.T) {
+	tests := []struct {
+		plmn models.PlmnId
+		want string
+	}{
+		{models.PlmnId{Mcc: "001", Mnc: "06"}, "001060000000012"},
+		{models.PlmnId{Mcc: "310", Mnc: "410"}, "310410000000012"},
+	}
+	for _, test := range tests {
+		gen, err := newIdentityGenerator(nil, test.plmn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		supi, err := gen.supi(12)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if supi != test.want {
+			t.Fatalf("got supi %s, want %s", supi, test.want)
+		}
+		if err := checkImsi(supi, test.plmn); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIdentityGeneratorGpsis(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  *GpsiConfig
+		want []string
+	}{
+		{"default msisdns", nil, []string{"+336100000000", "+336100000001"}},
+		{"msisdn range", &GpsiConfig{IdentitySource: IdentitySource{Ranges: []IdentityRange{{Start: "33612000000"}}}}, []string{"+33612000000", "+33612000001"}},
+		{"external ids from the index", &GpsiConfig{ExternalIdDomain: "iot.example.com"}, []string{"ue1@iot.example.com", "ue2@iot.example.com"}},
+		{
+			"explicit external ids",
+			&GpsiConfig{IdentitySource: IdentitySource{File: writeIdentityFile(t, "meter@grid.example.com", "sensor")}, ExternalIdDomain: "iot.example.com"},
+			[]string{"meter@grid.example.com", "sensor@iot.example.com"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gen, err := newIdentityGenerator(&IdentityConfig{Gpsi: test.cfg}, testPlmn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for index, want := range test.want {
+				if got, err := gen.gpsi(index + 1); err != nil || got != want {
+					t.Fatalf("ue %d: got gpsi %s (%v), want %s", index+1, got, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestIdentityGeneratorPeis(t *testing.T) {
+	gen, err := newIdentityGenerator(&IdentityConfig{Pei: &PeiConfig{Tacs: map[string][]string{ran.DeviceClassIoT: {"86723704"}}}}, testPlmn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		index       int
+		deviceClass string
+		want        string
+	}{
+		{1, ran.DeviceClassIoT, "867237040000002"},
+		{2, ran.DeviceClassIoT, "867237040000010"},
+		// the default TACs are kept for the other device classes
+		{1, ran.DeviceClassSmartphone, "353328110000005"},
+	}
+	for _, test := range tests {
+		pei := gen.pei(test.index, test.deviceClass)
+		if pei != test.want {
+			t.Fatalf("ue %d of class %s: got pei %s, want %s", test.index, test.deviceClass, pei, test.want)
+		}
+	}
+}
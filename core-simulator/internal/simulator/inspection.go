@@ -0,0 +1,246 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package simulator
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/core"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* Read only view of the simulation state */
+
+const (
+	defaultPageLimit = 100
+	maxPageLimit     = 1000
+)
+
+// PagedResponse wraps a page of a sorted collection
+type PagedResponse struct {
+	Total  int         `json:"total"`
+	Offset int         `json:"offset"`
+	Limit  int         `json:"limit"`
+	Items  interface{} `json:"items"`
+}
+
+// UeFilter selects UEs on their status, empty fields match any UE
+type UeFilter struct {
+	RmState models.RmState
+	CmState models.CmState
+	CellId  string
+	Dnn     string
+	Pinned  *bool
+}
+
+type AppSessionInfo struct {
+	AppSessionId string                   `json:"appSessionId"`
+	AppSession   models.AppSessionContext `json:"appSessionContext"`
+}
+
+type NwdafSubscriptionInfo struct {
+	SubscriptionId string                          `json:"subscriptionId"`
+	Subscription   models.NnwdafEventsSubscription `json:"subscription"`
+}
+
+type ChargingSessionInfo struct {
+	ChargingDataRef string `json:"chargingDataRef"`
+	core.ChargingSession
+}
+
+// TopUpRequest credits a charging account with a volume in bytes
+type TopUpRequest struct {
+	Volume int64 `json:"volume"`
+}
+
+type IpamResponse struct {
+	Subnet    string `json:"subnet"`
+	Size      int    `json:"size"`
+	Allocated int    `json:"allocated"`
+	Available int    `json:"available"`
+	PagedResponse
+}
+
+func (f *UeFilter) match(ueCtx *ran.UeContext) bool {
+	if f.RmState != "" && ueCtx.RmState != f.RmState {
+		return false
+	}
+	if f.CmState != "" && ueCtx.CmState != f.CmState {
+		return false
+	}
+	if f.CellId != "" && ueCtx.CurrentCellId != f.CellId {
+		return false
+	}
+	if f.Pinned != nil && ueCtx.Pinned != *f.Pinned {
+		return false
+	}
+	if f.Dnn != "" {
+		for _, pduSess := range ueCtx.PduSessions {
+			if pduSess.Dnn == f.Dnn {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// ListUes returns the context of the UEs matching the filter, sorted by IMSI
+func (n *NetworkInstance) ListUes(filter *UeFilter) []ran.UeContext {
+	n.ueListMutex.RLock()
+	ues := make([]*ran.Ue, 0, len(n.UeList))
+	for _, ue := range n.UeList {
+		ues = append(ues, ue)
+	}
+	n.ueListMutex.RUnlock()
+
+	contexts := make([]ran.UeContext, 0, len(ues))
+	for _, ue := range ues {
+		ueCtx := ue.GetContext()
+		if filter.match(&ueCtx) {
+			contexts = append(contexts, ueCtx)
+		}
+	}
+
+	sort.Slice(contexts, func(i, j int) bool {
+		return contexts[i].Imsi < contexts[j].Imsi
+	})
+	return contexts
+}
+
+// FindUe looks up a UE by IMSI or GPSI. The identifier may carry the "imsi-",
+// "msisdn-" or "extid-" prefix, and the MSISDN the leading "+".
+func (n *NetworkInstance) FindUe(ueId string) (*ran.Ue, bool) {
+	ueId = strings.TrimPrefix(ueId, "imsi-")
+	if ue, ok := n.GetUe(ueId); ok {
+		return ue, true
+	}
+
+	gpsi := strings.TrimPrefix(strings.TrimPrefix(ueId, "msisdn-"), "extid-")
+	msisdn := strings.TrimPrefix(gpsi, "+")
+
+	n.ueListMutex.RLock()
+	defer n.ueListMutex.RUnlock()
+	for _, ue := range n.UeList {
+		if strings.TrimPrefix(ue.Msidn, "+") == msisdn {
+			return ue, true
+		}
+	}
+	return nil, false
+}
+
+// IpamAllocations returns the addresses currently assigned to PDU Sessions
+func (n *NetworkInstance) IpamAllocations(supi string) []utils.IPAllocation {
+	allocations := n.ipam.Allocations()
+	if supi == "" {
+		return allocations
+	}
+
+	filtered := []utils.IPAllocation{}
+	for _, allocation := range allocations {
+		if allocation.Supi == supi {
+			filtered = append(filtered, allocation)
+		}
+	}
+	return filtered
+}
+
+func parseUeFilter(r *http.Request) (*UeFilter, error) {
+	query := r.URL.Query()
+	filter := &UeFilter{
+		RmState: models.RmState(strings.ToUpper(query.Get("rmState"))),
+		CmState: models.CmState(strings.ToUpper(query.Get("cmState"))),
+		CellId:  query.Get("cellId"),
+		Dnn:     query.Get("dnn"),
+	}
+
+	switch filter.RmState {
+	case "", models.RmStateRegistered, models.RmStateDeregistered:
+	default:
+		return nil, fmt.Errorf("invalid rmState %q", filter.RmState)
+	}
+	switch filter.CmState {
+	case "", models.CmStateConnected, models.CmStateIdle:
+	default:
+		return nil, fmt.Errorf("invalid cmState %q", filter.CmState)
+	}
+
+	if pinned := query.Get("pinned"); pinned != "" {
+		value, err := strconv.ParseBool(pinned)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pinned value %q", pinned)
+		}
+		filter.Pinned = &value
+	}
+
+	return filter, nil
+}
+
+// parsePagination reads the offset and limit query parameters
+func parsePagination(r *http.Request) (int, int, error) {
+	query := r.URL.Query()
+	offset, limit := 0, defaultPageLimit
+
+	if value := query.Get("offset"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			return 0, 0, fmt.Errorf("invalid offset %q", value)
+		}
+		offset = parsed
+	}
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxPageLimit {
+			return 0, 0, fmt.Errorf("invalid limit %q, must be in [1, %d]", value, maxPageLimit)
+		}
+		limit = parsed
+	}
+
+	return offset, limit, nil
+}
+
+func paginate[T any](items []T, offset int, limit int) PagedResponse {
+	page := []T{}
+	if offset < len(items) {
+		end := min(offset+limit, len(items))
+		page = items[offset:end]
+	}
+	return PagedResponse{
+		Total:  len(items),
+		Offset: offset,
+		Limit:  limit,
+		Items:  page,
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
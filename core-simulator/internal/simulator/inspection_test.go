@@ -0,0 +1,163 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package simulator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+func TestParsePagination(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		offset int
+		limit  int
+		valid  bool
+	}{
+		{"defaults", "", 0, defaultPageLimit, true},
+		{"offset and limit", "?offset=20&limit=10", 20, 10, true},
+		{"offset past the end", "?offset=5000", 5000, defaultPageLimit, true},
+		{"limit upper bound", "?limit=1000", 0, maxPageLimit, true},
+		{"limit above the upper bound", "?limit=1001", 0, 0, false},
+		{"zero limit", "?limit=0", 0, 0, false},
+		{"negative limit", "?limit=-1", 0, 0, false},
+		{"non numeric limit", "?limit=ten", 0, 0, false},
+		{"negative offset", "?offset=-1", 0, 0, false},
+		{"non numeric offset", "?offset=first", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset, limit, err := parsePagination(httptest.NewRequest(http.MethodGet, "/ues"+tt.query, nil))
+			if (err == nil) != tt.valid {
+				t.Fatalf("got error %v, want valid %v", err, tt.valid)
+			}
+			if offset != tt.offset || limit != tt.limit {
+				t.Fatalf("got offset %d and limit %d, want %d and %d", offset, limit, tt.offset, tt.limit)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{0, 1, 2, 3, 4}
+	tests := []struct {
+		name   string
+		offset int
+		limit  int
+		want   []int
+	}{
+		{"first page", 0, 2, []int{0, 1}},
+		{"last page", 4, 2, []int{4}},
+		{"whole collection", 0, maxPageLimit, items},
+		{"offset at the end", 5, 2, []int{}},
+		{"offset past the end", 50, 2, []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := paginate(items, tt.offset, tt.limit)
+			if page.Total != len(items) || page.Offset != tt.offset || page.Limit != tt.limit {
+				t.Fatalf("got page %+v of %d items at offset %d", page, len(items), tt.offset)
+			}
+			if got := page.Items.([]int); got == nil || !slices.Equal(got, tt.want) {
+				t.Fatalf("got items %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUeFilter(t *testing.T) {
+	pinned := true
+	tests := []struct {
+		name  string
+		query string
+		want  *UeFilter
+	}{
+		{"no filter", "", &UeFilter{}},
+		{"rm state", "?rmState=registered", &UeFilter{RmState: models.RmStateRegistered}},
+		{"cm state", "?cmState=IDLE", &UeFilter{CmState: models.CmStateIdle}},
+		{"cell and dnn", "?cellId=000000010&dnn=internet", &UeFilter{CellId: "000000010", Dnn: "internet"}},
+		{"pinned", "?pinned=true", &UeFilter{Pinned: &pinned}},
+		{"invalid rm state", "?rmState=ATTACHED", nil},
+		{"invalid cm state", "?cmState=ACTIVE", nil},
+		{"invalid pinned", "?pinned=maybe", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseUeFilter(httptest.NewRequest(http.MethodGet, "/ues"+tt.query, nil))
+			if tt.want == nil {
+				if err == nil {
+					t.Fatalf("got filter %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.RmState != tt.want.RmState || got.CmState != tt.want.CmState || got.CellId != tt.want.CellId ||
+				got.Dnn != tt.want.Dnn || (got.Pinned == nil) != (tt.want.Pinned == nil) || got.Pinned != nil && *got.Pinned != *tt.want.Pinned {
+				t.Fatalf("got filter %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUeFilterMatch(t *testing.T) {
+	pinned, unpinned := true, false
+	ueCtx := ran.UeContext{
+		Imsi:          "001060000000001",
+		RmState:       models.RmStateRegistered,
+		CmState:       models.CmStateConnected,
+		CurrentCellId: "000000010",
+		Pinned:        true,
+		PduSessions:   []ran.PduSessionContext{{Id: 1, Dnn: "internet"}, {Id: 2, Dnn: "ims"}},
+	}
+	tests := []struct {
+		name   string
+		filter UeFilter
+		match  bool
+	}{
+		{"no filter", UeFilter{}, true},
+		{"rm state", UeFilter{RmState: models.RmStateRegistered}, true},
+		{"other rm state", UeFilter{RmState: models.RmStateDeregistered}, false},
+		{"cm state", UeFilter{CmState: models.CmStateConnected}, true},
+		{"other cm state", UeFilter{CmState: models.CmStateIdle}, false},
+		{"cell", UeFilter{CellId: "000000010"}, true},
+		{"other cell", UeFilter{CellId: "000000020"}, false},
+		{"dnn of a pdu session", UeFilter{Dnn: "ims"}, true},
+		{"dnn without pdu session", UeFilter{Dnn: "iot"}, false},
+		{"pinned", UeFilter{Pinned: &pinned}, true},
+		{"unpinned", UeFilter{Pinned: &unpinned}, false},
+		{"all the filters", UeFilter{RmState: models.RmStateRegistered, CmState: models.CmStateConnected, CellId: "000000010", Dnn: "internet", Pinned: &pinned}, true},
+		{"one filter failing", UeFilter{RmState: models.RmStateRegistered, CellId: "000000020"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.match(&ueCtx); got != tt.match {
+				t.Fatalf("got match %v, want %v", got, tt.match)
+			}
+		})
+	}
+}
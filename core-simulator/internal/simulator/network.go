@@ -23,23 +23,31 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"math"
-	"math/rand"
+	"net"
 	"net/http"
 	"strconv"
 	"sync"
 	"time"
 
+	"github.com/giuliocarot0/gitc"
 	"github.com/google/uuid"
-	"github.com/gorilla/mux"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/arrival"
 	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/core"
 	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
 	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/trafficgen"
 )
 
+// inactivity timer applied to UEs attached outside of the markov process
+const ueInactivityTimer = 10 * time.Second
+
+// interval between two checks of the events left to process by a step of the simulation
+const stepPollInterval = 5 * time.Millisecond
+
 /* Network Instance Code*/
 
 type NetworkInstance struct {
@@ -50,17 +58,84 @@ type NetworkInstance struct {
 	Amf          *core.Amf
 	Smf          *core.Smf
 	Pcf          *core.Pcf
+	Udm          *core.Udm
+	Bsf          *core.Bsf
+	Chf          *core.Chf
+	Nwdaf        *core.Nwdaf
+	Nef          *core.Nef
+	notifier     *core.Notifier
 	config       *NetworkConfig
 	ueGenContext context.Context
 	ueGenCancel  context.CancelFunc
 	ipam         *utils.IPAllocator
 	sbiPort      uint16
 	simId        string
-	GnbList      []string
+	Gnbs         *ran.GnbPool
+	// index of the next UE added at runtime
+	nextUeIndex int
+	pause       *utils.PauseGate
+	// the tasks of the network functions and of the UEs are private to the instance
+	dispatcher *gitc.Dispatcher
+	identities *identityGenerator
+	traffic    *trafficgen.Catalog
+	devices    ran.DeviceClasses
+	// traffic sessions of all the UEs
+	scheduler     *trafficgen.Scheduler
+	stopScheduler context.CancelFunc
+	// markov process and timers of all the UEs
+	engine     *ran.Engine
+	stopEngine context.CancelFunc
+	engineDone chan struct{}
+	// UEs powered off by the departure process, waiting for a new arrival
+	departed  []string
+	sbiServer *http.Server
+	// scripted scenario
+	scenario      *ScenarioRunner
+	scenarioMutex sync.RWMutex
+	running       bool
+	// time source of the network functions, the UEs and the scenario
+	clock *utils.Clock
+	// source of the random draws of the arrivals, the UEs and their traffic
+	random *utils.Random
+	// listener of the 3GPP SBI server, bound to sbiPort when nil
+	sbiListener net.Listener
+	// observers of the events generated by the core network
+	observers []EventObserver
+	// set once the instance is shut down
+	shutDown      bool
+	shutdownMutex sync.Mutex
+}
+
+// EventObserver receives the events generated by the core network, identified
+// by their AMF or SMF event type, with the SUPI of the UE they relate to
+type EventObserver func(event string, supi string, timestamp time.Time)
+
+// An InstanceOption customizes a network instance built by NewNetworkInstance
+type InstanceOption func(n *NetworkInstance)
+
+// WithClock drives the simulation with the given clock instead of the wall clock
+func WithClock(clock *utils.Clock) InstanceOption {
+	return func(n *NetworkInstance) {
+		n.clock = clock
+	}
 }
 
-func NewNetworkInstance(sbiPort uint16, config *NetworkConfig) *NetworkInstance {
-	return &NetworkInstance{
+// WithSbiListener serves the 3GPP SBI on the given listener instead of binding the SBI port
+func WithSbiListener(listener net.Listener) InstanceOption {
+	return func(n *NetworkInstance) {
+		n.sbiListener = listener
+	}
+}
+
+// WithEventObserver registers an observer of the events generated by the core network
+func WithEventObserver(observer EventObserver) InstanceOption {
+	return func(n *NetworkInstance) {
+		n.observers = append(n.observers, observer)
+	}
+}
+
+func NewNetworkInstance(sbiPort uint16, config *NetworkConfig, opts ...InstanceOption) *NetworkInstance {
+	n := &NetworkInstance{
 		ctx:          context.Background(),
 		UeList:       make(map[string]*ran.Ue),
 		ueListMutex:  sync.RWMutex{},
@@ -72,7 +147,15 @@ func NewNetworkInstance(sbiPort uint16, config *NetworkConfig) *NetworkInstance
 		ipam:         nil,
 		sbiPort:      sbiPort,
 		simId:        uuid.NewString(),
+		clock:        utils.NewClock(),
+		dispatcher:   gitc.NewDispatcher(),
+	}
+	for _, opt := range opts {
+		opt(n)
 	}
+	// the time spent in pause is measured on the clock of the simulation
+	n.pause = utils.NewPauseGate(n.clock)
+	return n
 }
 
 func (n *NetworkInstance) InitNetworkInstance() error {
@@ -80,86 +163,222 @@ func (n *NetworkInstance) InitNetworkInstance() error {
 	// this should be configurable and per slice as well
 	n.ipam = utils.NewIpamService("12.1.0.0", "16")
 
-	n.Amf = core.NewAmf(n.config.Plmn)
-	n.Smf = core.NewSmf(n.config.Plmn, n.ipam)
-	n.Pcf = core.NewPcf(n.config.Plmn, n.ipam)
+	// a seeded profile draws the same UE behaviours and traffic at every run
+	seed := time.Now().UnixNano()
+	if n.config.Seed != nil {
+		seed = *n.config.Seed
+	}
+	n.random = utils.NewRandom(seed)
+
+	if n.config.Notifications != nil {
+		if err := n.config.Notifications.Validate(); err != nil {
+			return fmt.Errorf("invalid notification settings: %w", err)
+		}
+	}
+	if n.config.Udm != nil {
+		if err := n.config.Udm.Validate(); err != nil {
+			return fmt.Errorf("invalid UDM settings: %w", err)
+		}
+	}
+	if n.config.Chf != nil {
+		if err := n.config.Chf.Validate(); err != nil {
+			return fmt.Errorf("invalid CHF settings: %w", err)
+		}
+	}
+	if n.config.Nwdaf != nil {
+		if err := n.config.Nwdaf.Validate(); err != nil {
+			return fmt.Errorf("invalid NWDAF settings: %w", err)
+		}
+	}
+	if n.config.Nef != nil {
+		if err := n.config.Nef.Validate(); err != nil {
+			return fmt.Errorf("invalid NEF settings: %w", err)
+		}
+	}
+	if err := n.config.EdgeSites.Validate(); err != nil {
+		return fmt.Errorf("invalid edge sites: %w", err)
+	}
+	n.notifier = core.NewNotifier(n.config.Notifications)
+
+	n.Amf = core.NewAmf(n.config.Plmn, n.pause, n.notifier)
+	n.Amf.MobileReachableTimer = n.config.mobileReachableTimer()
+	n.Smf = core.NewSmf(n.config.Plmn, n.ipam, n.notifier)
+	n.Pcf = core.NewPcf(n.config.Plmn, n.ipam, n.notifier)
+	n.Amf.Clock, n.Smf.Clock, n.Pcf.Clock = n.clock, n.clock, n.clock
+	n.Amf.Dispatcher, n.Smf.Dispatcher, n.Pcf.Dispatcher = n.dispatcher, n.dispatcher, n.dispatcher
+	// the SMF registers the PCF serving the PDU sessions in the BSF
+	n.Bsf = core.NewBsf(n.config.Plmn)
+	n.Smf.Bsf, n.Smf.PcfInstanceId = n.Bsf, n.Pcf.InstanceId
+	// the PCF moves the PDU sessions to the edge sites of the SMF
+	n.Smf.EdgeSites, n.Pcf.Smf = n.config.EdgeSites, n.Smf
+	// the SMF charges the PDU sessions, the PCF the sponsored data connectivity
+	if n.config.Chf != nil {
+		n.Chf = core.NewChf(n.config.Plmn, n.config.Chf, n.notifier)
+		n.Chf.Clock = n.clock
+		n.Smf.Chf, n.Pcf.Chf = n.Chf, n.Chf
+	}
+	n.Udm = core.NewUdm(n.config.Plmn, n.config.Snssai, n.config.Dnn, n.config.Udm)
+	n.Udm.AmfInstanceId, n.Udm.SmfInstanceId = n.Amf.InstanceId, n.Smf.InstanceId
+	if n.config.Nef != nil {
+		n.Nef = core.NewNef(n.config.Plmn, n.config.Nef, n.Udm, n.ipam, n.notifier)
+		n.Nef.Clock = n.clock
+	}
 
 	n.Amf.InitAmf()
 	n.Smf.InitSmf()
 	n.Pcf.InitPcf()
 
+	// feed the scenario engine with the events generated by the core network
+	n.Amf.RegisterObserver(func(report models.AmfEventReport) {
+		n.observeEvent(string(report.Type), report.GetSupi(), report.TimeStamp)
+	})
+	n.Smf.RegisterObserver(func(event models.EventNotification) {
+		n.observeEvent(string(event.Event), event.GetSupi(), event.TimeStamp)
+	})
+	// the UDM learns the serving AMF and SMF of the UEs from their events
+	n.Amf.RegisterObserver(n.Udm.ObserveAmfEvent)
+	n.Smf.RegisterObserver(n.Udm.ObserveSmfEvent)
+	// the PCF applies the spatial validity of the AF routing requirements to the UE locations
+	n.Amf.RegisterObserver(n.Pcf.ObserveAmfEvent)
+	// the NWDAF learns the history of the UEs and the load of the network from their events
+	if n.config.Nwdaf != nil {
+		n.Nwdaf = core.NewNwdaf(n.config.Plmn, n.config.Nwdaf, n.notifier)
+		n.Nwdaf.Clock = n.clock
+		n.Nwdaf.AmfInstanceId, n.Nwdaf.SmfInstanceId = n.Amf.InstanceId, n.Smf.InstanceId
+		n.Amf.RegisterObserver(n.Nwdaf.ObserveAmfEvent)
+		n.Smf.RegisterObserver(n.Nwdaf.ObserveSmfEvent)
+		n.Nwdaf.InitNwdaf()
+	}
+
+	if _, err := arrival.NewProcess(n.config.arrivalConfig(), nil); err != nil {
+		n.stopCoreNetwork()
+		return fmt.Errorf("invalid arrival model: %w", err)
+	}
+	if n.config.Departure != nil {
+		if err := n.config.Departure.Validate(); err != nil {
+			n.stopCoreNetwork()
+			return fmt.Errorf("invalid departure process: %w", err)
+		}
+	}
+
+	identities, err := newIdentityGenerator(n.config.Identities, n.config.Plmn)
+	if err != nil {
+		n.stopCoreNetwork()
+		return err
+	}
+	n.identities = identities
+
+	traffic, err := trafficgen.NewCatalog(n.config.TrafficProfiles)
+	if err != nil {
+		n.stopCoreNetwork()
+		return fmt.Errorf("invalid traffic profiles: %w", err)
+	}
+	devices, err := ran.NewDeviceClasses(n.config.DeviceClasses, traffic)
+	if err != nil {
+		n.stopCoreNetwork()
+		return fmt.Errorf("invalid device classes: %w", err)
+	}
+	n.traffic, n.devices = traffic, devices
+
 	//spawn the gNBs
-	n.GnbList = generateNRCellIDsHex(uint64(n.config.NumOfGnb))
+	n.Gnbs = ran.NewGnbPool(n.config.NumOfGnb)
+	n.nextUeIndex = n.config.NumOfUe + 1
+
+	if n.config.ScenarioFile != "" {
+		scenario, err := LoadScenarioFile(n.config.ScenarioFile)
+		if err != nil {
+			n.stopCoreNetwork()
+			return err
+		}
+		if err := n.LoadScenario(scenario); err != nil {
+			n.stopCoreNetwork()
+			return err
+		}
+	}
 
 	/* enable corenetwork network service based interface */
-	r := mux.NewRouter()
+	// amf events, websocket notification channels, smf events, pcf policy authorization, udm and bsf apis
+	services := []core.SbiService{n.Amf, n.notifier, n.Smf, n.Pcf, n.Udm, n.Bsf}
+	if n.Chf != nil {
+		services = append(services, n.Chf)
+	}
+	if n.Nwdaf != nil {
+		services = append(services, n.Nwdaf)
+	}
+	if n.Nef != nil {
+		services = append(services, n.Nef)
+	}
+	r := core.NewSbiRouter(services...)
 
-	// register amf events api
-	n.Amf.RegisterNorthboundAPIs(r)
-	// register smf events api
-	n.Smf.RegisterNorthboundAPIs(r)
-	// register pcf policy authorization api
-	n.Pcf.RegisterNorthboundAPIs(r)
+	h2server := &http2.Server{}
+	h2chandler := h2c.NewHandler(r, h2server)
 
-	go func() {
-		h2server := &http2.Server{}
-		h2chandler := h2c.NewHandler(r, h2server)
+	n.sbiServer = &http.Server{
+		Addr:    fmt.Sprintf(":%d", n.sbiPort),
+		Handler: h2chandler,
+	}
 
-		server := &http.Server{
-			Addr:    fmt.Sprintf(":%d", n.sbiPort),
-			Handler: h2chandler,
+	// bind the port here, so that a busy port is reported to the caller
+	listener := n.sbiListener
+	if listener == nil {
+		listener, err = net.Listen("tcp", n.sbiServer.Addr)
+		if err != nil {
+			n.stopCoreNetwork()
+			return fmt.Errorf("could not start 3GPP sbi server: %w", err)
 		}
+	}
+	// the UDM and the NEF consume the services of the core network on the same SBI,
+	// the notification channels are addressed under its API root
+	n.Udm.ApiRoot = core.SbiApiRoot(listener.Addr())
+	n.notifier.ApiRoot = n.Udm.ApiRoot
+	if n.Nef != nil {
+		n.Nef.ApiRoot = core.SbiApiRoot(listener.Addr())
+	}
 
-		err := server.ListenAndServe()
+	go func() {
+		err := n.sbiServer.Serve(listener)
 		if err != nil && err != http.ErrServerClosed {
-			log.Fatalf("could not start 3GPP sbi server: %s", err.Error())
+			log.Printf("3GPP sbi server error: %s", err.Error())
 		}
 	}()
 
+	var schedulerCtx context.Context
+	schedulerCtx, n.stopScheduler = context.WithCancel(n.ctx)
+	n.scheduler = trafficgen.NewScheduler(n.pause, n.clock)
+	go n.scheduler.Run(schedulerCtx)
+
+	var engineCtx context.Context
+	engineCtx, n.stopEngine = context.WithCancel(n.ctx)
+	n.engine = ran.NewEngine(n.config.UeEngineShards, n.pause, n.clock)
+	n.engine.Dispatcher = n.dispatcher
+	n.engine.Random = n.random
+	n.engineDone = make(chan struct{})
+	go func() {
+		defer close(n.engineDone)
+		n.engine.Run(engineCtx)
+	}()
+
 	return nil
 
 }
 
 func (n *NetworkInstance) Start() error {
+	process, err := arrival.NewProcess(n.config.arrivalConfig(), n.random)
+	if err != nil {
+		return fmt.Errorf("invalid arrival model: %w", err)
+	}
+
 	n.ueGenContext, n.ueGenCancel = context.WithCancel(n.ctx)
 	log.Printf("starting simulation %s", n.simId)
 
-	go func() {
-		select {
-		case <-n.ueGenContext.Done():
-			return
-		default:
-			for i := 0; i < n.config.NumOfUe; i++ {
-				// generate a new UE with a unique IMSI
-
-				arrTime := expRand(float64(n.config.ArrivalRate))
-				time.Sleep(arrTime)
-
-				imsi := fmt.Sprintf("%s%s00000%05d", n.config.Plmn.Mcc, n.config.Plmn.Mnc, i+1)
-				imei := generateIMEI()
-				// Generate unique MSISDN per UE based on index
-				msisdn := fmt.Sprintf("+336%09d", 100000000+i)
-
-				ue := ran.NewUserEquipment(n.ctx, ran.UeConfig{
-					Imsi:   imsi,
-					Msidn:  msisdn,
-					Imei:   imei,
-					Dnn:    n.config.Dnn,
-					Snssai: n.config.Snssai,
-					Type:   "Smartphone",
-					Plmn:   n.config.Plmn,
-				}, n.ipam, n.simId, n.GnbList)
-
-				// if ue is not nil then start the UE and add it to the list
-				if ue != nil {
-					n.ueListMutex.Lock()
-					ue.PowerUp()
-					n.UeList[imsi] = ue
-					n.ueListMutex.Unlock()
-				}
-			}
-		}
-	}()
+	n.scenarioMutex.Lock()
+	n.running = true
+	if n.scenario != nil {
+		go n.scenario.Run(n.ueGenContext)
+	}
+	n.scenarioMutex.Unlock()
+
+	go n.runArrivals(n.ueGenContext, process)
 	return nil
 }
 
@@ -167,6 +386,13 @@ func (n *NetworkInstance) Stop() error {
 	// stop the generation of UEs
 	n.ueGenCancel()
 
+	n.scenarioMutex.Lock()
+	n.running = false
+	n.scenarioMutex.Unlock()
+
+	// a stopped simulation restarts unpaused
+	n.pause.Resume()
+
 	n.ueListMutex.Lock()
 	defer n.ueListMutex.Unlock()
 	// this function should always overtake the start function
@@ -175,25 +401,162 @@ func (n *NetworkInstance) Stop() error {
 		ue.TurnOff(true)
 		delete(n.UeList, imsi)
 	}
+	n.departed = nil
 
 	return nil
 }
 
-func generateIMEI() string {
+// Pause freezes the UE state machines, timers and traffic, the arrival of new UEs and the scenario timeline
+func (n *NetworkInstance) Pause() error {
+	if !n.pause.Pause() {
+		return fmt.Errorf("simulation %s is already paused", n.simId)
+	}
+	log.Printf("simulation %s paused", n.simId)
+	return nil
+}
 
-	// Generate TAC (Type Allocation Code) - 8 digits
-	tac := fmt.Sprintf("%08d", rand.Intn(100000000))
+// Resume restarts a paused simulation from the state it was frozen in
+func (n *NetworkInstance) Resume() error {
+	pausedFor := n.pause.PausedFor()
+	if !n.pause.IsPaused() {
+		return fmt.Errorf("simulation %s is not paused", n.simId)
+	}
 
-	// Generate SNR (Serial Number) - 6 digits
-	snr := fmt.Sprintf("%06d", rand.Intn(1000000))
+	// the time spent in pause does not count towards the UE timers and the scenario timeline
+	n.ueListMutex.RLock()
+	for _, ue := range n.UeList {
+		ue.ShiftActivity(pausedFor)
+	}
+	n.ueListMutex.RUnlock()
 
-	// Concatenate TAC + SNR (14 digits so far)
-	imei14 := tac + snr
+	n.scenarioMutex.RLock()
+	if n.scenario != nil {
+		n.scenario.shift(pausedFor)
+	}
+	n.scenarioMutex.RUnlock()
 
-	// Compute check digit using Luhn algorithm
-	checkDigit := luhnCheckDigit(imei14)
+	n.pause.Resume()
+	log.Printf("simulation %s resumed after %s", n.simId, pausedFor)
+	return nil
+}
 
-	return imei14 + checkDigit
+// Clock returns the clock driving the simulation
+func (n *NetworkInstance) Clock() *utils.Clock {
+	return n.clock
+}
+
+// Step moves the clock of the simulation forward by d and returns once the UE timers and
+// traffic emissions due in the skipped time have been processed, or once ctx is done.
+// Arrivals, departures, scenario steps and periodic reports catch up asynchronously.
+func (n *NetworkInstance) Step(ctx context.Context, d time.Duration) error {
+	if n.pause.IsPaused() {
+		return fmt.Errorf("simulation %s is paused", n.simId)
+	}
+	n.clock.Advance(d)
+
+	ticker := time.NewTicker(stepPollInterval)
+	defer ticker.Stop()
+	for n.engine.Due() > 0 || n.scheduler.Due() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// Shutdown stops the simulation and releases the resources of the instance:
+// the scenario, the 3GPP SBI server and the gitc tasks of the network functions.
+// Shutting down an instance already shut down is a no-op, so a failed shutdown can be retried.
+func (n *NetworkInstance) Shutdown(ctx context.Context) error {
+	n.shutdownMutex.Lock()
+	defer n.shutdownMutex.Unlock()
+	if n.shutDown {
+		return nil
+	}
+
+	n.scenarioMutex.RLock()
+	running := n.running
+	n.scenarioMutex.RUnlock()
+	if running {
+		if err := n.Stop(); err != nil {
+			return err
+		}
+	}
+
+	// a missing scenario is not an error here
+	_ = n.UnloadScenario()
+
+	if n.sbiServer != nil {
+		if err := n.sbiServer.Shutdown(ctx); err != nil {
+			log.Printf("could not gracefully stop 3GPP sbi server: %s", err.Error())
+			n.sbiServer.Close()
+		}
+	}
+
+	n.stopScheduler()
+	n.stopEngine()
+	// the RAN task is released once the engine returns
+	<-n.engineDone
+	n.stopCoreNetwork()
+	n.shutDown = true
+	log.Printf("simulation %s shut down", n.simId)
+	return nil
+}
+
+func (n *NetworkInstance) stopCoreNetwork() {
+	n.Amf.StopAmf()
+	n.Smf.StopSmf()
+	n.Pcf.StopPcf()
+	if n.Nwdaf != nil {
+		n.Nwdaf.StopNwdaf()
+	}
+	n.notifier.Stop()
+}
+
+// newUe builds the UE identified by its index in the simulation
+func (n *NetworkInstance) newUe(index int, deviceClass string) (*ran.Ue, error) {
+	imsi, err := n.identities.supi(index)
+	if err != nil {
+		return nil, err
+	}
+	gpsi, err := n.identities.gpsi(index)
+	if err != nil {
+		return nil, err
+	}
+
+	n.Udm.Provision(imsi, gpsi, deviceClass)
+
+	var suci *ran.SuciConfig
+	if n.config.Identities != nil {
+		suci = n.config.Identities.Suci
+	}
+
+	return ran.NewUserEquipment(n.ctx, ran.UeConfig{
+		Imsi:         imsi,
+		Msidn:        gpsi,
+		Imei:         n.identities.pei(index, deviceClass),
+		Dnn:          n.config.Dnn,
+		Snssai:       n.config.Snssai,
+		Type:         deviceClass,
+		Plmn:         n.config.Plmn,
+		Suci:         suci,
+		Traffic:      n.traffic,
+		Applications: n.devices.Applications(deviceClass),
+		Scheduler:    n.scheduler,
+		Engine:       n.engine,
+	}, n.ipam, n.simId, n.Gnbs), nil
+}
+
+// imsiFromIndex returns the IMSI of the UE with the given index, or
+// an empty string if the index exceeds the configured SUPI ranges
+func (n *NetworkInstance) imsiFromIndex(index int) string {
+	imsi, err := n.identities.supi(index)
+	if err != nil {
+		return ""
+	}
+	return imsi
 }
 
 // luhnCheckDigit computes the Luhn check digit for a given numeric string.
@@ -214,25 +577,3 @@ func luhnCheckDigit(number string) string {
 	checkDigit := (10 - (sum % 10)) % 10
 	return strconv.Itoa(checkDigit)
 }
-
-// exponential random variable with mean 1/λ
-func expRand(lambda float64) time.Duration {
-	u := rand.Float64()
-	return time.Duration(-math.Log(1-u) / lambda * float64(time.Second))
-}
-
-func generateNRCellIDsHex(max uint64) []string {
-	// NCI is 36 bits max (values: 0 .. 2^36-1)
-	const maxNCI = (1 << 36) - 1
-
-	if max > maxNCI {
-		max = maxNCI
-	}
-
-	ncis := make([]string, 0, max)
-	for i := uint64(0); i < max; i++ {
-		// %09x ensures 9 hex digits, lower-case, zero-padded
-		ncis = append(ncis, fmt.Sprintf("%09x", i))
-	}
-	return ncis
-}
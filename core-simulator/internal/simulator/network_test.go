@@ -0,0 +1,150 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package simulator
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/trafficgen"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// testStart is the start time of the clock of the test networks
+var testStart = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// testNetworkConfig is the profile of the test networks, a single gNB and no arrivals
+func testNetworkConfig() *NetworkConfig {
+	return &NetworkConfig{
+		Plmn:        models.PlmnId{Mcc: "001", Mnc: "06"},
+		Dnn:         "internet",
+		Snssai:      models.Snssai{Sst: 1, Sd: models.PtrString("FFFFFF")},
+		NumOfGnb:    1,
+		ArrivalRate: 1,
+	}
+}
+
+// newTestNetwork builds a network of the test profile, shut down when the test ends
+func newTestNetwork(t *testing.T) *NetworkInstance {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := NewNetworkInstance(0, testNetworkConfig(), WithClock(utils.NewClockAt(testStart)), WithSbiListener(listener))
+	if err := n.InitNetworkInstance(); err != nil {
+		listener.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := n.Shutdown(context.Background()); err != nil {
+			t.Errorf("could not shut the network down: %v", err)
+		}
+		listener.Close()
+	})
+	return n
+}
+
+func TestShutdownIsIdempotent(t *testing.T) {
+	n := newTestNetwork(t)
+	if err := n.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the tasks of the network functions are not stopped again
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(io.Discard)
+	if err := n.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if logs.Len() > 0 {
+		t.Fatalf("got logs %q from a second shutdown, want none", logs.String())
+	}
+}
+
+func TestInitNetworkInstanceValidatesTheProfile(t *testing.T) {
+	sensor := &trafficgen.Profile{Generator: "IOT", PacketSize: trafficgen.Fixed(50), Interval: trafficgen.Fixed(300)}
+	tests := []struct {
+		name      string
+		configure func(cfg *NetworkConfig)
+		err       string
+	}{
+		{"default profile", func(cfg *NetworkConfig) {}, ""},
+		{"custom traffic profile and device class", func(cfg *NetworkConfig) {
+			cfg.TrafficProfiles = map[string]*trafficgen.Profile{"sensor": sensor}
+			cfg.DeviceClasses = ran.DeviceClasses{"Meter": {{Profile: "sensor", Direction: ran.Uplink, Probability: models.PtrFloat64(0.5)}}}
+		}, ""},
+		{"unknown generator", func(cfg *NetworkConfig) {
+			cfg.TrafficProfiles = map[string]*trafficgen.Profile{"sensor": {Generator: "TELNET"}}
+		}, "invalid traffic profiles"},
+		{"invalid distribution", func(cfg *NetworkConfig) {
+			cfg.TrafficProfiles = map[string]*trafficgen.Profile{"sensor": {Generator: "IOT", PacketSize: trafficgen.Fixed(50),
+				Interval: &trafficgen.Distribution{Type: trafficgen.Exponential}}}
+		}, "invalid traffic profiles"},
+		{"unknown application profile", func(cfg *NetworkConfig) {
+			cfg.DeviceClasses = ran.DeviceClasses{"Meter": {{Profile: "sensor", Direction: ran.Uplink}}}
+		}, "invalid device classes"},
+		{"invalid application direction", func(cfg *NetworkConfig) {
+			cfg.DeviceClasses = ran.DeviceClasses{"Meter": {{Profile: "iot", Direction: "UPLINK"}}}
+		}, "invalid device classes"},
+		{"invalid application probability", func(cfg *NetworkConfig) {
+			cfg.DeviceClasses = ran.DeviceClasses{"Meter": {{Profile: "iot", Direction: ran.Uplink, Probability: models.PtrFloat64(1.5)}}}
+		}, "invalid device classes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer listener.Close()
+			cfg := testNetworkConfig()
+			tt.configure(cfg)
+			n := NewNetworkInstance(0, cfg, WithClock(utils.NewClockAt(testStart)), WithSbiListener(listener))
+			err = n.InitNetworkInstance()
+			if err == nil {
+				if err := n.Shutdown(context.Background()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
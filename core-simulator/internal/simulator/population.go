@@ -0,0 +1,261 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package simulator
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+/* Runtime changes of the simulated population */
+
+var (
+	ErrGnbNotFound          = errors.New("gnb not found")
+	ErrInvalidPopulationReq = errors.New("invalid population request")
+)
+
+type DecommissionMode string
+
+const (
+	// camped UEs are handed over to one of the remaining cells
+	DecommissionHandover DecommissionMode = "HANDOVER"
+	// camped UEs lose the connectivity
+	DecommissionLoss DecommissionMode = "LOSS_OF_CONNECTIVITY"
+)
+
+type AddUesRequest struct {
+	Count       int    `json:"count"`
+	DeviceClass string `json:"deviceClass,omitempty"`
+}
+
+// RemoveUesRequest selects the UEs to power off, either by IMSI or
+// by picking count UEs (optionally of a given device class)
+type RemoveUesRequest struct {
+	Imsis       []string `json:"imsis,omitempty"`
+	Count       int      `json:"count,omitempty"`
+	DeviceClass string   `json:"deviceClass,omitempty"`
+	// removed UEs are deleted from the simulation instead of being only powered off
+	Delete bool `json:"delete,omitempty"`
+}
+
+type AddGnbsRequest struct {
+	Count int `json:"count"`
+}
+
+type GnbInfo struct {
+	CellId  string `json:"cellId"`
+	NumOfUe int    `json:"numOfUe"`
+}
+
+type DecommissionReport struct {
+	CellId       string   `json:"cellId"`
+	HandedOver   []string `json:"handedOver"`
+	Disconnected []string `json:"disconnected"`
+}
+
+// AddUes spawns and powers up count new UEs of the given device class.
+// It returns the IMSIs of the new UEs.
+func (n *NetworkInstance) AddUes(count int, deviceClass string) ([]string, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("%w: count must be positive", ErrInvalidPopulationReq)
+	}
+	if deviceClass == "" {
+		deviceClass = ran.DeviceClassSmartphone
+	}
+	if !n.devices.IsDeviceClassSupported(deviceClass) {
+		return nil, fmt.Errorf("%w: unknown device class %q", ErrInvalidPopulationReq, deviceClass)
+	}
+
+	n.ueListMutex.Lock()
+	defer n.ueListMutex.Unlock()
+
+	added := make([]string, 0, count)
+	for len(added) < count {
+		index := n.nextUeIndex
+		n.nextUeIndex++
+		// skip the identities already taken by scenarios
+		if _, exists := n.UeList[n.imsiFromIndex(index)]; exists {
+			continue
+		}
+		ue, err := n.newUe(index, deviceClass)
+		if err != nil {
+			// the configured identities are exhausted
+			return added, fmt.Errorf("%w: %s", ErrInvalidPopulationReq, err.Error())
+		}
+		ue.PowerUp()
+		n.UeList[ue.Imsi] = ue
+		added = append(added, ue.Imsi)
+	}
+
+	log.Printf("added %d %s UEs to simulation %s", len(added), deviceClass, n.simId)
+	return added, nil
+}
+
+// RemoveUes powers off the selected UEs, which gracefully deregister from the network.
+// The UEs are switched off by the engine of the simulation.
+// It returns the IMSIs of the UEs switched off.
+func (n *NetworkInstance) RemoveUes(req *RemoveUesRequest) ([]string, error) {
+	if len(req.Imsis) == 0 && req.Count <= 0 {
+		return nil, fmt.Errorf("%w: either imsis or a positive count is required", ErrInvalidPopulationReq)
+	}
+
+	n.ueListMutex.Lock()
+	defer n.ueListMutex.Unlock()
+
+	targets := []*ran.Ue{}
+	if len(req.Imsis) > 0 {
+		for _, imsi := range req.Imsis {
+			ue, exists := n.UeList[imsi]
+			if !exists {
+				return nil, fmt.Errorf("%w: %s", ErrUeNotFound, imsi)
+			}
+			targets = append(targets, ue)
+		}
+	} else {
+		for _, imsi := range sortedKeys(n.UeList) {
+			ue := n.UeList[imsi]
+			if len(targets) == req.Count {
+				break
+			}
+			if req.DeviceClass != "" && ue.Profile != req.DeviceClass {
+				continue
+			}
+			// UEs driven by a scenario are left untouched
+			if ue.IsPinned() || (!ue.IsPoweredOn() && !req.Delete) {
+				continue
+			}
+			targets = append(targets, ue)
+		}
+	}
+
+	removed := make([]string, 0, len(targets))
+	for _, ue := range targets {
+		ue.Dispatch(func() { ue.TurnOff(true) })
+		if req.Delete {
+			delete(n.UeList, ue.Imsi)
+		}
+		removed = append(removed, ue.Imsi)
+	}
+
+	log.Printf("removed %d UEs from simulation %s", len(removed), n.simId)
+	return removed, nil
+}
+
+// AddGnbs spawns count new cells and returns their NR cell ids
+func (n *NetworkInstance) AddGnbs(count int) ([]string, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("%w: count must be positive", ErrInvalidPopulationReq)
+	}
+	added := n.Gnbs.Add(count)
+	if len(added) < count {
+		return added, fmt.Errorf("%w: NR cell id space exhausted", ErrInvalidPopulationReq)
+	}
+	log.Printf("added %d gNBs to simulation %s", count, n.simId)
+	return added, nil
+}
+
+// ListGnbs returns the active cells with the number of UEs camping on them
+func (n *NetworkInstance) ListGnbs() []GnbInfo {
+	camped := map[string]int{}
+	n.ueListMutex.RLock()
+	for _, ue := range n.UeList {
+		if rmState, _ := ue.GetStatus(); rmState == models.RmStateRegistered {
+			camped[ue.GetCurrentCellId()]++
+		}
+	}
+	n.ueListMutex.RUnlock()
+
+	cells := n.Gnbs.List()
+	sort.Strings(cells)
+	gnbs := make([]GnbInfo, 0, len(cells))
+	for _, cellId := range cells {
+		gnbs = append(gnbs, GnbInfo{CellId: cellId, NumOfUe: camped[cellId]})
+	}
+	return gnbs
+}
+
+// DecommissionGnb removes a cell from the simulation. The registered UEs camping on
+// the cell are handed over to the remaining cells or, when no cell is left or when
+// requested by the mode, they lose the connectivity. The procedures are run by the
+// engine of the simulation.
+func (n *NetworkInstance) DecommissionGnb(cellId string, mode DecommissionMode) (*DecommissionReport, error) {
+	switch mode {
+	case "":
+		mode = DecommissionHandover
+	case DecommissionHandover, DecommissionLoss:
+	default:
+		return nil, fmt.Errorf("%w: unknown decommission mode %q", ErrInvalidPopulationReq, mode)
+	}
+
+	if !n.Gnbs.Remove(cellId) {
+		return nil, fmt.Errorf("%w: %s", ErrGnbNotFound, cellId)
+	}
+
+	report := &DecommissionReport{
+		CellId:       cellId,
+		HandedOver:   []string{},
+		Disconnected: []string{},
+	}
+
+	camping := func(ue *ran.Ue) bool {
+		rmState, _ := ue.GetStatus()
+		return rmState == models.RmStateRegistered && ue.GetCurrentCellId() == cellId
+	}
+
+	n.ueListMutex.RLock()
+	defer n.ueListMutex.RUnlock()
+	for _, imsi := range sortedKeys(n.UeList) {
+		ue := n.UeList[imsi]
+		if !camping(ue) {
+			continue
+		}
+
+		targetCellId := ""
+		if mode == DecommissionHandover {
+			targetCellId = n.Gnbs.PickRandom(n.random, cellId)
+		}
+		// the UE may have left the cell by the time the procedure runs
+		if targetCellId != "" {
+			ue.Dispatch(func() {
+				if camping(ue) {
+					ue.DoHandover(targetCellId)
+				}
+			})
+			report.HandedOver = append(report.HandedOver, imsi)
+			continue
+		}
+
+		ue.Dispatch(func() {
+			if camping(ue) {
+				ue.LossOfConnection(false)
+				ue.SetState(models.Deregistered)
+			}
+		})
+		report.Disconnected = append(report.Disconnected, imsi)
+	}
+
+	log.Printf("decommissioned gNB %s: %d UEs handed over, %d UEs disconnected", cellId, len(report.HandedOver), len(report.Disconnected))
+	return report, nil
+}
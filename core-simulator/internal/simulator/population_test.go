@@ -0,0 +1,102 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package simulator
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+// addConnectedUe adds a pinned UE with an established PDU Session
+func addConnectedUe(t *testing.T, n *NetworkInstance) *ran.Ue {
+	t.Helper()
+	imsis, err := n.AddUes(1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ue, _ := n.GetUe(imsis[0])
+	ue.Pin(true)
+	for _, action := range []UeActionType{UeActionRegister, UeActionAttach, UeActionPduSessionEst} {
+		if err := n.ExecuteUeAction(ue.Imsi, &UeAction{Action: action}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ue
+}
+
+// waitForUe polls the context of the UE until it is accepted by done
+func waitForUe(t *testing.T, ue *ran.Ue, done func(ctx ran.UeContext) bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !done(ue.GetContext()) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for ue, got %+v", ue.GetContext())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDecommissionGnbLossOfConnectivity(t *testing.T) {
+	n := newTestNetwork(t)
+	ue := addConnectedUe(t, n)
+
+	report, err := n.DecommissionGnb(ue.GetCurrentCellId(), DecommissionLoss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Disconnected) != 1 || report.Disconnected[0] != ue.Imsi {
+		t.Fatalf("got report %+v, want ue %s disconnected", report, ue.Imsi)
+	}
+
+	waitForUe(t, ue, func(ctx ran.UeContext) bool { return ctx.RmState == models.RmStateDeregistered })
+	if sessions := ue.GetContext().PduSessions; len(sessions) != 0 {
+		t.Fatalf("got PDU Sessions %+v left after the loss of connectivity", sessions)
+	}
+	if allocations := n.ipam.Allocations(); len(allocations) != 0 {
+		t.Fatalf("got addresses %+v left after the loss of connectivity", allocations)
+	}
+}
+
+func TestRemoveUes(t *testing.T) {
+	n := newTestNetwork(t)
+	ue := addConnectedUe(t, n)
+
+	removed, err := n.RemoveUes(&RemoveUesRequest{Imsis: []string{ue.Imsi}, Delete: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0] != ue.Imsi {
+		t.Fatalf("got removed UEs %v, want %s", removed, ue.Imsi)
+	}
+	if _, ok := n.GetUe(ue.Imsi); ok {
+		t.Fatal("ue still part of the simulation")
+	}
+
+	waitForUe(t, ue, func(ctx ran.UeContext) bool { return !ctx.PoweredOn })
+	if rmState, _ := ue.GetStatus(); rmState != models.RmStateDeregistered {
+		t.Fatalf("got ue in %s, want a deregistered ue", rmState)
+	}
+	if allocations := n.ipam.Allocations(); len(allocations) != 0 {
+		t.Fatalf("got addresses %+v left after the removal", allocations)
+	}
+}
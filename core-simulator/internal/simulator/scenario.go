@@ -0,0 +1,518 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
+	"gopkg.in/yaml.v3"
+)
+
+/* Scripted scenario engine */
+
+// events are stamped by the UE when the procedure is executed, while the
+// expectation is evaluated after the network has processed them
+const scenarioGracePeriod = 250 * time.Millisecond
+
+type ScenarioStatus string
+
+const (
+	ScenarioLoaded  ScenarioStatus = "LOADED"
+	ScenarioRunning ScenarioStatus = "RUNNING"
+	ScenarioPassed  ScenarioStatus = "PASSED"
+	ScenarioFailed  ScenarioStatus = "FAILED"
+	ScenarioAborted ScenarioStatus = "ABORTED"
+	ScenarioPending ScenarioStatus = "PENDING"
+)
+
+// Scenario is a timed sequence of actions executed on pinned UEs,
+// together with the expectations on the events emitted by the network.
+// UEs are referenced by their index in the simulation (IMSI suffix).
+type Scenario struct {
+	Name         string                `yaml:"name" json:"name"`
+	Description  string                `yaml:"description,omitempty" json:"description,omitempty"`
+	Steps        []ScenarioStep        `yaml:"steps" json:"steps"`
+	Expectations []ScenarioExpectation `yaml:"expectations" json:"expectations"`
+}
+
+// ScenarioStep executes an action on a UE at a given offset from the scenario start.
+// Every UE targeted by a step is pinned to scripted behaviour.
+type ScenarioStep struct {
+	At       time.Duration `yaml:"at" json:"at"`
+	Ue       int           `yaml:"ue" json:"ue"`
+	UeAction `yaml:",inline"`
+}
+
+// ScenarioExpectation verifies that an event is emitted for a UE in the window [at, at+within].
+// When ue is 0 the event can be emitted for any UE. When absent is set,
+// the expectation is met only if no matching event is emitted in the window.
+type ScenarioExpectation struct {
+	Name   string        `yaml:"name" json:"name"`
+	Ue     int           `yaml:"ue,omitempty" json:"ue,omitempty"`
+	Event  string        `yaml:"event" json:"event"`
+	At     time.Duration `yaml:"at" json:"at"`
+	Within time.Duration `yaml:"within" json:"within"`
+	Absent bool          `yaml:"absent,omitempty" json:"absent,omitempty"`
+}
+
+type ScenarioStepResult struct {
+	At         string         `json:"at"`
+	Ue         string         `json:"ue"`
+	Action     UeActionType   `json:"action"`
+	Status     ScenarioStatus `json:"status"`
+	ExecutedAt *time.Time     `json:"executedAt,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+type ScenarioExpectationResult struct {
+	Name      string         `json:"name"`
+	Ue        string         `json:"ue,omitempty"`
+	Event     string         `json:"event"`
+	Window    string         `json:"window"`
+	Absent    bool           `json:"absent,omitempty"`
+	Status    ScenarioStatus `json:"status"`
+	MatchedAt *time.Time     `json:"matchedAt,omitempty"`
+}
+
+type ScenarioReport struct {
+	Name         string                      `json:"name"`
+	Status       ScenarioStatus              `json:"status"`
+	StartedAt    *time.Time                  `json:"startedAt,omitempty"`
+	FinishedAt   *time.Time                  `json:"finishedAt,omitempty"`
+	Steps        []ScenarioStepResult        `json:"steps"`
+	Expectations []ScenarioExpectationResult `json:"expectations"`
+}
+
+// LoadScenarioFile reads a scenario definition from a YAML file
+func LoadScenarioFile(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read scenario file: %w", err)
+	}
+	return ParseScenario(data)
+}
+
+// ParseScenario decodes and validates a YAML (or JSON) scenario definition
+func ParseScenario(data []byte) (*Scenario, error) {
+	scenario := &Scenario{}
+	if err := yaml.Unmarshal(data, scenario); err != nil {
+		return nil, fmt.Errorf("invalid scenario definition: %w", err)
+	}
+	if err := scenario.Validate(); err != nil {
+		return nil, err
+	}
+	return scenario, nil
+}
+
+func (s *Scenario) Validate() error {
+	if len(s.Steps) == 0 && len(s.Expectations) == 0 {
+		return fmt.Errorf("scenario %q has no steps nor expectations", s.Name)
+	}
+	for i, step := range s.Steps {
+		if step.Ue < 1 {
+			return fmt.Errorf("step %d: ue index must be greater than 0", i)
+		}
+		if step.At < 0 {
+			return fmt.Errorf("step %d: negative time offset", i)
+		}
+		if step.Action == "" {
+			return fmt.Errorf("step %d: missing action", i)
+		}
+	}
+	for i, exp := range s.Expectations {
+		if exp.Event == "" {
+			return fmt.Errorf("expectation %d: missing event", i)
+		}
+		if exp.Ue < 0 {
+			return fmt.Errorf("expectation %d: invalid ue index", i)
+		}
+		if exp.At < 0 || exp.Within <= 0 {
+			return fmt.Errorf("expectation %d: invalid time window", i)
+		}
+	}
+	return nil
+}
+
+// PinnedUes returns the indexes of the UEs driven by the scenario
+func (s *Scenario) PinnedUes() []int {
+	seen := make(map[int]bool)
+	ues := []int{}
+	for _, step := range s.Steps {
+		if !seen[step.Ue] {
+			seen[step.Ue] = true
+			ues = append(ues, step.Ue)
+		}
+	}
+	sort.Ints(ues)
+	return ues
+}
+
+// ScenarioRunner executes a scenario against a network instance
+type ScenarioRunner struct {
+	scenario  *Scenario
+	network   *NetworkInstance
+	mutex     sync.Mutex
+	report    ScenarioReport
+	startTime time.Time
+	// time spent in pause, not accounted in the scenario timeline
+	pausedFor time.Duration
+	cancel    context.CancelFunc
+}
+
+func NewScenarioRunner(scenario *Scenario, network *NetworkInstance) *ScenarioRunner {
+	r := &ScenarioRunner{
+		scenario: scenario,
+		network:  network,
+		report: ScenarioReport{
+			Name:         scenario.Name,
+			Status:       ScenarioLoaded,
+			Steps:        make([]ScenarioStepResult, len(scenario.Steps)),
+			Expectations: make([]ScenarioExpectationResult, len(scenario.Expectations)),
+		},
+	}
+
+	for i, step := range scenario.Steps {
+		r.report.Steps[i] = ScenarioStepResult{
+			At:     step.At.String(),
+			Ue:     network.imsiFromIndex(step.Ue),
+			Action: step.Action,
+			Status: ScenarioPending,
+		}
+	}
+	for i, exp := range scenario.Expectations {
+		r.report.Expectations[i] = ScenarioExpectationResult{
+			Name:   exp.Name,
+			Event:  exp.Event,
+			Window: fmt.Sprintf("[%s, %s]", exp.At, exp.At+exp.Within),
+			Absent: exp.Absent,
+			Status: ScenarioPending,
+		}
+		if exp.Ue > 0 {
+			r.report.Expectations[i].Ue = network.imsiFromIndex(exp.Ue)
+		}
+	}
+
+	return r
+}
+
+// Run pins the scenario UEs and executes the timeline until completion or
+// until the context is cancelled.
+func (r *ScenarioRunner) Run(ctx context.Context) {
+	runCtx, cancel := context.WithCancel(ctx)
+
+	r.mutex.Lock()
+	if r.report.Status != ScenarioLoaded {
+		r.mutex.Unlock()
+		cancel()
+		return
+	}
+	r.cancel = cancel
+	r.startTime = r.network.clock.Now()
+	r.report.StartedAt = &r.startTime
+	r.report.Status = ScenarioRunning
+	r.mutex.Unlock()
+	defer cancel()
+	// the UEs resume their stochastic behaviour once the scenario is over
+	defer r.unpinUes()
+
+	log.Printf("starting scenario %q", r.scenario.Name)
+
+	for _, index := range r.scenario.PinnedUes() {
+		if err := r.network.pinUe(index); err != nil {
+			log.Printf("scenario %q: could not pin ue %d: %s", r.scenario.Name, index, err.Error())
+		}
+	}
+
+	// build the timeline: step executions and expectation deadlines
+	type timelineEntry struct {
+		at          time.Duration
+		step        int
+		expectation int
+	}
+	timeline := []timelineEntry{}
+	for i, step := range r.scenario.Steps {
+		timeline = append(timeline, timelineEntry{at: step.At, step: i, expectation: -1})
+	}
+	for i, exp := range r.scenario.Expectations {
+		timeline = append(timeline, timelineEntry{at: exp.At + exp.Within + scenarioGracePeriod, step: -1, expectation: i})
+	}
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].at < timeline[j].at
+	})
+
+	for _, entry := range timeline {
+		if !r.waitFor(runCtx, entry.at) {
+			r.finish(ScenarioAborted)
+			return
+		}
+
+		if entry.step >= 0 {
+			r.executeStep(entry.step)
+		} else {
+			r.closeExpectation(entry.expectation)
+		}
+	}
+
+	r.finish("")
+}
+
+func (r *ScenarioRunner) executeStep(index int) {
+	step := r.scenario.Steps[index]
+	imsi := r.network.imsiFromIndex(step.Ue)
+	err := r.network.ExecuteUeAction(imsi, &step.UeAction)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	now := r.network.clock.Now()
+	result := &r.report.Steps[index]
+	result.ExecutedAt = &now
+	if err != nil {
+		log.Printf("scenario %q: step %d failed: %s", r.scenario.Name, index, err.Error())
+		result.Status = ScenarioFailed
+		result.Error = err.Error()
+	} else {
+		result.Status = ScenarioPassed
+	}
+}
+
+// waitFor blocks until the scenario clock reaches at, the time
+// spent with the simulation paused does not advance the clock
+func (r *ScenarioRunner) waitFor(ctx context.Context, at time.Duration) bool {
+	for {
+		if !r.network.clock.SleepUntil(ctx, r.deadline(at)) {
+			return false
+		}
+
+		if !r.network.pause.Wait(ctx) {
+			return false
+		}
+		if !r.network.clock.Now().Before(r.deadline(at)) {
+			return true
+		}
+	}
+}
+
+func (r *ScenarioRunner) deadline(at time.Duration) time.Time {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.startTime.Add(r.pausedFor + at)
+}
+
+// shift postpones the remaining steps and expectations by the time spent in pause
+func (r *ScenarioRunner) shift(d time.Duration) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.pausedFor += d
+}
+
+func (r *ScenarioRunner) closeExpectation(index int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	result := &r.report.Expectations[index]
+	if result.Status != ScenarioPending {
+		return
+	}
+	if r.scenario.Expectations[index].Absent {
+		result.Status = ScenarioPassed
+	} else {
+		result.Status = ScenarioFailed
+	}
+}
+
+// Observe matches an event emitted by the network against the open expectations
+func (r *ScenarioRunner) Observe(event string, supi string, timestamp time.Time) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.report.Status != ScenarioRunning {
+		return
+	}
+
+	offset := timestamp.Sub(r.startTime) - r.pausedFor
+	for i, exp := range r.scenario.Expectations {
+		result := &r.report.Expectations[i]
+		if result.Status != ScenarioPending || exp.Event != event {
+			continue
+		}
+		if result.Ue != "" && result.Ue != supi {
+			continue
+		}
+		if offset < exp.At || offset > exp.At+exp.Within {
+			continue
+		}
+
+		matchedAt := timestamp
+		result.MatchedAt = &matchedAt
+		if exp.Absent {
+			result.Status = ScenarioFailed
+		} else {
+			result.Status = ScenarioPassed
+		}
+	}
+}
+
+func (r *ScenarioRunner) finish(status ScenarioStatus) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if status == "" {
+		status = ScenarioPassed
+		for _, step := range r.report.Steps {
+			if step.Status != ScenarioPassed {
+				status = ScenarioFailed
+			}
+		}
+		for _, exp := range r.report.Expectations {
+			if exp.Status != ScenarioPassed {
+				status = ScenarioFailed
+			}
+		}
+	}
+
+	now := r.network.clock.Now()
+	r.report.FinishedAt = &now
+	r.report.Status = status
+	log.Printf("scenario %q completed: %s", r.scenario.Name, status)
+}
+
+// Abort stops the execution of the scenario and releases the pinned UEs
+func (r *ScenarioRunner) Abort() {
+	r.mutex.Lock()
+	cancel := r.cancel
+	r.mutex.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	r.unpinUes()
+}
+
+// unpinUes hands the UEs driven by the scenario back to the markov process
+func (r *ScenarioRunner) unpinUes() {
+	for _, index := range r.scenario.PinnedUes() {
+		if ue, ok := r.network.GetUe(r.network.imsiFromIndex(index)); ok {
+			ue.Pin(false)
+		}
+	}
+}
+
+// Report returns a snapshot of the scenario execution report
+func (r *ScenarioRunner) Report() ScenarioReport {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	report := r.report
+	report.Steps = append([]ScenarioStepResult{}, r.report.Steps...)
+	report.Expectations = append([]ScenarioExpectationResult{}, r.report.Expectations...)
+	return report
+}
+
+/* network instance hooks */
+
+// LoadScenario replaces the current scenario. If the simulation is already
+// running the scenario is started immediately, otherwise it starts with the simulation.
+func (n *NetworkInstance) LoadScenario(scenario *Scenario) error {
+	if err := scenario.Validate(); err != nil {
+		return err
+	}
+
+	n.scenarioMutex.Lock()
+	defer n.scenarioMutex.Unlock()
+
+	if n.scenario != nil {
+		n.scenario.Abort()
+	}
+
+	n.scenario = NewScenarioRunner(scenario, n)
+	if n.running {
+		go n.scenario.Run(n.ueGenContext)
+	}
+
+	log.Printf("loaded scenario %q", scenario.Name)
+	return nil
+}
+
+// UnloadScenario aborts and removes the current scenario
+func (n *NetworkInstance) UnloadScenario() error {
+	n.scenarioMutex.Lock()
+	defer n.scenarioMutex.Unlock()
+
+	if n.scenario == nil {
+		return fmt.Errorf("no scenario loaded")
+	}
+	n.scenario.Abort()
+	n.scenario = nil
+	return nil
+}
+
+// ScenarioReport returns the report of the current scenario, if any
+func (n *NetworkInstance) ScenarioReport() (*ScenarioReport, bool) {
+	n.scenarioMutex.RLock()
+	defer n.scenarioMutex.RUnlock()
+
+	if n.scenario == nil {
+		return nil, false
+	}
+	report := n.scenario.Report()
+	return &report, true
+}
+
+func (n *NetworkInstance) observeEvent(event string, supi string, timestamp time.Time) {
+	n.scenarioMutex.RLock()
+	runner := n.scenario
+	n.scenarioMutex.RUnlock()
+
+	if runner != nil {
+		runner.Observe(event, supi, timestamp)
+	}
+	for _, observer := range n.observers {
+		observer(event, supi, timestamp)
+	}
+}
+
+// pinUe spawns the UE with the given index if needed and detaches it from the markov process
+func (n *NetworkInstance) pinUe(index int) error {
+	n.ueListMutex.Lock()
+	defer n.ueListMutex.Unlock()
+
+	imsi := n.imsiFromIndex(index)
+	ue, exists := n.UeList[imsi]
+	if !exists {
+		ue, err := n.newUe(index, ran.DeviceClassSmartphone)
+		if err != nil {
+			return fmt.Errorf("could not create ue %d: %w", index, err)
+		}
+		ue.Pin(true)
+		ue.PowerUp()
+		n.UeList[imsi] = ue
+		return nil
+	}
+
+	ue.Pin(true)
+	return nil
+}
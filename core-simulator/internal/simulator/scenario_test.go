@@ -0,0 +1,217 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package simulator
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+// startScenario loads the scenario and starts the simulation, it returns once the scenario is running
+func startScenario(t *testing.T, n *NetworkInstance, scenario *Scenario) *ScenarioRunner {
+	t.Helper()
+	if err := n.LoadScenario(scenario); err != nil {
+		t.Fatal(err)
+	}
+	runner := n.scenario
+	if err := n.Start(); err != nil {
+		t.Fatal(err)
+	}
+	waitForScenario(t, runner, func(r ScenarioReport) bool { return r.Status == ScenarioRunning })
+	return runner
+}
+
+// waitForScenario polls the report of the scenario until it is accepted by done
+func waitForScenario(t *testing.T, r *ScenarioRunner, done func(r ScenarioReport) bool) ScenarioReport {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		report := r.Report()
+		if done(report) {
+			return report
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the scenario, got report %+v", report)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func isOver(r ScenarioReport) bool {
+	return r.Status != ScenarioLoaded && r.Status != ScenarioRunning
+}
+
+func TestScenarioStepTiming(t *testing.T) {
+	n := newTestNetwork(t)
+	r := startScenario(t, n, &Scenario{
+		Name: "timing",
+		Steps: []ScenarioStep{
+			{At: 2 * time.Second, Ue: 1, UeAction: UeAction{Action: UeActionRegister}},
+			{At: 4 * time.Second, Ue: 1, UeAction: UeAction{Action: UeActionAttach}},
+		},
+	})
+	start := *r.Report().StartedAt
+
+	n.clock.Advance(time.Second)
+	time.Sleep(50 * time.Millisecond)
+	if report := r.Report(); report.Steps[0].Status != ScenarioPending {
+		t.Fatalf("got step %+v before its time", report.Steps[0])
+	}
+
+	n.clock.Advance(time.Second)
+	report := waitForScenario(t, r, func(r ScenarioReport) bool { return r.Steps[0].Status != ScenarioPending })
+	if report.Steps[0].Status != ScenarioPassed {
+		t.Fatalf("got step %+v, want a passed step", report.Steps[0])
+	}
+	if executedAt := report.Steps[0].ExecutedAt.Sub(start); executedAt < 2*time.Second {
+		t.Fatalf("step executed %s after the start, want at least 2s", executedAt)
+	}
+	if report.Steps[1].Status != ScenarioPending {
+		t.Fatalf("got step %+v before its time", report.Steps[1])
+	}
+
+	n.clock.Advance(2 * time.Second)
+	report = waitForScenario(t, r, isOver)
+	if report.Status != ScenarioPassed {
+		t.Fatalf("got scenario %+v, want a passed scenario", report)
+	}
+	ue, _ := n.GetUe(n.imsiFromIndex(1))
+	if rmState, cmState := ue.GetStatus(); rmState != models.RmStateRegistered || cmState != models.CmStateConnected {
+		t.Fatalf("got ue in %s/%s, want a registered and connected ue", rmState, cmState)
+	}
+}
+
+func TestScenarioExpectations(t *testing.T) {
+	n := newTestNetwork(t)
+	r := startScenario(t, n, &Scenario{
+		Name: "expectations",
+		Steps: []ScenarioStep{
+			{At: time.Second, Ue: 1, UeAction: UeAction{Action: UeActionRegister}},
+			// the ue is not connected, the step fails
+			{At: 2 * time.Second, Ue: 1, UeAction: UeAction{Action: UeActionIdle}},
+		},
+		Expectations: []ScenarioExpectation{
+			{Name: "registered", Ue: 1, Event: "REGISTRATION_STATE_REPORT", At: time.Second, Within: time.Second},
+			{Name: "other ue", Ue: 2, Event: "REGISTRATION_STATE_REPORT", At: 0, Within: 2 * time.Second},
+			{Name: "no loss", Event: "LOSS_OF_CONNECTIVITY", At: 0, Within: 3 * time.Second, Absent: true},
+			{Name: "no registration", Event: "REGISTRATION_STATE_REPORT", At: 0, Within: 500 * time.Millisecond, Absent: true},
+			{Name: "late", Ue: 1, Event: "REGISTRATION_STATE_REPORT", At: 2 * time.Second, Within: time.Second},
+		},
+	})
+
+	// the events are reported asynchronously by the network functions
+	n.clock.Advance(time.Second)
+	waitForScenario(t, r, func(r ScenarioReport) bool { return r.Expectations[0].Status != ScenarioPending })
+	n.clock.Advance(4 * time.Second)
+	report := waitForScenario(t, r, isOver)
+	if report.Status != ScenarioFailed {
+		t.Fatalf("got status %s, want %s", report.Status, ScenarioFailed)
+	}
+	if report.Steps[0].Status != ScenarioPassed || report.Steps[1].Status != ScenarioFailed || report.Steps[1].Error == "" {
+		t.Fatalf("got steps %+v, want the idle step to fail", report.Steps)
+	}
+
+	want := []ScenarioStatus{ScenarioPassed, ScenarioFailed, ScenarioPassed, ScenarioPassed, ScenarioFailed}
+	for i, status := range want {
+		exp := report.Expectations[i]
+		if exp.Status != status {
+			t.Errorf("expectation %q: got %s, want %s", exp.Name, exp.Status, status)
+		}
+	}
+	if report.Expectations[0].MatchedAt == nil || report.Expectations[4].MatchedAt != nil {
+		t.Errorf("got expectations %+v, want only the first one matched", report.Expectations)
+	}
+
+	// the UEs are released once the scenario is over
+	if ue, _ := n.GetUe(n.imsiFromIndex(1)); ue.IsPinned() {
+		t.Fatal("ue still pinned after the end of the scenario")
+	}
+}
+
+func TestScenarioPauseShift(t *testing.T) {
+	n := newTestNetwork(t)
+	r := startScenario(t, n, &Scenario{
+		Name: "pause",
+		Steps: []ScenarioStep{
+			{At: time.Second, Ue: 1, UeAction: UeAction{Action: UeActionRegister}},
+		},
+		Expectations: []ScenarioExpectation{
+			{Name: "shifted", Event: "CUSTOM", At: 10 * time.Second, Within: 100 * time.Millisecond},
+		},
+	})
+	start := *r.Report().StartedAt
+
+	// the timeline is frozen while the simulation is paused
+	if err := n.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	n.clock.Advance(2 * time.Second)
+	time.Sleep(200 * time.Millisecond)
+	if report := r.Report(); report.Steps[0].Status != ScenarioPending {
+		t.Fatalf("got step %+v while paused", report.Steps[0])
+	}
+	if err := n.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	r.mutex.Lock()
+	pausedFor := r.pausedFor
+	r.mutex.Unlock()
+	if pausedFor < 200*time.Millisecond {
+		t.Fatalf("got a shift of %s, want at least the time spent in pause", pausedFor)
+	}
+
+	n.clock.Advance(3 * time.Second)
+	waitForScenario(t, r, func(r ScenarioReport) bool { return r.Steps[0].Status == ScenarioPassed })
+
+	// the events are matched on the timeline without the pause
+	r.Observe("CUSTOM", "", start.Add(10*time.Second+pausedFor+200*time.Millisecond))
+	if report := r.Report(); report.Expectations[0].Status != ScenarioPending {
+		t.Fatalf("got expectation %+v, matched outside of the shifted window", report.Expectations[0])
+	}
+	r.Observe("CUSTOM", "", start.Add(10*time.Second+pausedFor+50*time.Millisecond))
+	if report := r.Report(); report.Expectations[0].Status != ScenarioPassed {
+		t.Fatalf("got expectation %+v, want a match in the shifted window", report.Expectations[0])
+	}
+}
+
+func TestScenarioAbort(t *testing.T) {
+	n := newTestNetwork(t)
+	r := startScenario(t, n, &Scenario{
+		Name: "abort",
+		Steps: []ScenarioStep{
+			{At: time.Minute, Ue: 1, UeAction: UeAction{Action: UeActionRegister}},
+		},
+	})
+	ue, ok := n.GetUe(n.imsiFromIndex(1))
+	if !ok || !ue.IsPinned() {
+		t.Fatal("ue not pinned by the scenario")
+	}
+
+	r.Abort()
+	report := waitForScenario(t, r, isOver)
+	if report.Status != ScenarioAborted || report.Steps[0].Status != ScenarioPending {
+		t.Fatalf("got scenario %+v, want an aborted scenario", report)
+	}
+	if ue.IsPinned() {
+		t.Fatal("ue still pinned after the abort")
+	}
+}
@@ -21,11 +21,17 @@ package simulator
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/core"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
 )
 
 func (app *CoreSimulatorApp) handleInitSimulation(w http.ResponseWriter, r *http.Request) {
@@ -36,58 +42,586 @@ func (app *CoreSimulatorApp) handleInitSimulation(w http.ResponseWriter, r *http
 		// avoid cli config to override the default one
 		config = app.config.NetConfig
 	} else {
-		if r.Body != nil {
-			err := json.NewDecoder(r.Body).Decode(config)
-			if err != nil {
-				http.Error(w, "Invalid request body", http.StatusBadRequest)
-			}
-		} else {
+		if r.Body == nil {
 			http.Error(w, "Missing request body", http.StatusBadRequest)
+			return
+		}
+		err := json.NewDecoder(r.Body).Decode(config)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
 		}
 	}
 
-	err := app.InitNewSimulation(config)
+	app.handleTransition(w, func() error {
+		return app.InitNewSimulation(config)
+	})
+}
+
+func (app *CoreSimulatorApp) handleStartSimulation(w http.ResponseWriter, r *http.Request) {
+	app.handleTransition(w, app.StartSimulation)
+}
+
+func (app *CoreSimulatorApp) handleStatusSimulation(w http.ResponseWriter, r *http.Request) {
+	err := json.NewEncoder(w).Encode(SimulationStatusResponse{
+		Status: app.GetCurrentSimulationStatus(),
+	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, "could not encode response", http.StatusInternalServerError)
+	}
+}
+
+func (app *CoreSimulatorApp) handleStopSimulation(w http.ResponseWriter, r *http.Request) {
+	app.handleTransition(w, app.StopSimulation)
+}
+
+func (app *CoreSimulatorApp) handlePauseSimulation(w http.ResponseWriter, r *http.Request) {
+	app.handleTransition(w, app.PauseSimulation)
+}
+
+func (app *CoreSimulatorApp) handleResumeSimulation(w http.ResponseWriter, r *http.Request) {
+	app.handleTransition(w, app.ResumeSimulation)
+}
+
+func (app *CoreSimulatorApp) handleResetSimulation(w http.ResponseWriter, r *http.Request) {
+	app.handleTransition(w, app.ResetSimulation)
+}
+
+// handleTransition runs a simulation state transition and replies with the new status,
+// transitions not allowed in the current status are rejected with 409
+func (app *CoreSimulatorApp) handleTransition(w http.ResponseWriter, transition func() error) {
+	if err := transition(); err != nil {
+		if errors.Is(err, ErrInvalidTransition) {
+			http.Error(w, err.Error(), http.StatusConflict)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
 	}
 
-	err = json.NewEncoder(w).Encode(SimulationStatusResponse{
-		Status: app.status,
+	err := json.NewEncoder(w).Encode(SimulationStatusResponse{
+		Status: app.GetCurrentSimulationStatus(),
 	})
 	if err != nil {
 		http.Error(w, "could not encode response", http.StatusInternalServerError)
 	}
+}
 
+func (app *CoreSimulatorApp) handleScenario(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		// the scenario is described in YAML, JSON bodies are accepted as well
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		scenario, err := ParseScenario(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := app.LoadScenario(scenario); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		report, err := app.GetScenarioReport()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			http.Error(w, "could not encode response", http.StatusInternalServerError)
+		}
+
+	case http.MethodDelete:
+		if err := app.UnloadScenario(); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
 }
 
-func (app *CoreSimulatorApp) handleStartSimulation(w http.ResponseWriter, r *http.Request) {
-	err := app.StartSimulation()
+func (app *CoreSimulatorApp) handleScenarioReport(w http.ResponseWriter, r *http.Request) {
+	report, err := app.GetScenarioReport()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
 	}
-	err = json.NewEncoder(w).Encode(SimulationStatusResponse{
-		Status: app.status,
-	})
-	if err != nil {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(report); err != nil {
 		http.Error(w, "could not encode response", http.StatusInternalServerError)
 	}
 }
 
-func (app *CoreSimulatorApp) handleStatusSimulation(w http.ResponseWriter, r *http.Request) {
-	err := json.NewEncoder(w).Encode(SimulationStatusResponse{
-		Status: app.GetCurrentSimulationStatus(),
+func (app *CoreSimulatorApp) handleListUes(w http.ResponseWriter, r *http.Request) {
+	instance, err := app.getInstance()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	filter, err := parseUeFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	offset, limit, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJson(w, http.StatusOK, paginate(instance.ListUes(filter), offset, limit))
+}
+
+func (app *CoreSimulatorApp) handleGetUe(w http.ResponseWriter, r *http.Request) {
+	instance, err := app.getInstance()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	ueId := mux.Vars(r)["ueId"]
+	ue, ok := instance.FindUe(ueId)
+	if !ok {
+		http.Error(w, fmt.Sprintf("ue %s not found", ueId), http.StatusNotFound)
+		return
+	}
+
+	writeJson(w, http.StatusOK, ue.GetContext())
+}
+
+func (app *CoreSimulatorApp) handleListAmfSubscriptions(w http.ResponseWriter, r *http.Request) {
+	instance, err := app.getInstance()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	offset, limit, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	event := r.URL.Query().Get("event")
+	subs := instance.Amf.GetSubscriptions()
+	items := []models.AmfCreatedEventSubscription{}
+	for _, subId := range sortedKeys(subs) {
+		sub := subs[subId]
+		if event != "" && !subscribedToEvent(event, sub.EventList, func(e models.AmfEvent) string { return string(e.Type) }) {
+			continue
+		}
+		items = append(items, *models.NewAmfCreatedEventSubscription(sub, subId))
+	}
+
+	writeJson(w, http.StatusOK, paginate(items, offset, limit))
+}
+
+func (app *CoreSimulatorApp) handleTransferAmfSubscription(w http.ResponseWriter, r *http.Request) {
+	instance, err := app.getInstance()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	subId := mux.Vars(r)["subId"]
+	newId, err := instance.Amf.TransferSubscription(subId)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("subscription %s not found", subId), http.StatusNotFound)
+		return
+	}
+	writeJson(w, http.StatusOK, map[string]string{subId: newId})
+}
+
+func (app *CoreSimulatorApp) handleRelocateAmfSubscriptions(w http.ResponseWriter, r *http.Request) {
+	instance, err := app.getInstance()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeJson(w, http.StatusOK, instance.Amf.RelocateSubscriptions())
+}
+
+func (app *CoreSimulatorApp) handleListSmfSubscriptions(w http.ResponseWriter, r *http.Request) {
+	instance, err := app.getInstance()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	offset, limit, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	event := r.URL.Query().Get("event")
+	subs := instance.Smf.GetSubscriptions()
+	items := []models.NsmfEventExposure{}
+	for _, subId := range sortedKeys(subs) {
+		sub := subs[subId]
+		if event != "" && !subscribedToEvent(event, sub.EventSubs, func(e models.EventSubscription) string { return string(e.Event) }) {
+			continue
+		}
+		items = append(items, sub)
+	}
+
+	writeJson(w, http.StatusOK, paginate(items, offset, limit))
+}
+
+func (app *CoreSimulatorApp) handleListNwdafSubscriptions(w http.ResponseWriter, r *http.Request) {
+	instance, err := app.getInstance()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if instance.Nwdaf == nil {
+		http.Error(w, "the NWDAF is not enabled", http.StatusNotFound)
+		return
+	}
+	offset, limit, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	event := r.URL.Query().Get("event")
+	subs := instance.Nwdaf.GetSubscriptions()
+	items := []NwdafSubscriptionInfo{}
+	for _, subId := range sortedKeys(subs) {
+		sub := subs[subId]
+		if event != "" && !subscribedToEvent(event, sub.EventSubscriptions, func(e models.NwdafEventSubscription) string { return e.Event }) {
+			continue
+		}
+		items = append(items, NwdafSubscriptionInfo{SubscriptionId: subId, Subscription: sub})
+	}
+
+	writeJson(w, http.StatusOK, paginate(items, offset, limit))
+}
+
+func (app *CoreSimulatorApp) handleListAppSessions(w http.ResponseWriter, r *http.Request) {
+	instance, err := app.getInstance()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	offset, limit, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sessions := instance.Pcf.GetAppSessions()
+	items := []AppSessionInfo{}
+	for _, appSessId := range sortedKeys(sessions) {
+		items = append(items, AppSessionInfo{
+			AppSessionId: appSessId,
+			AppSession:   sessions[appSessId],
+		})
+	}
+
+	writeJson(w, http.StatusOK, paginate(items, offset, limit))
+}
+
+// chargingInstance returns the running network instance, it answers with an error when the CHF is not enabled
+func (app *CoreSimulatorApp) chargingInstance(w http.ResponseWriter) (*NetworkInstance, bool) {
+	instance, err := app.getInstance()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil, false
+	}
+	if instance.Chf == nil {
+		http.Error(w, "the CHF is not enabled", http.StatusNotFound)
+		return nil, false
+	}
+	return instance, true
+}
+
+func (app *CoreSimulatorApp) handleListChargingSessions(w http.ResponseWriter, r *http.Request) {
+	instance, ok := app.chargingInstance(w)
+	if !ok {
+		return
+	}
+	offset, limit, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sessions := instance.Chf.GetSessions()
+	items := []ChargingSessionInfo{}
+	for _, ref := range sortedKeys(sessions) {
+		items = append(items, ChargingSessionInfo{ChargingDataRef: ref, ChargingSession: sessions[ref]})
+	}
+	writeJson(w, http.StatusOK, paginate(items, offset, limit))
+}
+
+func (app *CoreSimulatorApp) handleListChargingAccounts(w http.ResponseWriter, r *http.Request) {
+	instance, ok := app.chargingInstance(w)
+	if !ok {
+		return
+	}
+	offset, limit, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	accounts := instance.Chf.GetAccounts()
+	items := []core.ChargingAccount{}
+	for _, id := range sortedKeys(accounts) {
+		items = append(items, accounts[id])
+	}
+	writeJson(w, http.StatusOK, paginate(items, offset, limit))
+}
+
+func (app *CoreSimulatorApp) handleTopUpChargingAccount(w http.ResponseWriter, r *http.Request) {
+	instance, ok := app.chargingInstance(w)
+	if !ok {
+		return
+	}
+
+	request := &TopUpRequest{}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	account, err := instance.Chf.TopUp(mux.Vars(r)["accountId"], request.Volume)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJson(w, http.StatusOK, account)
+}
+
+func (app *CoreSimulatorApp) handleGetIpam(w http.ResponseWriter, r *http.Request) {
+	instance, err := app.getInstance()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	offset, limit, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	allocated := len(instance.ipam.Allocations())
+	writeJson(w, http.StatusOK, IpamResponse{
+		Subnet:        instance.ipam.Subnet(),
+		Size:          instance.ipam.Size(),
+		Allocated:     allocated,
+		Available:     instance.ipam.Size() - allocated,
+		PagedResponse: paginate(instance.IpamAllocations(r.URL.Query().Get("supi")), offset, limit),
 	})
+}
+
+func (app *CoreSimulatorApp) handleUeAction(w http.ResponseWriter, r *http.Request) {
+	instance, err := app.getInstance()
 	if err != nil {
-		http.Error(w, "could not encode response", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	ueId := mux.Vars(r)["imsi"]
+	ue, ok := instance.FindUe(ueId)
+	if !ok {
+		http.Error(w, fmt.Sprintf("ue %s not found", ueId), http.StatusNotFound)
+		return
 	}
+
+	request := &UeActionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if request.Action == "" && request.Pin == nil {
+		http.Error(w, "Missing action", http.StatusBadRequest)
+		return
+	}
+
+	// the pin is applied before the action, so that the markov process does not
+	// interfere with it, and rolled back if the action is rejected
+	wasPinned := ue.IsPinned()
+	if request.Pin != nil {
+		ue.Pin(*request.Pin)
+	}
+
+	if request.Action != "" {
+		err = instance.ExecuteUeAction(ue.Imsi, &request.UeAction)
+		if err != nil {
+			ue.Pin(wasPinned)
+		}
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrUeNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		case errors.Is(err, ErrInvalidUeState):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		default:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	writeJson(w, http.StatusOK, ue.GetContext())
 }
 
-func (app *CoreSimulatorApp) handleStopSimulation(w http.ResponseWriter, r *http.Request) {
-	err := app.StopSimulation()
+func (app *CoreSimulatorApp) handleListBarredUes(w http.ResponseWriter, r *http.Request) {
+	instance, err := app.getInstance()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	offset, limit, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJson(w, http.StatusOK, paginate(instance.Amf.BarredUes(), offset, limit))
+}
+
+func (app *CoreSimulatorApp) handleAddUes(w http.ResponseWriter, r *http.Request) {
+	instance, err := app.getInstance()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	request := &AddUesRequest{}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	added, err := instance.AddUes(request.Count, request.DeviceClass)
+	if err != nil {
+		writePopulationError(w, err)
+		return
+	}
+	writeJson(w, http.StatusCreated, added)
+}
+
+func (app *CoreSimulatorApp) handleRemoveUes(w http.ResponseWriter, r *http.Request) {
+	instance, err := app.getInstance()
 	if err != nil {
-		http.Error(w, "could not stop simulation", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
+
+	query := r.URL.Query()
+	request := &RemoveUesRequest{
+		DeviceClass: query.Get("deviceClass"),
+		Delete:      query.Get("delete") == "true",
+	}
+	if ueId, ok := mux.Vars(r)["imsi"]; ok {
+		ue, found := instance.FindUe(ueId)
+		if !found {
+			http.Error(w, fmt.Sprintf("ue %s not found", ueId), http.StatusNotFound)
+			return
+		}
+		request.Imsis = []string{ue.Imsi}
+	} else if count := query.Get("count"); count != "" {
+		request.Count, err = strconv.Atoi(count)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid count %q", count), http.StatusBadRequest)
+			return
+		}
+	}
+
+	removed, err := instance.RemoveUes(request)
+	if err != nil {
+		writePopulationError(w, err)
+		return
+	}
+	writeJson(w, http.StatusOK, removed)
+}
+
+func (app *CoreSimulatorApp) handleListGnbs(w http.ResponseWriter, r *http.Request) {
+	instance, err := app.getInstance()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	offset, limit, err := parsePagination(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writeJson(w, http.StatusOK, paginate(instance.ListGnbs(), offset, limit))
+}
+
+func (app *CoreSimulatorApp) handleAddGnbs(w http.ResponseWriter, r *http.Request) {
+	instance, err := app.getInstance()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	request := &AddGnbsRequest{}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	added, err := instance.AddGnbs(request.Count)
+	if err != nil {
+		writePopulationError(w, err)
+		return
+	}
+	writeJson(w, http.StatusCreated, added)
+}
+
+func (app *CoreSimulatorApp) handleDecommissionGnb(w http.ResponseWriter, r *http.Request) {
+	instance, err := app.getInstance()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	mode := DecommissionMode(strings.ToUpper(r.URL.Query().Get("mode")))
+	report, err := instance.DecommissionGnb(mux.Vars(r)["cellId"], mode)
+	if err != nil {
+		writePopulationError(w, err)
+		return
+	}
+	writeJson(w, http.StatusOK, report)
+}
+
+func writePopulationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUeNotFound), errors.Is(err, ErrGnbNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+func subscribedToEvent[T any](event string, events []T, name func(T) string) bool {
+	for _, e := range events {
+		if name(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJson(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("could not encode response: %s", err.Error())
+	}
 }
 
 func (app *CoreSimulatorApp) startHttpServer() {
@@ -99,6 +633,31 @@ func (app *CoreSimulatorApp) startHttpServer() {
 	router.HandleFunc("/core-simulator/v1/start", app.handleStartSimulation)
 	router.HandleFunc("/core-simulator/v1/status", app.handleStatusSimulation)
 	router.HandleFunc("/core-simulator/v1/stop", app.handleStopSimulation)
+	router.HandleFunc("/core-simulator/v1/pause", app.handlePauseSimulation).Methods(http.MethodPost)
+	router.HandleFunc("/core-simulator/v1/resume", app.handleResumeSimulation).Methods(http.MethodPost)
+	router.HandleFunc("/core-simulator/v1/reset", app.handleResetSimulation).Methods(http.MethodPost)
+	router.HandleFunc("/core-simulator/v1/scenario", app.handleScenario)
+	router.HandleFunc("/core-simulator/v1/scenario/report", app.handleScenarioReport)
+	router.HandleFunc("/core-simulator/v1/ues", app.handleListUes).Methods(http.MethodGet)
+	router.HandleFunc("/core-simulator/v1/ues", app.handleAddUes).Methods(http.MethodPost)
+	router.HandleFunc("/core-simulator/v1/ues", app.handleRemoveUes).Methods(http.MethodDelete)
+	router.HandleFunc("/core-simulator/v1/ues/{ueId}", app.handleGetUe).Methods(http.MethodGet)
+	router.HandleFunc("/core-simulator/v1/ues/{imsi}", app.handleRemoveUes).Methods(http.MethodDelete)
+	router.HandleFunc("/core-simulator/v1/ues/{imsi}/actions", app.handleUeAction).Methods(http.MethodPost)
+	router.HandleFunc("/core-simulator/v1/barred-ues", app.handleListBarredUes).Methods(http.MethodGet)
+	router.HandleFunc("/core-simulator/v1/subscriptions/amf", app.handleListAmfSubscriptions).Methods(http.MethodGet)
+	router.HandleFunc("/core-simulator/v1/subscriptions/amf/relocate", app.handleRelocateAmfSubscriptions).Methods(http.MethodPost)
+	router.HandleFunc("/core-simulator/v1/subscriptions/amf/{subId}/transfer", app.handleTransferAmfSubscription).Methods(http.MethodPost)
+	router.HandleFunc("/core-simulator/v1/subscriptions/smf", app.handleListSmfSubscriptions).Methods(http.MethodGet)
+	router.HandleFunc("/core-simulator/v1/subscriptions/nwdaf", app.handleListNwdafSubscriptions).Methods(http.MethodGet)
+	router.HandleFunc("/core-simulator/v1/app-sessions", app.handleListAppSessions).Methods(http.MethodGet)
+	router.HandleFunc("/core-simulator/v1/ipam", app.handleGetIpam).Methods(http.MethodGet)
+	router.HandleFunc("/core-simulator/v1/charging/sessions", app.handleListChargingSessions).Methods(http.MethodGet)
+	router.HandleFunc("/core-simulator/v1/charging/accounts", app.handleListChargingAccounts).Methods(http.MethodGet)
+	router.HandleFunc("/core-simulator/v1/charging/accounts/{accountId}/top-up", app.handleTopUpChargingAccount).Methods(http.MethodPost)
+	router.HandleFunc("/core-simulator/v1/gnbs", app.handleListGnbs).Methods(http.MethodGet)
+	router.HandleFunc("/core-simulator/v1/gnbs", app.handleAddGnbs).Methods(http.MethodPost)
+	router.HandleFunc("/core-simulator/v1/gnbs/{cellId}", app.handleDecommissionGnb).Methods(http.MethodDelete)
 
 	app.server = &http.Server{Addr: fmt.Sprintf(":%d", app.config.OamPort), Handler: router}
 
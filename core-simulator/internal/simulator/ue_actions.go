@@ -0,0 +1,305 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package simulator
+
+import (
+	"errors"
+	"fmt"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+)
+
+var (
+	ErrUeNotFound      = errors.New("ue not found")
+	ErrInvalidUeState  = errors.New("invalid ue state")
+	ErrInvalidUeAction = errors.New("invalid ue action")
+)
+
+/* UE actions executed outside of the markov process */
+
+type UeActionType string
+
+const (
+	UeActionRegister           UeActionType = "REGISTER"
+	UeActionDeregister         UeActionType = "DEREGISTER"
+	UeActionAttach             UeActionType = "ATTACH"
+	UeActionIdle               UeActionType = "IDLE"
+	UeActionPaging             UeActionType = "PAGING"
+	UeActionHandover           UeActionType = "HANDOVER"
+	UeActionPduSessionEst      UeActionType = "PDU_SESSION_ESTABLISHMENT"
+	UeActionPduSessionRel      UeActionType = "PDU_SESSION_RELEASE"
+	UeActionNetworkPduSessRel  UeActionType = "NETWORK_PDU_SESSION_RELEASE"
+	UeActionPduSessionMod      UeActionType = "PDU_SESSION_MODIFICATION"
+	UeActionStartTraffic       UeActionType = "START_TRAFFIC"
+	UeActionLossOfConnectivity UeActionType = "LOSS_OF_CONNECTIVITY"
+	UeActionPowerOn            UeActionType = "POWER_ON"
+	UeActionPowerOff           UeActionType = "POWER_OFF"
+	UeActionNetworkDeregister  UeActionType = "NETWORK_DEREGISTRATION"
+	UeActionBar                UeActionType = "BAR"
+	UeActionUnbar              UeActionType = "UNBAR"
+)
+
+// UeAction describes a procedure forced on a single UE.
+// Only the parameters relevant to the selected action are taken into account.
+type UeAction struct {
+	Action UeActionType `yaml:"action" json:"action"`
+	// target cell for handovers, either as NR cell id or as index in the gNB list
+	CellId string `yaml:"cellId,omitempty" json:"cellId,omitempty"`
+	Cell   *int   `yaml:"cell,omitempty" json:"cell,omitempty"`
+	// pdu session parameters, defaults to the simulation profile
+	PduSessionId int32          `yaml:"pduSessionId,omitempty" json:"pduSessionId,omitempty"`
+	Dnn          string         `yaml:"dnn,omitempty" json:"dnn,omitempty"`
+	Snssai       *models.Snssai `yaml:"slice,omitempty" json:"slice,omitempty"`
+	// qos of the default flow for pdu session modifications
+	Qos *models.SessionQos `yaml:"qos,omitempty" json:"qos,omitempty"`
+	// traffic session parameters
+	TrafficProfile string `yaml:"trafficProfile,omitempty" json:"trafficProfile,omitempty"`
+	Uplink         bool   `yaml:"uplink,omitempty" json:"uplink,omitempty"`
+	DurationSec    uint   `yaml:"durationSec,omitempty" json:"durationSec,omitempty"`
+}
+
+// UeActionRequest is the body of the OAM UE action API. When pin is set the UE
+// is pinned (or released) before executing the action, so that the markov process
+// does not override the forced behaviour.
+type UeActionRequest struct {
+	UeAction
+	Pin *bool `json:"pin,omitempty"`
+}
+
+// ExecuteUeAction runs the requested procedure on the UE identified by imsi.
+// It verifies the preconditions of the procedure against the current UE state
+// and keeps the markov state of the UE aligned, so that unpinned UEs continue
+// their stochastic behaviour from the forced state.
+// The procedure runs on the shard of the UE, so that it does not interleave with the markov
+// process and the downlink messages of the UE, and the call waits for its completion.
+// Network initiated procedures (paging, deregistration, barring, network initiated release
+// and modification) are sent by the network functions to the UE, which runs them asynchronously.
+func (n *NetworkInstance) ExecuteUeAction(imsi string, action *UeAction) error {
+	ue, ok := n.GetUe(imsi)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUeNotFound, imsi)
+	}
+
+	if action.Action == UeActionPowerOn {
+		// a UE turned off has no procedure running on the engine
+		if ue.IsPoweredOn() {
+			return fmt.Errorf("%w: ue %s is already powered on", ErrInvalidUeState, imsi)
+		}
+		ue.PowerUp()
+		return nil
+	}
+	// the engine does not run the procedures while the simulation is paused
+	if n.pause.IsPaused() {
+		return fmt.Errorf("%w: simulation %s is paused", ErrInvalidUeState, n.simId)
+	}
+
+	var err error
+	if !ue.DispatchWait(func() { err = n.executeUeAction(ue, action) }) {
+		return fmt.Errorf("%w: ue %s is powered off", ErrInvalidUeState, imsi)
+	}
+	return err
+}
+
+// executeUeAction runs the procedure on the shard of the UE
+func (n *NetworkInstance) executeUeAction(ue *ran.Ue, action *UeAction) error {
+	imsi := ue.Imsi
+	rmState, cmState := ue.GetStatus()
+	sessionId := action.PduSessionId
+	if sessionId == 0 {
+		sessionId = 1
+	}
+
+	switch action.Action {
+	case UeActionRegister:
+		if rmState == models.RmStateRegistered {
+			return fmt.Errorf("%w: ue %s is already registered", ErrInvalidUeState, imsi)
+		}
+		ue.Register()
+		if rmState, _ := ue.GetStatus(); rmState != models.RmStateRegistered {
+			return fmt.Errorf("%w: no cell available for ue %s", ErrInvalidUeState, imsi)
+		}
+		ue.SetState(models.Registered)
+
+	case UeActionDeregister:
+		if rmState != models.RmStateRegistered {
+			return fmt.Errorf("%w: ue %s is not registered", ErrInvalidUeState, imsi)
+		}
+		ue.Deregister()
+		ue.SetState(models.Deregistered)
+
+	case UeActionAttach:
+		if rmState != models.RmStateRegistered {
+			return fmt.Errorf("%w: ue %s is not registered", ErrInvalidUeState, imsi)
+		}
+		ue.Attach(ueInactivityTimer)
+		ue.SetState(models.Attached)
+
+	case UeActionIdle:
+		if rmState != models.RmStateRegistered || cmState != models.CmStateConnected {
+			return fmt.Errorf("%w: ue %s is not connected", ErrInvalidUeState, imsi)
+		}
+		ue.Sleep(true)
+		ue.SetState(models.Idle)
+
+	case UeActionPaging:
+		if rmState != models.RmStateRegistered || cmState != models.CmStateIdle {
+			return fmt.Errorf("%w: ue %s is not in idle mode", ErrInvalidUeState, imsi)
+		}
+		if err := n.Amf.Page(imsi); err != nil {
+			return fmt.Errorf("%w: could not page ue %s: %v", ErrInvalidUeState, imsi, err)
+		}
+
+	case UeActionNetworkDeregister:
+		if rmState != models.RmStateRegistered {
+			return fmt.Errorf("%w: ue %s is not registered", ErrInvalidUeState, imsi)
+		}
+		if err := n.Amf.Deregister(imsi); err != nil {
+			return fmt.Errorf("%w: could not deregister ue %s: %v", ErrInvalidUeState, imsi, err)
+		}
+
+	case UeActionBar:
+		if n.Amf.IsBarred(imsi) {
+			return fmt.Errorf("%w: ue %s is already barred", ErrInvalidUeState, imsi)
+		}
+		if err := n.Amf.Bar(imsi); err != nil {
+			return fmt.Errorf("%w: could not deregister barred ue %s: %v", ErrInvalidUeState, imsi, err)
+		}
+
+	case UeActionUnbar:
+		if !n.Amf.Unbar(imsi) {
+			return fmt.Errorf("%w: ue %s is not barred", ErrInvalidUeState, imsi)
+		}
+
+	case UeActionHandover:
+		if rmState != models.RmStateRegistered {
+			return fmt.Errorf("%w: ue %s is not registered", ErrInvalidUeState, imsi)
+		}
+		cellId, err := n.resolveCell(action)
+		if err != nil {
+			return err
+		}
+		ue.DoHandover(cellId)
+
+	case UeActionPduSessionEst:
+		if sessionId < 1 || sessionId > 15 {
+			return fmt.Errorf("%w: pdu session id %d out of range [1, 15]", ErrInvalidUeAction, sessionId)
+		}
+		if cmState != models.CmStateConnected {
+			return fmt.Errorf("%w: ue %s is not connected", ErrInvalidUeState, imsi)
+		}
+		if ue.HasPduSession(sessionId) {
+			return fmt.Errorf("%w: pdu session %d of ue %s is already established", ErrInvalidUeState, sessionId, imsi)
+		}
+		dnn := action.Dnn
+		if dnn == "" {
+			dnn = n.config.Dnn
+		}
+		snssai := n.config.Snssai
+		if action.Snssai != nil {
+			snssai = *action.Snssai
+		}
+		ue.NewPduSession(sessionId, dnn, snssai, true)
+		if !ue.HasPduSession(sessionId) {
+			return fmt.Errorf("%w: could not establish pdu session %d for ue %s", ErrInvalidUeState, sessionId, imsi)
+		}
+		ue.SetState(models.Connected)
+
+	case UeActionPduSessionRel:
+		if !ue.HasPduSession(sessionId) {
+			return fmt.Errorf("%w: pdu session %d of ue %s is not established", ErrInvalidUeState, sessionId, imsi)
+		}
+		ue.ReleasePduSession(sessionId)
+
+	case UeActionNetworkPduSessRel:
+		if !ue.HasPduSession(sessionId) {
+			return fmt.Errorf("%w: pdu session %d of ue %s is not established", ErrInvalidUeState, sessionId, imsi)
+		}
+		if err := n.Smf.ReleasePduSession(imsi, sessionId); err != nil {
+			return fmt.Errorf("%w: could not release pdu session %d of ue %s: %v", ErrInvalidUeState, sessionId, imsi, err)
+		}
+
+	case UeActionPduSessionMod:
+		if action.Qos == nil {
+			return fmt.Errorf("%w: a qos is required", ErrInvalidUeAction)
+		}
+		if !ue.HasPduSession(sessionId) {
+			return fmt.Errorf("%w: pdu session %d of ue %s is not established", ErrInvalidUeState, sessionId, imsi)
+		}
+		if err := n.Smf.ModifyPduSession(imsi, sessionId, *action.Qos); err != nil {
+			return fmt.Errorf("%w: could not modify pdu session %d of ue %s: %v", ErrInvalidUeState, sessionId, imsi, err)
+		}
+
+	case UeActionStartTraffic:
+		if !n.traffic.IsProfileSupported(action.TrafficProfile) {
+			return fmt.Errorf("%w: unknown traffic profile %q", ErrInvalidUeAction, action.TrafficProfile)
+		}
+		if !ue.HasPduSession(sessionId) {
+			return fmt.Errorf("%w: pdu session %d of ue %s is not established", ErrInvalidUeState, sessionId, imsi)
+		}
+		ue.StartTrafficSession(sessionId, action.Uplink, action.TrafficProfile, action.DurationSec)
+
+	case UeActionLossOfConnectivity:
+		if rmState != models.RmStateRegistered {
+			return fmt.Errorf("%w: ue %s is not registered", ErrInvalidUeState, imsi)
+		}
+		ue.LossOfConnection(false)
+		ue.SetState(models.Deregistered)
+
+	case UeActionPowerOff:
+		if !ue.IsPoweredOn() {
+			return fmt.Errorf("%w: ue %s is already powered off", ErrInvalidUeState, imsi)
+		}
+		ue.TurnOff(true)
+
+	default:
+		return fmt.Errorf("%w: unsupported action %q", ErrInvalidUeAction, action.Action)
+	}
+
+	return nil
+}
+
+// resolveCell returns the NR cell id targeted by an action
+func (n *NetworkInstance) resolveCell(action *UeAction) (string, error) {
+	if action.CellId != "" {
+		if !n.Gnbs.Contains(action.CellId) {
+			return "", fmt.Errorf("%w: cell %s is not part of the simulation", ErrInvalidUeAction, action.CellId)
+		}
+		return action.CellId, nil
+	}
+
+	if action.Cell != nil {
+		cellId, ok := n.Gnbs.Get(*action.Cell)
+		if !ok {
+			return "", fmt.Errorf("%w: cell index %d is out of range [0, %d)", ErrInvalidUeAction, *action.Cell, n.Gnbs.Len())
+		}
+		return cellId, nil
+	}
+
+	return "", fmt.Errorf("%w: a target cell is required", ErrInvalidUeAction)
+}
+
+// GetUe returns the UE identified by imsi
+func (n *NetworkInstance) GetUe(imsi string) (*ran.Ue, bool) {
+	n.ueListMutex.RLock()
+	defer n.ueListMutex.RUnlock()
+	ue, ok := n.UeList[imsi]
+	return ue, ok
+}
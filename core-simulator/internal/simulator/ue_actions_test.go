@@ -0,0 +1,56 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package simulator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExecuteUeActionRejectsUnavailableUes(t *testing.T) {
+	n := newTestNetwork(t)
+	ue := addConnectedUe(t, n)
+
+	if err := n.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.ExecuteUeAction(ue.Imsi, &UeAction{Action: UeActionIdle}); !errors.Is(err, ErrInvalidUeState) {
+		t.Fatalf("got error %v while paused, want %v", err, ErrInvalidUeState)
+	}
+	if err := n.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.ExecuteUeAction(ue.Imsi, &UeAction{Action: UeActionIdle}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := n.ExecuteUeAction(ue.Imsi, &UeAction{Action: UeActionPowerOff}); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.ExecuteUeAction(ue.Imsi, &UeAction{Action: UeActionRegister}); !errors.Is(err, ErrInvalidUeState) {
+		t.Fatalf("got error %v for a powered off ue, want %v", err, ErrInvalidUeState)
+	}
+	if err := n.ExecuteUeAction(ue.Imsi, &UeAction{Action: UeActionPowerOn}); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.ExecuteUeAction(ue.Imsi, &UeAction{Action: UeActionRegister}); err != nil {
+		t.Fatal(err)
+	}
+}
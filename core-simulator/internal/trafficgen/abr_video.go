@@ -0,0 +1,143 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package trafficgen
+
+import (
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+	"sort"
+	"time"
+)
+
+const (
+	// share of the estimated throughput the player is willing to use
+	abrSafetyFactor = 0.8
+	// weight of the last segment in the throughput estimate
+	abrSmoothing = 0.3
+)
+
+// AbrVideoTraffic simulates adaptive bitrate streaming (DASH/HLS). The player downloads
+// the video in segments, each one as a burst at the available throughput, and selects
+// the quality of the next segment from a smoothed throughput estimate. Once the buffer
+// is full the player waits for the playback to make room for the next segment.
+type AbrVideoTraffic struct {
+	Ladder          []float64     // available video bitrates (bits per second)
+	SegmentDuration *Distribution // seconds of video per segment
+	Throughput      *Distribution // bits per second available to download a segment
+	PacketSize      *Distribution // bytes
+	BufferSec       float64       // maximum seconds of video buffered by the player
+	Random          *utils.Random // source of the draws, the process source when nil
+
+	Bitrate  float64 // bitrate of the segment being downloaded
+	Segments int     // downloaded segments
+	Stalls   int     // playback interruptions caused by an empty buffer
+
+	started    bool
+	clock      time.Time // last update of the buffer level
+	buffer     float64   // seconds of video ready to be played
+	playing    bool
+	estimate   float64
+	throughput float64
+	segment    float64
+	remaining  int
+}
+
+// NewAbrVideoTraffic creates an adaptive bitrate video generator
+func NewAbrVideoTraffic(ladder []float64, segment, throughput, pktSize *Distribution, bufferSec float64) *AbrVideoTraffic {
+	ladder = append([]float64{}, ladder...)
+	sort.Float64s(ladder)
+	return &AbrVideoTraffic{
+		Ladder:          ladder,
+		SegmentDuration: segment,
+		Throughput:      throughput,
+		PacketSize:      pktSize,
+		BufferSec:       bufferSec,
+	}
+}
+
+// NextPacket emits the next packet of the current segment, the last packet
+// of a segment is followed by a pause while the buffer is full
+func (v *AbrVideoTraffic) NextPacket(now time.Time) (*Packet, time.Time) {
+	if !v.started {
+		v.started, v.clock = true, now
+		v.startSegment()
+	}
+
+	size := min(v.PacketSize.SampleInt(v.Random, 1), v.remaining)
+	v.remaining -= size
+	next := now.Add(transmission(size, v.throughput))
+	if v.remaining == 0 {
+		next = v.completeSegment(next)
+	}
+
+	return &Packet{
+		SizeBytes: size,
+		Timestamp: now,
+	}, next
+}
+
+// startSegment selects the quality of the next segment
+func (v *AbrVideoTraffic) startSegment() {
+	v.Bitrate = v.Ladder[0]
+	for _, bitrate := range v.Ladder {
+		if bitrate <= abrSafetyFactor*v.estimate {
+			v.Bitrate = bitrate
+		}
+	}
+	v.segment = max(v.SegmentDuration.Sample(v.Random), 0.1)
+	v.throughput = positive(v.Random, v.Throughput)
+	v.remaining = max(int(v.Bitrate*v.segment/8), 1)
+}
+
+// completeSegment updates the buffer when the download of a segment ends,
+// it returns the start time of the next segment
+func (v *AbrVideoTraffic) completeSegment(at time.Time) time.Time {
+	v.Segments++
+	v.drain(at)
+	v.buffer += v.segment
+	v.playing = true
+
+	if v.estimate == 0 {
+		v.estimate = v.throughput
+	} else {
+		v.estimate = (1-abrSmoothing)*v.estimate + abrSmoothing*v.throughput
+	}
+
+	v.startSegment()
+	// wait until the buffer has room for the next segment
+	if excess := v.buffer + v.segment - v.BufferSec; excess > 0 {
+		return at.Add(seconds(excess))
+	}
+	return at
+}
+
+// drain plays the buffered video up to the given time
+func (v *AbrVideoTraffic) drain(to time.Time) {
+	if v.playing {
+		played := to.Sub(v.clock).Seconds()
+		if played >= v.buffer {
+			// rebuffering until the segment is downloaded
+			v.Stalls++
+			v.buffer = 0
+		} else {
+			v.buffer -= played
+		}
+	}
+	v.clock = to
+}
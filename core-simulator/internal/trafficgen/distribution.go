@@ -0,0 +1,163 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package trafficgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+	"gopkg.in/yaml.v3"
+)
+
+type DistributionType string
+
+const (
+	Constant    DistributionType = "CONSTANT"
+	Uniform     DistributionType = "UNIFORM"
+	Exponential DistributionType = "EXPONENTIAL"
+	Normal      DistributionType = "NORMAL"
+	LogNormal   DistributionType = "LOGNORMAL"
+	Pareto      DistributionType = "PARETO"
+)
+
+// Distribution describes a random traffic parameter.
+// A plain number in the configuration is a CONSTANT distribution.
+type Distribution struct {
+	Type DistributionType `yaml:"type" json:"type"`
+	// CONSTANT value
+	Value float64 `yaml:"value,omitempty" json:"value,omitempty"`
+	// UNIFORM bounds, also used to truncate the other distributions when set
+	Min float64 `yaml:"min,omitempty" json:"min,omitempty"`
+	Max float64 `yaml:"max,omitempty" json:"max,omitempty"`
+	// EXPONENTIAL and NORMAL mean, NORMAL standard deviation
+	Mean   float64 `yaml:"mean,omitempty" json:"mean,omitempty"`
+	StdDev float64 `yaml:"stdDev,omitempty" json:"stdDev,omitempty"`
+	// LOGNORMAL parameters of the underlying normal distribution
+	Mu    float64 `yaml:"mu,omitempty" json:"mu,omitempty"`
+	Sigma float64 `yaml:"sigma,omitempty" json:"sigma,omitempty"`
+	// PARETO shape (alpha) and scale (minimum value)
+	Shape float64 `yaml:"shape,omitempty" json:"shape,omitempty"`
+	Scale float64 `yaml:"scale,omitempty" json:"scale,omitempty"`
+}
+
+// Fixed returns a CONSTANT distribution
+func Fixed(value float64) *Distribution {
+	return &Distribution{Type: Constant, Value: value}
+}
+
+// distribution is the mapping form of Distribution, used to avoid recursive unmarshaling
+type distribution Distribution
+
+func (d *Distribution) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var value float64
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		*d = *Fixed(value)
+		return nil
+	}
+	return node.Decode((*distribution)(d))
+}
+
+func (d *Distribution) UnmarshalJSON(data []byte) error {
+	var value float64
+	if err := json.Unmarshal(data, &value); err == nil {
+		*d = *Fixed(value)
+		return nil
+	}
+	return json.Unmarshal(data, (*distribution)(d))
+}
+
+// Validate checks the parameters of the distribution
+func (d *Distribution) Validate() error {
+	switch d.Type {
+	case Constant, "":
+		if d.Value < 0 {
+			return fmt.Errorf("constant value must be non negative")
+		}
+		return nil
+	case Uniform:
+		if d.Min < 0 || d.Max < d.Min {
+			return fmt.Errorf("uniform distribution requires 0 <= min <= max")
+		}
+	case Exponential:
+		if d.Mean <= 0 {
+			return fmt.Errorf("exponential distribution requires a positive mean")
+		}
+	case Normal:
+		if d.Mean <= 0 || d.StdDev < 0 {
+			return fmt.Errorf("normal distribution requires a positive mean and a non negative standard deviation")
+		}
+	case LogNormal:
+		if d.Sigma < 0 {
+			return fmt.Errorf("lognormal distribution requires a non negative sigma")
+		}
+	case Pareto:
+		if d.Shape <= 0 || d.Scale <= 0 {
+			return fmt.Errorf("pareto distribution requires a positive shape and scale")
+		}
+	default:
+		return fmt.Errorf("unknown distribution %q", d.Type)
+	}
+	if d.Max != 0 && d.Max < d.Min {
+		return fmt.Errorf("max must be greater than min")
+	}
+	return nil
+}
+
+// Sample draws a non negative value from the distribution
+func (d *Distribution) Sample(random *utils.Random) float64 {
+	var value float64
+	switch d.Type {
+	case Constant, "":
+		return d.Value
+	case Uniform:
+		return d.Min + random.Float64()*(d.Max-d.Min)
+	case Exponential:
+		value = random.ExpFloat64() * d.Mean
+	case Normal:
+		value = d.Mean + random.NormFloat64()*d.StdDev
+	case LogNormal:
+		value = math.Exp(d.Mu + random.NormFloat64()*d.Sigma)
+	case Pareto:
+		// inverse transform sampling, 1 - U avoids a division by zero
+		value = d.Scale / math.Pow(1-random.Float64(), 1/d.Shape)
+	}
+
+	value = math.Max(value, d.Min)
+	if d.Max > 0 {
+		value = math.Min(value, d.Max)
+	}
+	return math.Max(value, 0)
+}
+
+// SampleInt draws a value rounded to the closest integer, at least minValue
+func (d *Distribution) SampleInt(random *utils.Random, minValue int) int {
+	return max(int(math.Round(d.Sample(random))), minValue)
+}
+
+// SampleDuration draws a duration expressed in seconds
+func (d *Distribution) SampleDuration(random *utils.Random) time.Duration {
+	return time.Duration(d.Sample(random) * float64(time.Second))
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package trafficgen
+
+import (
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+)
+
+// FileTransferTraffic simulates large file transfers at the available throughput.
+// Without think time the session ends with the first file, otherwise a new file
+// is transferred after each think time.
+type FileTransferTraffic struct {
+	Bitrate    float64       // bits per second
+	PacketSize *Distribution // bytes
+	FileSize   *Distribution // bytes
+	ThinkTime  *Distribution // seconds between two files, optional
+	Random     *utils.Random // source of the draws, the process source when nil
+
+	remaining int // bytes left in the current file
+}
+
+// NewFileTransferTraffic creates a file transfer generator
+func NewFileTransferTraffic(bitrate float64, pktSize, fileSize, think *Distribution) *FileTransferTraffic {
+	return &FileTransferTraffic{
+		Bitrate:    bitrate,
+		PacketSize: pktSize,
+		FileSize:   fileSize,
+		ThinkTime:  think,
+	}
+}
+
+// NextPacket emits the next packet of the current file, the session ends
+// with the last packet when no think time is configured
+func (f *FileTransferTraffic) NextPacket(now time.Time) (*Packet, time.Time) {
+	if f.remaining == 0 {
+		f.remaining = f.FileSize.SampleInt(f.Random, 1)
+	}
+
+	size := min(f.PacketSize.SampleInt(f.Random, 1), f.remaining)
+	f.remaining -= size
+	next := now.Add(transmission(size, f.Bitrate))
+	if f.remaining == 0 {
+		if f.ThinkTime == nil {
+			next = time.Time{}
+		} else {
+			next = next.Add(f.ThinkTime.SampleDuration(f.Random))
+		}
+	}
+
+	return &Packet{
+		SizeBytes: size,
+		Timestamp: now,
+	}, next
+}
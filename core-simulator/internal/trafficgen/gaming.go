@@ -0,0 +1,112 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package trafficgen
+
+import (
+	"math"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+)
+
+const (
+	// frames are sent this many times faster than the average bitrate
+	gamingBurstFactor = 4
+	// variability of the size of the video frames
+	gamingFrameSigma = 0.25
+)
+
+// CloudGamingTraffic simulates cloud gaming: a high rate video stream in the session
+// direction, sent frame by frame, and small user input packets at a steady rate in
+// the reverse direction.
+type CloudGamingTraffic struct {
+	Bitrate    float64       // video bits per second
+	FrameRate  float64       // video frames per second
+	PacketSize *Distribution // bytes of the video packets
+	InputRate  float64       // input packets per second
+	InputSize  *Distribution // bytes of the input packets
+	Random     *utils.Random // source of the draws, the process source when nil
+
+	nextFrame time.Time
+	nextVideo time.Time
+	nextInput time.Time
+	remaining int // bytes left in the current frame
+}
+
+// NewCloudGamingTraffic creates a cloud gaming generator
+func NewCloudGamingTraffic(bitrate, frameRate float64, pktSize *Distribution, inputRate float64, inputSize *Distribution) *CloudGamingTraffic {
+	return &CloudGamingTraffic{
+		Bitrate:    bitrate,
+		FrameRate:  frameRate,
+		PacketSize: pktSize,
+		InputRate:  inputRate,
+		InputSize:  inputSize,
+	}
+}
+
+// NextPacket emits the earliest due video or input packet
+func (g *CloudGamingTraffic) NextPacket(now time.Time) (*Packet, time.Time) {
+	if g.nextFrame.IsZero() {
+		g.nextFrame, g.nextInput = now, now
+	}
+	if g.remaining == 0 && !now.Before(g.nextFrame) {
+		g.remaining = g.frameSize()
+		g.nextVideo = g.nextFrame
+		g.nextFrame = g.nextFrame.Add(seconds(1 / g.FrameRate))
+	}
+
+	videoDue := g.remaining > 0 && !now.Before(g.nextVideo)
+	inputDue := !now.Before(g.nextInput)
+
+	var pkt *Packet
+	// inputs are latency sensitive, they are not queued behind the video frames
+	if inputDue && (!videoDue || !g.nextVideo.Before(g.nextInput)) {
+		g.nextInput = g.nextInput.Add(seconds(1 / g.InputRate))
+		pkt = &Packet{
+			SizeBytes: g.InputSize.SampleInt(g.Random, 1),
+			Timestamp: now,
+			Reverse:   true,
+		}
+	} else if videoDue {
+		size := min(g.PacketSize.SampleInt(g.Random, 1), g.remaining)
+		g.remaining -= size
+		g.nextVideo = g.nextVideo.Add(transmission(size, g.Bitrate*gamingBurstFactor))
+		pkt = &Packet{
+			SizeBytes: size,
+			Timestamp: now,
+		}
+	}
+
+	next := g.nextFrame
+	if g.remaining > 0 {
+		next = g.nextVideo
+	}
+	if g.nextInput.Before(next) {
+		next = g.nextInput
+	}
+	return pkt, next
+}
+
+// frameSize draws the size of a video frame, log-normal around the mean frame size
+func (g *CloudGamingTraffic) frameSize() int {
+	mean := g.Bitrate / g.FrameRate / 8
+	factor := math.Exp(g.Random.NormFloat64()*gamingFrameSigma - gamingFrameSigma*gamingFrameSigma/2)
+	return max(int(mean*factor), 1)
+}
@@ -0,0 +1,337 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package trafficgen
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+)
+
+var start = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// trace records the packets emitted by a generator
+type trace struct {
+	duration  time.Duration
+	packets   []Packet
+	ulPackets int
+	ulBytes   int
+	dlPackets int
+	dlBytes   int
+	completed bool
+}
+
+// run drives the generator for duration, calling it at the emission times it returns
+func run(gen TrafficGenerator, duration time.Duration) *trace {
+	t := &trace{duration: duration}
+	for now := start; now.Before(start.Add(duration)); {
+		pkt, next := gen.NextPacket(now)
+		if next.IsZero() {
+			t.completed = true
+		} else if next.Before(now) {
+			panic("generator going back in time")
+		}
+		if pkt != nil {
+			t.record(pkt)
+		}
+		if t.completed {
+			break
+		}
+		now = next
+	}
+	return t
+}
+
+func (t *trace) record(pkt *Packet) {
+	t.packets = append(t.packets, *pkt)
+	if pkt.Reverse {
+		t.ulPackets++
+		t.ulBytes += pkt.SizeBytes
+	} else {
+		t.dlPackets++
+		t.dlBytes += pkt.SizeBytes
+	}
+}
+
+// bitrate returns the average bitrate in the session direction between from and to
+func (t *trace) bitrate(from, to time.Duration) float64 {
+	bytes := 0
+	for _, pkt := range t.packets {
+		at := pkt.Timestamp.Sub(start)
+		if !pkt.Reverse && at >= from && at < to {
+			bytes += pkt.SizeBytes
+		}
+	}
+	return float64(bytes*8) / (to - from).Seconds()
+}
+
+// gaps returns the silences longer than threshold between two packets
+func (t *trace) gaps(threshold time.Duration) []time.Duration {
+	gaps := []time.Duration{}
+	for i := 1; i < len(t.packets); i++ {
+		if gap := t.packets[i].Timestamp.Sub(t.packets[i-1].Timestamp); gap > threshold {
+			gaps = append(gaps, gap)
+		}
+	}
+	return gaps
+}
+
+func assertClose(t *testing.T, name string, got, want, tolerance float64) {
+	t.Helper()
+	if math.Abs(got-want) > tolerance*math.Abs(want) {
+		t.Errorf("%s = %.4g, want %.4g ± %.0f%%", name, got, want, tolerance*100)
+	}
+}
+
+func TestDistributionMoments(t *testing.T) {
+	const samples = 200000
+	cases := []struct {
+		name string
+		dist Distribution
+		mean float64
+	}{
+		{"uniform", Distribution{Type: Uniform, Min: 10, Max: 30}, 20},
+		{"exponential", Distribution{Type: Exponential, Mean: 5}, 5},
+		{"normal", Distribution{Type: Normal, Mean: 1200, StdDev: 100}, 1200},
+		{"lognormal", Distribution{Type: LogNormal, Mu: 1, Sigma: 0.5}, math.Exp(1.125)},
+		{"pareto", Distribution{Type: Pareto, Shape: 3, Scale: 2}, 3},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.dist.Validate(); err != nil {
+				t.Fatal(err)
+			}
+			random := utils.NewRandom(1)
+			sum := 0.0
+			for range samples {
+				sum += c.dist.Sample(random)
+			}
+			assertClose(t, "mean", sum/samples, c.mean, 0.02)
+		})
+	}
+}
+
+func TestParetoTail(t *testing.T) {
+	// P(X > x) = (scale / x)^shape
+	const samples = 200000
+	dist := Distribution{Type: Pareto, Shape: 1.2, Scale: 8000}
+	random := utils.NewRandom(1)
+	for _, x := range []float64{16000, 80000, 800000} {
+		above := 0
+		for range samples {
+			if dist.Sample(random) > x {
+				above++
+			}
+		}
+		want := math.Pow(dist.Scale/x, dist.Shape)
+		assertClose(t, "tail probability", float64(above)/samples, want, 0.1)
+	}
+}
+
+func TestParetoWebTraffic(t *testing.T) {
+	think := &Distribution{Type: Pareto, Shape: 2.5, Scale: 1}
+	gen := NewParetoWebTraffic(8e6, Fixed(1000), Fixed(10000), Fixed(3), think)
+	tr := run(gen, time.Hour)
+
+	// a page of 30 kB takes 30 ms to download, followed by the think time
+	pageTime := 0.03 + think.Shape*think.Scale/(think.Shape-1)
+	assertClose(t, "average bitrate", tr.bitrate(0, time.Hour), 30000*8/pageTime, 0.1)
+
+	// reading times are the silences, their tail follows the think time distribution
+	gaps := tr.gaps(500 * time.Millisecond)
+	if len(gaps) < 1000 {
+		t.Fatalf("only %d pages downloaded", len(gaps))
+	}
+	above := 0
+	for _, gap := range gaps {
+		if gap > 2*time.Second {
+			above++
+		}
+	}
+	assertClose(t, "P(think > 2s)", float64(above)/float64(len(gaps)), math.Pow(0.5, think.Shape), 0.2)
+
+	// packets within a page are paced at the link bitrate
+	for i := 1; i < len(tr.packets); i++ {
+		gap := tr.packets[i].Timestamp.Sub(tr.packets[i-1].Timestamp)
+		if gap < time.Millisecond-time.Microsecond {
+			t.Fatalf("packets %d and %d sent %v apart, faster than the link bitrate", i-1, i, gap)
+		}
+	}
+}
+
+func TestAbrVideoTraffic(t *testing.T) {
+	ladder := []float64{1e6, 2e6, 4e6, 8e6}
+	gen := NewAbrVideoTraffic(ladder, Fixed(2), Fixed(6e6), Fixed(1460), 20)
+	tr := run(gen, 10*time.Minute)
+
+	// the player settles on the highest bitrate below 80% of the throughput
+	if gen.Bitrate != 4e6 {
+		t.Errorf("selected bitrate %g, want 4e6", gen.Bitrate)
+	}
+	if gen.Stalls != 0 {
+		t.Errorf("%d stalls with a throughput above the selected bitrate", gen.Stalls)
+	}
+	// the buffer is full after the first minute, then the download rate follows the video bitrate
+	assertClose(t, "steady state bitrate", tr.bitrate(2*time.Minute, 10*time.Minute), 4e6, 0.05)
+
+	// segments are downloaded in bursts at the throughput, 1.33 s every 2 s
+	gaps := tr.gaps(100 * time.Millisecond)
+	if len(gaps) < 200 {
+		t.Fatalf("got %d idle periods, want one per segment", len(gaps))
+	}
+	assertClose(t, "idle period", gaps[len(gaps)-1].Seconds(), 2-4.0*2/6, 0.05)
+}
+
+func TestAbrVideoAdaptsToThroughput(t *testing.T) {
+	ladder := []float64{1e6, 2e6, 4e6, 8e6}
+	gen := NewAbrVideoTraffic(ladder, Fixed(2), Fixed(1.5e6), Fixed(1460), 20)
+	run(gen, 5*time.Minute)
+
+	if gen.Bitrate != 1e6 {
+		t.Errorf("selected bitrate %g, want 1e6", gen.Bitrate)
+	}
+	if gen.Stalls != 0 {
+		t.Errorf("%d stalls", gen.Stalls)
+	}
+}
+
+func TestCloudGamingTraffic(t *testing.T) {
+	gen := NewCloudGamingTraffic(20e6, 60, Fixed(1400), 125, Fixed(60))
+	tr := run(gen, time.Minute)
+
+	assertClose(t, "video bitrate", tr.bitrate(0, time.Minute), 20e6, 0.03)
+	assertClose(t, "input rate", float64(tr.ulPackets)/60, 125, 0.01)
+	assertClose(t, "input bitrate", float64(tr.ulBytes*8)/60, 125*60*8, 0.01)
+
+	// inputs are never delayed by the video frames
+	var last time.Time
+	for _, pkt := range tr.packets {
+		if !pkt.Reverse {
+			continue
+		}
+		if !last.IsZero() && pkt.Timestamp.Sub(last) > 8*time.Millisecond+time.Microsecond {
+			t.Fatalf("input delayed, %v between two inputs", pkt.Timestamp.Sub(last))
+		}
+		last = pkt.Timestamp
+	}
+
+	// frames are sent as bursts, the video is idle for most of each frame interval
+	video := &trace{}
+	for _, pkt := range tr.packets {
+		if !pkt.Reverse {
+			video.packets = append(video.packets, pkt)
+		}
+	}
+	assertClose(t, "frames", float64(len(video.gaps(5*time.Millisecond))), 60*60, 0.05)
+}
+
+func TestFileTransferTraffic(t *testing.T) {
+	gen := NewFileTransferTraffic(10e6, Fixed(1460), Fixed(5e6), nil)
+	tr := run(gen, 10*time.Second)
+
+	if !tr.completed {
+		t.Fatal("transfer not completed")
+	}
+	if tr.dlBytes != 5e6 {
+		t.Errorf("transferred %d bytes, want 5e6", tr.dlBytes)
+	}
+	last := tr.packets[len(tr.packets)-1].Timestamp.Sub(start)
+	assertClose(t, "transfer time", last.Seconds(), 4, 0.01)
+}
+
+func TestFileTransferThinkTime(t *testing.T) {
+	gen := NewFileTransferTraffic(10e6, Fixed(1460), Fixed(1e6), Fixed(9.2))
+	tr := run(gen, 100*time.Second)
+
+	// one 0.8 s transfer every 10 s
+	if tr.completed {
+		t.Fatal("transfer with think time completed")
+	}
+	assertClose(t, "files", float64(len(tr.gaps(time.Second))+1), 10, 0.01)
+}
+
+func TestVoLTETraffic(t *testing.T) {
+	talk := &Distribution{Type: Exponential, Mean: 1.004}
+	silence := &Distribution{Type: Exponential, Mean: 1.587}
+	gen := NewVoLTETraffic(Fixed(73), 20*time.Millisecond, talk, silence)
+	tr := run(gen, 6*time.Hour)
+
+	voice, sid := 0, 0
+	for _, pkt := range tr.packets {
+		switch pkt.SizeBytes {
+		case 73:
+			voice++
+		case volteSidSize:
+			sid++
+		default:
+			t.Fatalf("unexpected packet of %d bytes", pkt.SizeBytes)
+		}
+	}
+
+	activity := talk.Mean / (talk.Mean + silence.Mean)
+	seconds := tr.duration.Seconds()
+	assertClose(t, "voice activity", float64(voice)*0.02/seconds, activity, 0.05)
+	// silences also end with a descriptor
+	assertClose(t, "silence descriptors", float64(sid)*volteSidInterval.Seconds()/seconds, 1-activity, 0.1)
+}
+
+func TestMmppTraffic(t *testing.T) {
+	states := []MmppState{
+		{PacketRate: 100, MeanDurationSec: 1},
+		{PacketRate: 10, MeanDurationSec: 3},
+	}
+	gen := NewMmppTraffic(states, Fixed(500))
+	tr := run(gen, 4*time.Hour)
+
+	// time average of the state rates
+	mean := (100*1 + 10*3) / 4.0
+	assertClose(t, "packet rate", float64(tr.dlPackets)/tr.duration.Seconds(), mean, 0.05)
+
+	// the modulation makes the counts over-dispersed, a Poisson process has an index of dispersion of 1
+	counts := make([]float64, int(tr.duration.Seconds()))
+	for _, pkt := range tr.packets {
+		counts[int(pkt.Timestamp.Sub(start).Seconds())]++
+	}
+	sum, sumSq := 0.0, 0.0
+	for _, count := range counts {
+		sum += count
+		sumSq += count * count
+	}
+	n := float64(len(counts))
+	variance := sumSq/n - (sum/n)*(sum/n)
+	if dispersion := variance / (sum / n); dispersion < 5 {
+		t.Errorf("index of dispersion %.2f, want bursty traffic", dispersion)
+	}
+}
+
+func TestDefaultProfiles(t *testing.T) {
+	catalog, err := NewCatalog(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range catalog.Names() {
+		profile, _ := catalog.Get(name)
+		if err := profile.Validate(); err != nil {
+			t.Errorf("profile %s: %v", name, err)
+		}
+	}
+}
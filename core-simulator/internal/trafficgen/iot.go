@@ -19,18 +19,21 @@
 
 package trafficgen
 
-import "time"
+import (
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+)
 
 // IoTTraffic simulates periodic status updates from IoT devices
 type IoTTraffic struct {
-	PacketSize        int           // bytes
-	HeartbeatInterval time.Duration // interval between updates
-
-	lastPacketTime time.Time
+	PacketSize        *Distribution // bytes
+	HeartbeatInterval *Distribution // interval between updates (seconds)
+	Random            *utils.Random // source of the draws, the process source when nil
 }
 
 // NewIoTTraffic creates an IoT traffic generator
-func NewIoTTraffic(pktSize int, interval time.Duration) *IoTTraffic {
+func NewIoTTraffic(pktSize *Distribution, interval *Distribution) *IoTTraffic {
 	return &IoTTraffic{
 		PacketSize:        pktSize,
 		HeartbeatInterval: interval,
@@ -38,13 +41,9 @@ func NewIoTTraffic(pktSize int, interval time.Duration) *IoTTraffic {
 }
 
 // NextPacket emits a packet periodically
-func (i *IoTTraffic) NextPacket(now time.Time) *Packet {
-	if now.Sub(i.lastPacketTime) >= i.HeartbeatInterval {
-		i.lastPacketTime = now
-		return &Packet{
-			SizeBytes: i.PacketSize,
-			Timestamp: now,
-		}
-	}
-	return nil
+func (i *IoTTraffic) NextPacket(now time.Time) (*Packet, time.Time) {
+	return &Packet{
+		SizeBytes: i.PacketSize.SampleInt(i.Random, 1),
+		Timestamp: now,
+	}, now.Add(i.HeartbeatInterval.SampleDuration(i.Random))
 }
@@ -0,0 +1,87 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package trafficgen
+
+import (
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+)
+
+// MmppState is a state of a Markov modulated Poisson process
+type MmppState struct {
+	PacketRate      float64 `yaml:"packetRate" json:"packetRate"`
+	MeanDurationSec float64 `yaml:"meanDurationSec" json:"meanDurationSec"`
+}
+
+// MmppTraffic emits packets as a Markov modulated Poisson process: the packet rate
+// depends on a state cycling through the configured states after exponentially
+// distributed durations, which produces bursty traffic.
+type MmppTraffic struct {
+	States     []MmppState
+	PacketSize *Distribution // bytes
+	Random     *utils.Random // source of the draws, the process source when nil
+
+	state    int
+	stateEnd time.Time
+}
+
+// NewMmppTraffic creates a MMPP generator
+func NewMmppTraffic(states []MmppState, pktSize *Distribution) *MmppTraffic {
+	return &MmppTraffic{
+		States:     states,
+		PacketSize: pktSize,
+	}
+}
+
+// NextPacket emits a packet and draws the arrival of the next one,
+// the session starts without packet
+func (m *MmppTraffic) NextPacket(now time.Time) (*Packet, time.Time) {
+	if m.stateEnd.IsZero() {
+		m.stateEnd = now.Add(m.stateDuration())
+		return nil, m.schedule(now)
+	}
+
+	return &Packet{
+		SizeBytes: m.PacketSize.SampleInt(m.Random, 1),
+		Timestamp: now,
+	}, m.schedule(now)
+}
+
+// schedule draws the next packet after from. Poisson processes are memoryless,
+// so a packet that would fall after the end of the state is drawn again from
+// the start of the following state.
+func (m *MmppTraffic) schedule(from time.Time) time.Time {
+	for {
+		if rate := m.States[m.state].PacketRate; rate > 0 {
+			next := from.Add(seconds(m.Random.ExpFloat64() / rate))
+			if next.Before(m.stateEnd) {
+				return next
+			}
+		}
+		from = m.stateEnd
+		m.state = (m.state + 1) % len(m.States)
+		m.stateEnd = from.Add(m.stateDuration())
+	}
+}
+
+func (m *MmppTraffic) stateDuration() time.Duration {
+	return seconds(m.Random.ExpFloat64() * m.States[m.state].MeanDurationSec)
+}
@@ -0,0 +1,79 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package trafficgen
+
+import (
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+)
+
+// ParetoWebTraffic simulates web browsing with heavy-tailed page sizes and think times.
+// Every page is made of a number of objects downloaded back to back at the link bitrate,
+// followed by a reading period before the next page is requested.
+type ParetoWebTraffic struct {
+	Bitrate        float64       // bits per second while downloading
+	PacketSize     *Distribution // bytes
+	ObjectSize     *Distribution // bytes, Pareto by default
+	ObjectsPerPage *Distribution // objects of a page, main object included
+	ThinkTime      *Distribution // reading time between two pages (seconds)
+	Random         *utils.Random // source of the draws, the process source when nil
+
+	remaining int // bytes left in the current page
+}
+
+// NewParetoWebTraffic creates a heavy-tailed web browsing generator
+func NewParetoWebTraffic(bitrate float64, pktSize, objectSize, objects, think *Distribution) *ParetoWebTraffic {
+	return &ParetoWebTraffic{
+		Bitrate:        bitrate,
+		PacketSize:     pktSize,
+		ObjectSize:     objectSize,
+		ObjectsPerPage: objects,
+		ThinkTime:      think,
+	}
+}
+
+// NextPacket emits the next packet of the current page, the last packet
+// of a page is followed by the reading time
+func (w *ParetoWebTraffic) NextPacket(now time.Time) (*Packet, time.Time) {
+	if w.remaining == 0 {
+		w.remaining = w.pageSize()
+	}
+
+	size := min(w.PacketSize.SampleInt(w.Random, 1), w.remaining)
+	w.remaining -= size
+	next := now.Add(transmission(size, w.Bitrate))
+	if w.remaining == 0 {
+		next = next.Add(w.ThinkTime.SampleDuration(w.Random))
+	}
+
+	return &Packet{
+		SizeBytes: size,
+		Timestamp: now,
+	}, next
+}
+
+func (w *ParetoWebTraffic) pageSize() int {
+	size := 0
+	for range w.ObjectsPerPage.SampleInt(w.Random, 1) {
+		size += w.ObjectSize.SampleInt(w.Random, 1)
+	}
+	return size
+}
@@ -0,0 +1,410 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package trafficgen
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+)
+
+/* Traffic profiles and registry of the generator types */
+
+// Profile describes the traffic of an application. The generator selects the
+// traffic model, the other fields are its parameters: the ones a generator does
+// not use are ignored. Values are drawn for every session, so that two sessions
+// of the same profile do not behave identically.
+type Profile struct {
+	Generator string `yaml:"generator" json:"generator"`
+	// bits per second, while active for on/off generators
+	Bitrate *Distribution `yaml:"bitrate,omitempty" json:"bitrate,omitempty"`
+	// bytes
+	PacketSize *Distribution `yaml:"packetSize,omitempty" json:"packetSize,omitempty"`
+	// packets per second
+	PacketRate *Distribution `yaml:"packetRate,omitempty" json:"packetRate,omitempty"`
+	// seconds between two packets of periodic generators
+	Interval *Distribution `yaml:"interval,omitempty" json:"interval,omitempty"`
+	// seconds of activity and silence of on/off generators
+	OnPeriod  *Distribution `yaml:"onPeriod,omitempty" json:"onPeriod,omitempty"`
+	OffPeriod *Distribution `yaml:"offPeriod,omitempty" json:"offPeriod,omitempty"`
+	// session duration in seconds, sessions last until the PDU Session is released when unset
+	Duration *Distribution `yaml:"duration,omitempty" json:"duration,omitempty"`
+
+	// PARETO_WEB: object size in bytes, objects per page and reading time in seconds
+	ObjectSize     *Distribution `yaml:"objectSize,omitempty" json:"objectSize,omitempty"`
+	ObjectsPerPage *Distribution `yaml:"objectsPerPage,omitempty" json:"objectsPerPage,omitempty"`
+	ThinkTime      *Distribution `yaml:"thinkTime,omitempty" json:"thinkTime,omitempty"`
+	// ABR_VIDEO: video bitrates in bits per second, segment duration, available throughput
+	// in bits per second and maximum buffered video in seconds
+	BitrateLadder   []float64     `yaml:"bitrateLadder,omitempty" json:"bitrateLadder,omitempty"`
+	SegmentDuration *Distribution `yaml:"segmentDuration,omitempty" json:"segmentDuration,omitempty"`
+	Throughput      *Distribution `yaml:"throughput,omitempty" json:"throughput,omitempty"`
+	BufferSec       float64       `yaml:"bufferSec,omitempty" json:"bufferSec,omitempty"`
+	// CLOUD_GAMING: video frames per second, input packets per second and their size in bytes
+	FrameRate *Distribution `yaml:"frameRate,omitempty" json:"frameRate,omitempty"`
+	InputRate *Distribution `yaml:"inputRate,omitempty" json:"inputRate,omitempty"`
+	InputSize *Distribution `yaml:"inputSize,omitempty" json:"inputSize,omitempty"`
+	// FILE_TRANSFER: file size in bytes
+	FileSize *Distribution `yaml:"fileSize,omitempty" json:"fileSize,omitempty"`
+	// MMPP: states of the packet process
+	States []MmppState `yaml:"states,omitempty" json:"states,omitempty"`
+}
+
+// A Factory builds a generator from a profile, drawing the per-session parameters from random
+type Factory func(profile *Profile, random *utils.Random) (TrafficGenerator, error)
+
+var (
+	generators      = map[string]Factory{}
+	generatorsMutex sync.RWMutex
+)
+
+// RegisterGenerator makes a generator type available to the traffic profiles
+func RegisterGenerator(name string, factory Factory) {
+	generatorsMutex.Lock()
+	defer generatorsMutex.Unlock()
+	generators[name] = factory
+}
+
+// Generators returns the registered generator types
+func Generators() []string {
+	generatorsMutex.RLock()
+	defer generatorsMutex.RUnlock()
+	names := make([]string, 0, len(generators))
+	for name := range generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	RegisterGenerator("VIDEO", func(p *Profile, random *utils.Random) (TrafficGenerator, error) {
+		if err := p.require("bitrate", "packetSize"); err != nil {
+			return nil, err
+		}
+		gen := NewVideoTraffic(positive(random, p.Bitrate), p.PacketSize)
+		gen.Random = random
+		return gen, nil
+	})
+	RegisterGenerator("WEB", func(p *Profile, random *utils.Random) (TrafficGenerator, error) {
+		if err := p.require("bitrate", "packetSize", "onPeriod", "offPeriod"); err != nil {
+			return nil, err
+		}
+		gen := NewWebTraffic(p.Bitrate, p.PacketSize, p.OnPeriod, p.OffPeriod)
+		gen.Random = random
+		return gen, nil
+	})
+	RegisterGenerator("IOT", func(p *Profile, random *utils.Random) (TrafficGenerator, error) {
+		if err := p.require("packetSize", "interval"); err != nil {
+			return nil, err
+		}
+		gen := NewIoTTraffic(p.PacketSize, p.Interval)
+		gen.Random = random
+		return gen, nil
+	})
+	RegisterGenerator("VOIP", func(p *Profile, random *utils.Random) (TrafficGenerator, error) {
+		if err := p.require("packetSize", "packetRate"); err != nil {
+			return nil, err
+		}
+		gen := NewVoIPTraffic(p.PacketSize, positive(random, p.PacketRate))
+		gen.Random = random
+		return gen, nil
+	})
+
+	// the following generators default to typical values for the parameters not configured
+	RegisterGenerator("PARETO_WEB", func(p *Profile, random *utils.Random) (TrafficGenerator, error) {
+		gen := NewParetoWebTraffic(
+			positive(random, or(p.Bitrate, Fixed(10e6))),
+			or(p.PacketSize, Fixed(1460)),
+			or(p.ObjectSize, &Distribution{Type: Pareto, Shape: 1.2, Scale: 8e3, Max: 2e6}),
+			or(p.ObjectsPerPage, &Distribution{Type: Pareto, Shape: 1.1, Scale: 2, Max: 55}),
+			or(p.ThinkTime, &Distribution{Type: Pareto, Shape: 1.5, Scale: 5, Max: 600}),
+		)
+		gen.Random = random
+		return gen, nil
+	})
+	RegisterGenerator("ABR_VIDEO", func(p *Profile, random *utils.Random) (TrafficGenerator, error) {
+		ladder := p.BitrateLadder
+		if len(ladder) == 0 {
+			ladder = []float64{0.4e6, 0.8e6, 1.5e6, 3e6, 6e6, 12e6}
+		}
+		for _, bitrate := range ladder {
+			if bitrate <= 0 {
+				return nil, fmt.Errorf("%s generator requires positive bitrates in the bitrate ladder", p.Generator)
+			}
+		}
+		bufferSec := p.BufferSec
+		if bufferSec == 0 {
+			bufferSec = 30
+		}
+		if bufferSec < 0 {
+			return nil, fmt.Errorf("%s generator requires a positive buffer", p.Generator)
+		}
+		gen := NewAbrVideoTraffic(
+			ladder,
+			or(p.SegmentDuration, Fixed(4)),
+			or(p.Throughput, &Distribution{Type: LogNormal, Mu: math.Log(8e6), Sigma: 0.5}),
+			or(p.PacketSize, Fixed(1460)),
+			bufferSec,
+		)
+		gen.Random = random
+		return gen, nil
+	})
+	RegisterGenerator("CLOUD_GAMING", func(p *Profile, random *utils.Random) (TrafficGenerator, error) {
+		gen := NewCloudGamingTraffic(
+			positive(random, or(p.Bitrate, &Distribution{Type: Uniform, Min: 15e6, Max: 35e6})),
+			positive(random, or(p.FrameRate, Fixed(60))),
+			or(p.PacketSize, Fixed(1400)),
+			positive(random, or(p.InputRate, Fixed(125))),
+			or(p.InputSize, Fixed(60)),
+		)
+		gen.Random = random
+		return gen, nil
+	})
+	RegisterGenerator("FILE_TRANSFER", func(p *Profile, random *utils.Random) (TrafficGenerator, error) {
+		gen := NewFileTransferTraffic(
+			positive(random, or(p.Bitrate, Fixed(50e6))),
+			or(p.PacketSize, Fixed(1460)),
+			or(p.FileSize, &Distribution{Type: LogNormal, Mu: math.Log(20e6), Sigma: 1, Max: 4e9}),
+			p.ThinkTime,
+		)
+		gen.Random = random
+		return gen, nil
+	})
+	RegisterGenerator("VOLTE", func(p *Profile, random *utils.Random) (TrafficGenerator, error) {
+		// AMR-WB 12.65 kbps frames with IPv4/UDP/RTP headers, ITU-T P.59 conversational speech
+		gen := NewVoLTETraffic(
+			or(p.PacketSize, Fixed(73)),
+			or(p.Interval, Fixed(0.02)).SampleDuration(random),
+			or(p.OnPeriod, &Distribution{Type: Exponential, Mean: 1.004}),
+			or(p.OffPeriod, &Distribution{Type: Exponential, Mean: 1.587}),
+		)
+		gen.Random = random
+		return gen, nil
+	})
+	RegisterGenerator("MMPP", func(p *Profile, random *utils.Random) (TrafficGenerator, error) {
+		active := false
+		for i, state := range p.States {
+			if state.PacketRate < 0 || state.MeanDurationSec <= 0 {
+				return nil, fmt.Errorf("%s state %d requires a non negative packet rate and a positive mean duration", p.Generator, i)
+			}
+			active = active || state.PacketRate > 0
+		}
+		if !active {
+			return nil, fmt.Errorf("%s generator requires a state with a positive packet rate", p.Generator)
+		}
+		gen := NewMmppTraffic(p.States, or(p.PacketSize, Fixed(1000)))
+		gen.Random = random
+		return gen, nil
+	})
+}
+
+// defaultProfiles are always available, the configuration may override them
+func defaultProfiles() map[string]*Profile {
+	return map[string]*Profile{
+		"web": {
+			Generator:  "WEB",
+			Bitrate:    Fixed(2e6),
+			PacketSize: Fixed(1200),
+			OnPeriod:   Fixed(20),
+			OffPeriod:  Fixed(40),
+		},
+		"video": {
+			Generator:  "VIDEO",
+			Bitrate:    Fixed(8e6),
+			PacketSize: Fixed(1300),
+		},
+		"iot": {
+			Generator:  "IOT",
+			PacketSize: Fixed(1000),
+			Interval:   Fixed(15),
+		},
+		"sip": {
+			Generator:  "VOIP",
+			PacketSize: Fixed(600),
+			PacketRate: Fixed(50),
+		},
+		"browsing":      {Generator: "PARETO_WEB"},
+		"streaming":     {Generator: "ABR_VIDEO"},
+		"gaming":        {Generator: "CLOUD_GAMING"},
+		"file-transfer": {Generator: "FILE_TRANSFER"},
+		"volte":         {Generator: "VOLTE"},
+	}
+}
+
+// Validate checks the distributions of the profile and its generator type
+func (p *Profile) Validate() error {
+	generatorsMutex.RLock()
+	_, ok := generators[p.Generator]
+	generatorsMutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("unknown traffic generator %q, supported: %v", p.Generator, Generators())
+	}
+
+	for _, name := range profileParams {
+		dist := p.param(name)
+		if dist == nil {
+			continue
+		}
+		if err := dist.Validate(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	// building a generator checks the parameters required by its type
+	_, err := p.NewGenerator(nil)
+	return err
+}
+
+// NewGenerator builds a traffic generator for a new session of the profile, drawing from random
+func (p *Profile) NewGenerator(random *utils.Random) (TrafficGenerator, error) {
+	generatorsMutex.RLock()
+	factory, ok := generators[p.Generator]
+	generatorsMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown traffic generator %q", p.Generator)
+	}
+	return factory(p, random)
+}
+
+// SessionDuration draws the duration of a session, zero means unlimited
+func (p *Profile) SessionDuration(random *utils.Random) time.Duration {
+	if p.Duration == nil {
+		return 0
+	}
+	return p.Duration.SampleDuration(random)
+}
+
+// A Catalog holds the traffic profiles of a simulation
+type Catalog struct {
+	profiles map[string]*Profile
+}
+
+// NewCatalog validates the configured profiles and merges them with the default ones
+func NewCatalog(custom map[string]*Profile) (*Catalog, error) {
+	profiles := defaultProfiles()
+	for name, profile := range custom {
+		if profile == nil {
+			return nil, fmt.Errorf("traffic profile %q is empty", name)
+		}
+		if err := profile.Validate(); err != nil {
+			return nil, fmt.Errorf("traffic profile %q: %w", name, err)
+		}
+		profiles[name] = profile
+	}
+	return &Catalog{profiles: profiles}, nil
+}
+
+// Get returns the profile with the given name
+func (c *Catalog) Get(name string) (*Profile, bool) {
+	profile, ok := c.profiles[name]
+	return profile, ok
+}
+
+// IsProfileSupported reports whether a traffic profile name is known
+func (c *Catalog) IsProfileSupported(name string) bool {
+	_, ok := c.profiles[name]
+	return ok
+}
+
+// Names returns the sorted names of the profiles
+func (c *Catalog) Names() []string {
+	names := make([]string, 0, len(c.profiles))
+	for name := range c.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var profileParams = []string{
+	"bitrate", "packetSize", "packetRate", "interval", "onPeriod", "offPeriod", "duration",
+	"objectSize", "objectsPerPage", "thinkTime", "segmentDuration", "throughput",
+	"frameRate", "inputRate", "inputSize", "fileSize",
+}
+
+// param returns the distribution of a parameter from its configuration name
+func (p *Profile) param(name string) *Distribution {
+	switch name {
+	case "bitrate":
+		return p.Bitrate
+	case "packetSize":
+		return p.PacketSize
+	case "packetRate":
+		return p.PacketRate
+	case "interval":
+		return p.Interval
+	case "onPeriod":
+		return p.OnPeriod
+	case "offPeriod":
+		return p.OffPeriod
+	case "duration":
+		return p.Duration
+	case "objectSize":
+		return p.ObjectSize
+	case "objectsPerPage":
+		return p.ObjectsPerPage
+	case "thinkTime":
+		return p.ThinkTime
+	case "segmentDuration":
+		return p.SegmentDuration
+	case "throughput":
+		return p.Throughput
+	case "frameRate":
+		return p.FrameRate
+	case "inputRate":
+		return p.InputRate
+	case "inputSize":
+		return p.InputSize
+	case "fileSize":
+		return p.FileSize
+	}
+	return nil
+}
+
+// require checks that the parameters used by a generator are set
+func (p *Profile) require(names ...string) error {
+	for _, name := range names {
+		if p.param(name) == nil {
+			return fmt.Errorf("%s generator requires %s", p.Generator, name)
+		}
+	}
+	return nil
+}
+
+// or returns the configured distribution, or the default one when unset
+func or(d *Distribution, def *Distribution) *Distribution {
+	if d != nil {
+		return d
+	}
+	return def
+}
+
+// positive draws a strictly positive value, used for rates
+func positive(random *utils.Random, d *Distribution) float64 {
+	if value := d.Sample(random); value > 0 {
+		return value
+	}
+	return 1
+}
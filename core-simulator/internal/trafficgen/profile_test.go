@@ -0,0 +1,93 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package trafficgen
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestProfileValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		valid   bool
+	}{
+		{"video", Profile{Generator: "VIDEO", Bitrate: Fixed(4e6), PacketSize: Fixed(1300)}, true},
+		{"web with distributions", Profile{Generator: "WEB", Bitrate: &Distribution{Type: Uniform, Min: 1e6, Max: 4e6}, PacketSize: Fixed(1200),
+			OnPeriod: &Distribution{Type: Exponential, Mean: 10}, OffPeriod: &Distribution{Type: Pareto, Shape: 1.5, Scale: 5}}, true},
+		{"iot with a session duration", Profile{Generator: "IOT", PacketSize: Fixed(100), Interval: Fixed(60), Duration: Fixed(600)}, true},
+		{"defaulted parameters", Profile{Generator: "PARETO_WEB"}, true},
+		{"abr video ladder", Profile{Generator: "ABR_VIDEO", BitrateLadder: []float64{1e6, 2e6}, BufferSec: 10}, true},
+		{"mmpp", Profile{Generator: "MMPP", States: []MmppState{{PacketRate: 0, MeanDurationSec: 10}, {PacketRate: 100, MeanDurationSec: 1}}}, true},
+		{"unknown generator", Profile{Generator: "TELNET"}, false},
+		{"missing generator", Profile{}, false},
+		{"missing required parameter", Profile{Generator: "VIDEO", Bitrate: Fixed(4e6)}, false},
+		{"negative constant", Profile{Generator: "VOIP", PacketSize: Fixed(-1), PacketRate: Fixed(50)}, false},
+		{"inverted uniform bounds", Profile{Generator: "VIDEO", Bitrate: &Distribution{Type: Uniform, Min: 4e6, Max: 1e6}, PacketSize: Fixed(1300)}, false},
+		{"exponential without mean", Profile{Generator: "IOT", PacketSize: Fixed(100), Interval: &Distribution{Type: Exponential}}, false},
+		{"pareto without shape", Profile{Generator: "PARETO_WEB", ObjectSize: &Distribution{Type: Pareto, Scale: 8e3}}, false},
+		{"unknown distribution", Profile{Generator: "FILE_TRANSFER", FileSize: &Distribution{Type: "ZIPF"}}, false},
+		{"invalid session duration", Profile{Generator: "VOLTE", Duration: &Distribution{Type: Normal, Mean: -1}}, false},
+		{"non positive ladder bitrate", Profile{Generator: "ABR_VIDEO", BitrateLadder: []float64{1e6, 0}}, false},
+		{"negative buffer", Profile{Generator: "ABR_VIDEO", BufferSec: -1}, false},
+		{"mmpp without active state", Profile{Generator: "MMPP", States: []MmppState{{PacketRate: 0, MeanDurationSec: 10}}}, false},
+		{"mmpp state without duration", Profile{Generator: "MMPP", States: []MmppState{{PacketRate: 100}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.profile.Validate(); (err == nil) != tt.valid {
+				t.Fatalf("got error %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestNewCatalog(t *testing.T) {
+	custom := map[string]*Profile{
+		"video":  {Generator: "VIDEO", Bitrate: Fixed(2e6), PacketSize: Fixed(1300)},
+		"sensor": {Generator: "IOT", PacketSize: Fixed(50), Interval: Fixed(300)},
+	}
+	catalog, err := NewCatalog(custom)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if video, _ := catalog.Get("video"); video != custom["video"] {
+		t.Fatalf("got video profile %+v, want the configured one", video)
+	}
+	for _, name := range []string{"sensor", "web", "volte"} {
+		if !catalog.IsProfileSupported(name) {
+			t.Fatalf("profile %s missing from %v", name, catalog.Names())
+		}
+	}
+	if names := catalog.Names(); !slices.IsSorted(names) {
+		t.Fatalf("got unsorted names %v", names)
+	}
+
+	for name, custom := range map[string]map[string]*Profile{
+		"empty profile":    {"sensor": nil},
+		"invalid profile":  {"sensor": {Generator: "IOT", PacketSize: Fixed(50)}},
+		"invalid override": {"web": {Generator: "WEB"}},
+	} {
+		if _, err := NewCatalog(custom); err == nil {
+			t.Fatalf("%s: catalog accepted", name)
+		}
+	}
+}
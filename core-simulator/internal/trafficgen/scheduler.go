@@ -0,0 +1,361 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package trafficgen
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+)
+
+/* Event driven scheduling of the traffic sessions */
+
+const (
+	// interval between two reports of the packets emitted by a session
+	batchInterval = 100 * time.Millisecond
+	// minimum gap between two emissions of a session, protects the scheduler from generators not moving forward
+	minEmissionGap = time.Microsecond
+)
+
+type EndReason string
+
+const (
+	// the duration of the session elapsed
+	SessionExpired EndReason = "expired"
+	// the generator has no packet left
+	SessionCompleted EndReason = "completed"
+	// the context of the session was cancelled
+	SessionCancelled EndReason = "cancelled"
+)
+
+// Batch aggregates the packets emitted by a session between two reports
+type Batch struct {
+	UlPackets int64
+	UlBytes   int64
+	DlPackets int64
+	DlBytes   int64
+	// time of the last packet of each direction
+	LastUl time.Time
+	LastDl time.Time
+}
+
+// Last returns the time of the last packet of the batch
+func (b *Batch) Last() time.Time {
+	if b.LastUl.After(b.LastDl) {
+		return b.LastUl
+	}
+	return b.LastDl
+}
+
+func (b *Batch) add(pkt *Packet, uplink bool, at time.Time) {
+	if uplink {
+		b.UlPackets++
+		b.UlBytes += int64(pkt.SizeBytes)
+		b.LastUl = at
+	} else {
+		b.DlPackets++
+		b.DlBytes += int64(pkt.SizeBytes)
+		b.LastDl = at
+	}
+}
+
+// SessionConfig describes a traffic session driven by the scheduler
+type SessionConfig struct {
+	Generator TrafficGenerator
+	Uplink    bool
+	// zero means the session lasts until its context is cancelled or the generator ends
+	Duration time.Duration
+	// Report receives the packets emitted by the session, aggregated in batches
+	Report func(batch Batch)
+	// OnEnd is called once when the session ends, after the last report
+	OnEnd func(reason EndReason)
+}
+
+type session struct {
+	SessionConfig
+	next    time.Time // next emission, on the scheduler clock
+	end     time.Time // end of the session, zero if unlimited
+	batch   Batch
+	pending bool
+	index   int // position in the queue, -1 once the session ended
+	stopCtx func() bool
+}
+
+// A Scheduler drives all the traffic sessions of a simulation from a single routine.
+// Sessions are kept in a heap ordered by their next emission: the scheduler sleeps until
+// the earliest one, calls its generator and reports the emitted packets in batches.
+// The scheduler clock follows the clock of the simulation and stops while the simulation
+// is paused, so that generators and session durations are not affected by pauses.
+type Scheduler struct {
+	mutex   sync.Mutex
+	queue   sessionQueue
+	pending []*session
+	paused  time.Duration // total time spent paused
+	wakeup  chan struct{}
+	pause   *utils.PauseGate
+	source  *utils.Clock
+}
+
+// NewScheduler creates a scheduler driven by the clock and frozen by the pause gate
+func NewScheduler(pause *utils.PauseGate, clock *utils.Clock) *Scheduler {
+	return &Scheduler{
+		wakeup: make(chan struct{}, 1),
+		pause:  pause,
+		source: clock,
+	}
+}
+
+// clock returns the current time of the scheduler
+func (s *Scheduler) clock() time.Time {
+	return s.source.Now().Add(-s.paused)
+}
+
+// wallTime converts a scheduler time to the clock of the simulation
+func (s *Scheduler) wallTime(at time.Time) time.Time {
+	return at.Add(s.paused)
+}
+
+// Start schedules a new session, which is cancelled with ctx
+func (s *Scheduler) Start(ctx context.Context, cfg SessionConfig) {
+	s.mutex.Lock()
+	now := s.clock()
+	sess := &session{
+		SessionConfig: cfg,
+		next:          now,
+	}
+	if cfg.Duration > 0 {
+		sess.end = now.Add(cfg.Duration)
+	}
+	heap.Push(&s.queue, sess)
+	sess.stopCtx = context.AfterFunc(ctx, func() {
+		s.mutex.Lock()
+		ended := s.remove(sess)
+		s.mutex.Unlock()
+		if ended {
+			sess.finish(SessionCancelled)
+		}
+	})
+	s.mutex.Unlock()
+
+	s.notify()
+}
+
+// Len returns the number of active sessions
+func (s *Scheduler) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.queue.Len()
+}
+
+// Due returns the number of sessions with an emission due by now
+func (s *Scheduler) Due() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	total := 0
+	now := s.clock()
+	for _, sess := range s.queue {
+		if !sess.next.After(now) {
+			total++
+		}
+	}
+	return total
+}
+
+// Run drives the sessions until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	timer := time.NewTimer(batchInterval)
+	defer timer.Stop()
+	flush := time.NewTicker(batchInterval)
+	defer flush.Stop()
+
+	for {
+		if s.pause.IsPaused() {
+			s.flush()
+			pausedAt := s.source.Now().Add(-s.pause.PausedFor())
+			if !s.pause.Wait(ctx) {
+				return
+			}
+			s.mutex.Lock()
+			s.paused += s.source.Since(pausedAt)
+			s.mutex.Unlock()
+		}
+
+		ended := s.emitDue()
+		for _, sess := range ended {
+			sess.finish(sess.reason())
+		}
+
+		advanced := s.source.Advanced()
+		s.mutex.Lock()
+		wait := batchInterval
+		if s.queue.Len() > 0 {
+			wait = min(s.queue[0].next.Sub(s.clock()), wait)
+		}
+		s.mutex.Unlock()
+
+		timer.Reset(wait)
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		case <-s.wakeup:
+		case <-advanced:
+		case <-flush.C:
+			s.flush()
+		}
+	}
+}
+
+// emitDue runs the emissions due by now, it returns the sessions that ended
+func (s *Scheduler) emitDue() []*session {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ended := []*session{}
+	now := s.clock()
+	for s.queue.Len() > 0 && !s.queue[0].next.After(now) {
+		sess := s.queue[0]
+		at := sess.next
+		if !sess.end.IsZero() && !at.Before(sess.end) {
+			s.remove(sess)
+			ended = append(ended, sess)
+			continue
+		}
+
+		pkt, next := sess.Generator.NextPacket(at)
+		if pkt != nil {
+			// interactive applications also send packets in the reverse direction
+			sess.batch.add(pkt, sess.Uplink != pkt.Reverse, s.wallTime(at))
+			if !sess.pending {
+				sess.pending = true
+				s.pending = append(s.pending, sess)
+			}
+		}
+
+		if next.IsZero() {
+			s.remove(sess)
+			ended = append(ended, sess)
+			continue
+		}
+		if next.Sub(at) < minEmissionGap {
+			next = at.Add(minEmissionGap)
+		}
+		// the session expires at its end even if no packet is due
+		if !sess.end.IsZero() && next.After(sess.end) {
+			next = sess.end
+		}
+		sess.next = next
+		heap.Fix(&s.queue, sess.index)
+	}
+	return ended
+}
+
+// remove takes the session out of the queue, it returns false if the session already ended.
+// The packets not reported yet are left to finish.
+func (s *Scheduler) remove(sess *session) bool {
+	if sess.index < 0 {
+		return false
+	}
+	heap.Remove(&s.queue, sess.index)
+	return true
+}
+
+// flush reports the pending batches
+func (s *Scheduler) flush() {
+	s.mutex.Lock()
+	pending := make([]*session, 0, len(s.pending))
+	batches := make([]Batch, 0, len(s.pending))
+	for _, sess := range s.pending {
+		// ended sessions report their last batch themselves
+		if sess.index < 0 {
+			continue
+		}
+		pending = append(pending, sess)
+		batches = append(batches, sess.batch)
+		sess.batch = Batch{}
+		sess.pending = false
+	}
+	s.pending = nil
+	s.mutex.Unlock()
+
+	for i, sess := range pending {
+		if sess.Report != nil {
+			sess.Report(batches[i])
+		}
+	}
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// reason tells why a session taken out of the queue by the scheduler ended
+func (sess *session) reason() EndReason {
+	if !sess.end.IsZero() && !sess.next.Before(sess.end) {
+		return SessionExpired
+	}
+	return SessionCompleted
+}
+
+// finish reports the last packets of an ended session and notifies its end.
+// It is called once, without the scheduler lock, after the session left the queue.
+func (sess *session) finish(reason EndReason) {
+	sess.stopCtx()
+	if sess.pending && sess.Report != nil {
+		sess.Report(sess.batch)
+	}
+	if sess.OnEnd != nil {
+		sess.OnEnd(reason)
+	}
+}
+
+// sessionQueue is a min heap of sessions ordered by next emission
+type sessionQueue []*session
+
+func (q sessionQueue) Len() int           { return len(q) }
+func (q sessionQueue) Less(i, j int) bool { return q[i].next.Before(q[j].next) }
+
+func (q sessionQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *sessionQueue) Push(x any) {
+	sess := x.(*session)
+	sess.index = len(*q)
+	*q = append(*q, sess)
+}
+
+func (q *sessionQueue) Pop() any {
+	old := *q
+	sess := old[len(old)-1]
+	old[len(old)-1] = nil
+	sess.index = -1
+	*q = old[:len(old)-1]
+	return sess
+}
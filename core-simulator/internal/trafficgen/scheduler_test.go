@@ -0,0 +1,185 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package trafficgen
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+)
+
+// recorder collects the reports of a session
+type recorder struct {
+	mutex   sync.Mutex
+	batches int
+	total   Batch
+	ended   chan EndReason
+}
+
+func newRecorder() *recorder {
+	return &recorder{ended: make(chan EndReason, 1)}
+}
+
+func (r *recorder) config(gen TrafficGenerator, uplink bool, duration time.Duration) SessionConfig {
+	return SessionConfig{
+		Generator: gen,
+		Uplink:    uplink,
+		Duration:  duration,
+		Report: func(batch Batch) {
+			r.mutex.Lock()
+			defer r.mutex.Unlock()
+			r.batches++
+			r.total.UlPackets += batch.UlPackets
+			r.total.UlBytes += batch.UlBytes
+			r.total.DlPackets += batch.DlPackets
+			r.total.DlBytes += batch.DlBytes
+		},
+		OnEnd: func(reason EndReason) { r.ended <- reason },
+	}
+}
+
+func (r *recorder) snapshot() (Batch, int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.total, r.batches
+}
+
+func (r *recorder) waitEnd(t *testing.T, timeout time.Duration) EndReason {
+	t.Helper()
+	select {
+	case reason := <-r.ended:
+		return reason
+	case <-time.After(timeout):
+		t.Fatal("session did not end")
+		return ""
+	}
+}
+
+func startScheduler(t *testing.T) (*Scheduler, *utils.PauseGate) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	clock := utils.NewClock()
+	pause := utils.NewPauseGate(clock)
+	scheduler := NewScheduler(pause, clock)
+	go scheduler.Run(ctx)
+	return scheduler, pause
+}
+
+func TestSchedulerBatchesPackets(t *testing.T) {
+	scheduler, _ := startScheduler(t)
+	rec := newRecorder()
+	gen := NewIoTTraffic(Fixed(100), Fixed(0.01))
+	scheduler.Start(context.Background(), rec.config(gen, true, time.Second))
+
+	if reason := rec.waitEnd(t, 2*time.Second); reason != SessionExpired {
+		t.Fatalf("session ended with %s, want %s", reason, SessionExpired)
+	}
+	total, batches := rec.snapshot()
+	if total.UlPackets < 95 || total.UlPackets > 101 || total.DlPackets != 0 {
+		t.Errorf("got %d UL and %d DL packets, want 100 UL packets", total.UlPackets, total.DlPackets)
+	}
+	if total.UlBytes != 100*total.UlPackets {
+		t.Errorf("got %d bytes for %d packets", total.UlBytes, total.UlPackets)
+	}
+	// packets are reported every batch interval, not one by one
+	if batches > 2*int(time.Second/batchInterval) {
+		t.Errorf("%d reports for %d packets", batches, total.UlPackets)
+	}
+	if scheduler.Len() != 0 {
+		t.Errorf("%d sessions left in the scheduler", scheduler.Len())
+	}
+}
+
+func TestSchedulerReverseDirection(t *testing.T) {
+	scheduler, _ := startScheduler(t)
+	rec := newRecorder()
+	gen := NewCloudGamingTraffic(1e6, 50, Fixed(1000), 100, Fixed(50))
+	scheduler.Start(context.Background(), rec.config(gen, false, 500*time.Millisecond))
+
+	rec.waitEnd(t, 2*time.Second)
+	total, _ := rec.snapshot()
+	if total.DlPackets == 0 || total.UlPackets == 0 {
+		t.Errorf("got %d DL video and %d UL input packets", total.DlPackets, total.UlPackets)
+	}
+}
+
+func TestSchedulerCompletedSession(t *testing.T) {
+	scheduler, _ := startScheduler(t)
+	rec := newRecorder()
+	gen := NewFileTransferTraffic(100e6, Fixed(1000), Fixed(1e6), nil)
+	scheduler.Start(context.Background(), rec.config(gen, false, 0))
+
+	if reason := rec.waitEnd(t, 2*time.Second); reason != SessionCompleted {
+		t.Fatalf("session ended with %s, want %s", reason, SessionCompleted)
+	}
+	if total, _ := rec.snapshot(); total.DlBytes != 1e6 {
+		t.Errorf("transferred %d bytes, want 1e6", total.DlBytes)
+	}
+}
+
+func TestSchedulerCancelledSession(t *testing.T) {
+	scheduler, _ := startScheduler(t)
+	rec := newRecorder()
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduler.Start(ctx, rec.config(NewVoIPTraffic(Fixed(100), 50), true, 0))
+
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	if reason := rec.waitEnd(t, time.Second); reason != SessionCancelled {
+		t.Fatalf("session ended with %s, want %s", reason, SessionCancelled)
+	}
+	if total, _ := rec.snapshot(); total.UlPackets == 0 {
+		t.Error("no packet reported before the cancellation")
+	}
+	if scheduler.Len() != 0 {
+		t.Errorf("%d sessions left in the scheduler", scheduler.Len())
+	}
+}
+
+func TestSchedulerPause(t *testing.T) {
+	scheduler, pause := startScheduler(t)
+	rec := newRecorder()
+	scheduler.Start(context.Background(), rec.config(NewVoIPTraffic(Fixed(100), 100), true, 400*time.Millisecond))
+
+	time.Sleep(200 * time.Millisecond)
+	pause.Pause()
+	time.Sleep(50 * time.Millisecond)
+	before, _ := rec.snapshot()
+	time.Sleep(500 * time.Millisecond)
+	after, _ := rec.snapshot()
+	if after.UlPackets != before.UlPackets {
+		t.Errorf("%d packets emitted while paused", after.UlPackets-before.UlPackets)
+	}
+	select {
+	case <-rec.ended:
+		t.Fatal("session expired while paused")
+	default:
+	}
+
+	pause.Resume()
+	rec.waitEnd(t, time.Second)
+	// the session runs 400 ms in total, pause excluded
+	if total, _ := rec.snapshot(); total.UlPackets < 35 || total.UlPackets > 45 {
+		t.Errorf("got %d packets, want 40", total.UlPackets)
+	}
+}
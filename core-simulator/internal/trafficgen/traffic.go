@@ -25,9 +25,23 @@ import "time"
 type Packet struct {
 	SizeBytes int       // Packet size in bytes
 	Timestamp time.Time // Timestamp when generated
+	Reverse   bool      // sent in the opposite direction of the session, for interactive applications
 }
 
-// TrafficGenerator defines the interface for all traffic generators
+// TrafficGenerator defines the interface for all traffic generators.
+// Generators are event driven: NextPacket is first called with the start time of the
+// session, then at each time it returns. It returns the packet emitted at now, if any,
+// and the time of the next emission, or a zero time when the session is over.
 type TrafficGenerator interface {
-	NextPacket(now time.Time) *Packet
+	NextPacket(now time.Time) (*Packet, time.Time)
+}
+
+// seconds converts a floating point number of seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// transmission returns the time needed to send size bytes at bitrate
+func transmission(size int, bitrate float64) time.Duration {
+	return seconds(float64(size*8) / bitrate)
 }
@@ -19,36 +19,33 @@
 
 package trafficgen
 
-import "time"
+import (
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+)
 
 // VideoTraffic simulates steady video streaming traffic
 type VideoTraffic struct {
 	Bitrate    float64       // bits per second
-	PacketSize int           // average packet size (bytes)
-	Interval   time.Duration // inter-packet interval
-
-	lastPacketTime time.Time
+	PacketSize *Distribution // packet size (bytes)
+	Random     *utils.Random // source of the draws, the process source when nil
 }
 
 // NewVideoTraffic creates a new VideoTraffic generator
-func NewVideoTraffic(bitrate float64, pktSize int) *VideoTraffic {
-	pktPerSec := bitrate / float64(pktSize*8)
-	interval := time.Duration(1e9/pktPerSec) * time.Nanosecond
+func NewVideoTraffic(bitrate float64, pktSize *Distribution) *VideoTraffic {
 	return &VideoTraffic{
 		Bitrate:    bitrate,
 		PacketSize: pktSize,
-		Interval:   interval,
 	}
 }
 
-// NextPacket emits the next packet or nil if no packet at this time
-func (v *VideoTraffic) NextPacket(now time.Time) *Packet {
-	if now.Sub(v.lastPacketTime) >= v.Interval {
-		v.lastPacketTime = now
-		return &Packet{
-			SizeBytes: v.PacketSize,
-			Timestamp: now,
-		}
-	}
-	return nil
+// NextPacket emits a packet, the gap to the following packet keeps the bitrate
+// constant whatever the packet size
+func (v *VideoTraffic) NextPacket(now time.Time) (*Packet, time.Time) {
+	size := v.PacketSize.SampleInt(v.Random, 1)
+	return &Packet{
+		SizeBytes: size,
+		Timestamp: now,
+	}, now.Add(transmission(size, v.Bitrate))
 }
@@ -19,18 +19,21 @@
 
 package trafficgen
 
-import "time"
+import (
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+)
 
 // VoIPTraffic simulates voice traffic with small, steady packets
 type VoIPTraffic struct {
-	PacketSize int           // bytes
+	PacketSize *Distribution // bytes
 	Interval   time.Duration // inter-packet interval
-
-	lastPacketTime time.Time
+	Random     *utils.Random // source of the draws, the process source when nil
 }
 
 // NewVoIPTraffic creates a VoIP traffic generator
-func NewVoIPTraffic(pktSize int, pktRate float64) *VoIPTraffic {
+func NewVoIPTraffic(pktSize *Distribution, pktRate float64) *VoIPTraffic {
 	interval := time.Duration(1e9/pktRate) * time.Nanosecond
 	return &VoIPTraffic{
 		PacketSize: pktSize,
@@ -38,14 +41,10 @@ func NewVoIPTraffic(pktSize int, pktRate float64) *VoIPTraffic {
 	}
 }
 
-// NextPacket emits the next packet or nil if no packet at this time
-func (v *VoIPTraffic) NextPacket(now time.Time) *Packet {
-	if now.Sub(v.lastPacketTime) >= v.Interval {
-		v.lastPacketTime = now
-		return &Packet{
-			SizeBytes: v.PacketSize,
-			Timestamp: now,
-		}
-	}
-	return nil
+// NextPacket emits a packet every interval
+func (v *VoIPTraffic) NextPacket(now time.Time) (*Packet, time.Time) {
+	return &Packet{
+		SizeBytes: v.PacketSize.SampleInt(v.Random, 1),
+		Timestamp: now,
+	}, now.Add(v.Interval)
 }
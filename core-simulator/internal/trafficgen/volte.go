@@ -0,0 +1,86 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package trafficgen
+
+import (
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+)
+
+const (
+	// silence descriptor frames (AMR-WB SID with IPv4/UDP/RTP headers), sent every 160 ms
+	volteSidSize     = 47
+	volteSidInterval = 160 * time.Millisecond
+)
+
+// VoLTETraffic simulates a voice call with talk spurts. While talking a voice frame
+// is sent every frame interval, during silences only comfort noise descriptors are sent.
+type VoLTETraffic struct {
+	PacketSize    *Distribution // bytes of the voice frames
+	FrameInterval time.Duration
+	TalkSpurt     *Distribution // seconds
+	Silence       *Distribution // seconds
+	Random        *utils.Random // source of the draws, the process source when nil
+
+	spurtEnd time.Time // end of the current talk spurt or silence
+	talking  bool
+}
+
+// NewVoLTETraffic creates a VoLTE generator
+func NewVoLTETraffic(pktSize *Distribution, interval time.Duration, talk, silence *Distribution) *VoLTETraffic {
+	return &VoLTETraffic{
+		PacketSize:    pktSize,
+		FrameInterval: interval,
+		TalkSpurt:     talk,
+		Silence:       silence,
+	}
+}
+
+// NextPacket emits the next voice or silence descriptor frame
+func (v *VoLTETraffic) NextPacket(now time.Time) (*Packet, time.Time) {
+	if v.spurtEnd.IsZero() {
+		v.talking = true
+		v.spurtEnd = now.Add(v.TalkSpurt.SampleDuration(v.Random))
+	}
+
+	for !now.Before(v.spurtEnd) {
+		v.talking = !v.talking
+		if v.talking {
+			v.spurtEnd = v.spurtEnd.Add(v.TalkSpurt.SampleDuration(v.Random))
+		} else {
+			v.spurtEnd = v.spurtEnd.Add(v.Silence.SampleDuration(v.Random))
+		}
+	}
+
+	size, next := volteSidSize, now.Add(volteSidInterval)
+	if v.talking {
+		size, next = v.PacketSize.SampleInt(v.Random, 1), now.Add(v.FrameInterval)
+	}
+	// the next talk spurt starts on time, and ends with a silence descriptor
+	if v.spurtEnd.Before(next) {
+		next = v.spurtEnd
+	}
+
+	return &Packet{
+		SizeBytes: size,
+		Timestamp: now,
+	}, next
+}
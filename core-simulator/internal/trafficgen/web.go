@@ -19,52 +19,53 @@
 
 package trafficgen
 
-import "time"
+import (
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+)
 
 // WebTraffic simulates bursty HTTP traffic (web browsing)
 type WebTraffic struct {
-	AvgBitrate    float64       // bits per second during burst
-	PacketSize    int           // average packet size (bytes)
-	BurstDuration time.Duration // duration of burst activity
-	IdleDuration  time.Duration // duration of idle period
+	AvgBitrate    *Distribution // bits per second during burst, drawn for every burst
+	PacketSize    *Distribution // packet size (bytes)
+	BurstDuration *Distribution // duration of burst activity (seconds)
+	IdleDuration  *Distribution // duration of idle period (seconds)
+	Random        *utils.Random // source of the draws, the process source when nil
 
-	lastPacketTime time.Time
-	burstEndTime   time.Time
-	inBurst        bool
+	burstEndTime time.Time
+	inBurst      bool
+	bitrate      float64
 }
 
 // NewWebTraffic creates a new WebTraffic generator
-func NewWebTraffic(bitrate float64, pktSize int, burst, idle time.Duration) *WebTraffic {
+func NewWebTraffic(bitrate, pktSize, burst, idle *Distribution) *WebTraffic {
 	return &WebTraffic{
 		AvgBitrate:    bitrate,
 		PacketSize:    pktSize,
 		BurstDuration: burst,
 		IdleDuration:  idle,
-		inBurst:       true,
 	}
 }
 
-// NextPacket emits the next packet or nil if no packet at this time
-func (w *WebTraffic) NextPacket(now time.Time) *Packet {
-	if now.After(w.burstEndTime) {
-		// Switch between burst and idle
-		w.inBurst = !w.inBurst
-		if w.inBurst {
-			w.burstEndTime = now.Add(w.BurstDuration)
-		} else {
-			w.burstEndTime = now.Add(w.IdleDuration)
-		}
+// NextPacket emits the next packet of the burst, or switches between burst and idle
+func (w *WebTraffic) NextPacket(now time.Time) (*Packet, time.Time) {
+	if w.inBurst && !now.Before(w.burstEndTime) {
+		w.inBurst = false
+		return nil, now.Add(w.IdleDuration.SampleDuration(w.Random))
 	}
-
-	if w.inBurst {
-		interval := time.Duration(float64(w.PacketSize*8)/w.AvgBitrate*1e9) * time.Nanosecond
-		if now.Sub(w.lastPacketTime) >= interval {
-			w.lastPacketTime = now
-			return &Packet{
-				SizeBytes: w.PacketSize,
-				Timestamp: now,
-			}
-		}
+	if !w.inBurst {
+		w.inBurst = true
+		w.burstEndTime = now.Add(w.BurstDuration.SampleDuration(w.Random))
+		w.bitrate = w.AvgBitrate.Sample(w.Random)
 	}
-	return nil
+	if w.bitrate <= 0 {
+		return nil, w.burstEndTime
+	}
+
+	size := w.PacketSize.SampleInt(w.Random, 1)
+	return &Packet{
+		SizeBytes: size,
+		Timestamp: now,
+	}, now.Add(transmission(size, w.bitrate))
 }
@@ -0,0 +1,404 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+// Package coresim embeds the core simulator in Go programs and tests. It builds a
+// simulated network with its 3GPP SBI, driven by a controllable clock, without
+// running the core-simulator binary.
+package coresim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/utils"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/simulator"
+	"gopkg.in/yaml.v3"
+)
+
+/* Embeddable simulator */
+
+// Clock is the time source of the simulation, which can be moved forward with Step
+type Clock = utils.Clock
+
+type UeActionType string
+
+const (
+	UeActionRegister           = UeActionType(simulator.UeActionRegister)
+	UeActionDeregister         = UeActionType(simulator.UeActionDeregister)
+	UeActionAttach             = UeActionType(simulator.UeActionAttach)
+	UeActionIdle               = UeActionType(simulator.UeActionIdle)
+	UeActionPaging             = UeActionType(simulator.UeActionPaging)
+	UeActionHandover           = UeActionType(simulator.UeActionHandover)
+	UeActionPduSessionEst      = UeActionType(simulator.UeActionPduSessionEst)
+	UeActionPduSessionRel      = UeActionType(simulator.UeActionPduSessionRel)
+	UeActionNetworkPduSessRel  = UeActionType(simulator.UeActionNetworkPduSessRel)
+	UeActionPduSessionMod      = UeActionType(simulator.UeActionPduSessionMod)
+	UeActionStartTraffic       = UeActionType(simulator.UeActionStartTraffic)
+	UeActionLossOfConnectivity = UeActionType(simulator.UeActionLossOfConnectivity)
+	UeActionPowerOn            = UeActionType(simulator.UeActionPowerOn)
+	UeActionPowerOff           = UeActionType(simulator.UeActionPowerOff)
+	UeActionNetworkDeregister  = UeActionType(simulator.UeActionNetworkDeregister)
+	UeActionBar                = UeActionType(simulator.UeActionBar)
+	UeActionUnbar              = UeActionType(simulator.UeActionUnbar)
+)
+
+// UeAction is a procedure forced on a single UE.
+// Only the parameters relevant to the selected action are taken into account.
+type UeAction struct {
+	Action UeActionType `yaml:"action" json:"action"`
+	// target cell for handovers, either as NR cell id or as index in the gNB list
+	CellId string `yaml:"cellId,omitempty" json:"cellId,omitempty"`
+	Cell   *int   `yaml:"cell,omitempty" json:"cell,omitempty"`
+	// pdu session parameters, defaults to the simulation profile
+	PduSessionId int32   `yaml:"pduSessionId,omitempty" json:"pduSessionId,omitempty"`
+	Dnn          string  `yaml:"dnn,omitempty" json:"dnn,omitempty"`
+	Snssai       *Snssai `yaml:"slice,omitempty" json:"slice,omitempty"`
+	// qos of the default flow for pdu session modifications
+	Qos *SessionQos `yaml:"qos,omitempty" json:"qos,omitempty"`
+	// traffic session parameters
+	TrafficProfile string `yaml:"trafficProfile,omitempty" json:"trafficProfile,omitempty"`
+	Uplink         bool   `yaml:"uplink,omitempty" json:"uplink,omitempty"`
+	DurationSec    uint   `yaml:"durationSec,omitempty" json:"durationSec,omitempty"`
+}
+
+func (a *UeAction) ueAction() *simulator.UeAction {
+	return &simulator.UeAction{
+		Action:         simulator.UeActionType(a.Action),
+		CellId:         a.CellId,
+		Cell:           a.Cell,
+		PduSessionId:   a.PduSessionId,
+		Dnn:            a.Dnn,
+		Snssai:         a.Snssai,
+		Qos:            a.Qos,
+		TrafficProfile: a.TrafficProfile,
+		Uplink:         a.Uplink,
+		DurationSec:    a.DurationSec,
+	}
+}
+
+// UeContext is a point in time view of a UE
+type UeContext struct {
+	Imsi             string              `json:"imsi"`
+	Msisdn           string              `json:"msisdn"`
+	Imei             string              `json:"imei"`
+	Profile          string              `json:"profile"`
+	RmState          RmState             `json:"rmState"`
+	CmState          CmState             `json:"cmState"`
+	CurrentCellId    string              `json:"currentCellId"`
+	Pinned           bool                `json:"pinned"`
+	PoweredOn        bool                `json:"poweredOn"`
+	LastActivityTime time.Time           `json:"lastActivityTime"`
+	PduSessions      []PduSessionContext `json:"pduSessions"`
+}
+
+type PduSessionContext struct {
+	Id           int32      `json:"pduSessionId"`
+	Ipv4         string     `json:"ipv4Addr"`
+	Dnn          string     `json:"dnn"`
+	Snssai       Snssai     `json:"snssai"`
+	Qos          SessionQos `json:"qos"`
+	QosFlows     []QosFlow  `json:"qosFlows,omitempty"`
+	Dnai         string     `json:"dnai,omitempty"`
+	TotalUlBytes int64      `json:"totalUlBytes"`
+	TotalDlBytes int64      `json:"totalDlBytes"`
+	NumUlPackets int64      `json:"numUlPackets"`
+	NumDlPackets int64      `json:"numDlPackets"`
+}
+
+func newUeContext(ue ran.UeContext) UeContext {
+	ctx := UeContext{
+		Imsi:             ue.Imsi,
+		Msisdn:           ue.Msisdn,
+		Imei:             ue.Imei,
+		Profile:          ue.Profile,
+		RmState:          ue.RmState,
+		CmState:          ue.CmState,
+		CurrentCellId:    ue.CurrentCellId,
+		Pinned:           ue.Pinned,
+		PoweredOn:        ue.PoweredOn,
+		LastActivityTime: ue.LastActivityTime,
+	}
+	for _, session := range ue.PduSessions {
+		ctx.PduSessions = append(ctx.PduSessions, PduSessionContext(session))
+	}
+	return ctx
+}
+
+const (
+	DeviceClassSmartphone = ran.DeviceClassSmartphone
+	DeviceClassIoT        = ran.DeviceClassIoT
+)
+
+// shutdownTimeout bounds the graceful stop of the SBI server when the simulator is closed
+const shutdownTimeout = 5 * time.Second
+
+// NewClock creates a clock following the wall clock
+func NewClock() *Clock {
+	return utils.NewClock()
+}
+
+// NewClockAt creates a clock starting at the given time
+func NewClockAt(start time.Time) *Clock {
+	return utils.NewClockAt(start)
+}
+
+// DefaultProfile returns a profile with a single gNB and no UE arrival:
+// the UEs are added explicitly with AddUes
+func DefaultProfile() *Profile {
+	return &Profile{
+		Plmn:        PlmnId{Mcc: "001", Mnc: "06"},
+		Dnn:         "internet",
+		Snssai:      Snssai{Sst: 1, Sd: models.PtrString("FFFFFF")},
+		NumOfGnb:    1,
+		NumOfUe:     0,
+		ArrivalRate: 1,
+	}
+}
+
+// LoadProfile reads a simulation profile from a YAML file, either a bare
+// profile or a configuration file with a simulationProfile section
+func LoadProfile(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := struct {
+		Profile *Profile `yaml:"simulationProfile"`
+	}{}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid simulation profile: %w", err)
+	}
+	if cfg.Profile != nil {
+		return cfg.Profile, nil
+	}
+	profile := &Profile{}
+	if err := yaml.Unmarshal(data, profile); err != nil {
+		return nil, fmt.Errorf("invalid simulation profile: %w", err)
+	}
+	return profile, nil
+}
+
+// Event is an event generated by the core network, identified by its
+// AMF or SMF event type, with the SUPI of the UE it relates to
+type Event struct {
+	Type      string
+	Supi      string
+	TimeStamp time.Time
+}
+
+type options struct {
+	profile   *Profile
+	clock     *Clock
+	seed      *int64
+	listener  net.Listener
+	listeners []func(Event)
+}
+
+// An Option customizes a simulator built by New
+type Option func(o *options)
+
+// WithProfile runs the given simulation profile, DefaultProfile by default
+func WithProfile(profile *Profile) Option {
+	return func(o *options) {
+		o.profile = profile
+	}
+}
+
+// WithClock drives the simulation with the given clock, a clock following the wall clock by default
+func WithClock(clock *Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithSeed seeds the random draws of the simulation, overriding the seed of the profile.
+// Every simulator draws from its own source, seeded simulations replay the same run
+// even when other simulators run at the same time.
+func WithSeed(seed int64) Option {
+	return func(o *options) {
+		o.seed = &seed
+	}
+}
+
+// WithListener serves the 3GPP SBI on the given listener, a random local port by default
+func WithListener(listener net.Listener) Option {
+	return func(o *options) {
+		o.listener = listener
+	}
+}
+
+// WithEventListener registers a listener of the events generated by the core network.
+// Listeners are called synchronously by the network functions and must not block.
+func WithEventListener(listener func(Event)) Option {
+	return func(o *options) {
+		o.listeners = append(o.listeners, listener)
+	}
+}
+
+// A Simulator is an embedded simulated network
+type Simulator struct {
+	network  *simulator.NetworkInstance
+	listener net.Listener
+	close    sync.Once
+	err      error
+}
+
+// New builds and initializes a simulated network. The network functions and the UE
+// engine run as soon as New returns, the arrivals of the profile start with Start.
+// Several simulators can run at the same time in a process.
+func New(opts ...Option) (*Simulator, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	profile := o.profile
+	if profile == nil {
+		profile = DefaultProfile()
+	}
+	config, err := profile.networkConfig()
+	if err != nil {
+		return nil, err
+	}
+	if o.seed != nil {
+		config.Seed = o.seed
+	}
+	if o.clock == nil {
+		o.clock = NewClock()
+	}
+
+	listener := o.listener
+	if listener == nil {
+		if listener, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+			return nil, fmt.Errorf("could not start 3GPP sbi server: %w", err)
+		}
+	}
+
+	instanceOpts := []simulator.InstanceOption{
+		simulator.WithClock(o.clock),
+		simulator.WithSbiListener(listener),
+	}
+	for _, l := range o.listeners {
+		instanceOpts = append(instanceOpts, simulator.WithEventObserver(func(event string, supi string, timestamp time.Time) {
+			l(Event{Type: event, Supi: supi, TimeStamp: timestamp})
+		}))
+	}
+
+	network := simulator.NewNetworkInstance(0, config, instanceOpts...)
+	if err := network.InitNetworkInstance(); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return &Simulator{network: network, listener: listener}, nil
+}
+
+// SbiURL returns the base URL of the 3GPP SBI of the simulated network
+func (s *Simulator) SbiURL() string {
+	return "http://" + s.listener.Addr().String()
+}
+
+// Clock returns the clock driving the simulation
+func (s *Simulator) Clock() *Clock {
+	return s.network.Clock()
+}
+
+// Start starts the arrival process and the scenario of the profile
+func (s *Simulator) Start() error {
+	return s.network.Start()
+}
+
+// Stop powers off all the UEs, the simulator can be started again
+func (s *Simulator) Stop() error {
+	return s.network.Stop()
+}
+
+// Pause freezes the simulation
+func (s *Simulator) Pause() error {
+	return s.network.Pause()
+}
+
+// Resume restarts a paused simulation
+func (s *Simulator) Resume() error {
+	return s.network.Resume()
+}
+
+// Step moves the clock of the simulation forward by d and returns once the UE
+// timers and traffic emissions due in the skipped time have been processed
+func (s *Simulator) Step(ctx context.Context, d time.Duration) error {
+	return s.network.Step(ctx, d)
+}
+
+// AddUes powers up count new UEs of the given device class, Smartphone by default.
+// The new UEs follow the markov process of the simulation until they are pinned.
+// It returns their SUPIs.
+func (s *Simulator) AddUes(count int, deviceClass string) ([]string, error) {
+	return s.network.AddUes(count, deviceClass)
+}
+
+// Ue returns the context of the UE identified by its IMSI or GPSI
+func (s *Simulator) Ue(ueId string) (UeContext, bool) {
+	ue, ok := s.network.FindUe(ueId)
+	if !ok {
+		return UeContext{}, false
+	}
+	return newUeContext(ue.GetContext()), true
+}
+
+// Ues returns the context of all the UEs, sorted by IMSI
+func (s *Simulator) Ues() []UeContext {
+	var ues []UeContext
+	for _, ue := range s.network.ListUes(&simulator.UeFilter{}) {
+		ues = append(ues, newUeContext(ue))
+	}
+	return ues
+}
+
+// Pin detaches the UE from the markov process, so that only the forced actions change its state
+func (s *Simulator) Pin(supi string, pinned bool) error {
+	ue, ok := s.network.GetUe(supi)
+	if !ok {
+		return fmt.Errorf("%w: %s", simulator.ErrUeNotFound, supi)
+	}
+	ue.Pin(pinned)
+	return nil
+}
+
+// ForceUeAction runs a procedure on the UE, see the OAM UE action API for the preconditions
+func (s *Simulator) ForceUeAction(supi string, action UeAction) error {
+	return s.network.ExecuteUeAction(supi, action.ueAction())
+}
+
+// Close shuts the simulated network down and releases the SBI listener
+func (s *Simulator) Close() error {
+	s.close.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		s.err = s.network.Shutdown(ctx)
+		if err := s.listener.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			s.err = errors.Join(s.err, err)
+		}
+	})
+	return s.err
+}
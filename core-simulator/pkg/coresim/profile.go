@@ -0,0 +1,397 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package coresim
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/arrival"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/models"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/simulator"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/trafficgen"
+	"gopkg.in/yaml.v3"
+)
+
+/* Simulation profile */
+
+// 3GPP data types of the profile and of the UE contexts
+type (
+	PlmnId     = models.PlmnId
+	Snssai     = models.Snssai
+	Ambr       = models.Ambr
+	SessionQos = models.SessionQos
+	QosFlow    = models.QosFlow
+	RmState    = models.RmState
+	CmState    = models.CmState
+)
+
+// Profile is the simulation profile, as in the simulationProfile section of config.yaml
+type Profile struct {
+	Snssai      Snssai  `yaml:"slice" json:"slice"`
+	Plmn        PlmnId  `yaml:"plmn" json:"plmn"`
+	Dnn         string  `yaml:"dnn" json:"dnn"`
+	NumOfGnb    int     `yaml:"numOfgNB" json:"numOfgNB"`
+	NumOfUe     int     `yaml:"numOfUe" json:"numOfUe"`
+	ArrivalRate float32 `yaml:"arrivalRate" json:"arrivalRate"`
+	// optional arrival model, poisson arrivals with arrivalRate by default
+	Arrival *ArrivalConfig `yaml:"arrival,omitempty" json:"arrival,omitempty"`
+	// optional departure process, UEs stay powered on by default
+	Departure *DepartureConfig `yaml:"departure,omitempty" json:"departure,omitempty"`
+	// optional UE identities generation, SUPIs and MSISDNs are derived from the UE index by default
+	Identities *IdentityConfig `yaml:"identities,omitempty" json:"identities,omitempty"`
+	// optional traffic profiles, added to or overriding the default web, video, iot and sip profiles
+	TrafficProfiles map[string]*TrafficProfile `yaml:"trafficProfiles,omitempty" json:"trafficProfiles,omitempty"`
+	// optional application mix of the device classes, added to or overriding Smartphone and IoT
+	DeviceClasses DeviceClasses `yaml:"deviceClasses,omitempty" json:"deviceClasses,omitempty"`
+	// optional number of shards of the UE engine, one per CPU by default
+	UeEngineShards int `yaml:"ueEngineShards,omitempty" json:"ueEngineShards,omitempty"`
+	// optional mobile reachable timer of the AMF in seconds, 3480 by default, negative to disable the implicit deregistration
+	MobileReachableTimerSec int `yaml:"mobileReachableTimerSec,omitempty" json:"mobileReachableTimerSec,omitempty"`
+	// optional delivery settings of the event notifications
+	Notifications *NotificationConfig `yaml:"notifications,omitempty" json:"notifications,omitempty"`
+	// optional scripted scenario executed when the simulation starts
+	ScenarioFile string `yaml:"scenarioFile,omitempty" json:"scenarioFile,omitempty"`
+	// optional subscription data of the UEs provisioned in the UDM
+	Udm *UdmConfig `yaml:"udm,omitempty" json:"udm,omitempty"`
+	// optional CHF charging the PDU sessions, disabled by default
+	Chf *ChfConfig `yaml:"chf,omitempty" json:"chf,omitempty"`
+	// optional NWDAF learning the analytics of the network from the AMF and SMF events, disabled by default
+	Nwdaf *NwdafConfig `yaml:"nwdaf,omitempty" json:"nwdaf,omitempty"`
+	// optional NEF exposing the northbound APIs of TS 29.522 on the SBI, disabled by default
+	Nef *NefConfig `yaml:"nef,omitempty" json:"nef,omitempty"`
+	// optional edge sites where the AF routing requirements move the PDU sessions, none by default
+	EdgeSites []EdgeSite `yaml:"edgeSites,omitempty" json:"edgeSites,omitempty"`
+	// optional seed of the random draws, a different sequence at every run by default
+	Seed *int64 `yaml:"seed,omitempty" json:"seed,omitempty"`
+}
+
+// networkConfig converts the profile to the configuration of the network instance.
+// Both share the same YAML representation.
+func (p *Profile) networkConfig() (*simulator.NetworkConfig, error) {
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid simulation profile: %w", err)
+	}
+	cfg := &simulator.NetworkConfig{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid simulation profile: %w", err)
+	}
+	return cfg, nil
+}
+
+/* Arrivals and departures */
+
+type ArrivalModel string
+
+const (
+	ArrivalPoisson       = ArrivalModel(arrival.Poisson)
+	ArrivalDeterministic = ArrivalModel(arrival.Deterministic)
+	ArrivalMmpp          = ArrivalModel(arrival.Mmpp)
+	ArrivalDiurnal       = ArrivalModel(arrival.Diurnal)
+	ArrivalFlashCrowd    = ArrivalModel(arrival.FlashCrowd)
+	ArrivalTrace         = ArrivalModel(arrival.Trace)
+)
+
+// ArrivalConfig selects the arrival model and its parameters.
+// Rates are expressed in UEs per second, times in seconds.
+type ArrivalConfig struct {
+	Model ArrivalModel `yaml:"model" json:"model"`
+	// mean rate of POISSON, DETERMINISTIC and DIURNAL, base rate of FLASH_CROWD
+	Rate float64 `yaml:"rate,omitempty" json:"rate,omitempty"`
+	// MMPP states, visited in a round robin fashion
+	States []ArrivalMmppState `yaml:"states,omitempty" json:"states,omitempty"`
+	// DIURNAL: relative amplitude in [0, 1], period and time of the peak within the period
+	Amplitude float64 `yaml:"amplitude,omitempty" json:"amplitude,omitempty"`
+	PeriodSec float64 `yaml:"periodSec,omitempty" json:"periodSec,omitempty"`
+	PeakAtSec float64 `yaml:"peakAtSec,omitempty" json:"peakAtSec,omitempty"`
+	// FLASH_CROWD: additional rate applied for durationSec starting at atSec
+	AtSec       float64 `yaml:"atSec,omitempty" json:"atSec,omitempty"`
+	DurationSec float64 `yaml:"durationSec,omitempty" json:"durationSec,omitempty"`
+	PeakRate    float64 `yaml:"peakRate,omitempty" json:"peakRate,omitempty"`
+	// TRACE: file listing the arrival times
+	TraceFile string `yaml:"traceFile,omitempty" json:"traceFile,omitempty"`
+}
+
+type ArrivalMmppState struct {
+	Rate            float64 `yaml:"rate" json:"rate"`
+	MeanDurationSec float64 `yaml:"meanDurationSec" json:"meanDurationSec"`
+}
+
+// DepartureConfig powers the UEs off after an exponentially distributed on time
+type DepartureConfig struct {
+	MeanOnTimeSec float64 `yaml:"meanOnTimeSec" json:"meanOnTimeSec"`
+}
+
+/* UE identities */
+
+// IdentityConfig describes how the permanent and public identities of the UEs are built.
+// The UE with index i (starting from 1) gets the i-th identity of each source.
+type IdentityConfig struct {
+	Supi *IdentitySource `yaml:"supi,omitempty" json:"supi,omitempty"`
+	Gpsi *GpsiConfig     `yaml:"gpsi,omitempty" json:"gpsi,omitempty"`
+	Pei  *PeiConfig      `yaml:"pei,omitempty" json:"pei,omitempty"`
+	Suci *SuciConfig     `yaml:"suci,omitempty" json:"suci,omitempty"`
+}
+
+// IdentitySource lists identities explicitly, from a file with one identity per line,
+// and as numeric ranges. File entries come first, followed by the ranges in order.
+type IdentitySource struct {
+	File   string          `yaml:"file,omitempty" json:"file,omitempty"`
+	Ranges []IdentityRange `yaml:"ranges,omitempty" json:"ranges,omitempty"`
+}
+
+// IdentityRange is a range of numeric identities, bounds included. An empty end
+// leaves the range open, which is only allowed for the last range of a source.
+// The width of start is preserved, e.g. "001060000000001".
+type IdentityRange struct {
+	Start string `yaml:"start" json:"start"`
+	End   string `yaml:"end,omitempty" json:"end,omitempty"`
+}
+
+// GpsiConfig builds MSISDNs, or external identifiers when a domain is set.
+// With a domain, the values of the source (or "ue<index>") are the local identifiers.
+type GpsiConfig struct {
+	IdentitySource   `yaml:",inline"`
+	ExternalIdDomain string `yaml:"externalIdDomain,omitempty" json:"externalIdDomain,omitempty"`
+}
+
+// PeiConfig lists the Type Allocation Codes assigned to each device class
+type PeiConfig struct {
+	Tacs map[string][]string `yaml:"tacs,omitempty" json:"tacs,omitempty"`
+}
+
+// SuciConfig conceals the SUPIs in the SUCIs reported at registration
+type SuciConfig struct {
+	ProtectionScheme       string `yaml:"protectionScheme" json:"protectionScheme"`
+	HomeNetworkPublicKeyId int    `yaml:"homeNetworkPublicKeyId,omitempty" json:"homeNetworkPublicKeyId,omitempty"`
+	HomeNetworkPublicKey   string `yaml:"homeNetworkPublicKey,omitempty" json:"homeNetworkPublicKey,omitempty"`
+	RoutingIndicator       string `yaml:"routingIndicator,omitempty" json:"routingIndicator,omitempty"`
+}
+
+/* Traffic */
+
+// TrafficProfile configures a traffic generator. Parameters that the generator
+// does not use are ignored.
+type TrafficProfile struct {
+	Generator string `yaml:"generator" json:"generator"`
+	// bits per second, while active for on/off generators
+	Bitrate *Distribution `yaml:"bitrate,omitempty" json:"bitrate,omitempty"`
+	// bytes
+	PacketSize *Distribution `yaml:"packetSize,omitempty" json:"packetSize,omitempty"`
+	// packets per second
+	PacketRate *Distribution `yaml:"packetRate,omitempty" json:"packetRate,omitempty"`
+	// seconds between two packets of periodic generators
+	Interval *Distribution `yaml:"interval,omitempty" json:"interval,omitempty"`
+	// seconds of activity and silence of on/off generators
+	OnPeriod  *Distribution `yaml:"onPeriod,omitempty" json:"onPeriod,omitempty"`
+	OffPeriod *Distribution `yaml:"offPeriod,omitempty" json:"offPeriod,omitempty"`
+	// session duration in seconds, sessions last until the PDU Session is released when unset
+	Duration *Distribution `yaml:"duration,omitempty" json:"duration,omitempty"`
+
+	// PARETO_WEB: object size in bytes, objects per page and reading time in seconds
+	ObjectSize     *Distribution `yaml:"objectSize,omitempty" json:"objectSize,omitempty"`
+	ObjectsPerPage *Distribution `yaml:"objectsPerPage,omitempty" json:"objectsPerPage,omitempty"`
+	ThinkTime      *Distribution `yaml:"thinkTime,omitempty" json:"thinkTime,omitempty"`
+	// ABR_VIDEO: video bitrates in bits per second, segment duration, available throughput
+	// in bits per second and maximum buffered video in seconds
+	BitrateLadder   []float64     `yaml:"bitrateLadder,omitempty" json:"bitrateLadder,omitempty"`
+	SegmentDuration *Distribution `yaml:"segmentDuration,omitempty" json:"segmentDuration,omitempty"`
+	Throughput      *Distribution `yaml:"throughput,omitempty" json:"throughput,omitempty"`
+	BufferSec       float64       `yaml:"bufferSec,omitempty" json:"bufferSec,omitempty"`
+	// CLOUD_GAMING: video frames per second, input packets per second and their size in bytes
+	FrameRate *Distribution `yaml:"frameRate,omitempty" json:"frameRate,omitempty"`
+	InputRate *Distribution `yaml:"inputRate,omitempty" json:"inputRate,omitempty"`
+	InputSize *Distribution `yaml:"inputSize,omitempty" json:"inputSize,omitempty"`
+	// FILE_TRANSFER: file size in bytes
+	FileSize *Distribution `yaml:"fileSize,omitempty" json:"fileSize,omitempty"`
+	// MMPP: states of the packet process
+	States []TrafficMmppState `yaml:"states,omitempty" json:"states,omitempty"`
+}
+
+type TrafficMmppState struct {
+	PacketRate      float64 `yaml:"packetRate" json:"packetRate"`
+	MeanDurationSec float64 `yaml:"meanDurationSec" json:"meanDurationSec"`
+}
+
+type DistributionType string
+
+const (
+	DistributionConstant    = DistributionType(trafficgen.Constant)
+	DistributionUniform     = DistributionType(trafficgen.Uniform)
+	DistributionExponential = DistributionType(trafficgen.Exponential)
+	DistributionNormal      = DistributionType(trafficgen.Normal)
+	DistributionLogNormal   = DistributionType(trafficgen.LogNormal)
+	DistributionPareto      = DistributionType(trafficgen.Pareto)
+)
+
+// Distribution describes a random traffic parameter.
+// A plain number in the configuration is a CONSTANT distribution.
+type Distribution struct {
+	Type DistributionType `yaml:"type" json:"type"`
+	// CONSTANT value
+	Value float64 `yaml:"value,omitempty" json:"value,omitempty"`
+	// UNIFORM bounds, also used to truncate the other distributions when set
+	Min float64 `yaml:"min,omitempty" json:"min,omitempty"`
+	Max float64 `yaml:"max,omitempty" json:"max,omitempty"`
+	// EXPONENTIAL and NORMAL mean, NORMAL standard deviation
+	Mean   float64 `yaml:"mean,omitempty" json:"mean,omitempty"`
+	StdDev float64 `yaml:"stdDev,omitempty" json:"stdDev,omitempty"`
+	// LOGNORMAL parameters of the underlying normal distribution
+	Mu    float64 `yaml:"mu,omitempty" json:"mu,omitempty"`
+	Sigma float64 `yaml:"sigma,omitempty" json:"sigma,omitempty"`
+	// PARETO shape (alpha) and scale (minimum value)
+	Shape float64 `yaml:"shape,omitempty" json:"shape,omitempty"`
+	Scale float64 `yaml:"scale,omitempty" json:"scale,omitempty"`
+}
+
+// Fixed returns a CONSTANT distribution
+func Fixed(value float64) *Distribution {
+	return &Distribution{Type: DistributionConstant, Value: value}
+}
+
+// distribution is the mapping form of Distribution, used to avoid recursive unmarshaling
+type distribution Distribution
+
+func (d *Distribution) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		var value float64
+		if err := node.Decode(&value); err != nil {
+			return err
+		}
+		*d = *Fixed(value)
+		return nil
+	}
+	return node.Decode((*distribution)(d))
+}
+
+func (d *Distribution) UnmarshalJSON(data []byte) error {
+	var value float64
+	if err := json.Unmarshal(data, &value); err == nil {
+		*d = *Fixed(value)
+		return nil
+	}
+	return json.Unmarshal(data, (*distribution)(d))
+}
+
+type Direction string
+
+const (
+	Uplink   = Direction(ran.Uplink)
+	Downlink = Direction(ran.Downlink)
+)
+
+// Application is a traffic flow started when the UE establishes its PDU Session.
+// Each application of the device class mix is started with the given probability.
+type Application struct {
+	Profile     string    `yaml:"profile" json:"profile"`
+	Direction   Direction `yaml:"direction" json:"direction"`
+	Probability *float64  `yaml:"probability,omitempty" json:"probability,omitempty"`
+}
+
+// DeviceClasses maps each device class to its application mix
+type DeviceClasses map[string][]Application
+
+/* Network functions */
+
+// NotificationConfig sets the delivery of the event notifications
+type NotificationConfig struct {
+	// timeout of a single notification request
+	TimeoutMs int `yaml:"timeoutMs,omitempty" json:"timeoutMs,omitempty"`
+	// retries of a notification after the first attempt, negative to disable the retries
+	MaxRetries int `yaml:"maxRetries,omitempty" json:"maxRetries,omitempty"`
+	// backoff before the first retry, doubled at every retry up to maxBackoffMs
+	InitialBackoffMs int `yaml:"initialBackoffMs,omitempty" json:"initialBackoffMs,omitempty"`
+	MaxBackoffMs     int `yaml:"maxBackoffMs,omitempty" json:"maxBackoffMs,omitempty"`
+	// notifications waiting for each subscriber, the newest are dropped when the queue is full
+	QueueSize int `yaml:"queueSize,omitempty" json:"queueSize,omitempty"`
+}
+
+// UdmConfig is the subscription data of the UEs provisioned in the UDM
+type UdmConfig struct {
+	// optional subscribed UE-AMBR, 1 Gbps uplink and 2 Gbps downlink by default
+	UeAmbr *Ambr `yaml:"ueAmbr,omitempty" json:"ueAmbr,omitempty"`
+	// optional session AMBR of the subscribed DNNs, 500 Mbps uplink and 1 Gbps downlink by default
+	SessionAmbr *Ambr `yaml:"sessionAmbr,omitempty" json:"sessionAmbr,omitempty"`
+	// optional additional DNNs and slices
+	Dnns   []string `yaml:"dnns,omitempty" json:"dnns,omitempty"`
+	Slices []Snssai `yaml:"slices,omitempty" json:"slices,omitempty"`
+	// optional internal group ids of the subscribers by device class
+	GroupIds map[string][]string `yaml:"groupIds,omitempty" json:"groupIds,omitempty"`
+}
+
+// ChfConfig sets the accounts and the quotas of the CHF
+type ChfConfig struct {
+	// optional initial balance of the subscriber accounts, 1 GiB by default
+	InitialBalance *int64 `yaml:"initialBalance,omitempty" json:"initialBalance,omitempty"`
+	// optional volume granted by each quota, 10 MiB by default
+	QuotaVolume int64 `yaml:"quotaVolume,omitempty" json:"quotaVolume,omitempty"`
+	// optional action on the PDU sessions out of credit, TERMINATE by default or RESTRICT_ACCESS
+	FinalUnitAction string `yaml:"finalUnitAction,omitempty" json:"finalUnitAction,omitempty"`
+	// optional QoS of the restricted PDU sessions, 64 Kbps by default
+	RestrictedQos *SessionQos `yaml:"restrictedQos,omitempty" json:"restrictedQos,omitempty"`
+	// optional initial balance of the sponsors of the sponsored data connectivity
+	Sponsors map[string]int64 `yaml:"sponsors,omitempty" json:"sponsors,omitempty"`
+}
+
+// NwdafConfig sets the statistics and the thresholds of the NWDAF analytics
+type NwdafConfig struct {
+	// optional history of the statistics and of the predictions in seconds, 1 hour by default
+	HistorySec int `yaml:"historySec,omitempty" json:"historySec,omitempty"`
+	// optional number of registered UEs loading the AMF at 100%, 1000 by default
+	AmfCapacity int `yaml:"amfCapacity,omitempty" json:"amfCapacity,omitempty"`
+	// optional number of PDU sessions loading the SMF at 100%, 1000 by default
+	SmfCapacity int `yaml:"smfCapacity,omitempty" json:"smfCapacity,omitempty"`
+	// optional number of PDU sessions loading a slice at 100%, the SMF capacity by default
+	SliceCapacity int `yaml:"sliceCapacity,omitempty" json:"sliceCapacity,omitempty"`
+	// optional occurrences in the history making an abnormal behaviour
+	PingPongCount         int `yaml:"pingPongCount,omitempty" json:"pingPongCount,omitempty"`
+	ServiceAccessCount    int `yaml:"serviceAccessCount,omitempty" json:"serviceAccessCount,omitempty"`
+	RadioLinkFailureCount int `yaml:"radioLinkFailureCount,omitempty" json:"radioLinkFailureCount,omitempty"`
+	// optional bit rate of a PDU session making an unexpected large rate flow, 100 Mbps by default
+	LargeRateMbps float64 `yaml:"largeRateMbps,omitempty" json:"largeRateMbps,omitempty"`
+}
+
+// NefConfig sets the northbound APIs of the NEF
+type NefConfig struct {
+	// optional QoS references of the AS session with QoS API, added to or overriding the default ones
+	QosReferences map[string]QosReference `yaml:"qosReferences,omitempty" json:"qosReferences,omitempty"`
+}
+
+// QosReference is a QoS of the AS session with QoS API
+type QosReference struct {
+	MedType string `yaml:"medType" json:"medType"`
+	MarBwUl string `yaml:"marBwUl,omitempty" json:"marBwUl,omitempty"`
+	MarBwDl string `yaml:"marBwDl,omitempty" json:"marBwDl,omitempty"`
+}
+
+// EdgeSite is a local access to the data network, served by its own UPF
+type EdgeSite struct {
+	Dnai string `yaml:"dnai" json:"dnai"`
+	// UPF serving the site and its IPv4 address
+	UpfId   string `yaml:"upfId" json:"upfId"`
+	UpfAddr string `yaml:"upfAddr,omitempty" json:"upfAddr,omitempty"`
+	// N6 traffic routing towards the edge application servers, none when empty
+	N6Ipv4Addr string `yaml:"n6Ipv4Addr,omitempty" json:"n6Ipv4Addr,omitempty"`
+	N6Port     int32  `yaml:"n6Port,omitempty" json:"n6Port,omitempty"`
+	// NR cells where the site is reachable, all the cells when empty
+	Cells []string `yaml:"cells,omitempty" json:"cells,omitempty"`
+}
@@ -0,0 +1,177 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package coresim
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/components/ran"
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/internal/simulator"
+)
+
+// checkSameFields fails when the exported type and the internal type it mirrors
+// do not have the same fields and tags, at any depth
+func checkSameFields(t *testing.T, path string, exported reflect.Type, internal reflect.Type) {
+	t.Helper()
+	if exported == internal {
+		return
+	}
+	if exported.Kind() != internal.Kind() {
+		t.Fatalf("%s: got kind %s, want %s", path, exported.Kind(), internal.Kind())
+	}
+	switch exported.Kind() {
+	case reflect.Pointer, reflect.Slice:
+		checkSameFields(t, path, exported.Elem(), internal.Elem())
+	case reflect.Map:
+		checkSameFields(t, path+"[key]", exported.Key(), internal.Key())
+		checkSameFields(t, path, exported.Elem(), internal.Elem())
+	case reflect.Struct:
+		exportedFields := reflect.VisibleFields(exported)
+		var internalFields []reflect.StructField
+		for _, field := range reflect.VisibleFields(internal) {
+			if field.IsExported() {
+				internalFields = append(internalFields, field)
+			}
+		}
+		if len(exportedFields) != len(internalFields) {
+			t.Fatalf("%s: got %d fields, want %d", path, len(exportedFields), len(internalFields))
+		}
+		for i, field := range exportedFields {
+			want := internalFields[i]
+			if field.Name != want.Name || field.Tag != want.Tag {
+				t.Fatalf("%s: got field %s `%s`, want %s `%s`", path, field.Name, field.Tag, want.Name, want.Tag)
+			}
+			if len(field.Index) == 1 {
+				checkSameFields(t, path+"."+field.Name, field.Type, want.Type)
+			}
+		}
+	}
+}
+
+func TestTypesMirrorTheSimulator(t *testing.T) {
+	checkSameFields(t, "Profile", reflect.TypeOf(Profile{}), reflect.TypeOf(simulator.NetworkConfig{}))
+	checkSameFields(t, "UeAction", reflect.TypeOf(UeAction{}), reflect.TypeOf(simulator.UeAction{}))
+	checkSameFields(t, "UeContext", reflect.TypeOf(UeContext{}), reflect.TypeOf(ran.UeContext{}))
+}
+
+func TestLoadProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profile.yaml")
+	data := `simulationProfile:
+  plmn: {mcc: "001", mnc: "06"}
+  slice: {sst: 1, sd: "FFFFFF"}
+  dnn: internet
+  numOfgNB: 2
+  numOfUe: 10
+  arrivalRate: 1
+  arrival:
+    model: MMPP
+    states:
+      - {rate: 5, meanDurationSec: 10}
+      - {rate: 0, meanDurationSec: 60}
+  departure: {meanOnTimeSec: 600}
+  identities:
+    supi:
+      ranges: [{start: "001060000000001"}]
+    gpsi:
+      ranges: [{start: "33600000001"}]
+      externalIdDomain: coresim.org
+    pei:
+      tacs: {IoT: ["86068703"]}
+    suci: {protectionScheme: NULL}
+  trafficProfiles:
+    cbr:
+      generator: CBR
+      bitrate: 64000
+      packetSize: {type: UNIFORM, min: 100, max: 200}
+  deviceClasses:
+    IoT:
+      - {profile: cbr, direction: UL, probability: 0.5}
+  notifications: {maxRetries: 2}
+  udm: {dnns: [ims]}
+  chf: {quotaVolume: 1000, sponsors: {sponsor: 10}}
+  nwdaf: {historySec: 60}
+  nef:
+    qosReferences:
+      voice: {medType: AUDIO}
+  edgeSites:
+    - {dnai: edge, upfId: upf-edge, cells: ["000000010"]}
+  seed: 7
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	profile, err := LoadProfile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := profile.TrafficProfiles["cbr"].Bitrate; *got != *Fixed(64000) {
+		t.Fatalf("got bitrate %+v, want a constant 64000", got)
+	}
+	if got := profile.Identities.Gpsi.Ranges[0].Start; got != "33600000001" {
+		t.Fatalf("got gpsi range start %s, want 33600000001", got)
+	}
+
+	config, err := profile.networkConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.Marshal(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("got network configuration %s, want %s", got, want)
+	}
+}
+
+func TestLoadProfileFormats(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		valid bool
+	}{
+		{"simulation profile", "simulationProfile:\n  dnn: internet\n  numOfgNB: 1\n", true},
+		{"bare profile", "dnn: internet\nnumOfgNB: 1\n", true},
+		{"scalar distribution", "trafficProfiles:\n  sensor: {generator: IOT, packetSize: 50, interval: 300}\n", true},
+		{"malformed yaml", "simulationProfile: [dnn: internet\n", false},
+		{"wrong field type", "simulationProfile:\n  numOfgNB: many\n", false},
+		{"list distribution", "trafficProfiles:\n  sensor: {generator: IOT, packetSize: [50, 100]}\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "profile.yaml")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			profile, err := LoadProfile(path)
+			if (err == nil) != tt.valid {
+				t.Fatalf("got profile %+v and error %v, want valid %v", profile, err, tt.valid)
+			}
+		})
+	}
+}
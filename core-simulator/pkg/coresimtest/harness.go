@@ -0,0 +1,155 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+// Package coresimtest provides a harness running an isolated core simulator per test,
+// with a fake notification sink and helpers to step the simulation, force UE actions
+// and wait for the expected events.
+package coresimtest
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/pkg/coresim"
+)
+
+/* Test harness */
+
+// DefaultTimeout bounds the waits of the harness
+const DefaultTimeout = 5 * time.Second
+
+// A Harness is a simulator bound to a test, closed when the test ends.
+// It records the events generated by the core network.
+type Harness struct {
+	*coresim.Simulator
+	t testing.TB
+
+	mutex   sync.Mutex
+	events  []coresim.Event
+	next    int           // first event not matched yet
+	updated chan struct{} // closed and replaced on every new event
+}
+
+// New builds a simulator for the test, it fails the test if the simulator cannot be built
+func New(t testing.TB, opts ...coresim.Option) *Harness {
+	t.Helper()
+	h := &Harness{t: t, updated: make(chan struct{})}
+
+	opts = append(slices.Clone(opts), coresim.WithEventListener(h.record))
+	sim, err := coresim.New(opts...)
+	if err != nil {
+		t.Fatalf("could not build the simulator: %v", err)
+	}
+	h.Simulator = sim
+	t.Cleanup(func() {
+		if err := sim.Close(); err != nil {
+			t.Errorf("could not close the simulator: %v", err)
+		}
+	})
+	return h
+}
+
+func (h *Harness) record(event coresim.Event) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.events = append(h.events, event)
+	close(h.updated)
+	h.updated = make(chan struct{})
+}
+
+// Events returns all the events recorded so far
+func (h *Harness) Events() []coresim.Event {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return slices.Clone(h.events)
+}
+
+// Step moves the simulation forward by d, see coresim.Simulator.Step
+func (h *Harness) Step(d time.Duration) {
+	h.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultTimeout)
+	defer cancel()
+	if err := h.Simulator.Step(ctx, d); err != nil {
+		h.t.Fatalf("could not step the simulation by %s: %v", d, err)
+	}
+}
+
+// AddUe powers up a new smartphone pinned to the test: its state only
+// changes with the forced actions. It returns the SUPI of the UE.
+func (h *Harness) AddUe() string {
+	h.t.Helper()
+	supis, err := h.AddUes(1, coresim.DeviceClassSmartphone)
+	if err != nil {
+		h.t.Fatalf("could not add a ue: %v", err)
+	}
+	if err := h.Pin(supis[0], true); err != nil {
+		h.t.Fatal(err)
+	}
+	return supis[0]
+}
+
+// Force runs a procedure on the UE, it fails the test if the procedure is rejected
+func (h *Harness) Force(supi string, action coresim.UeAction) {
+	h.t.Helper()
+	if err := h.ForceUeAction(supi, action); err != nil {
+		h.t.Fatalf("could not force %s on ue %s: %v", action.Action, supi, err)
+	}
+}
+
+// WaitForEvent waits for an event of the given type related to the UE, an empty
+// supi matches any UE. Events are matched in order: the events before the returned
+// one are skipped by the next waits. It fails the test after DefaultTimeout.
+func (h *Harness) WaitForEvent(eventType string, supi string) coresim.Event {
+	h.t.Helper()
+	return h.WaitFor(func(event coresim.Event) bool {
+		return event.Type == eventType && (supi == "" || event.Supi == supi)
+	}, eventType+" of ue "+supi)
+}
+
+// WaitFor waits for an event accepted by match, described by what in the failure message.
+// See WaitForEvent for the ordering of the events.
+func (h *Harness) WaitFor(match func(event coresim.Event) bool, what string) coresim.Event {
+	h.t.Helper()
+	timeout := time.NewTimer(DefaultTimeout)
+	defer timeout.Stop()
+
+	for {
+		h.mutex.Lock()
+		for i := h.next; i < len(h.events); i++ {
+			if match(h.events[i]) {
+				h.next = i + 1
+				event := h.events[i]
+				h.mutex.Unlock()
+				return event
+			}
+		}
+		updated := h.updated
+		h.mutex.Unlock()
+
+		select {
+		case <-updated:
+		case <-timeout.C:
+			h.t.Fatalf("timed out waiting for %s", what)
+			return coresim.Event{}
+		}
+	}
+}
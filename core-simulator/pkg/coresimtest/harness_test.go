@@ -0,0 +1,131 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package coresimtest
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.eurecom.fr/open-exposure/coresim/core-simulator/pkg/coresim"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+func TestHarness(t *testing.T) {
+	start := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	h := New(t, coresim.WithClock(coresim.NewClockAt(start)), coresim.WithSeed(1))
+	sink := NewSink(t)
+
+	body := `{"subscription":{"eventList":[{"type":"REGISTRATION_STATE_REPORT"},{"type":"CONNECTIVITY_STATE_REPORT"}],
+		"eventNotifyUri":"` + sink.URL("/amf") + `","notifyCorrelationId":"1","nfId":"0b4ba5f6-8e4c-4f8c-9c1a-6f2b7e1c2d3e","anyUE":true}}`
+	resp, err := http.Post(h.SbiURL()+"/namf-evts/v1/subscriptions", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+
+	supi := h.AddUe()
+	h.Force(supi, coresim.UeAction{Action: coresim.UeActionRegister})
+	registered := h.WaitForEvent("REGISTRATION_STATE_REPORT", supi)
+	if registered.TimeStamp.Before(start) || registered.TimeStamp.After(start.Add(time.Minute)) {
+		t.Fatalf("event at %s, not on the simulation clock starting at %s", registered.TimeStamp, start)
+	}
+
+	h.Force(supi, coresim.UeAction{Action: coresim.UeActionAttach})
+	h.WaitForEvent("CONNECTIVITY_STATE_REPORT", supi)
+
+	// the inactivity timer of the attached UE expires within the step
+	h.Step(time.Minute)
+	idle := h.WaitForEvent("CONNECTIVITY_STATE_REPORT", supi)
+	if idle.TimeStamp.Sub(registered.TimeStamp) < time.Minute {
+		t.Fatalf("idle at %s, before the end of the step", idle.TimeStamp)
+	}
+	if ue, ok := h.Ue(supi); !ok || ue.CmState != "IDLE" {
+		t.Fatalf("got ue %+v, want an idle ue", ue)
+	}
+
+	for _, notification := range sink.WaitFor("/amf", 3) {
+		n := struct {
+			ReportList []struct {
+				Type string `json:"type"`
+				Supi string `json:"supi"`
+			} `json:"reportList"`
+		}{}
+		if err := json.Unmarshal(notification, &n); err != nil {
+			t.Fatal(err)
+		}
+		if len(n.ReportList) != 1 || n.ReportList[0].Supi != supi {
+			t.Fatalf("got notification %s, want a report of ue %s", notification, supi)
+		}
+	}
+}
+
+func TestHarnessIsolation(t *testing.T) {
+	// every test gets a fresh network, the UE identities start over
+	for range 2 {
+		t.Run("network", func(t *testing.T) {
+			h := New(t)
+			if ues := h.Ues(); len(ues) != 0 {
+				t.Fatalf("got %d ues in a new network", len(ues))
+			}
+			supi := h.AddUe()
+			h.Force(supi, coresim.UeAction{Action: coresim.UeActionRegister})
+			h.WaitForEvent("REGISTRATION_STATE_REPORT", supi)
+		})
+	}
+}
+
+func TestHarnessConcurrentNetworks(t *testing.T) {
+	// the networks run side by side and the UEs with the same identity stay apart
+	first, second := New(t), New(t)
+	supi := first.AddUe()
+	if other := second.AddUe(); other != supi {
+		t.Fatalf("got ues %s and %s, want the same identity in both networks", supi, other)
+	}
+	for _, h := range []*Harness{first, second} {
+		h.Force(supi, coresim.UeAction{Action: coresim.UeActionRegister})
+		h.WaitForEvent("REGISTRATION_STATE_REPORT", supi)
+	}
+
+	before := len(second.Events())
+	first.Force(supi, coresim.UeAction{Action: coresim.UeActionDeregister})
+	first.WaitForEvent("REGISTRATION_STATE_REPORT", supi)
+	if ue, ok := first.Ue(supi); !ok || ue.RmState != "DEREGISTERED" {
+		t.Fatalf("got ue %+v in the first network, want a deregistered ue", ue)
+	}
+	if ue, ok := second.Ue(supi); !ok || ue.RmState != "REGISTERED" {
+		t.Fatalf("got ue %+v in the second network, want a registered ue", ue)
+	}
+	if events := second.Events(); len(events) != before {
+		t.Fatalf("got events %+v in the second network after the deregistration in the first one", events[before:])
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 EURECOM
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// Contributors:
+//   Giulio CAROTA
+//   Thomas DU
+//   Adlen KSENTINI
+
+package coresimtest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+/* Fake notification sink */
+
+// A Sink is a fake subscriber recording the notifications sent to it, indexed by
+// the path of their notification URI. It replies 204 No Content to every notification.
+type Sink struct {
+	server *httptest.Server
+	t      testing.TB
+
+	mutex   sync.Mutex
+	bodies  map[string][][]byte
+	updated chan struct{}
+}
+
+// NewSink starts a notification sink, closed when the test ends
+func NewSink(t testing.TB) *Sink {
+	s := &Sink{
+		t:       t,
+		bodies:  make(map[string][][]byte),
+		updated: make(chan struct{}),
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.server.Close)
+	return s
+}
+
+func (s *Sink) handle(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	s.mutex.Lock()
+	s.bodies[r.URL.Path] = append(s.bodies[r.URL.Path], body)
+	close(s.updated)
+	s.updated = make(chan struct{})
+	s.mutex.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// URL returns the notification URI of the given path, to be used in subscriptions
+func (s *Sink) URL(path string) string {
+	return s.server.URL + path
+}
+
+// Notifications returns the bodies of the notifications received on path so far
+func (s *Sink) Notifications(path string) [][]byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return slices.Clone(s.bodies[path])
+}
+
+// WaitFor waits until count notifications have been received on path and returns
+// their bodies. It fails the test after DefaultTimeout.
+func (s *Sink) WaitFor(path string, count int) [][]byte {
+	s.t.Helper()
+	timeout := time.NewTimer(DefaultTimeout)
+	defer timeout.Stop()
+
+	for {
+		s.mutex.Lock()
+		bodies := s.bodies[path]
+		updated := s.updated
+		s.mutex.Unlock()
+		if len(bodies) >= count {
+			return slices.Clone(bodies)
+		}
+
+		select {
+		case <-updated:
+		case <-timeout.C:
+			s.t.Fatalf("timed out waiting for %d notifications on %s, received %d", count, path, len(bodies))
+			return nil
+		}
+	}
+}
@@ -12,7 +12,7 @@ while true; do
     # Check simulation status
     STATUS=$(curl -s "${CORESIM_API}/status" | jq -r '.Status' 2>/dev/null)
     
-    if [ "$STATUS" == "STOPPED" ] || [ "$STATUS" == "ERROR" ]; then
+    if [ "$STATUS" == "STOPPED" ]; then
         echo "[$(date '+%Y-%m-%d %H:%M:%S')] Simulation is $STATUS, restarting..."
         
         # Start the simulation
@@ -25,6 +25,10 @@ while true; do
         fi
     elif [ "$STATUS" == "STARTED" ]; then
         echo "[$(date '+%Y-%m-%d %H:%M:%S')] Simulation is running"
+    elif [ "$STATUS" == "PAUSED" ]; then
+        echo "[$(date '+%Y-%m-%d %H:%M:%S')] Simulation is paused"
+    elif [ "$STATUS" == "ERROR" ]; then
+        echo "[$(date '+%Y-%m-%d %H:%M:%S')] ✗ Simulation is in error, a reset is required"
     else
         echo "[$(date '+%Y-%m-%d %H:%M:%S')] Unknown status: $STATUS"
     fi